@@ -27,12 +27,11 @@ func main() {
 	}
 
 	// Create a new router
-	gor.NoTrailingSlash = true
-	gor.ServeMinifiedAssetsIfPresent = true
-
 	mux := gor.NewRouter(
+		gor.WithNoTrailingSlash(true),
 		gor.WithTemplates(t),
 		gor.PassContextToViews(true),
+		gor.WithAssetRules(gor.MinifiedAssets),
 	)
 
 	mux.Use(recovery.New(true))
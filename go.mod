@@ -8,8 +8,15 @@ require (
 )
 
 require (
+	github.com/andybalholm/brotli v1.1.1
 	github.com/gorilla/sessions v1.2.2
+	github.com/klauspost/compress v1.18.0
+	golang.org/x/crypto v0.27.0
 	golang.org/x/net v0.29.0
+	golang.org/x/oauth2 v0.23.0
 )
 
-require golang.org/x/text v0.18.0 // indirect
+require (
+	golang.org/x/sys v0.25.0 // indirect
+	golang.org/x/text v0.18.0 // indirect
+)
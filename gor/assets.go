@@ -0,0 +1,95 @@
+package gor
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// AssetRule maps the name of a requested static asset to an alternative name.
+// If ok is true and the alternative exists, it is served in place of the original.
+// Names are slash separated and start with "/".
+type AssetRule func(name string) (alt string, ok bool)
+
+// MinifiedAssets serves minified Javascript and CSS if present instead of the original file.
+// e.g /static/js/main.js will serve /static/js/main.min.js if present.
+// This is important since we maintain the same script sources in our templates/html.
+func MinifiedAssets(name string) (string, bool) {
+	ext := path.Ext(name)
+	if ext != ".js" && ext != ".css" {
+		return "", false
+	}
+	return strings.TrimSuffix(name, ext) + ".min" + ext, true
+}
+
+// AssetsFromDir serves assets from the subtree dir if present.
+// e.g AssetsFromDir("dist") serves /dist/js/main.js for /js/main.js.
+func AssetsFromDir(dir string) AssetRule {
+	dir = path.Join("/", dir)
+	return func(name string) (string, bool) {
+		return path.Join(dir, name), true
+	}
+}
+
+// WithAssetRules sets the rules applied to assets served by Static and StaticFS.
+// Rules are tried in order and the first alternative that exists is served.
+// If no rule matches, the requested file is served.
+//
+// Example:
+//
+//	r := gor.NewRouter(gor.WithAssetRules(gor.AssetsFromDir("dist"), gor.MinifiedAssets))
+func WithAssetRules(rules ...AssetRule) RouterOption {
+	return func(r *Router) {
+		r.assetRules = rules
+	}
+}
+
+// assetRulesFor returns the asset rules of the router.
+// Without rules, the deprecated ServeMinifiedAssetsIfPresent global is honored.
+func (r *Router) assetRulesFor() []AssetRule {
+	if len(r.assetRules) > 0 {
+		return r.assetRules
+	}
+
+	if ServeMinifiedAssetsIfPresent {
+		return []AssetRule{MinifiedAssets}
+	}
+	return nil
+}
+
+// AssetFS wraps fs so that the asset rules are applied when opening files.
+// Use it to configure a single mount:
+//
+//	r.StaticFS("/static", gor.AssetFS(http.FS(staticFs), gor.MinifiedAssets))
+//
+// A file system returned by AssetFS is not wrapped again by the router rules.
+func AssetFS(fs http.FileSystem, rules ...AssetRule) http.FileSystem {
+	return &assetFS{FileSystem: fs, rules: rules}
+}
+
+type assetFS struct {
+	http.FileSystem
+	rules []AssetRule
+}
+
+// Open opens the first alternative of name that exists and is not a directory.
+// Otherwise the original file is opened.
+func (afs *assetFS) Open(name string) (http.File, error) {
+	for _, rule := range afs.rules {
+		alt, ok := rule(name)
+		if !ok || alt == name {
+			continue
+		}
+
+		f, err := afs.FileSystem.Open(alt)
+		if err != nil {
+			continue
+		}
+
+		if stat, err := f.Stat(); err == nil && !stat.IsDir() {
+			return f, nil
+		}
+		f.Close()
+	}
+	return afs.FileSystem.Open(name)
+}
@@ -0,0 +1,369 @@
+// Package auth implements session based authentication for gor applications.
+//
+// A Manager keeps the id of the logged in user in a gorilla/sessions store
+// and loads the user on every request with a UserLoader.
+//
+//	manager := auth.NewManager(store, auth.UserLoaderFunc(func(ctx context.Context, id string) (any, error) {
+//		return db.FindUser(ctx, id)
+//	}))
+//
+//	r.Use(manager.Middleware())
+//	r.Post("/login", func(w http.ResponseWriter, req *http.Request) {
+//		user := ... // verify the credentials
+//		auth.LoginUser(w, req, user.ID)
+//		gor.Redirect(w, req, auth.NextURL(req, "/"))
+//	})
+//
+//	dashboard := r.Group("/dashboard", manager.RequireLogin())
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/gorilla/sessions"
+)
+
+type contextKeyType string
+
+const (
+	managerKey   contextKeyType = "auth_manager"
+	userKey      contextKeyType = "auth_user"
+	twoFactorKey contextKeyType = "auth_2fa_verified"
+)
+
+// Session values.
+const (
+	userIDKey    = "user_id"      // Id of the logged in user
+	twoFactorVal = "2fa_verified" // Set once the second factor is verified
+)
+
+var (
+	// ErrNoManager is returned when the Manager middleware did not run for the request.
+	ErrNoManager = errors.New("auth: Manager middleware is not installed")
+
+	// ErrNoSession is returned by MarkTwoFactorVerified without a logged in user.
+	ErrNoSession = errors.New("auth: no user is logged in")
+
+	// ErrUserNotFound may be returned by a UserLoader for unknown ids.
+	// The session is then logged out.
+	ErrUserNotFound = errors.New("auth: user not found")
+)
+
+// TwoFactorUser is implemented by users that may have two-factor authentication.
+// When a Manager has a TwoFactorURL, RequireLogin also requires the second
+// factor of users whose TwoFactorEnabled returns true. See MarkTwoFactorVerified.
+type TwoFactorUser interface {
+	TwoFactorEnabled() bool
+}
+
+// UserLoader loads the user with the id stored at login.
+type UserLoader interface {
+	LoadUser(ctx context.Context, id string) (any, error)
+}
+
+// UserLoaderFunc is a function implementing UserLoader.
+type UserLoaderFunc func(ctx context.Context, id string) (any, error)
+
+// LoadUser calls f(ctx, id).
+func (f UserLoaderFunc) LoadUser(ctx context.Context, id string) (any, error) {
+	return f(ctx, id)
+}
+
+// Manager logs users in and out and loads the current user of each request.
+type Manager struct {
+	// Store keeps the session of logged in users.
+	Store sessions.Store
+
+	// Loader loads the current user from its id.
+	Loader UserLoader
+
+	// SessionName is the name of the session. Defaults to "gor_auth".
+	SessionName string
+
+	// LoginURL is where RequireLogin redirects HTML requests. Defaults to "/login".
+	LoginURL string
+
+	// NextParam is the query parameter of LoginURL carrying the requested URL.
+	// Defaults to "next".
+	NextParam string
+
+	// TwoFactorURL is where RequireLogin redirects HTML requests of users
+	// who have not verified their second factor yet. If empty, two-factor
+	// authentication is not enforced.
+	TwoFactorURL string
+}
+
+// Option configures a Manager.
+type Option func(m *Manager)
+
+// WithSessionName sets the name of the session.
+func WithSessionName(name string) Option {
+	return func(m *Manager) {
+		m.SessionName = name
+	}
+}
+
+// WithLoginURL sets where unauthenticated HTML requests are redirected.
+func WithLoginURL(url string) Option {
+	return func(m *Manager) {
+		m.LoginURL = url
+	}
+}
+
+// WithNextParam sets the query parameter carrying the requested URL.
+func WithNextParam(param string) Option {
+	return func(m *Manager) {
+		m.NextParam = param
+	}
+}
+
+// WithTwoFactor enforces two-factor authentication, redirecting users
+// who have not verified their second factor to url. See TwoFactorUser.
+func WithTwoFactor(url string) Option {
+	return func(m *Manager) {
+		m.TwoFactorURL = url
+	}
+}
+
+// NewManager creates a Manager keeping sessions in store and loading users with loader.
+func NewManager(store sessions.Store, loader UserLoader, options ...Option) *Manager {
+	m := &Manager{
+		Store:       store,
+		Loader:      loader,
+		SessionName: "gor_auth",
+		LoginURL:    "/login",
+		NextParam:   "next",
+	}
+
+	for _, option := range options {
+		option(m)
+	}
+	return m
+}
+
+// Middleware loads the user of the session, making it available with CurrentUser.
+// It must run before LoginUser, LogoutUser, CurrentUser and RequireLogin are used.
+// Requests with an invalid session or an unknown user are treated as anonymous.
+func (m *Manager) Middleware() gor.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			gor.SetContextValue(req, managerKey, m)
+
+			if user, verified := m.loadUser(w, req); user != nil {
+				gor.SetContextValue(req, userKey, user)
+				gor.SetContextValue(req, twoFactorKey, verified)
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// loadUser returns the user of the session or nil if there is none,
+// and whether their second factor was verified.
+func (m *Manager) loadUser(w http.ResponseWriter, req *http.Request) (any, bool) {
+	session, err := m.Store.Get(req, m.SessionName)
+	if err != nil {
+		return nil, false
+	}
+
+	id, ok := session.Values[userIDKey].(string)
+	if !ok || id == "" {
+		return nil, false
+	}
+
+	user, err := m.Loader.LoadUser(req.Context(), id)
+	if errors.Is(err, ErrUserNotFound) {
+		session.Values = map[any]any{}
+		session.Save(req, w)
+		return nil, false
+	}
+
+	if err != nil {
+		return nil, false
+	}
+
+	verified, _ := session.Values[twoFactorVal].(bool)
+	return user, verified
+}
+
+// RequireLogin rejects requests without a logged in user.
+// HTML requests are redirected to LoginURL with the requested URL in NextParam.
+// Other requests get a 401 Unauthorized JSON error.
+//
+// With a TwoFactorURL, users with two-factor authentication enabled must also
+// have verified their second factor, or are redirected to TwoFactorURL.
+func (m *Manager) RequireLogin() gor.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			user := CurrentUser(req)
+			if user == nil {
+				m.deny(w, req, m.LoginURL, "Unauthorized")
+				return
+			}
+
+			if m.TwoFactorURL != "" && !TwoFactorVerified(req) {
+				if u, ok := user.(TwoFactorUser); ok && u.TwoFactorEnabled() {
+					m.deny(w, req, m.TwoFactorURL, "Two-factor authentication required")
+					return
+				}
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// deny redirects HTML requests to target with the requested URL in NextParam,
+// and sends a 401 JSON error with message to other requests.
+func (m *Manager) deny(w http.ResponseWriter, req *http.Request, target, message string) {
+	if !wantsHTML(req) {
+		gor.SendJSONError(w, map[string]any{"error": message}, http.StatusUnauthorized)
+		return
+	}
+
+	if req.Method == http.MethodGet {
+		sep := "?"
+		if strings.Contains(target, "?") {
+			sep = "&"
+		}
+		target += sep + m.NextParam + "=" + url.QueryEscape(req.URL.RequestURI())
+	}
+	gor.Redirect(w, req, target)
+}
+
+// wantsHTML reports whether the request is made by a browser expecting a page.
+func wantsHTML(req *http.Request) bool {
+	return strings.Contains(req.Header.Get("Accept"), "text/html")
+}
+
+// LoginUser stores the user id in a new session and loads the user.
+// To prevent session fixation, the previous session is expired, which deletes
+// it from server-side stores, and the new session gets a new ID.
+func LoginUser(w http.ResponseWriter, req *http.Request, id string) error {
+	m, ok := gor.GetContextValue(req, managerKey).(*Manager)
+	if !ok {
+		return ErrNoManager
+	}
+
+	user, err := m.Loader.LoadUser(req.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	// The session is rotated in place: the store caches it for the request,
+	// and later calls to Store.Get, e.g by MarkTwoFactorVerified, must see
+	// the new session.
+	session, _ := m.Store.Get(req, m.SessionName)
+	var options *sessions.Options
+	if session.Options != nil {
+		copied := *session.Options
+		options = &copied
+	}
+
+	if !session.IsNew {
+		previous := *session
+		expired := sessions.Options{MaxAge: -1}
+		if options != nil {
+			expired = *options
+			expired.MaxAge = -1
+		}
+
+		previous.Options = &expired
+		previous.Values = map[any]any{}
+		if err := previous.Save(req, w); err != nil {
+			return err
+		}
+	}
+
+	session.ID = ""
+	session.IsNew = true
+	session.Options = options
+	session.Values = map[any]any{}
+	session.Values[userIDKey] = id
+	if err := session.Save(req, w); err != nil {
+		return err
+	}
+
+	gor.SetContextValue(req, userKey, user)
+	gor.SetContextValue(req, twoFactorKey, false)
+	return nil
+}
+
+// LogoutUser removes the user from the session.
+func LogoutUser(w http.ResponseWriter, req *http.Request) error {
+	m, ok := gor.GetContextValue(req, managerKey).(*Manager)
+	if !ok {
+		return ErrNoManager
+	}
+
+	session, _ := m.Store.Get(req, m.SessionName)
+	session.Values = map[any]any{}
+	if session.Options != nil {
+		session.Options.MaxAge = -1
+	}
+	if err := session.Save(req, w); err != nil {
+		return err
+	}
+
+	gor.SetContextValue(req, userKey, nil)
+	gor.SetContextValue(req, twoFactorKey, false)
+	return nil
+}
+
+// MarkTwoFactorVerified records in the session that the logged in user
+// verified their second factor, e.g with a TOTP code. The flag is cleared
+// at login and logout.
+func MarkTwoFactorVerified(w http.ResponseWriter, req *http.Request) error {
+	m, ok := gor.GetContextValue(req, managerKey).(*Manager)
+	if !ok {
+		return ErrNoManager
+	}
+
+	if CurrentUser(req) == nil {
+		return ErrNoSession
+	}
+
+	session, _ := m.Store.Get(req, m.SessionName)
+	session.Values[twoFactorVal] = true
+	if err := session.Save(req, w); err != nil {
+		return err
+	}
+
+	gor.SetContextValue(req, twoFactorKey, true)
+	return nil
+}
+
+// TwoFactorVerified reports whether the logged in user verified their second factor.
+func TwoFactorVerified(req *http.Request) bool {
+	verified, _ := gor.GetContextValue(req, twoFactorKey).(bool)
+	return verified
+}
+
+// CurrentUser returns the logged in user returned by the UserLoader, or nil.
+func CurrentUser(req *http.Request) any {
+	return gor.GetContextValue(req, userKey)
+}
+
+// IsAuthenticated reports whether a user is logged in.
+func IsAuthenticated(req *http.Request) bool {
+	return CurrentUser(req) != nil
+}
+
+// NextURL returns the URL requested before the login redirect,
+// or fallback if it is missing or not a local path.
+func NextURL(req *http.Request, fallback string) string {
+	param := "next"
+	if m, ok := gor.GetContextValue(req, managerKey).(*Manager); ok {
+		param = m.NextParam
+	}
+
+	next := req.FormValue(param)
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return fallback
+	}
+	return next
+}
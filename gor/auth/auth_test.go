@@ -0,0 +1,286 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/abiiranathan/gor/gor/auth"
+	"github.com/gorilla/sessions"
+)
+
+type user struct {
+	ID   string
+	Name string
+	TOTP bool
+}
+
+func (u *user) TwoFactorEnabled() bool {
+	return u.TOTP
+}
+
+var users = map[string]*user{
+	"1": {ID: "1", Name: "alice"},
+	"3": {ID: "3", Name: "carol", TOTP: true},
+}
+
+func loadUser(ctx context.Context, id string) (any, error) {
+	u, ok := users[id]
+	if !ok {
+		return nil, auth.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func newRouter(options ...auth.Option) *gor.Router {
+	manager := auth.NewManager(sessions.NewCookieStore([]byte("secret key")), auth.UserLoaderFunc(loadUser), options...)
+
+	r := gor.NewRouter()
+	r.Use(manager.Middleware())
+	r.Post("/login", func(w http.ResponseWriter, req *http.Request) {
+		if err := auth.LoginUser(w, req, req.FormValue("id")); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		gor.Redirect(w, req, auth.NextURL(req, "/"))
+	})
+	r.Post("/logout", func(w http.ResponseWriter, req *http.Request) {
+		auth.LogoutUser(w, req)
+	})
+	r.Post("/2fa", func(w http.ResponseWriter, req *http.Request) {
+		if req.FormValue("code") != "123456" {
+			http.Error(w, "invalid code", http.StatusUnauthorized)
+			return
+		}
+		auth.MarkTwoFactorVerified(w, req)
+	})
+	r.Get("/me", func(w http.ResponseWriter, req *http.Request) {
+		gor.SendString(w, auth.CurrentUser(req).(*user).Name)
+	}, manager.RequireLogin())
+	return r
+}
+
+func TestRequireLogin(t *testing.T) {
+	r := newRouter()
+
+	req := httptest.NewRequest("GET", "/me?tab=1", nil)
+	req.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/login?next=%2Fme%3Ftab%3D1" {
+		t.Errorf("expected a redirect to the login page, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	req = httptest.NewRequest("GET", "/me", nil)
+	req.Header.Set("Accept", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized || w.Header().Get("Content-Type") != gor.ContentTypeJSON {
+		t.Errorf("expected a 401 JSON error, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
+func TestLoginLogout(t *testing.T) {
+	r := newRouter()
+
+	req := httptest.NewRequest("POST", "/login?next=/me", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.PostForm = map[string][]string{"id": {"1"}}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Header().Get("Location") != "/me" {
+		t.Fatalf("expected a redirect to /me, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+	session := w.Result().Cookies()[0]
+
+	req = httptest.NewRequest("GET", "/me", nil)
+	req.AddCookie(session)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Body.String() != "alice" {
+		t.Errorf("expected alice, got %d %q", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "/logout", nil)
+	req.AddCookie(session)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if c := w.Result().Cookies(); len(c) != 1 || c[0].MaxAge >= 0 {
+		t.Errorf("expected the session cookie to be removed, got %v", c)
+	}
+
+	req = httptest.NewRequest("POST", "/login", nil)
+	req.PostForm = map[string][]string{"id": {"2"}}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected unknown users to be rejected, got %d", w.Code)
+	}
+}
+
+func TestLoginRegeneratesSessionID(t *testing.T) {
+	store := sessions.NewFilesystemStore(t.TempDir(), []byte("secret key"))
+	manager := auth.NewManager(store, auth.UserLoaderFunc(loadUser))
+
+	r := gor.NewRouter()
+	r.Use(manager.Middleware())
+	r.Post("/cart", func(w http.ResponseWriter, req *http.Request) {
+		session, _ := store.Get(req, manager.SessionName)
+		session.Values["cart"] = "book"
+		session.Save(req, w)
+	})
+	r.Post("/login", func(w http.ResponseWriter, req *http.Request) {
+		if err := auth.LoginUser(w, req, "1"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	sessionID := func(cookie *http.Cookie) string {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(cookie)
+		session, err := store.Get(req, manager.SessionName)
+		if err != nil || session.IsNew {
+			return ""
+		}
+		return session.ID
+	}
+
+	// The session of an anonymous visitor, or one planted by an attacker.
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/cart", nil))
+	anonymous := w.Result().Cookies()[0]
+	anonymousID := sessionID(anonymous)
+	if anonymousID == "" {
+		t.Fatal("expected an anonymous session")
+	}
+
+	req := httptest.NewRequest("POST", "/login", nil)
+	req.AddCookie(anonymous)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	cookies := w.Result().Cookies()
+	loggedIn := cookies[len(cookies)-1]
+	if id := sessionID(loggedIn); id == "" || id == anonymousID {
+		t.Errorf("expected a new session ID, got %q (was %q)", id, anonymousID)
+	}
+
+	if sessionID(anonymous) != "" {
+		t.Error("expected the previous session to be deleted")
+	}
+}
+
+func TestLoginThenMarkTwoFactorVerified(t *testing.T) {
+	store := sessions.NewFilesystemStore(t.TempDir(), []byte("secret key"))
+	manager := auth.NewManager(store, auth.UserLoaderFunc(loadUser))
+
+	r := gor.NewRouter()
+	r.Use(manager.Middleware())
+	r.Post("/cart", func(w http.ResponseWriter, req *http.Request) {
+		session, _ := store.Get(req, manager.SessionName)
+		session.Values["cart"] = "book"
+		session.Save(req, w)
+	})
+	r.Post("/login", func(w http.ResponseWriter, req *http.Request) {
+		if err := auth.LoginUser(w, req, "1"); err != nil {
+			t.Error(err)
+		}
+
+		// The session cached for the request must be the new one.
+		if err := auth.MarkTwoFactorVerified(w, req); err != nil {
+			t.Error(err)
+		}
+	})
+	r.Get("/me", func(w http.ResponseWriter, req *http.Request) {
+		if auth.CurrentUser(req) == nil || !auth.TwoFactorVerified(req) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/cart", nil))
+	anonymous := w.Result().Cookies()[0]
+
+	req := httptest.NewRequest("POST", "/login", nil)
+	req.AddCookie(anonymous)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	cookies := w.Result().Cookies()
+	req = httptest.NewRequest("GET", "/me", nil)
+	req.AddCookie(cookies[len(cookies)-1])
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected a logged in and verified user, got status %d", w.Code)
+	}
+}
+
+func TestNextURL(t *testing.T) {
+	tests := map[string]string{
+		"/dashboard":         "/dashboard",
+		"//evil.com":         "/",
+		"https://evil.com/x": "/",
+		"/\\evil.com":        "/",
+		"":                   "/",
+	}
+
+	for next, want := range tests {
+		req := httptest.NewRequest("GET", "/login", nil)
+		req.URL.RawQuery = "next=" + next
+		if got := auth.NextURL(req, "/"); got != want {
+			t.Errorf("%q: expected %q, got %q", next, want, got)
+		}
+	}
+}
+
+func TestRequireTwoFactor(t *testing.T) {
+	r := newRouter(auth.WithTwoFactor("/2fa"))
+
+	login := func(id string) *http.Cookie {
+		req := httptest.NewRequest("POST", "/login", nil)
+		req.PostForm = map[string][]string{"id": {id}}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Result().Cookies()[0]
+	}
+
+	me := func(session *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/me", nil)
+		req.Header.Set("Accept", "text/html")
+		req.AddCookie(session)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Users without two-factor authentication are not affected.
+	if w := me(login("1")); w.Body.String() != "alice" {
+		t.Errorf("expected alice, got %d %q", w.Code, w.Body.String())
+	}
+
+	session := login("3")
+	if w := me(session); w.Header().Get("Location") != "/2fa?next=%2Fme" {
+		t.Errorf("expected a redirect to /2fa, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	req := httptest.NewRequest("POST", "/2fa", nil)
+	req.PostForm = map[string][]string{"code": {"123456"}}
+	req.AddCookie(session)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	session = w.Result().Cookies()[0]
+
+	if w := me(session); w.Body.String() != "carol" {
+		t.Errorf("expected carol after the second factor, got %d %q", w.Code, w.Body.String())
+	}
+}
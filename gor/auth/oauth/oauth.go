@@ -0,0 +1,331 @@
+// Package oauth implements OAuth2 login with providers such as Google and GitHub.
+//
+// A Provider redirects users to the authorization server with a random state
+// and a PKCE challenge, kept in an encrypted cookie, then exchanges the code
+// returned to its callback, fetches the user profile and calls OnLogin.
+//
+//	google := oauth.Google(clientID, clientSecret, "https://example.com/auth/google/callback")
+//	google.Keys = cookieKeys
+//	google.OnLogin = oauth.SessionLogin(func(ctx context.Context, p oauth.Profile) (string, error) {
+//		return db.UpsertUser(ctx, p.Provider, p.ID, p.Email)
+//	})
+//
+//	r.Use(manager.Middleware())
+//	r.OAuth("/auth/google", google)
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/abiiranathan/gor/gor/auth"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrInvalidState = errors.New("oauth: invalid state")
+	ErrNoCode       = errors.New("oauth: missing authorization code")
+)
+
+// Profile is the user profile returned by a provider.
+type Profile struct {
+	Provider  string         // Name of the provider, e.g "google"
+	ID        string         // Id of the user at the provider
+	Email     string         // Email, may be empty
+	Name      string         // Display name
+	AvatarURL string         // Picture of the user
+	Raw       map[string]any // Decoded profile response
+}
+
+// LoginFunc is called with the profile of the user after a successful login.
+// It typically creates or loads the user and logs them in, see SessionLogin.
+type LoginFunc func(w http.ResponseWriter, req *http.Request, profile Profile, token *oauth2.Token) error
+
+// Provider is the configuration of an OAuth2 provider.
+// It implements gor.OAuthHandler to be registered with Router.OAuth.
+type Provider struct {
+	// Name of the provider, set in Profile.Provider.
+	Name string
+
+	// Config is the OAuth2 client configuration.
+	// Its RedirectURL must be the callback route.
+	Config *oauth2.Config
+
+	// ProfileURL returns the profile of the user authenticated by the access token.
+	ProfileURL string
+
+	// MapProfile maps the decoded profile response to a Profile.
+	// Defaults to DefaultProfile.
+	MapProfile func(raw map[string]any) Profile
+
+	// OnLogin is called after the user is authenticated.
+	OnLogin LoginFunc
+
+	// RedirectURL is where users are sent after login if the login
+	// request had no local "next" query parameter. Defaults to "/".
+	RedirectURL string
+
+	// Keys encrypt the state cookie. Random keys are used if empty,
+	// which only works if the login and callback hit the same instance.
+	Keys gor.CookieKeys
+
+	// DisablePKCE disables the PKCE challenge for providers that reject it.
+	DisablePKCE bool
+}
+
+// New creates a generic OAuth2 provider.
+func New(name string, config *oauth2.Config, profileURL string) *Provider {
+	return &Provider{
+		Name:        name,
+		Config:      config,
+		ProfileURL:  profileURL,
+		MapProfile:  DefaultProfile,
+		RedirectURL: "/",
+	}
+}
+
+// Google creates a provider for Google accounts with the openid, email and profile scopes.
+func Google(clientID, clientSecret, redirectURL string, scopes ...string) *Provider {
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	return New("google", &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  "https://accounts.google.com/o/oauth2/auth",
+			TokenURL: "https://oauth2.googleapis.com/token",
+		},
+	}, "https://openidconnect.googleapis.com/v1/userinfo")
+}
+
+// GitHub creates a provider for GitHub accounts with the read:user and user:email scopes.
+func GitHub(clientID, clientSecret, redirectURL string, scopes ...string) *Provider {
+	if len(scopes) == 0 {
+		scopes = []string{"read:user", "user:email"}
+	}
+
+	p := New("github", &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  "https://github.com/login/oauth/authorize",
+			TokenURL: "https://github.com/login/oauth/access_token",
+		},
+	}, "https://api.github.com/user")
+
+	p.MapProfile = func(raw map[string]any) Profile {
+		profile := DefaultProfile(raw)
+		if profile.Name == "" {
+			profile.Name = stringValue(raw, "login")
+		}
+		return profile
+	}
+	return p
+}
+
+// DefaultProfile maps the common fields of profile responses:
+// "sub" or "id", "email", "name" and "picture" or "avatar_url".
+func DefaultProfile(raw map[string]any) Profile {
+	return Profile{
+		ID:        stringValue(raw, "sub", "id"),
+		Email:     stringValue(raw, "email"),
+		Name:      stringValue(raw, "name"),
+		AvatarURL: stringValue(raw, "picture", "avatar_url"),
+		Raw:       raw,
+	}
+}
+
+// stringValue returns the first non empty value of keys formatted as a string.
+func stringValue(raw map[string]any, keys ...string) string {
+	for _, key := range keys {
+		switch v := raw[key].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case float64:
+			return fmt.Sprintf("%.0f", v)
+		}
+	}
+	return ""
+}
+
+// SessionLogin returns a LoginFunc logging the user in with gor/auth.
+// resolve returns the id of the local user of the profile, creating it if needed.
+// The auth.Manager middleware must run before the callback.
+func SessionLogin(resolve func(ctx context.Context, profile Profile) (string, error)) LoginFunc {
+	return func(w http.ResponseWriter, req *http.Request, profile Profile, token *oauth2.Token) error {
+		id, err := resolve(req.Context(), profile)
+		if err != nil {
+			return err
+		}
+		return auth.LoginUser(w, req, id)
+	}
+}
+
+// loginState is kept in the state cookie between the login and the callback.
+type loginState struct {
+	State    string `json:"s"`
+	Verifier string `json:"v,omitempty"`
+	Next     string `json:"n"`
+}
+
+// randomKeys encrypt the state cookie of providers without Keys.
+var randomKeys = func() gor.CookieKeys {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return gor.CookieKeys{key}
+}()
+
+func (p *Provider) keys() gor.CookieKeys {
+	if len(p.Keys) > 0 {
+		return p.Keys
+	}
+	return randomKeys
+}
+
+func (p *Provider) cookieName() string {
+	return "oauth_" + p.Name
+}
+
+// LoginHandler redirects the user to the authorization server.
+// A local "next" query parameter is where the user is sent after login.
+func (p *Provider) LoginHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		state := loginState{
+			State: base64.RawURLEncoding.EncodeToString(b),
+			Next:  auth.NextURL(req, p.RedirectURL),
+		}
+
+		var options []oauth2.AuthCodeOption
+		if !p.DisablePKCE {
+			state.Verifier = oauth2.GenerateVerifier()
+			options = append(options, oauth2.S256ChallengeOption(state.Verifier))
+		}
+
+		value, err := json.Marshal(state)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		cookie := gor.NewCookie(req, p.cookieName(), string(value))
+		cookie.MaxAge = 600
+		if err := gor.SetEncryptedCookie(w, cookie, p.keys()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, req, p.Config.AuthCodeURL(state.State, options...), http.StatusFound)
+	})
+}
+
+// CallbackHandler verifies the state, exchanges the code for a token,
+// fetches the profile and calls OnLogin before redirecting the user.
+// Failed logins get 400 Bad Request, or 500 if OnLogin fails.
+func (p *Provider) CallbackHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		value, err := gor.GetEncryptedCookie(req, p.cookieName(), p.keys())
+
+		// The state is single use.
+		expired := gor.NewCookie(req, p.cookieName(), "")
+		expired.MaxAge = -1
+		http.SetCookie(w, expired)
+
+		var state loginState
+		if err != nil || json.Unmarshal([]byte(value), &state) != nil ||
+			subtle.ConstantTimeCompare([]byte(state.State), []byte(req.URL.Query().Get("state"))) != 1 {
+			http.Error(w, ErrInvalidState.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if e := req.URL.Query().Get("error"); e != "" {
+			http.Error(w, "oauth: "+e, http.StatusBadRequest)
+			return
+		}
+
+		code := req.URL.Query().Get("code")
+		if code == "" {
+			http.Error(w, ErrNoCode.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var options []oauth2.AuthCodeOption
+		if state.Verifier != "" {
+			options = append(options, oauth2.VerifierOption(state.Verifier))
+		}
+
+		token, err := p.Config.Exchange(req.Context(), code, options...)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		profile, err := p.fetchProfile(req.Context(), token)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if p.OnLogin != nil {
+			if err := p.OnLogin(w, req, profile, token); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		http.Redirect(w, req, state.Next, http.StatusSeeOther)
+	})
+}
+
+// fetchProfile fetches and maps the profile of the token owner.
+func (p *Provider) fetchProfile(ctx context.Context, token *oauth2.Token) (Profile, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.ProfileURL, nil)
+	if err != nil {
+		return Profile{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := p.Config.Client(ctx, token).Do(req)
+	if err != nil {
+		return Profile{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return Profile{}, fmt.Errorf("oauth: profile request failed with status %d", res.StatusCode)
+	}
+
+	raw := make(map[string]any)
+	if err := json.NewDecoder(res.Body).Decode(&raw); err != nil {
+		return Profile{}, err
+	}
+
+	mapProfile := p.MapProfile
+	if mapProfile == nil {
+		mapProfile = DefaultProfile
+	}
+
+	profile := mapProfile(raw)
+	profile.Provider = p.Name
+	return profile, nil
+}
@@ -0,0 +1,88 @@
+package oauth_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/abiiranathan/gor/gor/auth/oauth"
+	"golang.org/x/oauth2"
+)
+
+func TestProvider(t *testing.T) {
+	var challenge string
+
+	// A fake authorization server.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/token":
+			sum := sha256.Sum256([]byte(req.FormValue("code_verifier")))
+			if req.FormValue("code") != "the-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+				http.Error(w, "invalid_grant", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"the-token","token_type":"Bearer"}`))
+		case "/user":
+			if req.Header.Get("Authorization") != "Bearer the-token" {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"id":42,"login":"octocat","email":"octo@example.com"}`))
+		}
+	}))
+	defer server.Close()
+
+	provider := oauth.GitHub("client", "secret", "http://app.test/auth/github/callback")
+	provider.Config.Endpoint = oauth2.Endpoint{AuthURL: server.URL + "/authorize", TokenURL: server.URL + "/token"}
+	provider.ProfileURL = server.URL + "/user"
+
+	var profile oauth.Profile
+	provider.OnLogin = func(w http.ResponseWriter, req *http.Request, p oauth.Profile, token *oauth2.Token) error {
+		profile = p
+		return nil
+	}
+
+	r := gor.NewRouter()
+	r.OAuth("/auth/github", provider)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/auth/github?next=/dashboard", nil))
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil || w.Code != http.StatusFound {
+		t.Fatalf("expected a redirect to the provider, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	query := location.Query()
+	challenge = query.Get("code_challenge")
+	if query.Get("state") == "" || challenge == "" || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("expected a state and a PKCE challenge, got %q", location.RawQuery)
+	}
+	cookie := w.Result().Cookies()[0]
+
+	callback := func(state string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/auth/github/callback?code=the-code&state="+state, nil)
+		req.AddCookie(cookie)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := callback("forged"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected a forged state to be rejected, got %d", w.Code)
+	}
+
+	w = callback(query.Get("state"))
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/dashboard" {
+		t.Fatalf("expected a redirect to /dashboard, got %d %q %s", w.Code, w.Header().Get("Location"), w.Body)
+	}
+
+	if profile.Provider != "github" || profile.ID != "42" || profile.Name != "octocat" || profile.Email != "octo@example.com" {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+}
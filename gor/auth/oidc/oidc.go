@@ -0,0 +1,471 @@
+// Package oidc implements OpenID Connect login with providers such as
+// Keycloak, Azure AD or Google, configured with discovery.
+//
+// The Provider handles the authorization code flow with state, nonce and PKCE,
+// validates ID tokens against the keys published by the provider, keeps the
+// tokens in a gorilla/sessions store and refreshes them when they expire.
+//
+//	provider, err := oidc.Discover(ctx, "https://sso.example.com/realms/intranet", &oauth2.Config{
+//		ClientID:     clientID,
+//		ClientSecret: clientSecret,
+//		RedirectURL:  "https://intranet.example.com/auth/oidc/callback",
+//	}, store)
+//
+//	r.Use(provider.Middleware())
+//	r.OAuth("/auth/oidc", provider)
+//	r.Post("/logout", provider.LogoutHandler())
+//
+//	app := r.Group("/app", provider.RequireAuth())
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/abiiranathan/gor/gor/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/sessions"
+	"golang.org/x/oauth2"
+)
+
+type contextKeyType string
+
+// ClaimsKey is the context key of the ID token claims of the logged in user.
+// They are also returned by Claims.
+//
+//	claims, _ := gor.GetContextValue(req, oidc.ClaimsKey).(jwt.MapClaims)
+const ClaimsKey contextKeyType = "oidc_claims"
+
+// Session values.
+const (
+	idTokenKey      = "id_token"
+	accessTokenKey  = "access_token"
+	refreshTokenKey = "refresh_token"
+	expiryKey       = "expiry"
+	claimsKey       = "claims"
+)
+
+var (
+	ErrInvalidState   = errors.New("oidc: invalid state")
+	ErrInvalidNonce   = errors.New("oidc: invalid nonce")
+	ErrNoIDToken      = errors.New("oidc: token response has no id_token")
+	ErrIssuerMismatch = errors.New("oidc: issuer does not match the discovery document")
+)
+
+// signingMethods are the accepted ID token algorithms.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// Metadata is the provider configuration served at /.well-known/openid-configuration.
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+// Provider is an OpenID Connect relying party.
+// It implements gor.OAuthHandler to be registered with Router.OAuth.
+type Provider struct {
+	// Metadata is the discovered provider configuration.
+	Metadata Metadata
+
+	// Config is the OAuth2 client configuration. Its endpoints are set by discovery
+	// and its RedirectURL must be the callback route.
+	Config *oauth2.Config
+
+	// Store keeps the tokens of logged in users. As tokens are large,
+	// prefer a server side store to a cookie store.
+	Store sessions.Store
+
+	// SessionName is the name of the session. Defaults to "gor_oidc".
+	SessionName string
+
+	// Keys encrypt the state cookie of the login flow.
+	// Random keys are used if empty, which only works with a single instance.
+	Keys gor.CookieKeys
+
+	// RedirectURL is where users are sent after login if the login
+	// request had no local "next" query parameter. Defaults to "/".
+	RedirectURL string
+
+	// LoginURL is where RequireAuth redirects HTML requests,
+	// the route of LoginHandler. Defaults to "/login".
+	LoginURL string
+
+	// PostLogoutRedirectURL is where the provider sends users after logout.
+	PostLogoutRedirectURL string
+
+	// ExpiryLeeway refreshes tokens this long before they expire. Defaults to 10 seconds.
+	ExpiryLeeway time.Duration
+
+	// Client makes the requests to the provider. Defaults to http.DefaultClient.
+	Client *http.Client
+
+	keys *keySet
+}
+
+// Discover fetches the configuration of the provider at issuer and returns
+// a Provider for the client config. The "openid" scope is added if missing.
+func Discover(ctx context.Context, issuer string, config *oauth2.Config, store sessions.Store) (*Provider, error) {
+	p := &Provider{
+		Config:       config,
+		Store:        store,
+		SessionName:  "gor_oidc",
+		RedirectURL:  "/",
+		LoginURL:     "/login",
+		ExpiryLeeway: 10 * time.Second,
+		Client:       http.DefaultClient,
+	}
+
+	wellKnown := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, p.Client, wellKnown, &p.Metadata); err != nil {
+		return nil, err
+	}
+
+	if p.Metadata.Issuer != issuer {
+		return nil, fmt.Errorf("%w: %q != %q", ErrIssuerMismatch, p.Metadata.Issuer, issuer)
+	}
+
+	config.Endpoint = oauth2.Endpoint{
+		AuthURL:  p.Metadata.AuthorizationEndpoint,
+		TokenURL: p.Metadata.TokenEndpoint,
+	}
+
+	if !slices.Contains(config.Scopes, "openid") {
+		config.Scopes = append([]string{"openid"}, config.Scopes...)
+	}
+
+	p.keys = &keySet{url: p.Metadata.JWKSURI, client: p.Client}
+	return p, nil
+}
+
+// VerifyIDToken verifies the signature, issuer, audience and expiry of an ID token
+// and returns its claims. If nonce is not empty, the nonce claim must match.
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.keys.get(ctx, kid)
+	},
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(p.Metadata.Issuer),
+		jwt.WithAudience(p.Config.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if nonce != "" {
+		got, _ := claims["nonce"].(string)
+		if subtle.ConstantTimeCompare([]byte(got), []byte(nonce)) != 1 {
+			return nil, ErrInvalidNonce
+		}
+	}
+	return claims, nil
+}
+
+// Claims returns the ID token claims of the logged in user, or nil.
+func Claims(req *http.Request) jwt.MapClaims {
+	claims, _ := gor.GetContextValue(req, ClaimsKey).(jwt.MapClaims)
+	return claims
+}
+
+// context returns ctx with the client used by the oauth2 package.
+func (p *Provider) context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, p.Client)
+}
+
+// Middleware loads the claims of the logged in user into the request context,
+// refreshing the tokens when they expire. Users whose tokens can not be
+// refreshed are logged out. Use RequireAuth to reject anonymous requests.
+func (p *Provider) Middleware() gor.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if claims := p.loadClaims(w, req); claims != nil {
+				gor.SetContextValue(req, ClaimsKey, claims)
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// loadClaims returns the claims of the session, or nil if there are none.
+func (p *Provider) loadClaims(w http.ResponseWriter, req *http.Request) jwt.MapClaims {
+	session, err := p.Store.Get(req, p.SessionName)
+	if err != nil {
+		return nil
+	}
+
+	raw, ok := session.Values[claimsKey].(string)
+	if !ok {
+		return nil
+	}
+
+	expiry, _ := session.Values[expiryKey].(int64)
+	if expiry != 0 && time.Now().Add(p.ExpiryLeeway).Unix() >= expiry {
+		if err := p.refresh(req.Context(), session); err != nil {
+			session.Values = map[any]any{}
+			session.Save(req, w)
+			return nil
+		}
+
+		if err := session.Save(req, w); err != nil {
+			return nil
+		}
+		raw = session.Values[claimsKey].(string)
+	}
+
+	claims := jwt.MapClaims{}
+	if err := json.Unmarshal([]byte(raw), &claims); err != nil {
+		return nil
+	}
+	return claims
+}
+
+// refresh exchanges the refresh token of the session for new tokens.
+func (p *Provider) refresh(ctx context.Context, session *sessions.Session) error {
+	refreshToken, _ := session.Values[refreshTokenKey].(string)
+	if refreshToken == "" {
+		return errors.New("oidc: session has no refresh token")
+	}
+
+	ctx = p.context(ctx)
+	token, err := p.Config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		return err
+	}
+	return p.storeToken(ctx, session, token, "")
+}
+
+// storeToken stores the tokens in the session, verifying the ID token if any.
+// The ID token is required when nonce is set, at login.
+func (p *Provider) storeToken(ctx context.Context, session *sessions.Session, token *oauth2.Token, nonce string) error {
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if rawIDToken == "" && nonce != "" {
+		return ErrNoIDToken
+	}
+
+	if rawIDToken != "" {
+		claims, err := p.VerifyIDToken(ctx, rawIDToken, nonce)
+		if err != nil {
+			return err
+		}
+
+		b, err := json.Marshal(claims)
+		if err != nil {
+			return err
+		}
+
+		session.Values[idTokenKey] = rawIDToken
+		session.Values[claimsKey] = string(b)
+	}
+
+	session.Values[accessTokenKey] = token.AccessToken
+	if token.RefreshToken != "" {
+		session.Values[refreshTokenKey] = token.RefreshToken
+	}
+
+	if token.Expiry.IsZero() {
+		delete(session.Values, expiryKey)
+	} else {
+		session.Values[expiryKey] = token.Expiry.Unix()
+	}
+	return nil
+}
+
+// AccessToken returns the access token of the logged in user, e.g to call APIs
+// on their behalf. Middleware refreshes it before it expires.
+func (p *Provider) AccessToken(req *http.Request) string {
+	session, err := p.Store.Get(req, p.SessionName)
+	if err != nil {
+		return ""
+	}
+
+	token, _ := session.Values[accessTokenKey].(string)
+	return token
+}
+
+// RequireAuth rejects requests without a logged in user.
+// HTML requests are redirected to LoginURL with the requested URL in the
+// "next" query parameter. Other requests get a 401 Unauthorized JSON error.
+func (p *Provider) RequireAuth() gor.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if Claims(req) != nil {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			if !strings.Contains(req.Header.Get("Accept"), "text/html") {
+				gor.SendJSONError(w, map[string]any{"error": "Unauthorized"}, http.StatusUnauthorized)
+				return
+			}
+
+			loginURL := p.LoginURL
+			if req.Method == http.MethodGet {
+				loginURL += "?next=" + url.QueryEscape(req.URL.RequestURI())
+			}
+			gor.Redirect(w, req, loginURL)
+		})
+	}
+}
+
+// loginState is kept in the state cookie between the login and the callback.
+type loginState struct {
+	State    string `json:"s"`
+	Nonce    string `json:"o"`
+	Verifier string `json:"v"`
+	Next     string `json:"n"`
+}
+
+// randomKeys encrypt the state cookie of providers without Keys.
+var randomKeys = func() gor.CookieKeys {
+	return gor.CookieKeys{[]byte(randomString())}
+}()
+
+func randomString() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (p *Provider) stateKeys() gor.CookieKeys {
+	if len(p.Keys) > 0 {
+		return p.Keys
+	}
+	return randomKeys
+}
+
+func (p *Provider) stateCookieName() string {
+	return p.SessionName + "_state"
+}
+
+// LoginHandler redirects the user to the provider.
+// A local "next" query parameter is where the user is sent after login.
+func (p *Provider) LoginHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		state := loginState{
+			State:    randomString(),
+			Nonce:    randomString(),
+			Verifier: oauth2.GenerateVerifier(),
+			Next:     auth.NextURL(req, p.RedirectURL),
+		}
+
+		value, err := json.Marshal(state)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		cookie := gor.NewCookie(req, p.stateCookieName(), string(value))
+		cookie.MaxAge = 600
+		if err := gor.SetEncryptedCookie(w, cookie, p.stateKeys()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		authURL := p.Config.AuthCodeURL(state.State,
+			oauth2.SetAuthURLParam("nonce", state.Nonce),
+			oauth2.S256ChallengeOption(state.Verifier))
+		http.Redirect(w, req, authURL, http.StatusFound)
+	})
+}
+
+// CallbackHandler verifies the state, exchanges the code for tokens, validates
+// the ID token and its nonce and stores the tokens in the session before
+// redirecting the user. Failed logins get 400 Bad Request.
+func (p *Provider) CallbackHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		value, err := gor.GetEncryptedCookie(req, p.stateCookieName(), p.stateKeys())
+
+		// The state is single use.
+		expired := gor.NewCookie(req, p.stateCookieName(), "")
+		expired.MaxAge = -1
+		http.SetCookie(w, expired)
+
+		var state loginState
+		if err != nil || json.Unmarshal([]byte(value), &state) != nil ||
+			subtle.ConstantTimeCompare([]byte(state.State), []byte(req.URL.Query().Get("state"))) != 1 {
+			http.Error(w, ErrInvalidState.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if e := req.URL.Query().Get("error"); e != "" {
+			http.Error(w, "oidc: "+e, http.StatusBadRequest)
+			return
+		}
+
+		ctx := p.context(req.Context())
+		token, err := p.Config.Exchange(ctx, req.URL.Query().Get("code"), oauth2.VerifierOption(state.Verifier))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		session, _ := p.Store.Get(req, p.SessionName)
+		session.Values = map[any]any{}
+		if err := p.storeToken(ctx, session, token, state.Nonce); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := session.Save(req, w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, req, state.Next, http.StatusSeeOther)
+	})
+}
+
+// LogoutHandler clears the session and redirects to the end session endpoint
+// of the provider, if any, to end the single sign-on session too.
+// Otherwise the user is redirected to PostLogoutRedirectURL or "/".
+func (p *Provider) LogoutHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		session, _ := p.Store.Get(req, p.SessionName)
+		idToken, _ := session.Values[idTokenKey].(string)
+
+		session.Values = map[any]any{}
+		if session.Options != nil {
+			session.Options.MaxAge = -1
+		}
+
+		if err := session.Save(req, w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		redirect := p.PostLogoutRedirectURL
+		if p.Metadata.EndSessionEndpoint != "" {
+			query := url.Values{"client_id": {p.Config.ClientID}}
+			if idToken != "" {
+				query.Set("id_token_hint", idToken)
+			}
+			if redirect != "" {
+				query.Set("post_logout_redirect_uri", redirect)
+			}
+			redirect = p.Metadata.EndSessionEndpoint + "?" + query.Encode()
+		}
+
+		if redirect == "" {
+			redirect = "/"
+		}
+		gor.Redirect(w, req, redirect)
+	}
+}
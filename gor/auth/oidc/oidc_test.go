@@ -0,0 +1,181 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/abiiranathan/gor/gor/auth/oidc"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/sessions"
+	"golang.org/x/oauth2"
+)
+
+// fakeProvider is a minimal OpenID Connect provider.
+type fakeProvider struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	nonce     string
+	refreshes int
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fakeProvider{key: key}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	return f
+}
+
+func (f *fakeProvider) idToken(nonce string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   f.URL,
+		"aud":   "client",
+		"sub":   "alice",
+		"nonce": nonce,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = "key-1"
+
+	s, err := token.SignedString(f.key)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (f *fakeProvider) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch req.URL.Path {
+	case "/.well-known/openid-configuration":
+		json.NewEncoder(w).Encode(oidc.Metadata{
+			Issuer:                f.URL,
+			AuthorizationEndpoint: f.URL + "/authorize",
+			TokenEndpoint:         f.URL + "/token",
+			JWKSURI:               f.URL + "/jwks",
+			EndSessionEndpoint:    f.URL + "/logout",
+		})
+	case "/jwks":
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": "key-1",
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(f.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(f.key.E)).Bytes()),
+		}}})
+	case "/token":
+		nonce := f.nonce
+		if req.FormValue("grant_type") == "refresh_token" {
+			f.refreshes++
+			nonce = ""
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  "access",
+			"refresh_token": "refresh",
+			"token_type":    "Bearer",
+			"expires_in":    5,
+			"id_token":      f.idToken(nonce),
+		})
+	}
+}
+
+func TestProvider(t *testing.T) {
+	fake := newFakeProvider(t)
+	defer fake.Close()
+
+	provider, err := oidc.Discover(context.Background(), fake.URL, &oauth2.Config{
+		ClientID:    "client",
+		RedirectURL: "http://app.test/auth/oidc/callback",
+	}, sessions.NewCookieStore([]byte("secret key")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gor.NewRouter()
+	r.Use(provider.Middleware())
+	r.OAuth("/auth/oidc", provider)
+	r.Get("/me", func(w http.ResponseWriter, req *http.Request) {
+		gor.SendString(w, oidc.Claims(req)["sub"].(string))
+	}, provider.RequireAuth())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/me", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 before login, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/auth/oidc?next=/me", nil))
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := location.Query()
+	if query.Get("nonce") == "" || query.Get("code_challenge") == "" || query.Get("scope") != "openid" {
+		t.Fatalf("unexpected authorization request %q", location.RawQuery)
+	}
+	stateCookie := w.Result().Cookies()[0]
+
+	callback := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/auth/oidc/callback?code=code&state="+query.Get("state"), nil)
+		req.AddCookie(stateCookie)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// An ID token with another nonce is rejected.
+	fake.nonce = "replayed"
+	if w := callback(); w.Code != http.StatusBadRequest {
+		t.Errorf("expected a nonce mismatch to be rejected, got %d", w.Code)
+	}
+
+	fake.nonce = query.Get("nonce")
+	w = callback()
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/me" {
+		t.Fatalf("expected a redirect to /me, got %d %q", w.Code, w.Body)
+	}
+
+	var session *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "gor_oidc" {
+			session = c
+		}
+	}
+
+	// The token expires within the leeway, so it is refreshed.
+	req := httptest.NewRequest("GET", "/me", nil)
+	req.AddCookie(session)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Body.String() != "alice" || fake.refreshes != 1 {
+		t.Errorf("expected alice after a refresh, got %q with %d refreshes", w.Body.String(), fake.refreshes)
+	}
+
+	claims, err := provider.VerifyIDToken(context.Background(), fake.idToken(""), "")
+	if err != nil || claims["sub"] != "alice" {
+		t.Errorf("expected a valid token, got %v", err)
+	}
+
+	provider.Config.ClientID = "other"
+	if _, err := provider.VerifyIDToken(context.Background(), fake.idToken(""), ""); err == nil {
+		t.Error("expected a token for another audience to be rejected")
+	}
+}
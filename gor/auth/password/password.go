@@ -0,0 +1,203 @@
+// Package password hashes and verifies passwords with argon2id or bcrypt.
+//
+//	hash, err := password.Hash(req.FormValue("password"))
+//
+//	ok, err := password.Verify(req.FormValue("password"), user.PasswordHash)
+//	if ok && password.NeedsRehash(user.PasswordHash) {
+//		user.PasswordHash, _ = password.Hash(req.FormValue("password"))
+//	}
+//
+// Verify accepts hashes of both algorithms, so applications can move from
+// bcrypt to argon2id, or to stronger parameters, as users log in.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidHash is returned for hashes of an unknown format.
+	ErrInvalidHash = errors.New("password: invalid hash")
+
+	// ErrIncompatibleVersion is returned for argon2 hashes of another version.
+	ErrIncompatibleVersion = errors.New("password: incompatible argon2 version")
+)
+
+// Hasher hashes and verifies passwords with an algorithm.
+type Hasher interface {
+	// Hash returns the encoded hash of password, including its salt and parameters.
+	Hash(password string) (string, error)
+
+	// Verify reports whether password matches the encoded hash.
+	Verify(password, hash string) (bool, error)
+
+	// NeedsRehash reports whether the hash was not created with the
+	// algorithm and parameters of the hasher.
+	NeedsRehash(hash string) bool
+}
+
+// Argon2id hashes passwords with argon2id, encoded in the PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=2$salt$key.
+type Argon2id struct {
+	Memory      uint32 // Memory in KiB
+	Iterations  uint32 // Number of passes over the memory
+	Parallelism uint8  // Number of threads
+	SaltLength  uint32 // Length of the random salt in bytes
+	KeyLength   uint32 // Length of the derived key in bytes
+}
+
+// DefaultArgon2id are the parameters recommended by RFC 9106 for
+// memory constrained environments.
+var DefaultArgon2id = Argon2id{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Hash returns the argon2id hash of password.
+func (a Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		a.Memory, a.Iterations, a.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify reports whether password matches the argon2id hash,
+// using the parameters encoded in the hash.
+func (a Argon2id) Verify(password, hash string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// NeedsRehash reports whether hash is not an argon2id hash with the parameters of a.
+func (a Argon2id) NeedsRehash(hash string) bool {
+	params, _, _, err := decodeArgon2id(hash)
+	return err != nil || params != a
+}
+
+// decodeArgon2id decodes the parameters, salt and key of an argon2id hash.
+func decodeArgon2id(hash string) (params Argon2id, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	if version != argon2.Version {
+		return params, nil, nil, ErrIncompatibleVersion
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	// argon2.IDKey panics without iterations or threads. RFC 9106 requires
+	// at least 8 KiB of memory per thread.
+	if params.Iterations < 1 || params.Parallelism < 1 || params.Memory < 8*uint32(params.Parallelism) {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+// Bcrypt hashes passwords with bcrypt.
+// Note that bcrypt only uses the first 72 bytes of passwords.
+type Bcrypt struct {
+	Cost int // Defaults to bcrypt.DefaultCost
+}
+
+func (b Bcrypt) cost() int {
+	if b.Cost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return b.Cost
+}
+
+// Hash returns the bcrypt hash of password.
+func (b Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost())
+	return string(hash), err
+}
+
+// Verify reports whether password matches the bcrypt hash.
+func (b Bcrypt) Verify(password, hash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, ErrInvalidHash
+	}
+	return true, nil
+}
+
+// NeedsRehash reports whether hash is not a bcrypt hash with the cost of b.
+func (b Bcrypt) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != b.cost()
+}
+
+// Default is the hasher of Hash and NeedsRehash.
+var Default Hasher = DefaultArgon2id
+
+// Hash returns the hash of password with the Default hasher.
+func Hash(password string) (string, error) {
+	return Default.Hash(password)
+}
+
+// Verify reports whether password matches hash. Both argon2id and bcrypt
+// hashes are accepted, whatever the Default hasher.
+func Verify(password, hash string) (bool, error) {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return Argon2id{}.Verify(password, hash)
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return Bcrypt{}.Verify(password, hash)
+	}
+	return false, ErrInvalidHash
+}
+
+// NeedsRehash reports whether hash was not created by the Default hasher with
+// its current parameters, and should be replaced after a successful Verify.
+func NeedsRehash(hash string) bool {
+	return Default.NeedsRehash(hash)
+}
@@ -0,0 +1,82 @@
+package password_test
+
+import (
+	"testing"
+
+	"github.com/abiiranathan/gor/gor/auth/password"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fast keeps the tests quick.
+var fast = password.Argon2id{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestHashVerify(t *testing.T) {
+	hashers := map[string]password.Hasher{
+		"argon2id": fast,
+		"bcrypt":   password.Bcrypt{Cost: bcrypt.MinCost},
+	}
+
+	for name, h := range hashers {
+		hash, err := h.Hash("correct horse")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ok, err := h.Verify("correct horse", hash); !ok || err != nil {
+			t.Errorf("%s: expected the password to match, got %v, %v", name, ok, err)
+		}
+
+		if ok, _ := password.Verify("battery staple", hash); ok {
+			t.Errorf("%s: expected a wrong password not to match", name)
+		}
+
+		if ok, err := password.Verify("correct horse", hash); !ok || err != nil {
+			t.Errorf("%s: expected Verify to detect the algorithm, got %v, %v", name, ok, err)
+		}
+
+		if h.NeedsRehash(hash) {
+			t.Errorf("%s: expected a hash with the same parameters not to need a rehash", name)
+		}
+	}
+
+	if _, err := password.Verify("x", "plaintext"); err != password.ErrInvalidHash {
+		t.Errorf("expected ErrInvalidHash, got %v", err)
+	}
+}
+
+func TestVerifyInvalidParams(t *testing.T) {
+	const salt, key = "c2FsdHNhbHRzYWx0c2FsdA", "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+
+	for _, params := range []string{"m=1024,t=0,p=1", "m=1024,t=1,p=0", "m=0,t=1,p=1", "m=8,t=1,p=2", "m=1024,t=1,p=256"} {
+		hash := "$argon2id$v=19$" + params + "$" + salt + "$" + key
+		if _, err := password.Verify("x", hash); err != password.ErrInvalidHash {
+			t.Errorf("%s: expected ErrInvalidHash, got %v", params, err)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	old, _ := password.Bcrypt{Cost: bcrypt.MinCost}.Hash("secret")
+	weak, _ := fast.Hash("secret")
+
+	stronger := fast
+	stronger.Iterations = 2
+
+	tests := []struct {
+		hasher password.Hasher
+		hash   string
+		want   bool
+	}{
+		{fast, old, true},
+		{stronger, weak, true},
+		{fast, weak, false},
+		{password.Bcrypt{Cost: bcrypt.MinCost + 1}, old, true},
+		{password.Bcrypt{Cost: bcrypt.MinCost}, weak, true},
+	}
+
+	for i, tt := range tests {
+		if got := tt.hasher.NeedsRehash(tt.hash); got != tt.want {
+			t.Errorf("%d: expected %v, got %v", i, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Package totp implements time-based one-time passwords (RFC 6238)
+// for two-factor authentication, compatible with authenticator apps.
+//
+//	secret, _ := totp.GenerateSecret()
+//	uri := totp.ProvisioningURI(secret, "Example", user.Email) // Render as a QR code
+//
+//	if totp.Verify(secret, req.FormValue("code")) {
+//		auth.MarkTwoFactorVerified(w, req)
+//	}
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrInvalidOptions is returned for codes with Options.Digits other than
+// 6, 7 or 8, or an Options.Period shorter than a second.
+var ErrInvalidOptions = errors.New("totp: digits must be 6 to 8 and the period at least 1 second")
+
+// Options are the parameters of the codes. They must match the provisioning URI.
+// Zero fields take the value of DefaultOptions.
+type Options struct {
+	Digits int           // Number of digits of a code, 6 to 8. Defaults to 6.
+	Period time.Duration // Lifetime of a code. Defaults to 30 seconds.
+
+	// Skew is the number of accepted periods before and after the current one.
+	// Defaults to 1. A negative value only accepts the current period.
+	Skew int
+}
+
+// DefaultOptions are the options supported by all authenticator apps.
+var DefaultOptions = Options{Digits: 6, Period: 30 * time.Second, Skew: 1}
+
+// options returns the options with their defaults, or ErrInvalidOptions.
+func options(opts []Options) (Options, error) {
+	if len(opts) == 0 {
+		return DefaultOptions, nil
+	}
+
+	o := opts[0]
+	if o.Digits == 0 {
+		o.Digits = DefaultOptions.Digits
+	}
+	if o.Period == 0 {
+		o.Period = DefaultOptions.Period
+	}
+	if o.Skew == 0 {
+		o.Skew = DefaultOptions.Skew
+	} else if o.Skew < 0 {
+		o.Skew = 0
+	}
+
+	if o.Digits < 6 || o.Digits > 8 || o.Period < time.Second {
+		return o, ErrInvalidOptions
+	}
+	return o, nil
+}
+
+// encoding is the base32 encoding of secrets, without padding.
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a random 160 bit secret encoded in base32.
+func GenerateSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// ProvisioningURI returns the otpauth:// URI of the secret, to be rendered
+// as a QR code scanned by authenticator apps.
+// It panics if the options are invalid. See ErrInvalidOptions.
+func ProvisioningURI(secret, issuer, account string, opts ...Options) string {
+	o, err := options(opts)
+	if err != nil {
+		panic(err)
+	}
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(o.Digits))
+	query.Set("period", fmt.Sprint(int(o.Period/time.Second)))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// Code returns the code of the secret at time t.
+func Code(secret string, t time.Time, opts ...Options) (string, error) {
+	o, err := options(opts)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, uint64(t.Unix()/int64(o.Period/time.Second)), o.Digits), nil
+}
+
+// Verify reports whether code is valid for the secret now,
+// allowing for the clock drift of Options.Skew periods.
+func Verify(secret, code string, opts ...Options) bool {
+	_, ok := VerifyAt(secret, code, time.Now(), opts...)
+	return ok
+}
+
+// VerifyAt reports whether code is valid for the secret at time t and returns
+// the counter of the matching period. Store the counter of the last accepted code
+// and reject codes with a counter not greater than it to prevent replays.
+// Codes are never valid with invalid options.
+func VerifyAt(secret, code string, t time.Time, opts ...Options) (counter uint64, ok bool) {
+	o, err := options(opts)
+	if err != nil {
+		return 0, false
+	}
+
+	key, err := decodeSecret(secret)
+	if err != nil || len(code) != o.Digits {
+		return 0, false
+	}
+
+	current := int64(t.Unix() / int64(o.Period/time.Second))
+	for i := -o.Skew; i <= o.Skew; i++ {
+		c := uint64(current + int64(i))
+		if subtle.ConstantTimeCompare([]byte(hotp(key, c, o.Digits)), []byte(code)) == 1 {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	return encoding.DecodeString(strings.TrimRight(secret, "="))
+}
+
+// hotp returns the HMAC-based one-time password of RFC 4226.
+func hotp(key []byte, counter uint64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+// GenerateRecoveryCodes returns n random single use recovery codes,
+// formatted like "a1b2c-3d4e5", to be shown once to the user.
+// Store their HashRecoveryCode hashes instead of the codes.
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, n)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+
+		s := hex.EncodeToString(b)
+		codes[i] = s[:5] + "-" + s[5:]
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode returns the hash of a recovery code to store.
+// Codes are compared ignoring case, spaces and dashes.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// UseRecoveryCode reports whether code matches one of the hashes and returns
+// the hashes without it, to be stored so that the code can not be used again.
+func UseRecoveryCode(hashes []string, code string) (remaining []string, ok bool) {
+	hash := HashRecoveryCode(code)
+	for i, h := range hashes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			remaining = append(remaining, hashes[:i]...)
+			return append(remaining, hashes[i+1:]...), true
+		}
+	}
+	return hashes, false
+}
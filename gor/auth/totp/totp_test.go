@@ -0,0 +1,101 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abiiranathan/gor/gor/auth/totp"
+)
+
+// The SHA1 test vectors of RFC 6238, truncated to 6 digits.
+func TestCode(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, want := range tests {
+		code, err := totp.Code(secret, time.Unix(unix, 0))
+		if err != nil || code != want {
+			t.Errorf("%d: expected %s, got %s, %v", unix, want, code, err)
+		}
+	}
+}
+
+func TestVerifyAt(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	code, _ := totp.Code(secret, now.Add(-30*time.Second))
+
+	counter, ok := totp.VerifyAt(secret, code, now)
+	if !ok || counter != uint64(now.Unix()/30-1) {
+		t.Errorf("expected a code of the previous period to be accepted, got %d, %v", counter, ok)
+	}
+
+	if _, ok := totp.VerifyAt(secret, code, now.Add(time.Minute)); ok {
+		t.Error("expected an old code to be rejected")
+	}
+
+	// Partial options default the skew like the other fields.
+	if _, ok := totp.VerifyAt(secret, code, now, totp.Options{Period: 30 * time.Second}); !ok {
+		t.Error("expected the default skew with partial options")
+	}
+
+	if _, ok := totp.VerifyAt(secret, code, now, totp.Options{Skew: -1}); ok {
+		t.Error("expected no drift to be allowed with a negative skew")
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	for _, o := range []totp.Options{{Digits: 5}, {Digits: 10}, {Period: time.Millisecond}} {
+		if _, err := totp.Code(secret, time.Unix(59, 0), o); err != totp.ErrInvalidOptions {
+			t.Errorf("%+v: expected ErrInvalidOptions, got %v", o, err)
+		}
+	}
+
+	code, err := totp.Code(secret, time.Unix(59, 0), totp.Options{Digits: 8})
+	if err != nil || code != "94287082" {
+		t.Errorf("expected the 8 digits code of RFC 6238, got %s, %v", code, err)
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := totp.ProvisioningURI("JBSWY3DPEHPK3PXP", "Example Co", "alice@example.com")
+	want := "otpauth://totp/Example%20Co:alice@example.com?algorithm=SHA1&digits=6&issuer=Example+Co&period=30&secret=JBSWY3DPEHPK3PXP"
+	if uri != want {
+		t.Errorf("expected %s, got %s", want, uri)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := totp.GenerateRecoveryCodes(10)
+	if err != nil || len(codes) != 10 {
+		t.Fatalf("expected 10 codes, got %v, %v", codes, err)
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = totp.HashRecoveryCode(code)
+	}
+
+	remaining, ok := totp.UseRecoveryCode(hashes, strings.ToUpper(codes[3]))
+	if !ok || len(remaining) != 9 {
+		t.Fatalf("expected the code to be used, got %v with %d remaining", ok, len(remaining))
+	}
+
+	if _, ok := totp.UseRecoveryCode(remaining, codes[3]); ok {
+		t.Error("expected a used code to be rejected")
+	}
+}
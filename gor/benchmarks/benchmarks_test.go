@@ -0,0 +1,194 @@
+package benchmarks_test
+
+import (
+	"bytes"
+	"html/template"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abiiranathan/gor/gor"
+)
+
+type user struct {
+	Name  string   `json:"name" form:"name" xml:"name"`
+	Age   int      `json:"age" form:"age" xml:"age"`
+	Email string   `json:"email" form:"email" xml:"email"`
+	Tags  []string `json:"tags" form:"tags" xml:"tags"`
+}
+
+func noop(w http.ResponseWriter, req *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func passthrough(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(w, req)
+	})
+}
+
+func serve(b *testing.B, h http.Handler, newReq func() *http.Request, expectedStatus ...int) {
+	w := httptest.NewRecorder()
+
+	// make sure we are benchmarking the expected response
+	status := http.StatusNoContent
+	if len(expectedStatus) > 0 {
+		status = expectedStatus[0]
+	}
+
+	h.ServeHTTP(w, newReq())
+	if w.Code != status {
+		b.Fatalf("expected status %d, got %d", status, w.Code)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.Body.Reset()
+		h.ServeHTTP(w, newReq())
+	}
+}
+
+func BenchmarkServeHTTP(b *testing.B) {
+	r := gor.NewRouter()
+	r.Get("/users/{id}", noop)
+
+	req := httptest.NewRequest("GET", "/users/10", nil)
+	serve(b, r, func() *http.Request { return req })
+}
+
+func BenchmarkServeHTTPMiddleware(b *testing.B) {
+	r := gor.NewRouter()
+	r.Use(passthrough, passthrough, passthrough)
+	r.Get("/users/{id}", noop, passthrough, passthrough)
+
+	req := httptest.NewRequest("GET", "/users/10", nil)
+	serve(b, r, func() *http.Request { return req })
+}
+
+func BenchmarkServeHTTPNotFound(b *testing.B) {
+	r := gor.NewRouter()
+	r.Get("/users/{id}", noop)
+
+	req := httptest.NewRequest("GET", "/notfound", nil)
+	serve(b, r, func() *http.Request { return req }, http.StatusNotFound)
+}
+
+func BenchmarkRender(b *testing.B) {
+	t := template.Must(template.New("base.html").Parse(`<html><body>{{ .Content }}</body></html>`))
+	template.Must(t.New("page.html").Parse(`<h1>{{ .Title }}</h1>{{ range .Items }}<p>{{ . }}</p>{{ end }}`))
+
+	r := gor.NewRouter(gor.WithTemplates(t), gor.BaseLayout("base.html"))
+	r.Get("/page", func(w http.ResponseWriter, req *http.Request) {
+		r.Render(w, req, "page.html", gor.Map{
+			"Title": "Benchmark",
+			"Items": []string{"one", "two", "three"},
+		})
+	})
+
+	req := httptest.NewRequest("GET", "/page", nil)
+	serve(b, r, func() *http.Request { return req }, http.StatusOK)
+}
+
+func bodyParserRouter() *gor.Router {
+	r := gor.NewRouter()
+	r.Post("/users", func(w http.ResponseWriter, req *http.Request) {
+		var u user
+		if err := gor.BodyParser(req, &u); err != nil {
+			panic(err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return r
+}
+
+func BenchmarkBodyParserJSON(b *testing.B) {
+	body := `{"name":"John Doe","age":30,"email":"john@example.com","tags":["a","b"]}`
+	serve(b, bodyParserRouter(), func() *http.Request {
+		req := httptest.NewRequest("POST", "/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", gor.ContentTypeJSON)
+		return req
+	})
+}
+
+func BenchmarkBodyParserXML(b *testing.B) {
+	body := `<user><name>John Doe</name><age>30</age><email>john@example.com</email><tags>a</tags><tags>b</tags></user>`
+	serve(b, bodyParserRouter(), func() *http.Request {
+		req := httptest.NewRequest("POST", "/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", gor.ContentTypeXML)
+		return req
+	})
+}
+
+func BenchmarkBodyParserUrlEncoded(b *testing.B) {
+	form := url.Values{
+		"name":  {"John Doe"},
+		"age":   {"30"},
+		"email": {"john@example.com"},
+		"tags":  {"a", "b"},
+	}.Encode()
+
+	serve(b, bodyParserRouter(), func() *http.Request {
+		req := httptest.NewRequest("POST", "/users", strings.NewReader(form))
+		req.Header.Set("Content-Type", gor.ContentTypeUrlEncoded)
+		return req
+	})
+}
+
+func BenchmarkBodyParserMultipart(b *testing.B) {
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	mw.WriteField("name", "John Doe")
+	mw.WriteField("age", "30")
+	mw.WriteField("email", "john@example.com")
+	mw.WriteField("tags", "a")
+	mw.WriteField("tags", "b")
+	mw.Close()
+
+	data := body.Bytes()
+	contentType := mw.FormDataContentType()
+
+	serve(b, bodyParserRouter(), func() *http.Request {
+		req := httptest.NewRequest("POST", "/users", bytes.NewReader(data))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	})
+}
+
+func BenchmarkStatic(b *testing.B) {
+	dir := b.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "main.js"), bytes.Repeat([]byte("console.log(1);\n"), 256), 0644)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	r := gor.NewRouter()
+	r.Static("/static", dir)
+
+	req := httptest.NewRequest("GET", "/static/main.js", nil)
+	serve(b, r, func() *http.Request { return req }, http.StatusOK)
+}
+
+func BenchmarkStaticFS(b *testing.B) {
+	// StaticFS does not strip the prefix, the file system root contains the static directory.
+	dir := b.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "static"), 0755); err != nil {
+		b.Fatal(err)
+	}
+
+	err := os.WriteFile(filepath.Join(dir, "static", "main.js"), bytes.Repeat([]byte("console.log(1);\n"), 256), 0644)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	r := gor.NewRouter()
+	r.StaticFS("/static", http.Dir(dir))
+
+	req := httptest.NewRequest("GET", "/static/main.js", nil)
+	serve(b, r, func() *http.Request { return req }, http.StatusOK)
+}
@@ -0,0 +1,14 @@
+/*
+Package benchmarks contains the benchmark suite for the gor hot paths:
+request dispatch with and without middleware, template rendering,
+BodyParser for each supported content type and static file serving.
+
+Run the suite and compare against the committed baseline with benchstat
+(golang.org/x/perf/cmd/benchstat) before submitting changes to the hot path:
+
+	go test -run=^$ -bench=. -benchmem -count=6 ./gor/benchmarks > new.txt
+	benchstat gor/benchmarks/baseline.txt new.txt
+
+Update baseline.txt in the same change when a regression is intended.
+*/
+package benchmarks
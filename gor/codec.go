@@ -0,0 +1,131 @@
+package gor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Codec encodes and decodes values of a given content type.
+// Implement Codec to plug in a different encoding library.
+type Codec interface {
+	// Encode writes the encoding of v to w.
+	Encode(w io.Writer, v any) error
+
+	// Decode reads an encoded value from r and stores it in v.
+	Decode(r io.Reader, v any) error
+}
+
+// JSONCodec is the codec used by SendJSON, SendJSONError and BodyParser.
+// The default uses encoding/json. It can be replaced with a faster implementation
+// like jsoniter or sonic. It should be set once at program start up
+// before the router starts serving requests.
+//
+//	gor.JSONCodec = myJSONCodec{}
+var JSONCodec Codec = stdJSONCodec{}
+
+// MsgPackCodec is the codec used by SendMsgPack and BodyParser for
+// application/msgpack. There is no default so that gor does not depend on
+// a MessagePack library. Plug one in at program start up, e.g with
+// github.com/vmihailenco/msgpack/v5:
+//
+//	type msgpackCodec struct{}
+//
+//	func (msgpackCodec) Encode(w io.Writer, v any) error { return msgpack.NewEncoder(w).Encode(v) }
+//	func (msgpackCodec) Decode(r io.Reader, v any) error { return msgpack.NewDecoder(r).Decode(v) }
+//
+//	gor.MsgPackCodec = msgpackCodec{}
+var MsgPackCodec Codec
+
+// ErrNoMsgPackCodec is returned when MessagePack is used without a MsgPackCodec.
+var ErrNoMsgPackCodec = errors.New("gor: no MsgPackCodec configured")
+
+// stdJSONCodec implements Codec with encoding/json.
+type stdJSONCodec struct{}
+
+func (stdJSONCodec) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (stdJSONCodec) Decode(r io.Reader, v any) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// jsonFormat is the formatting of JSON responses configured with JSONIndent
+// and JSONEscapeHTML.
+type jsonFormat struct {
+	indent       bool   // Indent the responses
+	pretty       bool   // Indent the responses of requests with the "pretty" query parameter
+	prefix       string // Prefix of indented lines
+	indentStr    string // Indentation of indented lines
+	noEscapeHTML bool   // Do not escape HTML characters in strings
+}
+
+// forRequest returns the format of the JSON responses of req.
+func (f jsonFormat) forRequest(req *http.Request) jsonFormat {
+	if f.pretty && strings.Contains(req.URL.RawQuery, "pretty") {
+		if pretty, err := strconv.ParseBool(req.URL.Query().Get("pretty")); err == nil && pretty {
+			f.indent = true
+		}
+	}
+	return f
+}
+
+// responseJSONFormat returns the JSON format of the gor.ResponseWriter
+// wrapped by w, if any.
+func responseJSONFormat(w http.ResponseWriter) jsonFormat {
+	for {
+		switch rw := w.(type) {
+		case *ResponseWriter:
+			return rw.jsonFormat
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return jsonFormat{}
+		}
+	}
+}
+
+// encodeJSON writes the JSON encoding of v to w with JSONCodec, or with
+// encoding/json if the format is indented or does not escape HTML.
+func encodeJSON(w io.Writer, v any, format jsonFormat) error {
+	if !format.indent && !format.noEscapeHTML {
+		return JSONCodec.Encode(w, v)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(!format.noEscapeHTML)
+	if format.indent {
+		enc.SetIndent(format.prefix, format.indentStr)
+	}
+	return enc.Encode(v)
+}
+
+// Buffers larger than this are not returned to the pool to avoid
+// holding on to memory after large responses.
+const maxPooledBufferSize = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer returns the buffer to the pool.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
@@ -0,0 +1,111 @@
+package gor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// paramConstraint validates the value of a path wildcard at match time.
+type paramConstraint struct {
+	name  string            // wildcard name
+	match func(string) bool // reports whether the value is valid
+}
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// paramTypes are the named constraints supported in patterns, e.g "{id:int}".
+// Any other constraint is compiled as a regular expression matching the whole value.
+var paramTypes = map[string]func(string) bool{
+	"int": func(s string) bool {
+		_, err := strconv.ParseInt(s, 10, 64)
+		return err == nil
+	},
+	"uint": func(s string) bool {
+		_, err := strconv.ParseUint(s, 10, 64)
+		return err == nil
+	},
+	"float": func(s string) bool {
+		_, err := strconv.ParseFloat(s, 64)
+		return err == nil
+	},
+	"bool": func(s string) bool {
+		_, err := strconv.ParseBool(s)
+		return err == nil
+	},
+	"uuid": uuidRegex.MatchString,
+	"alpha": func(s string) bool {
+		return s != "" && strings.IndexFunc(s, func(r rune) bool {
+			return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z')
+		}) == -1
+	},
+	"alnum": func(s string) bool {
+		return s != "" && strings.IndexFunc(s, func(r rune) bool {
+			return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+		}) == -1
+	},
+}
+
+// parseConstraints removes the constraints from the wildcards in path
+// and returns the plain ServeMux pattern with the constraints.
+//
+// A constraint follows the wildcard name after a colon. It is either one of
+// int, uint, float, bool, uuid, alpha and alnum, or a regular expression
+// that must match the whole value. e.g "/users/{id:int}" or "/files/{slug:[a-z-]+}".
+// It panics if a wildcard is not closed or a regular expression is invalid.
+func parseConstraints(path string) (string, []paramConstraint) {
+	if !strings.Contains(path, ":") {
+		return path, nil
+	}
+
+	var b strings.Builder
+	var constraints []paramConstraint
+
+	for i := 0; i < len(path); i++ {
+		if path[i] != '{' {
+			b.WriteByte(path[i])
+			continue
+		}
+
+		// Find the matching brace. Regular expressions may contain braces, e.g {3}.
+		end, depth := -1, 0
+		for j := i; j < len(path); j++ {
+			if path[j] == '{' {
+				depth++
+			} else if path[j] == '}' {
+				depth--
+				if depth == 0 {
+					end = j
+					break
+				}
+			}
+		}
+
+		if end == -1 {
+			panic(fmt.Sprintf("gor: invalid pattern %q: unclosed wildcard", path))
+		}
+
+		name, expr, found := strings.Cut(path[i+1:end], ":")
+		if found {
+			constraints = append(constraints, paramConstraint{name: name, match: compileConstraint(path, expr)})
+		}
+
+		b.WriteString("{" + name + "}")
+		i = end
+	}
+	return b.String(), constraints
+}
+
+// compileConstraint returns the matcher for a named constraint or regular expression.
+func compileConstraint(path, expr string) func(string) bool {
+	if match, ok := paramTypes[expr]; ok {
+		return match
+	}
+
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		panic(fmt.Sprintf("gor: invalid pattern %q: %v", path, err))
+	}
+	return re.MatchString
+}
@@ -0,0 +1,246 @@
+package gor
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// CookieKeys are the secret keys of signed and encrypted cookies.
+// The first key signs or encrypts new cookies and all keys are tried when
+// reading them, so keys can be rotated by prepending a new key and removing
+// the oldest one once its cookies have expired. Keys can be of any length
+// but should have at least 32 random bytes.
+type CookieKeys [][]byte
+
+var (
+	// ErrInvalidCookie is returned when a signed or encrypted cookie was
+	// tampered with or was not created with any of the keys.
+	ErrInvalidCookie = errors.New("gor: invalid cookie")
+
+	// ErrCookieTooLong is returned when an encoded cookie exceeds 4096 bytes.
+	ErrCookieTooLong = errors.New("gor: cookie value too long")
+
+	// ErrNoCookieKeys is returned when no key is given.
+	ErrNoCookieKeys = errors.New("gor: no cookie keys")
+)
+
+// maxCookieSize is the size limit of cookies in most browsers.
+const maxCookieSize = 4096
+
+// NewCookie returns a cookie with sane defaults: Path "/", HttpOnly,
+// SameSite=Lax and Secure if the request was made over https,
+// directly or through a proxy setting X-Forwarded-Proto.
+func NewCookie(req *http.Request, name, value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// SetSignedCookie sets the cookie with its value signed with HMAC-SHA256.
+// The value is readable by the client but can not be modified.
+//
+//	gor.SetSignedCookie(w, gor.NewCookie(req, "theme", "dark"), keys)
+func SetSignedCookie(w http.ResponseWriter, cookie *http.Cookie, keys CookieKeys) error {
+	if len(keys) == 0 {
+		return ErrNoCookieKeys
+	}
+
+	value := base64.RawURLEncoding.EncodeToString([]byte(cookie.Value))
+	value += "." + base64.RawURLEncoding.EncodeToString(signCookie(keys[0], cookie.Name, value))
+	return setEncodedCookie(w, cookie, value)
+}
+
+// GetSignedCookie returns the value of the signed cookie name.
+// It returns http.ErrNoCookie if the cookie is missing and ErrInvalidCookie
+// if its signature does not match any of the keys.
+func GetSignedCookie(req *http.Request, name string, keys CookieKeys) (string, error) {
+	cookie, err := req.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+
+	value, sig, ok := strings.Cut(cookie.Value, ".")
+	if !ok {
+		return "", ErrInvalidCookie
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+
+	for _, key := range keys {
+		if hmac.Equal(mac, signCookie(key, name, value)) {
+			b, err := base64.RawURLEncoding.DecodeString(value)
+			if err != nil {
+				return "", ErrInvalidCookie
+			}
+			return string(b), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+// signCookie returns the HMAC of the encoded value bound to the cookie name,
+// so that a signed value can not be replayed in another cookie.
+func signCookie(key []byte, name, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name))
+	mac.Write([]byte{'='})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// SetEncryptedCookie sets the cookie with its value encrypted with AES-256-GCM.
+// The value can neither be read nor modified by the client.
+func SetEncryptedCookie(w http.ResponseWriter, cookie *http.Cookie, keys CookieKeys) error {
+	if len(keys) == 0 {
+		return ErrNoCookieKeys
+	}
+
+	aead, err := cookieAEAD(keys[0])
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(cookie.Value)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(cookie.Value), []byte(cookie.Name))
+	return setEncodedCookie(w, cookie, base64.RawURLEncoding.EncodeToString(sealed))
+}
+
+// GetEncryptedCookie returns the decrypted value of the encrypted cookie name.
+// It returns http.ErrNoCookie if the cookie is missing and ErrInvalidCookie
+// if it can not be decrypted with any of the keys.
+func GetEncryptedCookie(req *http.Request, name string, keys CookieKeys) (string, error) {
+	cookie, err := req.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+
+	for _, key := range keys {
+		aead, err := cookieAEAD(key)
+		if err != nil {
+			return "", err
+		}
+
+		if len(sealed) < aead.NonceSize() {
+			return "", ErrInvalidCookie
+		}
+
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		if plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(name)); err == nil {
+			return string(plaintext), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+// cookieAEAD returns AES-256-GCM keyed with the SHA-256 of key.
+func cookieAEAD(key []byte) (cipher.AEAD, error) {
+	sum := sha256.Sum256(key)
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// routerCookieKeys returns the cookie keys of the router handling req.
+func routerCookieKeys(req *http.Request) CookieKeys {
+	if ctx, ok := req.Context().Value(contextKey).(*CTX); ok && ctx.Router != nil {
+		return ctx.Router.cookieKeys
+	}
+	return nil
+}
+
+// CookieParser parses the request cookies and stores the result in v,
+// which must be a pointer to a struct. Fields are bound with the "cookie" tag
+// and values are converted like BodyParser.
+//
+// The "signed" and "encrypted" tag options read cookies set with SetSignedCookie
+// and SetEncryptedCookie using the keys of the router, see WithCookieKeys.
+// A cookie that fails verification is a ParseError wrapping ErrInvalidCookie.
+//
+//	type Preferences struct {
+//		Theme    string   `cookie:"theme"`
+//		PageSize int      `cookie:"page_size"`
+//		Columns  []string `cookie:"columns"`
+//		UserID   int      `cookie:"uid,signed,required"`
+//	}
+func CookieParser(req *http.Request, v interface{}) error {
+	// Make sure v is a pointer to a struct
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return FormError{
+			Err:  fmt.Errorf("v must be a pointer to a struct"),
+			Kind: InvalidStructPointer,
+		}
+	}
+
+	data := make(map[string]interface{})
+	for _, cookie := range req.Cookies() {
+		if _, ok := data[cookie.Name]; !ok && cookie.Value != "" {
+			data[cookie.Name] = cookie.Value // The first cookie is the most specific
+		}
+	}
+
+	opts := newParseOptions(req, "cookie")
+	opts.value = func(data map[string]interface{}, name string, options []string) (interface{}, bool, error) {
+		var value string
+		var err error
+		switch {
+		case slices.Contains(options, "signed"):
+			value, err = GetSignedCookie(req, name, routerCookieKeys(req))
+		case slices.Contains(options, "encrypted"):
+			value, err = GetEncryptedCookie(req, name, routerCookieKeys(req))
+		default:
+			value, _ = data[name].(string)
+		}
+
+		if errors.Is(err, http.ErrNoCookie) || (err == nil && value == "") {
+			return nil, false, nil
+		}
+
+		if err != nil {
+			return nil, false, err
+		}
+		return value, true, nil
+	}
+	return parseStruct(data, rv.Elem(), opts)
+}
+
+// setEncodedCookie sets a copy of cookie with the encoded value.
+func setEncodedCookie(w http.ResponseWriter, cookie *http.Cookie, value string) error {
+	if len(cookie.Name)+len(value) > maxCookieSize {
+		return ErrCookieTooLong
+	}
+
+	c := *cookie
+	c.Value = value
+	http.SetCookie(w, &c)
+	return nil
+}
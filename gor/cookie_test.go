@@ -0,0 +1,173 @@
+package gor
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTrip returns a request carrying the cookies set on w.
+func roundTrip(w *httptest.ResponseRecorder) *http.Request {
+	req := httptest.NewRequest("GET", "/", nil)
+	for _, c := range w.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	return req
+}
+
+func TestNewCookie(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	c := NewCookie(req, "name", "value")
+	if c.Path != "/" || !c.HttpOnly || c.SameSite != http.SameSiteLaxMode || c.Secure {
+		t.Errorf("unexpected defaults %+v", c)
+	}
+
+	req.TLS = &tls.ConnectionState{}
+	if c := NewCookie(req, "name", "value"); !c.Secure {
+		t.Error("expected a secure cookie over https")
+	}
+}
+
+func TestSignedCookie(t *testing.T) {
+	oldKey, newKey := []byte("old key"), []byte("new key")
+	req := httptest.NewRequest("GET", "/", nil)
+
+	w := httptest.NewRecorder()
+	if err := SetSignedCookie(w, NewCookie(req, "theme", "dark; mode"), CookieKeys{oldKey}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cookies signed with an old key are still valid after rotation.
+	value, err := GetSignedCookie(roundTrip(w), "theme", CookieKeys{newKey, oldKey})
+	if err != nil || value != "dark; mode" {
+		t.Errorf("expected dark; mode, got %q, %v", value, err)
+	}
+
+	if _, err := GetSignedCookie(roundTrip(w), "theme", CookieKeys{newKey}); !errors.Is(err, ErrInvalidCookie) {
+		t.Errorf("expected ErrInvalidCookie for a removed key, got %v", err)
+	}
+
+	// Tampered values and values copied to another cookie are rejected.
+	signed := w.Result().Cookies()[0].Value
+	_, sig, _ := strings.Cut(signed, ".")
+
+	tests := []*http.Cookie{
+		{Name: "theme", Value: "bGlnaHQ." + sig},
+		{Name: "other", Value: signed},
+		{Name: "theme", Value: "garbage"},
+	}
+
+	for _, c := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(c)
+		if _, err := GetSignedCookie(req, c.Name, CookieKeys{oldKey}); !errors.Is(err, ErrInvalidCookie) {
+			t.Errorf("%s=%s: expected ErrInvalidCookie, got %v", c.Name, c.Value, err)
+		}
+	}
+
+	if _, err := GetSignedCookie(req, "missing", CookieKeys{oldKey}); !errors.Is(err, http.ErrNoCookie) {
+		t.Errorf("expected http.ErrNoCookie, got %v", err)
+	}
+}
+
+func TestEncryptedCookie(t *testing.T) {
+	oldKey, newKey := []byte("old key"), []byte("new key")
+	req := httptest.NewRequest("GET", "/", nil)
+
+	w := httptest.NewRecorder()
+	if err := SetEncryptedCookie(w, NewCookie(req, "cart", "item-1,item-2"), CookieKeys{oldKey}); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(w.Result().Cookies()[0].Value, "item") {
+		t.Error("expected the value to be encrypted")
+	}
+
+	value, err := GetEncryptedCookie(roundTrip(w), "cart", CookieKeys{newKey, oldKey})
+	if err != nil || value != "item-1,item-2" {
+		t.Errorf("expected item-1,item-2, got %q, %v", value, err)
+	}
+
+	if _, err := GetEncryptedCookie(roundTrip(w), "cart", CookieKeys{newKey}); !errors.Is(err, ErrInvalidCookie) {
+		t.Errorf("expected ErrInvalidCookie for a removed key, got %v", err)
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "other", Value: w.Result().Cookies()[0].Value})
+	if _, err := GetEncryptedCookie(req, "other", CookieKeys{oldKey}); !errors.Is(err, ErrInvalidCookie) {
+		t.Errorf("expected ErrInvalidCookie for a renamed cookie, got %v", err)
+	}
+
+	if err := SetEncryptedCookie(httptest.NewRecorder(), NewCookie(req, "big", strings.Repeat("x", 4096)), CookieKeys{oldKey}); !errors.Is(err, ErrCookieTooLong) {
+		t.Errorf("expected ErrCookieTooLong, got %v", err)
+	}
+}
+
+func TestCookieParser(t *testing.T) {
+	type Preferences struct {
+		Theme    string    `cookie:"theme"`
+		PageSize int       `cookie:"page_size"`
+		Columns  []string  `cookie:"columns"`
+		Since    time.Time `cookie:"since" layout:"2006-01-02"`
+		UserID   int       `cookie:"uid,signed,required"`
+	}
+
+	key := []byte("secret key")
+	r := NewRouter(WithCookieKeys(key))
+
+	var prefs Preferences
+	var parseErr error
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		prefs = Preferences{}
+		parseErr = CookieParser(req, &prefs)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	if err := SetSignedCookie(w, NewCookie(req, "uid", "42"), CookieKeys{key}); err != nil {
+		t.Fatal(err)
+	}
+
+	req = roundTrip(w)
+	req.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+	req.AddCookie(&http.Cookie{Name: "page_size", Value: "25"})
+	req.AddCookie(&http.Cookie{Name: "columns", Value: "name,email"})
+	req.AddCookie(&http.Cookie{Name: "since", Value: "2024-03-15"})
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+
+	want := Preferences{
+		Theme:    "dark",
+		PageSize: 25,
+		Columns:  []string{"name", "email"},
+		Since:    time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+		UserID:   42,
+	}
+	if !reflect.DeepEqual(prefs, want) {
+		t.Errorf("expected %+v, got %+v", want, prefs)
+	}
+
+	// An unsigned value is rejected.
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "uid", Value: "1"})
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var formErr FormError
+	if !errors.As(parseErr, &formErr) || formErr.Field != "UserID" || !errors.Is(formErr.Err, ErrInvalidCookie) {
+		t.Errorf("expected ErrInvalidCookie for UserID, got %v", parseErr)
+	}
+
+	// A missing signed cookie is only an error because it is required.
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if !errors.As(parseErr, &formErr) || formErr.Kind != RequiredFieldMissing {
+		t.Errorf("expected UserID to be required, got %v", parseErr)
+	}
+}
@@ -0,0 +1,395 @@
+package gor
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVError is the error of a row of a CSV file bound by BindCSV.
+type CSVError struct {
+	Line int   // Line of the row in the file, the header being line 1
+	Err  error // Error of the row
+}
+
+// Error implements the error interface.
+func (e *CSVError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+// Unwrap returns the error of the row.
+func (e *CSVError) Unwrap() error {
+	return e.Err
+}
+
+// BindCSV decodes a CSV file into v, a pointer to a slice of structs or of
+// pointers to structs. The file is the request body for text/csv requests,
+// or the file uploaded in the multipart field named field, "file" by default.
+//
+// The first row is the header. Fields are bound to the column named by their
+// "csv" tag, or the snake case of their name, and values are converted like
+// BodyParser. Empty cells keep the zero value, unless the field is required:
+//
+//	type Product struct {
+//		SKU   string  `csv:"sku,required"`
+//		Name  string  `csv:"name"`
+//		Price float64 `csv:"price"`
+//	}
+//
+//	var products []Product
+//	err := gor.BindCSV(req, &products)
+//
+// A row that fails to parse returns a FormError wrapping a *CSVError with
+// its line. Each row is then validated like BodyParser, reporting fields
+// like "[2].Price" for the third row.
+func BindCSV(req *http.Request, v interface{}, field ...string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice || !isNestedStruct(rv.Elem().Type().Elem()) {
+		return FormError{
+			Err:  fmt.Errorf("v must be a pointer to a slice of structs"),
+			Kind: InvalidStructPointer,
+		}
+	}
+
+	body, err := csvBody(req, field...)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return FormError{Err: err, Kind: ParseError}
+	}
+
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	header[0] = strings.TrimPrefix(header[0], "\ufeff") // Byte order mark of files saved by Excel
+
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	opts := newParseOptions(req, "csv")
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return FormError{Err: err, Kind: ParseError}
+		}
+
+		data := make(map[string]interface{}, len(record))
+		for i, value := range record {
+			if value != "" {
+				data[header[i]] = value
+			}
+		}
+
+		elem := reflect.New(elemType).Elem()
+		target := elem
+		if elemType.Kind() == reflect.Ptr {
+			elem = reflect.New(elemType.Elem())
+			target = elem.Elem()
+		}
+
+		line, _ := reader.FieldPos(0)
+		if err := parseStruct(data, target, opts); err != nil {
+			var formErr FormError
+			if errors.As(err, &formErr) {
+				formErr.Err = &CSVError{Line: line, Err: formErr.Err}
+				return formErr
+			}
+			return &CSVError{Line: line, Err: err}
+		}
+
+		if err := validate(req, target.Addr().Interface()); err != nil {
+			var validationErrs ValidationErrors
+			if errors.As(err, &validationErrs) {
+				for i := range validationErrs {
+					validationErrs[i].Field = fmt.Sprintf("[%d].%s", slice.Len(), validationErrs[i].Field)
+				}
+				return validationErrs
+			}
+			return err
+		}
+		slice.Set(reflect.Append(slice, elem))
+	}
+	return nil
+}
+
+// csvBody returns the request body of text/csv requests, or the uploaded file.
+func csvBody(req *http.Request, field ...string) (io.ReadCloser, error) {
+	if ContentType(req) != ContentTypeMultipartForm {
+		return req.Body, nil
+	}
+
+	name := "file"
+	if len(field) > 0 {
+		name = field[0]
+	}
+
+	if err := req.ParseMultipartForm(maxMultipartMemory(req, 0)); err != nil {
+		return nil, FormError{Err: err, Kind: ParseError}
+	}
+
+	files := req.MultipartForm.File[name]
+	if len(files) == 0 {
+		return nil, FormError{
+			Err:   fmt.Errorf("field '%s' is required", name),
+			Kind:  RequiredFieldMissing,
+			Field: name,
+		}
+	}
+
+	file, err := files[0].Open()
+	if err != nil {
+		return nil, FormError{Err: err, Kind: ParseError, Field: name}
+	}
+	return file, nil
+}
+
+// CSVOptions configures SendCSV.
+type CSVOptions struct {
+	// Filename, if set, is sent in a Content-Disposition header so that
+	// browsers download the file, e.g "products.csv".
+	Filename string
+
+	// Comma is the field delimiter. Defaults to ','.
+	Comma rune
+
+	// NoHeader omits the header row.
+	NoHeader bool
+
+	// FlushEvery is the number of rows written between flushes of the
+	// response, so that large exports reach the client while they are
+	// generated. Defaults to 1000.
+	FlushEvery int
+}
+
+// csvColumn is a column of SendCSV, the field at index of the row struct.
+type csvColumn struct {
+	name   string
+	index  []int
+	layout string // Layout of time fields
+}
+
+// SendCSV streams rows as CSV with content-type text/csv. rows is a slice or
+// an array of structs or of pointers to structs, or a channel of them that is
+// read until it is closed, e.g to export the rows of a database query.
+//
+// Columns are named like BindCSV reads them, by the "csv" tag of the fields,
+// or the snake case of their name. Fields tagged `csv:"-"` are skipped and the
+// fields of untagged embedded structs are promoted. Slices are joined with
+// commas, time fields are formatted with their "layout" tag or time.RFC3339,
+// and types implementing encoding.TextMarshaler or fmt.Stringer format themselves.
+//
+//	gor.SendCSV(w, products, gor.CSVOptions{Filename: "products.csv"})
+//
+// The response is flushed every CSVOptions.FlushEvery rows. Errors after the
+// first flush cannot change the status of the response and are only returned.
+func SendCSV(w http.ResponseWriter, rows any, opts CSVOptions) error {
+	rv := reflect.ValueOf(rows)
+	kind := rv.Kind()
+	if kind != reflect.Slice && kind != reflect.Array && kind != reflect.Chan {
+		return fmt.Errorf("gor: SendCSV rows must be a slice, an array or a channel of structs, got %T", rows)
+	}
+
+	elemType := rv.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("gor: SendCSV rows must be a slice, an array or a channel of structs, got %T", rows)
+	}
+
+	columns := csvColumns(structType, nil)
+	if opts.FlushEvery <= 0 {
+		opts.FlushEvery = 1000
+	}
+
+	w.Header().Set("Content-Type", ContentTypeCSV)
+	if opts.Filename != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": opts.Filename}))
+	}
+
+	rc := http.NewResponseController(w)
+	writer := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		writer.Comma = opts.Comma
+	}
+
+	record := make([]string, len(columns))
+	if !opts.NoHeader {
+		for i, column := range columns {
+			record[i] = column.name
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	written := 0
+	writeRow := func(row reflect.Value) error {
+		if row.Kind() == reflect.Ptr {
+			if row.IsNil() {
+				return nil
+			}
+			row = row.Elem()
+		}
+
+		for i, column := range columns {
+			record[i] = ""
+			if field, err := row.FieldByIndexErr(column.index); err == nil {
+				record[i] = formatCSVValue(field, column.layout)
+			}
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+
+		written++
+		if written%opts.FlushEvery == 0 {
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return err
+			}
+
+			if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if kind == reflect.Chan {
+		for {
+			row, ok := rv.Recv()
+			if !ok {
+				break
+			}
+
+			if err := writeRow(row); err != nil {
+				return err
+			}
+		}
+	} else {
+		for i := 0; i < rv.Len(); i++ {
+			if err := writeRow(rv.Index(i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvColumns returns the columns of the fields of the struct type t.
+// index is the index of t in the row struct, if it is embedded.
+func csvColumns(t reflect.Type, index []int) []csvColumn {
+	var columns []csvColumn
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("csv")
+		fieldIndex := append(slices.Clip(index), i)
+
+		if field.Anonymous && tag == "" && isNestedStruct(field.Type) {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			columns = append(columns, csvColumns(embedded, fieldIndex)...)
+			continue
+		}
+
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if name = strings.TrimSpace(name); name == "" {
+			name = SnakeCase(field.Name)
+		}
+		columns = append(columns, csvColumn{name: name, index: fieldIndex, layout: timeLayout(field)})
+	}
+	return columns
+}
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// formatCSVValue formats the value of a CSV cell.
+func formatCSVValue(v reflect.Value, layout string) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		switch {
+		case t.IsZero():
+			return ""
+		case layout == TimeLayoutUnix:
+			return strconv.FormatInt(t.Unix(), 10)
+		case layout == TimeLayoutUnixMilli:
+			return strconv.FormatInt(t.UnixMilli(), 10)
+		case layout != "":
+			return t.Format(layout)
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err == nil {
+			return string(text)
+		}
+	}
+
+	if v.Type().Implements(stringerType) {
+		return v.Interface().(fmt.Stringer).String()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())
+	case reflect.Slice, reflect.Array:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatCSVValue(v.Index(i), layout)
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,170 @@
+package gor
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Product struct {
+	SKU      string    `csv:"sku,required"`
+	Name     string    `csv:"name" validate:"max=10"`
+	Price    float64   `csv:"price"`
+	Tags     []string  `csv:"tags"`
+	Released time.Time `csv:"released" layout:"2006-01-02"`
+}
+
+func TestBindCSV(t *testing.T) {
+	body := "\ufeffsku, name,price,tags,released\n" +
+		"A1,Pen,1.5,\"office,blue\",2024-03-15\n" +
+		"B2,Cap,,,\n"
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", ContentTypeCSV)
+
+	var products []Product
+	if err := BindCSV(req, &products); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(products) != 2 {
+		t.Fatalf("expected 2 products, got %+v", products)
+	}
+
+	pen := products[0]
+	if pen.SKU != "A1" || pen.Name != "Pen" || pen.Price != 1.5 || len(pen.Tags) != 2 || pen.Released.Day() != 15 {
+		t.Errorf("unexpected product %+v", pen)
+	}
+
+	if products[1].SKU != "B2" || products[1].Price != 0 {
+		t.Errorf("expected empty cells to keep zero values, got %+v", products[1])
+	}
+}
+
+func TestBindCSVErrors(t *testing.T) {
+	bind := func(body string) ([]*Product, error) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", ContentTypeCSV)
+
+		var products []*Product
+		err := BindCSV(req, &products)
+		return products, err
+	}
+
+	var formErr FormError
+	var csvErr *CSVError
+	_, err := bind("sku,price\nA1,1\nB2,cheap\n")
+	if !errors.As(err, &formErr) || formErr.Field != "Price" || !errors.As(err, &csvErr) || csvErr.Line != 3 {
+		t.Errorf("expected a parse error of Price on line 3, got %v", err)
+	}
+
+	_, err = bind("sku,price\n,1\n")
+	if !errors.As(err, &formErr) || formErr.Kind != RequiredFieldMissing || !errors.As(err, &csvErr) || csvErr.Line != 2 {
+		t.Errorf("expected SKU to be required on line 2, got %v", err)
+	}
+
+	var validationErrs ValidationErrors
+	_, err = bind("sku,name\nA1,Pen\nB2,A very long name\n")
+	if !errors.As(err, &validationErrs) || validationErrs[0].Field != "[1].Name" {
+		t.Errorf("expected a validation error of [1].Name, got %v", err)
+	}
+}
+
+func TestBindCSVMultipart(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	w, _ := mw.CreateFormFile("products", "products.csv")
+	w.Write([]byte("sku,name\nA1,Pen\n"))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var products []Product
+	if err := BindCSV(req, &products, "products"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(products) != 1 || products[0].Name != "Pen" {
+		t.Errorf("unexpected products %+v", products)
+	}
+}
+
+func TestSendCSV(t *testing.T) {
+	released := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	products := []Product{
+		{SKU: "A1", Name: "Pen", Price: 1.5, Tags: []string{"office", "blue"}, Released: released},
+		{SKU: "B2", Name: "Cap"},
+	}
+
+	w := httptest.NewRecorder()
+	if err := SendCSV(w, products, CSVOptions{Filename: "products.csv"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != ContentTypeCSV {
+		t.Errorf("expected content type %s, got %s", ContentTypeCSV, ct)
+	}
+
+	if cd := w.Header().Get("Content-Disposition"); cd != "attachment; filename=products.csv" {
+		t.Errorf("unexpected Content-Disposition %q", cd)
+	}
+
+	expected := "sku,name,price,tags,released\n" +
+		"A1,Pen,1.5,\"office,blue\",2024-03-15\n" +
+		"B2,Cap,0,,\n"
+	if w.Body.String() != expected {
+		t.Errorf("expected %q, got %q", expected, w.Body.String())
+	}
+
+	// The output is read back by BindCSV.
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(w.Body.String()))
+	req.Header.Set("Content-Type", ContentTypeCSV)
+
+	var bound []Product
+	if err := BindCSV(req, &bound); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bound) != 2 || bound[0].Name != "Pen" || len(bound[0].Tags) != 2 || !bound[0].Released.Equal(released) {
+		t.Errorf("unexpected products %+v", bound)
+	}
+}
+
+func TestSendCSVChannel(t *testing.T) {
+	type Row struct {
+		ID     int     `csv:"id"`
+		Secret string  `csv:"-"`
+		Score  *uint16 `csv:"score"`
+	}
+
+	rows := make(chan *Row)
+	go func() {
+		defer close(rows)
+		for i := 1; i <= 3; i++ {
+			rows <- &Row{ID: i, Secret: "x"}
+		}
+	}()
+
+	w := httptest.NewRecorder()
+	if err := SendCSV(w, rows, CSVOptions{Comma: ';', NoHeader: true, FlushEvery: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "1;\n2;\n3;\n"; w.Body.String() != expected {
+		t.Errorf("expected %q, got %q", expected, w.Body.String())
+	}
+
+	if !w.Flushed {
+		t.Error("expected the response to be flushed")
+	}
+
+	if err := SendCSV(httptest.NewRecorder(), []int{1}, CSVOptions{}); err == nil {
+		t.Error("expected an error for rows that are not structs")
+	}
+}
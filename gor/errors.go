@@ -0,0 +1,235 @@
+package gor
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// errNotFound is the error sent for requests that match no route.
+var errNotFound = errors.New(http.StatusText(http.StatusNotFound))
+
+// errMethodNotAllowed is the error sent for requests whose path only matches routes of other methods.
+var errMethodNotAllowed = errors.New(http.StatusText(http.StatusMethodNotAllowed))
+
+// HTTPError is an error with the HTTP status code to send it with, and
+// optional details sent to clients accepting JSON.
+// HandleError, and the recovery middleware for panics, use its status.
+//
+//	user, err := findUser(id)
+//	if errors.Is(err, sql.ErrNoRows) {
+//		return gor.Errorf(http.StatusNotFound, "user %d not found", id)
+//	}
+type HTTPError struct {
+	Code    int    // HTTP status code
+	Message string // Message sent to the client
+	Details Map    // Details sent with the message to clients accepting JSON
+	Err     error  // Wrapped error, if any
+}
+
+// NewError returns an HTTPError with the status code and message.
+func NewError(code int, message string) *HTTPError {
+	return &HTTPError{Code: code, Message: message}
+}
+
+// Errorf returns an HTTPError with the status code and a message formatted
+// like fmt.Errorf. Error arguments of the %w verb are wrapped.
+func Errorf(code int, format string, args ...any) *HTTPError {
+	err := fmt.Errorf(format, args...)
+	return &HTTPError{Code: code, Message: err.Error(), Err: err}
+}
+
+// Error returns the message, or the status text if it is empty.
+func (e *HTTPError) Error() string {
+	if e.Message == "" {
+		return http.StatusText(e.Code)
+	}
+	return e.Message
+}
+
+// Status returns the HTTP status code.
+func (e *HTTPError) Status() int {
+	return e.Code
+}
+
+// Unwrap returns the wrapped error.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// WithDetails returns a copy of e with the details.
+func (e *HTTPError) WithDetails(details Map) *HTTPError {
+	clone := *e
+	clone.Details = details
+	return &clone
+}
+
+// ErrorHandlerFunc handles an error that occurred while serving req.
+// status is the HTTP status code to send.
+type ErrorHandlerFunc func(w http.ResponseWriter, req *http.Request, err error, status int)
+
+// HandlerFuncE is an http handler that returns an error.
+// A returned error is sent to the client with HandleError.
+//
+// Example:
+//
+//	r.Get("/users/{id}", gor.HandlerFuncE(func(w http.ResponseWriter, req *http.Request) error {
+//		user, err := findUser(req.PathValue("id"))
+//		if err != nil {
+//			return err
+//		}
+//		return gor.SendJSON(w, user)
+//	}).ServeHTTP)
+type HandlerFuncE func(w http.ResponseWriter, req *http.Request) error
+
+// ServeHTTP implements http.Handler.
+func (h HandlerFuncE) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if err := h(w, req); err != nil {
+		HandleError(w, req, err)
+	}
+}
+
+// HandleError sends err to the client with the ErrorHandler of the group
+// containing the request path, or the router's ErrorHandler.
+// If no ErrorHandler is configured, DefaultErrorHandler is used.
+// If status is not provided, it is derived from err. See HandleError.
+func (r *Router) HandleError(w http.ResponseWriter, req *http.Request, err error, status ...int) {
+	statusCode := errorStatus(err, status...)
+
+	if len(r.groups) > 0 {
+		g := r.groupFor(req.URL.Path, func(g *Group) bool { return g.ErrorHandler != nil })
+		if g != nil {
+			g.ErrorHandler(w, req, err, statusCode)
+			return
+		}
+	}
+
+	if r.ErrorHandler != nil {
+		r.ErrorHandler(w, req, err, statusCode)
+		return
+	}
+	DefaultErrorHandler(w, req, err, statusCode)
+}
+
+// HandleError sends err to the client through the error pipeline of the
+// gor.Router serving req. Outside of a gor.Router, DefaultErrorHandler is used.
+//
+// If status is not provided, errors with a Status() int method like HTTPError
+// are sent with their status, ValidationErrors produce a 422 Unprocessable Entity,
+// a FormError of kind RequestTooLarge a 413 Request Entity Too Large, other
+// FormErrors a 400 Bad Request and any other error a 500 Internal Server Error.
+func HandleError(w http.ResponseWriter, req *http.Request, err error, status ...int) {
+	if ctx, ok := req.Context().Value(contextKey).(*CTX); ok && ctx.Router != nil {
+		ctx.Router.HandleError(w, req, err, status...)
+		return
+	}
+	DefaultErrorHandler(w, req, err, errorStatus(err, status...))
+}
+
+// DefaultErrorHandler is the ErrorHandlerFunc used when the Router has no ErrorHandler.
+//
+// The response format is chosen by content negotiation:
+//   - htmx requests receive the error message as html.
+//   - Clients accepting JSON but not HTML receive {"error": "message"}.
+//   - Otherwise the router's error template is rendered if configured,
+//     falling back to the error message as html.
+//
+// If the response header has already been written, the error is only logged.
+// Custom error handlers may call DefaultErrorHandler but must not call SendError
+// or HandleError since those dispatch back to the ErrorHandler.
+func DefaultErrorHandler(w http.ResponseWriter, req *http.Request, err error, status int) {
+	// The status can not be changed once the header is sent.
+	if headerWritten(w) {
+		log.Printf("gor: response already written, dropping error: %v\n", err)
+		return
+	}
+
+	// In case its htmx, return the escaped error message
+	if req.Header.Get("HX-Request") == "true" {
+		writeErrorHTML(w, err, status)
+		return
+	}
+
+	if acceptsJSON(req) {
+		var validationErrs ValidationErrors
+		if errors.As(err, &validationErrs) {
+			SendJSONError(w, Map{"error": err.Error(), "errors": validationErrs.Map()}, status)
+			return
+		}
+
+		var formErrs FormErrors
+		if errors.As(err, &formErrs) {
+			SendJSONError(w, Map{"error": err.Error(), "errors": formErrs.Map()}, status)
+			return
+		}
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && len(httpErr.Details) > 0 {
+			SendJSONError(w, Map{"error": err.Error(), "details": httpErr.Details}, status)
+			return
+		}
+		SendJSONError(w, Map{"error": err.Error()}, status)
+		return
+	}
+
+	if ctx, ok := req.Context().Value(contextKey).(*CTX); ok && ctx.Router != nil {
+		if ctx.Router.errorTemplateFor(status) != "" {
+			ctx.Router.renderErrorTemplate(w, err, status)
+			return
+		}
+	}
+
+	writeErrorHTML(w, err, status)
+}
+
+// writeErrorHTML sends the message of err as HTML. The message is escaped
+// since it may contain user input, e.g the invalid value of a form field.
+func writeErrorHTML(w http.ResponseWriter, err error, status int) {
+	w.Header().Set("Content-Type", ContentTypeHTML)
+	w.WriteHeader(status)
+	w.Write([]byte(html.EscapeString(err.Error())))
+}
+
+// errorStatus returns status[0] if provided, the status of errors with a Status
+// method, 422 for ValidationErrors, 400 for a FormError and 500 otherwise.
+func errorStatus(err error, status ...int) int {
+	if len(status) > 0 {
+		return status[0]
+	}
+
+	var statusErr interface{ Status() int }
+	if errors.As(err, &statusErr) && statusErr.Status() >= 400 {
+		return statusErr.Status()
+	}
+
+	var validationErrs ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return http.StatusUnprocessableEntity
+	}
+
+	var formErr FormError
+	if errors.As(err, &formErr) {
+		if formErr.Kind == RequestTooLarge {
+			return http.StatusRequestEntityTooLarge
+		}
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// acceptsJSON reports whether the client prefers a JSON response.
+// This is the case if the Accept header lists JSON but not HTML, or if
+// there is no Accept header and the request body is JSON.
+func acceptsJSON(req *http.Request) bool {
+	accept := req.Header.Get("Accept")
+	if accept == "" {
+		return ContentType(req) == ContentTypeJSON
+	}
+
+	if strings.Contains(accept, ContentTypeHTML) {
+		return false
+	}
+	return strings.Contains(accept, ContentTypeJSON) || strings.Contains(accept, "+json")
+}
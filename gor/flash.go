@@ -0,0 +1,112 @@
+package gor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+)
+
+// FlashCookieName is the name of the cookie carrying flash messages
+// to the next request.
+var FlashCookieName = "gor_flash"
+
+// flashesKey is the template data key of the flash messages injected by Render.
+const flashesKey = "flashes"
+
+type flashKeyType string
+
+// flashKey is the context key of the flash messages of the request.
+const flashKey flashKeyType = flashesKey
+
+// FlashMessage is a one-time message shown on the next rendered page,
+// typically after a redirect.
+type FlashMessage struct {
+	Category string `json:"c"` // e.g "success", "error", "info"
+	Message  string `json:"m"`
+}
+
+// WithCookieKeys sets the keys signing the cookies set by the router,
+// such as the flash cookie, and the URLs of SignURL.
+// Without keys, flash messages are stored unsigned.
+func WithCookieKeys(keys ...[]byte) RouterOption {
+	return func(r *Router) {
+		r.cookieKeys = keys
+	}
+}
+
+// Flash adds a message of category to the flash messages, which are kept in a
+// cookie until they are read with Flashes or rendered by the next page.
+// Messages added before rendering in the same request are shown right away.
+//
+//	gor.Flash(w, req, "success", "Saved!")
+//	gor.Redirect(w, req, "/posts")
+func Flash(w http.ResponseWriter, req *http.Request, category, message string) error {
+	flashes := append(pendingFlashes(req), FlashMessage{Category: category, Message: message})
+	if ctx, ok := req.Context().Value(contextKey).(*CTX); ok {
+		ctx.Set(flashKey, flashes)
+	}
+
+	b, err := json.Marshal(flashes)
+	if err != nil {
+		return err
+	}
+
+	cookie := NewCookie(req, FlashCookieName, string(b))
+	if keys := routerCookieKeys(req); len(keys) > 0 {
+		return SetSignedCookie(w, cookie, keys)
+	}
+	return setEncodedCookie(w, cookie, base64.RawURLEncoding.EncodeToString(b))
+}
+
+// Flashes returns the flash messages of the request and clears them,
+// so each message is returned only once. It returns nil if there are none.
+func Flashes(w http.ResponseWriter, req *http.Request) []FlashMessage {
+	flashes := pendingFlashes(req)
+	if len(flashes) == 0 {
+		return nil
+	}
+
+	if ctx, ok := req.Context().Value(contextKey).(*CTX); ok {
+		ctx.Set(flashKey, []FlashMessage{})
+	}
+
+	cookie := NewCookie(req, FlashCookieName, "")
+	cookie.MaxAge = -1
+	http.SetCookie(w, cookie)
+	return flashes
+}
+
+// pendingFlashes returns the flash messages added during the request,
+// or those of the flash cookie if none were added yet.
+func pendingFlashes(req *http.Request) []FlashMessage {
+	if ctx, ok := req.Context().Value(contextKey).(*CTX); ok {
+		if flashes, ok := ctx.Get(flashKey).([]FlashMessage); ok {
+			return flashes
+		}
+	}
+
+	var value []byte
+	if keys := routerCookieKeys(req); len(keys) > 0 {
+		v, err := GetSignedCookie(req, FlashCookieName, keys)
+		if err != nil {
+			return nil
+		}
+		value = []byte(v)
+	} else {
+		cookie, err := req.Cookie(FlashCookieName)
+		if err != nil {
+			return nil
+		}
+
+		value, err = base64.RawURLEncoding.DecodeString(cookie.Value)
+		if err != nil {
+			return nil
+		}
+	}
+
+	var flashes []FlashMessage
+	if err := json.Unmarshal(value, &flashes); err != nil {
+		return nil
+	}
+	return flashes
+}
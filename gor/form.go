@@ -1,15 +1,20 @@
 package gor
 
 import (
+	"bytes"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"mime/multipart"
 	"net/http"
 	"reflect"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -38,6 +43,12 @@ const (
 	UnsupportedType FormErrorKind = "unsupported_type"
 	// ParseError indicates that an error occurred during parsing.
 	ParseError FormErrorKind = "parse_error"
+	// ValidationFailed indicates that a custom Validator rejected the parsed value.
+	ValidationFailed FormErrorKind = "validation_failed"
+	// RequestTooLarge indicates that the request body exceeds the maximum body size.
+	RequestTooLarge FormErrorKind = "request_too_large"
+	// InvalidFile indicates that an uploaded file breaks the rules of its "file" tag.
+	InvalidFile FormErrorKind = "invalid_file"
 )
 
 // Error implements the error interface.
@@ -48,21 +59,217 @@ func (e FormError) Error() string {
 	return fmt.Sprintf("BodyParser error: field=%q kind=%s, err=%s", e.Field, e.Kind, e.Err)
 }
 
+// Unwrap returns the original error.
+func (e FormError) Unwrap() error {
+	return e.Err
+}
+
+// FormErrors are the errors of every invalid field, returned instead of the
+// first FormError when all errors are collected. See BodyParserOptions.AllErrors
+// and WithAllFormErrors. errors.As finds the first FormError.
+type FormErrors []FormError
+
+// Error implements the error interface.
+func (e FormErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Unwrap returns the errors of the fields.
+func (e FormErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Map returns the message of the error of each field keyed by its path,
+// e.g to show the errors next to the inputs of a form.
+func (e FormErrors) Map() map[string]string {
+	m := make(map[string]string, len(e))
+	for _, err := range e {
+		if _, ok := m[err.Field]; !ok && err.Err != nil {
+			m[err.Field] = err.Err.Error()
+		}
+	}
+	return m
+}
+
+// withPrefix returns err with prefix prepended to the field of its form errors,
+// e.g "Address." to report "Address.Street".
+func withPrefix(err error, prefix string) error {
+	switch e := err.(type) {
+	case FormError:
+		e.Field = prefix + e.Field
+		return e
+	case FormErrors:
+		prefixed := make(FormErrors, len(e))
+		for i, formErr := range e {
+			formErr.Field = prefix + formErr.Field
+			prefixed[i] = formErr
+		}
+		return prefixed
+	}
+	return err
+}
+
 var DefaultTimezone = time.UTC
 
+// DefaultMaxMultipartMemory is the default maximum number of bytes of a
+// multipart form kept in memory by BodyParser (32 MB).
+// Larger forms spill over to temporary files on disk.
+const DefaultMaxMultipartMemory int64 = 32 << 20
+
+// maxMultipartMemory returns n if positive, then the limit configured on
+// the gor.Router serving r, and finally DefaultMaxMultipartMemory.
+func maxMultipartMemory(r *http.Request, n int64) int64 {
+	if n > 0 {
+		return n
+	}
+
+	if ctx, ok := r.Context().Value(contextKey).(*CTX); ok && ctx.Router != nil && ctx.Router.maxMultipartMemory > 0 {
+		return ctx.Router.maxMultipartMemory
+	}
+	return DefaultMaxMultipartMemory
+}
+
+// maxBodySize returns n if positive, or the limit configured on the gor.Router
+// serving r with MaxBodySize. Zero means no limit.
+func maxBodySize(r *http.Request, n int64) int64 {
+	if n > 0 {
+		return n
+	}
+
+	if ctx, ok := r.Context().Value(contextKey).(*CTX); ok && ctx.Router != nil {
+		return ctx.Router.maxBodySize
+	}
+	return 0
+}
+
+// BodyParserOptions configures BodyParserWithOptions.
+type BodyParserOptions struct {
+	// Location used to parse date and time fields in forms.
+	// Defaults to gor.DefaultTimezone.
+	Location *time.Location
+
+	// MaxMultipartMemory is the maximum number of bytes of a multipart form
+	// kept in memory. Defaults to the value configured on the gor.Router
+	// with the MaxMultipartMemory option, or DefaultMaxMultipartMemory.
+	MaxMultipartMemory int64
+
+	// MaxBodySize is the maximum number of bytes read from the request body.
+	// Larger bodies fail with a FormError of kind RequestTooLarge.
+	// Defaults to the value configured on the gor.Router with MaxBodySize.
+	MaxBodySize int64
+
+	// TimeLayouts are tried on time values that ParseTime cannot parse,
+	// see WithTimeLayouts. Defaults to the layouts configured on the gor.Router.
+	TimeLayouts []string
+
+	// AllErrors returns FormErrors listing every invalid field instead of the
+	// first FormError. Defaults to the value configured on the gor.Router
+	// with WithAllFormErrors.
+	AllErrors bool
+
+	// DisallowUnknownFields rejects JSON objects with keys that do not match
+	// a field of v. JSON is then decoded with encoding/json instead of JSONCodec.
+	DisallowUnknownFields bool
+
+	// DisallowTrailingData rejects JSON bodies with data after the first value,
+	// e.g two concatenated objects. JSON is then decoded with encoding/json.
+	DisallowTrailingData bool
+
+	// MaxDepth is the maximum nesting of JSON objects and arrays.
+	// Zero means no limit.
+	MaxDepth int
+}
+
+var (
+	// ErrJSONTrailingData is returned for JSON bodies with data after the
+	// first value when BodyParserOptions.DisallowTrailingData is set.
+	ErrJSONTrailingData = errors.New("gor: unexpected data after JSON value")
+
+	// ErrJSONTooDeep is returned for JSON bodies nested deeper than BodyParserOptions.MaxDepth.
+	ErrJSONTooDeep = errors.New("gor: JSON nested too deeply")
+)
+
+// newParseOptions returns the options of parsing r with the tagName tag,
+// using the time layouts and errors settings of the gor.Router serving r.
+func newParseOptions(r *http.Request, tagName string) *parseOptions {
+	opts := &parseOptions{timezone: DefaultTimezone, tagName: tagName}
+	if ctx, ok := r.Context().Value(contextKey).(*CTX); ok && ctx.Router != nil {
+		opts.layouts = ctx.Router.timeLayouts
+		opts.allErrors = ctx.Router.allFormErrors
+	}
+	return opts
+}
+
 // BodyParser parses the request body and stores the result in v.
 // v must be a pointer to a struct.
 // If timezone is provided, all date and time fields in forms are parsed with the provided location info.
 // Otherwise gor.DefaultTimezone is used and defaults to UTC.
 //
 // Supported content types: application/json, application/x-www-form-urlencoded, multipart/form-data, application/xml
+// and application/msgpack if MsgPackCodec is set.
 // For more robust form decoding we recommend using
 // https://github.com/gorilla/schema package.
 // Any form value can implement the FormScanner interface to implement custom form scanning.
 // Struct tags are used to specify the form field name.
 // If parsing forms, the default tag name is "form",
 // followed by the "json" tag name, and then snake case of the field name.
+// Nested struct fields are parsed from keys like "address.street" or "address[street]",
+// and map fields with string keys from keys like "meta[color]" or a JSON object.
+// Slices of structs are parsed from indexed keys like "items[0].name", ordered by index.
+// The fields of untagged embedded structs are promoted and parsed from their own keys.
+//
+// Uploaded files are bound to *multipart.FileHeader and []*multipart.FileHeader
+// fields, and checked with ValidateFile against the rules of their "file" tag:
+//
+//	type Profile struct {
+//		Avatar *multipart.FileHeader `form:"avatar" file:"maxsize=2MB,ext=.png .jpg,mime=image/png image/jpeg"`
+//	}
+//
+// The parsed struct is then validated with the rules of its "validate" tags,
+// see Validate and WithValidator.
+//
+// Time fields are parsed with ParseTime, then the layouts of WithTimeLayouts.
+// The "layout" or "time_format" tag sets the only layout of a field:
+//
+//	type Event struct {
+//		Day     time.Time `form:"day" layout:"2006-01-02"`
+//		Created time.Time `form:"created" time_format:"unix"`
+//	}
 func BodyParser(r *http.Request, v interface{}, loc ...*time.Location) error {
+	var opts BodyParserOptions
+	if len(loc) > 0 {
+		opts.Location = loc[0]
+	}
+	return BodyParserWithOptions(r, v, opts)
+}
+
+// BodyParserWithOptions is like BodyParser but accepts options
+// to override the timezone and multipart memory limit for this call.
+func BodyParserWithOptions(r *http.Request, v interface{}, opts BodyParserOptions) error {
+	if limit := maxBodySize(r, opts.MaxBodySize); limit > 0 {
+		r.Body = http.MaxBytesReader(nil, r.Body, limit)
+	}
+
+	if err := parseBody(r, v, opts); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return FormError{Err: maxBytesErr, Kind: RequestTooLarge}
+		}
+		return err
+	}
+	return validate(r, v)
+}
+
+// parseBody parses the request body into v according to its content type.
+func parseBody(r *http.Request, v interface{}, opts BodyParserOptions) error {
 	// Make sure v is a pointer to a struct
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
@@ -75,13 +282,12 @@ func BodyParser(r *http.Request, v interface{}, loc ...*time.Location) error {
 
 	contentType := ContentType(r)
 	timezone := DefaultTimezone
-	if len(loc) > 0 && loc[0] != nil {
-		timezone = loc[0]
+	if opts.Location != nil {
+		timezone = opts.Location
 	}
 
 	if contentType == ContentTypeJSON {
-		decoder := json.NewDecoder(r.Body)
-		err := decoder.Decode(v)
+		err := decodeJSON(r.Body, v, opts)
 		if err != nil {
 			return FormError{
 				Err:  err,
@@ -93,7 +299,7 @@ func BodyParser(r *http.Request, v interface{}, loc ...*time.Location) error {
 		var form *multipart.Form
 		var err error
 		if contentType == ContentTypeMultipartForm {
-			err = r.ParseMultipartForm(r.ContentLength)
+			err = r.ParseMultipartForm(maxMultipartMemory(r, opts.MaxMultipartMemory))
 			if err != nil {
 				return FormError{
 					Err:  err,
@@ -135,12 +341,43 @@ func BodyParser(r *http.Request, v interface{}, loc ...*time.Location) error {
 			}
 		}
 
-		err = parseFormData(data, v, timezone)
+		for k, files := range form.File {
+			if len(files) > 0 {
+				data[k] = files
+			}
+		}
+
+		parseOpts := newParseOptions(r, "form")
+		parseOpts.timezone = timezone
+		if len(opts.TimeLayouts) > 0 {
+			parseOpts.layouts = opts.TimeLayouts
+		}
+
+		if opts.AllErrors {
+			parseOpts.allErrors = true
+		}
+
+		err = parseFormData(data, v, parseOpts)
 		if err != nil {
 			// propagate the error
 			return err
 		}
 		return nil
+	} else if contentType == ContentTypeMsgPack || contentType == "application/x-msgpack" {
+		if MsgPackCodec == nil {
+			return FormError{
+				Err:  ErrNoMsgPackCodec,
+				Kind: InvalidContentType,
+			}
+		}
+
+		if err := MsgPackCodec.Decode(r.Body, v); err != nil {
+			return FormError{
+				Err:  err,
+				Kind: ParseError,
+			}
+		}
+		return nil
 	} else if contentType == ContentTypeXML {
 		xmlDecoder := xml.NewDecoder(r.Body)
 		err := xmlDecoder.Decode(v)
@@ -159,6 +396,73 @@ func BodyParser(r *http.Request, v interface{}, loc ...*time.Location) error {
 	}
 }
 
+// decodeJSON decodes the JSON body into v with JSONCodec, or with encoding/json
+// to apply the strict options.
+func decodeJSON(body io.Reader, v interface{}, opts BodyParserOptions) error {
+	if !opts.DisallowUnknownFields && !opts.DisallowTrailingData && opts.MaxDepth <= 0 {
+		return JSONCodec.Decode(body, v)
+	}
+
+	if opts.MaxDepth > 0 {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+
+		if jsonDepth(data) > opts.MaxDepth {
+			return ErrJSONTooDeep
+		}
+		body = bytes.NewReader(data)
+	}
+
+	if !opts.DisallowUnknownFields && !opts.DisallowTrailingData {
+		return JSONCodec.Decode(body, v)
+	}
+
+	decoder := json.NewDecoder(body)
+	if opts.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+
+	if opts.DisallowTrailingData {
+		if _, err := decoder.Token(); err != io.EOF {
+			return ErrJSONTrailingData
+		}
+	}
+	return nil
+}
+
+// jsonDepth returns the maximum nesting of objects and arrays in data,
+// ignoring brackets in strings. It does not validate the JSON.
+func jsonDepth(data []byte) int {
+	depth, maxDepth := 0, 0
+	inString, escaped := false, false
+	for _, c := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			depth++
+			maxDepth = max(maxDepth, depth)
+		case c == '}' || c == ']':
+			depth--
+		}
+	}
+	return maxDepth
+}
+
 func SnakeCase(s string) string {
 	var res strings.Builder
 	for i, r := range s {
@@ -171,20 +475,118 @@ func SnakeCase(s string) string {
 }
 
 // Parses the form data and stores the result in v.
-// Default tag name is "form". You can specify a different tag name using the tag argument.
-// Forexample "query" tag name will parse the form data using the "query" tag.
-func parseFormData(data map[string]interface{}, v interface{}, timezone *time.Location, tag ...string) error {
-	var tagName string = "form"
-	if len(tag) > 0 {
-		tagName = tag[0]
+// The tag name of opts is "form" for forms, or forexample "query" to parse
+// the form data using the "query" tag.
+//
+// Nested struct fields are populated from keys prefixed with their tag name,
+// using either dots or brackets, e.g "address.street" or "address[street]".
+func parseFormData(data map[string]interface{}, v interface{}, opts *parseOptions) error {
+	return parseStruct(normalizeKeys(data), reflect.ValueOf(v).Elem(), opts)
+}
+
+// parseOptions are the options of parseStruct and the nested fields it parses.
+type parseOptions struct {
+	timezone *time.Location // Location of time fields
+	tagName  string         // Struct tag of the form keys, e.g "form" or "query"
+	layouts  []string       // Fallback layouts of time fields
+
+	// key, if set, converts tag names to data keys, e.g to canonical header keys.
+	key func(tag string) string
+
+	// value, if set, returns the value of the key in data with the given
+	// tag options, e.g to verify signed cookies.
+	value func(data map[string]interface{}, key string, options []string) (interface{}, bool, error)
+
+	// allErrors collects the errors of all fields instead of stopping at the first.
+	allErrors bool
+}
+
+// collect adds the form errors of err to errs and returns nil if all errors
+// are collected. Otherwise, or for other errors, it returns err to stop parsing.
+func (opts *parseOptions) collect(errs *FormErrors, err error) error {
+	if !opts.allErrors {
+		return err
+	}
+
+	switch e := err.(type) {
+	case FormError:
+		*errs = append(*errs, e)
+	case FormErrors:
+		*errs = append(*errs, e...)
+	default:
+		return err
+	}
+	return nil
+}
+
+// formErrors returns errs as an error, or nil if it is empty.
+func formErrors(errs FormErrors) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// lookup returns the value of key in data, or from the value function.
+func (opts *parseOptions) lookup(data map[string]interface{}, key string, options []string) (interface{}, bool, error) {
+	if opts.value != nil {
+		return opts.value(data, key, options)
 	}
 
-	rv := reflect.ValueOf(v).Elem()
-	rt := rv.Type()
+	value, ok := data[key]
+	return value, ok, nil
+}
+
+// fieldKind is how parseStruct populates a struct field.
+type fieldKind int
+
+const (
+	scalarField      fieldKind = iota // Scalar, slice, time and file fields
+	embeddedField                     // Untagged embedded structs
+	nestedField                       // Nested structs
+	mapField                          // Maps with string keys
+	structSliceField                  // Slices of structs
+)
+
+// fieldPlan is the parsing metadata of a struct field, derived from its
+// type and tags.
+type fieldPlan struct {
+	index    int
+	field    reflect.StructField
+	kind     fieldKind
+	tag      string   // Key of the field in the form data
+	tagList  []string // Key followed by the tag options, e.g "required"
+	required bool
+}
+
+// fieldPlanKey identifies the plans of a struct type parsed with a tag name.
+type fieldPlanKey struct {
+	t       reflect.Type
+	tagName string
+}
+
+// fieldPlans caches the []fieldPlan of struct types by fieldPlanKey,
+// so that struct tags are parsed once per type instead of on every request.
+var fieldPlans sync.Map
+
+// structPlan returns the plans of the fields of the struct type t parsed with tagName.
+func structPlan(t reflect.Type, tagName string) []fieldPlan {
+	key := fieldPlanKey{t: t, tagName: tagName}
+	if plans, ok := fieldPlans.Load(key); ok {
+		return plans.([]fieldPlan)
+	}
 
-	for i := 0; i < rt.NumField(); i++ {
-		field := rt.Field(i)
+	plans := make([]fieldPlan, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
 		tag := field.Tag.Get(tagName)
+
+		// Promote the fields of untagged embedded structs, like encoding/json.
+		if field.Anonymous && tag == "" && field.Tag.Get("json") == "" && isNestedStruct(field.Type) {
+			plans = append(plans, fieldPlan{index: i, field: field, kind: embeddedField})
+			continue
+		}
+
 		if tag == "" {
 			// try json tag name and fallback to snake case
 			tag = field.Tag.Get("json")
@@ -198,33 +600,573 @@ func parseFormData(data map[string]interface{}, v interface{}, timezone *time.Lo
 			tagList[i] = strings.TrimSpace(tagList[i])
 		}
 
-		// Take tag name to be the first in the tagList
-		tag = tagList[0]
+		plan := fieldPlan{
+			index:    i,
+			field:    field,
+			tag:      tagList[0], // Take tag name to be the first in the tagList
+			tagList:  tagList,
+			required: slices.Contains(tagList, "required") || field.Tag.Get("required") == "true",
+		}
 
-		required := slices.Contains(tagList, "required") || field.Tag.Get("required") == "true"
-		value, ok := data[tag]
-		if !ok {
-			if required {
-				return FormError{
-					Err:   fmt.Errorf("field '%s' is required", tag),
-					Kind:  RequiredFieldMissing,
-					Field: field.Name,
+		switch {
+		case isNestedStruct(field.Type):
+			plan.kind = nestedField
+		case isStringMap(field.Type):
+			plan.kind = mapField
+		case isStructSlice(field.Type):
+			plan.kind = structSliceField
+		}
+		plans = append(plans, plan)
+	}
+
+	actual, _ := fieldPlans.LoadOrStore(key, plans)
+	return actual.([]fieldPlan)
+}
+
+// parseStruct stores the form data in the fields of the struct rv.
+func parseStruct(data map[string]interface{}, rv reflect.Value, opts *parseOptions) error {
+	var errs FormErrors
+
+	for _, plan := range structPlan(rv.Type(), opts.tagName) {
+		field, fieldVal := plan.field, rv.Field(plan.index)
+		if plan.kind == embeddedField {
+			if err := parseEmbedded(data, fieldVal, opts); err != nil {
+				if err := opts.collect(&errs, err); err != nil {
+					return err
 				}
 			}
 			continue
 		}
 
-		// set the value
-		fieldVal := rv.Field(i)
-		if err := setField(field.Name, fieldVal, value, timezone); err != nil {
+		tag := plan.tag
+		if opts.key != nil {
+			tag = opts.key(tag)
+		}
+
+		var err error
+		switch plan.kind {
+		case nestedField:
+			err = parseNested(data, tag, field, fieldVal, plan.required, opts)
+		case mapField:
+			err = parseMap(data, tag, field, fieldVal, plan.required, opts)
+		case structSliceField:
+			err = parseStructSlice(data, tag, field, fieldVal, plan.required, opts)
+		default:
+			err = parseField(data, tag, plan.tagList, field, fieldVal, plan.required, opts)
+		}
+
+		if err != nil {
+			if err := opts.collect(&errs, err); err != nil {
+				return err
+			}
+		}
+	}
+	return formErrors(errs)
+}
+
+// parseField stores the value of tag in the scalar, slice or time field.
+func parseField(data map[string]interface{}, tag string, tagList []string, field reflect.StructField,
+	fieldVal reflect.Value, required bool, opts *parseOptions) error {
+	value, ok, err := opts.lookup(data, tag, tagList[1:])
+	if err != nil {
+		return FormError{
+			Err:   err,
+			Kind:  ParseError,
+			Field: field.Name,
+		}
+	}
+
+	if isSliceType(field.Type) && !isFileType(field.Type) {
+		if !ok && opts.value == nil {
+			value, ok = bracketValues(data, tag)
+		}
+
+		if ok {
+			value = explodeValues(field, value)
+		}
+	}
+
+	if !ok {
+		if required {
+			return FormError{
+				Err:   fmt.Errorf("field '%s' is required", tag),
+				Kind:  RequiredFieldMissing,
+				Field: field.Name,
+			}
+		}
+		return nil
+	}
+
+	if files, ok := value.([]*multipart.FileHeader); ok {
+		return setFileField(field, fieldVal, files)
+	}
+
+	// set the value
+	if err := opts.setField(field, fieldVal, value); err != nil {
+		return FormError{
+			Err:   err,
+			Kind:  ParseError,
+			Field: field.Name,
+		}
+	}
+	return nil
+}
+
+// isSliceType reports whether t is a slice, or a pointer to a slice.
+func isSliceType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Slice
+}
+
+// bracketValues returns the values of the slice tag from keys with empty
+// brackets like "ids[]", or indexed keys like "ids[0]", ordered by index.
+func bracketValues(data map[string]interface{}, tag string) (interface{}, bool) {
+	if value, ok := data[tag+"[]"]; ok {
+		return value, true
+	}
+
+	prefix := tag + "."
+	indexed := make(map[int]string)
+	for k, v := range data {
+		index, ok := strings.CutPrefix(k, prefix)
+		if !ok {
+			continue
+		}
+
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 {
+			continue
+		}
+
+		switch v := v.(type) {
+		case string:
+			indexed[i] = v
+		case []string:
+			indexed[i] = v[0]
+		}
+	}
+
+	if len(indexed) == 0 {
+		return nil, false
+	}
+
+	indexes := make([]int, 0, len(indexed))
+	for i := range indexed {
+		indexes = append(indexes, i)
+	}
+	slices.Sort(indexes)
+
+	values := make([]string, len(indexes))
+	for j, i := range indexes {
+		values[j] = indexed[i]
+	}
+	return values, true
+}
+
+// explodeValues applies the "explode" and "delim" tags of the slice field to value.
+// By default, repeated keys are elements and a single value is split on commas.
+// With `explode:"true"`, only repeated keys are elements. With `explode:"false"`
+// or a `delim` tag, every value is split on the delimiter, "," by default.
+func explodeValues(field reflect.StructField, value interface{}) interface{} {
+	explode, hasExplode := field.Tag.Lookup("explode")
+	delim, hasDelim := field.Tag.Lookup("delim")
+	if !hasExplode && !hasDelim {
+		return value
+	}
+
+	values, ok := value.([]string)
+	if !ok {
+		s, ok := value.(string)
+		if !ok {
+			return value
+		}
+		values = []string{s}
+	}
+
+	if explode == "true" && !hasDelim {
+		return values
+	}
+
+	if delim == "" {
+		delim = ","
+	}
+
+	split := make([]string, 0, len(values))
+	for _, v := range values {
+		for _, item := range strings.Split(v, delim) {
+			if item = strings.TrimSpace(item); item != "" {
+				split = append(split, item)
+			}
+		}
+	}
+	return split
+}
+
+// parseEmbedded stores the form data in the promoted fields of the embedded
+// struct fieldVal. A nil pointer is allocated unless its type is unexported.
+func parseEmbedded(data map[string]interface{}, fieldVal reflect.Value, opts *parseOptions) error {
+	if fieldVal.Kind() == reflect.Ptr {
+		if fieldVal.IsNil() {
+			if !fieldVal.CanSet() {
+				return nil
+			}
+			fieldVal.Set(reflect.New(fieldVal.Type().Elem()))
+		}
+		fieldVal = fieldVal.Elem()
+	}
+	return parseStruct(data, fieldVal, opts)
+}
+
+// setField stores value in fieldVal, parsing time values with the layout of
+// field, or with ParseTime and then the fallback layouts.
+func (opts *parseOptions) setField(field reflect.StructField, fieldVal reflect.Value, value interface{}) error {
+	layout := timeLayout(field)
+	if !isTimeType(fieldVal.Type()) || (layout == "" && len(opts.layouts) == 0) {
+		return setField(field.Name, fieldVal, value, opts.timezone)
+	}
+
+	parse := func(v string) (time.Time, error) {
+		if layout != "" {
+			return ParseTimeLayouts(v, []string{layout}, opts.timezone)
+		}
+
+		t, err := ParseTime(v, opts.timezone)
+		if err != nil {
+			if t, fallbackErr := ParseTimeLayouts(v, opts.layouts, opts.timezone); fallbackErr == nil {
+				return t, nil
+			}
+		}
+		return t, err
+	}
+
+	if fieldVal.Kind() == reflect.Ptr {
+		if fieldVal.IsNil() {
+			fieldVal.Set(reflect.New(fieldVal.Type().Elem()))
+		}
+		fieldVal = fieldVal.Elem()
+	}
+
+	values, ok := value.([]string)
+	if !ok {
+		values = []string{value.(string)}
+	}
+
+	if fieldVal.Kind() != reflect.Slice {
+		t, err := parse(values[0])
+		if err != nil {
+			return err
+		}
+		fieldVal.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	slice := reflect.MakeSlice(fieldVal.Type(), len(values), len(values))
+	for i, v := range values {
+		t, err := parse(v)
+		if err != nil {
+			return err
+		}
+		slice.Index(i).Set(reflect.ValueOf(t))
+	}
+	fieldVal.Set(slice)
+	return nil
+}
+
+// timeLayout returns the layout of the "layout" or "time_format" tag of field.
+func timeLayout(field reflect.StructField) string {
+	if layout := field.Tag.Get("layout"); layout != "" {
+		return layout
+	}
+	return field.Tag.Get("time_format")
+}
+
+// isTimeType reports whether t is time.Time, or a pointer or slice of time.Time.
+func isTimeType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t == reflect.TypeOf(time.Time{})
+}
+
+// formScannerType is the reflect.Type of the FormScanner interface.
+var formScannerType = reflect.TypeOf((*FormScanner)(nil)).Elem()
+
+// isNestedStruct reports whether t is a struct, or a pointer to a struct,
+// populated from prefixed keys. time.Time, file headers and FormScanner types are scanned
+// from a single value instead.
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{}) &&
+		t != fileHeaderType.Elem() && !reflect.PointerTo(t).Implements(formScannerType) &&
+		!hasFormConverter(t)
+}
+
+// parseNested populates the nested struct field from the keys prefixed with tag.
+// A nil pointer field is only allocated if there are such keys.
+func parseNested(data map[string]interface{}, tag string, field reflect.StructField, fieldVal reflect.Value,
+	required bool, opts *parseOptions) error {
+	prefix := tag + "."
+	nested := make(map[string]interface{})
+	for k, v := range data {
+		if strings.HasPrefix(k, prefix) {
+			nested[k[len(prefix):]] = v
+		}
+	}
+
+	if len(nested) == 0 {
+		if required {
+			return FormError{
+				Err:   fmt.Errorf("field '%s' is required", tag),
+				Kind:  RequiredFieldMissing,
+				Field: field.Name,
+			}
+		}
+
+		// Required fields of the nested struct are still checked,
+		// unless it is optional through a pointer.
+		if fieldVal.Kind() == reflect.Ptr {
+			return nil
+		}
+	}
+
+	if fieldVal.Kind() == reflect.Ptr {
+		if fieldVal.IsNil() {
+			fieldVal.Set(reflect.New(fieldVal.Type().Elem()))
+		}
+		fieldVal = fieldVal.Elem()
+	}
+
+	if err := parseStruct(nested, fieldVal, opts); err != nil {
+		// Report the path of the nested field, e.g "Address.Street".
+		return withPrefix(err, field.Name+".")
+	}
+	return nil
+}
+
+// isStringMap reports whether t is a map, or a pointer to a map, with string keys.
+func isStringMap(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String
+}
+
+// parseMap populates the map field from the keys prefixed with tag, e.g "meta.color"
+// or "meta[color]", or from a JSON object in the value of tag.
+// Values are parsed like struct fields of the map element type.
+// Struct elements are populated from keys like "meta[key][field]".
+func parseMap(data map[string]interface{}, tag string, field reflect.StructField, fieldVal reflect.Value,
+	required bool, opts *parseOptions) error {
+	prefix := tag + "."
+	entries := make(map[string]interface{})
+	for k, v := range data {
+		if strings.HasPrefix(k, prefix) {
+			entries[k[len(prefix):]] = v
+		}
+	}
+
+	value, hasValue := data[tag]
+	if len(entries) == 0 && !hasValue {
+		if required {
+			return FormError{
+				Err:   fmt.Errorf("field '%s' is required", tag),
+				Kind:  RequiredFieldMissing,
+				Field: field.Name,
+			}
+		}
+		return nil
+	}
+
+	if fieldVal.Kind() == reflect.Ptr {
+		if fieldVal.IsNil() {
+			fieldVal.Set(reflect.New(fieldVal.Type().Elem()))
+		}
+		fieldVal = fieldVal.Elem()
+	}
+
+	if fieldVal.IsNil() {
+		fieldVal.Set(reflect.MakeMap(fieldVal.Type()))
+	}
+
+	// A JSON object, e.g from a hidden input.
+	if s, ok := value.(string); ok && len(entries) == 0 {
+		if err := JSONCodec.Decode(strings.NewReader(s), fieldVal.Addr().Interface()); err != nil {
 			return FormError{
 				Err:   err,
 				Kind:  ParseError,
 				Field: field.Name,
 			}
 		}
+		return nil
 	}
-	return nil
+
+	elemType := fieldVal.Type().Elem()
+	if isNestedStruct(elemType) {
+		// Group the fields of each element by key, e.g "home.street" and "home.city".
+		groups := make(map[string]map[string]interface{})
+		for k, v := range entries {
+			key, rest, ok := strings.Cut(k, ".")
+			if !ok {
+				continue
+			}
+
+			if groups[key] == nil {
+				groups[key] = make(map[string]interface{})
+			}
+			groups[key][rest] = v
+		}
+
+		var errs FormErrors
+		for key, group := range groups {
+			elem := reflect.New(elemType).Elem()
+			target := elem
+			if elemType.Kind() == reflect.Ptr {
+				elem = reflect.New(elemType.Elem())
+				target = elem.Elem()
+			}
+
+			if err := parseStruct(group, target, opts); err != nil {
+				if err := opts.collect(&errs, withPrefix(err, field.Name+"["+key+"].")); err != nil {
+					return err
+				}
+				continue
+			}
+			fieldVal.SetMapIndex(reflect.ValueOf(key).Convert(fieldVal.Type().Key()), elem)
+		}
+		return formErrors(errs)
+	}
+
+	var errs FormErrors
+	for key, v := range entries {
+		// Repeated keys only fill slice elements, keep the first value otherwise.
+		if values, ok := v.([]string); ok && elemType.Kind() != reflect.Slice {
+			v = values[0]
+		}
+
+		elem := reflect.New(elemType).Elem()
+		if err := opts.setField(field, elem, v); err != nil {
+			err = FormError{
+				Err:   err,
+				Kind:  ParseError,
+				Field: field.Name + "[" + key + "]",
+			}
+			if err := opts.collect(&errs, err); err != nil {
+				return err
+			}
+			continue
+		}
+		fieldVal.SetMapIndex(reflect.ValueOf(key).Convert(fieldVal.Type().Key()), elem)
+	}
+	return formErrors(errs)
+}
+
+// isStructSlice reports whether t is a slice, or a pointer to a slice, of nested structs.
+func isStructSlice(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Slice && isNestedStruct(t.Elem())
+}
+
+// parseStructSlice populates the slice of structs field from indexed keys like
+// "items[0].name" or "items[0][name]", ordered by index. Errors report the
+// index of the element, e.g "Items[1].Quantity".
+func parseStructSlice(data map[string]interface{}, tag string, field reflect.StructField, fieldVal reflect.Value,
+	required bool, opts *parseOptions) error {
+	prefix := tag + "."
+	rows := make(map[int]map[string]interface{})
+	for k, v := range data {
+		rest, ok := strings.CutPrefix(k, prefix)
+		if !ok {
+			continue
+		}
+
+		index, key, ok := strings.Cut(rest, ".")
+		i, err := strconv.Atoi(index)
+		if !ok || err != nil || i < 0 {
+			continue
+		}
+
+		if rows[i] == nil {
+			rows[i] = make(map[string]interface{})
+		}
+		rows[i][key] = v
+	}
+
+	if len(rows) == 0 {
+		if required {
+			return FormError{
+				Err:   fmt.Errorf("field '%s' is required", tag),
+				Kind:  RequiredFieldMissing,
+				Field: field.Name,
+			}
+		}
+		return nil
+	}
+
+	if fieldVal.Kind() == reflect.Ptr {
+		if fieldVal.IsNil() {
+			fieldVal.Set(reflect.New(fieldVal.Type().Elem()))
+		}
+		fieldVal = fieldVal.Elem()
+	}
+
+	indexes := make([]int, 0, len(rows))
+	for i := range rows {
+		indexes = append(indexes, i)
+	}
+	slices.Sort(indexes)
+
+	elemType := fieldVal.Type().Elem()
+	slice := reflect.MakeSlice(fieldVal.Type(), 0, len(indexes))
+
+	var errs FormErrors
+	for _, i := range indexes {
+		elem := reflect.New(elemType).Elem()
+		target := elem
+		if elemType.Kind() == reflect.Ptr {
+			elem = reflect.New(elemType.Elem())
+			target = elem.Elem()
+		}
+
+		if err := parseStruct(rows[i], target, opts); err != nil {
+			if err := opts.collect(&errs, withPrefix(err, fmt.Sprintf("%s[%d].", field.Name, i))); err != nil {
+				return err
+			}
+		}
+		slice = reflect.Append(slice, elem)
+	}
+
+	fieldVal.Set(slice)
+	return formErrors(errs)
+}
+
+// normalizeKeys converts bracket keys to dot keys, e.g "address[street]" to
+// "address.street". Empty brackets like "tags[]" are kept.
+func normalizeKeys(data map[string]interface{}) map[string]interface{} {
+	var normalized map[string]interface{}
+	for k, v := range data {
+		if !strings.Contains(k, "[") || strings.HasSuffix(k, "[]") {
+			continue
+		}
+
+		if normalized == nil {
+			normalized = maps.Clone(data)
+		}
+
+		key := strings.NewReplacer("][", ".", "[", ".", "]", "").Replace(k)
+		delete(normalized, k)
+		normalized[key] = v
+	}
+
+	if normalized == nil {
+		return data
+	}
+	return normalized
 }
 
 func setField(name string, fieldVal reflect.Value, value interface{}, timezone ...*time.Location) error {
@@ -233,6 +1175,10 @@ func setField(name string, fieldVal reflect.Value, value interface{}, timezone .
 		tz = timezone[0]
 	}
 
+	if converter, ok := formConverter(fieldVal.Type()); ok {
+		return convertField(fieldVal, value, converter)
+	}
+
 	// Dereference pointer if the field is a pointer
 	if fieldVal.Kind() == reflect.Ptr {
 		// Create a new value of the underlying type
@@ -240,6 +1186,10 @@ func setField(name string, fieldVal reflect.Value, value interface{}, timezone .
 			fieldVal.Set(reflect.New(fieldVal.Type().Elem()))
 		}
 		fieldVal = fieldVal.Elem()
+
+		if converter, ok := formConverter(fieldVal.Type()); ok {
+			return convertField(fieldVal, value, converter)
+		}
 	}
 
 	switch fieldVal.Kind() {
@@ -363,6 +1313,18 @@ func handleSlice(name string, fieldVal reflect.Value, value any, timezone *time.
 
 	slice := reflect.MakeSlice(fieldVal.Type(), sliceLen, sliceLen)
 
+	// Elements of types with a registered converter
+	elemType := fieldVal.Type().Elem()
+	if hasFormConverter(elemType) || (elemType.Kind() == reflect.Ptr && hasFormConverter(elemType.Elem())) {
+		for i, v := range valueSlice {
+			if err := setField(name, slice.Index(i), v, timezone); err != nil {
+				return err
+			}
+		}
+		fieldVal.Set(slice)
+		return nil
+	}
+
 	// get the kind of the slice element
 	elemKind := fieldVal.Type().Elem().Kind()
 	switch elemKind {
@@ -493,7 +1455,93 @@ type FormScanner interface {
 	FormScan(value interface{}) error
 }
 
-// QueryParser parses the query string and stores the result in v.
+// FormConverter converts a form value to a value of the type it is registered for.
+type FormConverter func(value string) (any, error)
+
+var (
+	formConvertersMu sync.RWMutex
+	formConverters   = make(map[reflect.Type]FormConverter)
+)
+
+// RegisterFormConverter registers the converter of form, query, header, cookie
+// and path values to fields of type t, and slices of t. Converters take precedence
+// over the built-in conversions and FormScanner, so that third-party types can be
+// bound without wrapping them. Register converters at program start up.
+//
+//	gor.RegisterFormConverter(reflect.TypeOf(uuid.UUID{}), func(value string) (any, error) {
+//		return uuid.Parse(value)
+//	})
+func RegisterFormConverter(t reflect.Type, converter FormConverter) {
+	formConvertersMu.Lock()
+	defer formConvertersMu.Unlock()
+	formConverters[t] = converter
+
+	// Converted structs are no longer parsed as nested structs.
+	fieldPlans.Range(func(key, _ any) bool {
+		fieldPlans.Delete(key)
+		return true
+	})
+}
+
+// formConverter returns the converter registered for t.
+func formConverter(t reflect.Type) (FormConverter, bool) {
+	formConvertersMu.RLock()
+	defer formConvertersMu.RUnlock()
+	converter, ok := formConverters[t]
+	return converter, ok
+}
+
+// hasFormConverter reports whether a converter is registered for t.
+func hasFormConverter(t reflect.Type) bool {
+	_, ok := formConverter(t)
+	return ok
+}
+
+// convertField stores the value returned by converter in fieldVal.
+// The first of repeated values is converted.
+func convertField(fieldVal reflect.Value, value any, converter FormConverter) error {
+	s, ok := value.(string)
+	if values, isSlice := value.([]string); isSlice && len(values) > 0 {
+		s, ok = values[0], true
+	}
+
+	if !ok {
+		return fmt.Errorf("unsupported value %T for %s", value, fieldVal.Type())
+	}
+
+	converted, err := converter(s)
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(converted)
+	switch {
+	case !rv.IsValid():
+		fieldVal.Set(reflect.Zero(fieldVal.Type()))
+	case rv.Type().AssignableTo(fieldVal.Type()):
+		fieldVal.Set(rv)
+	case rv.Type().ConvertibleTo(fieldVal.Type()):
+		fieldVal.Set(rv.Convert(fieldVal.Type()))
+	default:
+		return fmt.Errorf("form converter of %s returned %s", fieldVal.Type(), rv.Type())
+	}
+	return nil
+}
+
+// QueryParser parses the query string and stores the result in v,
+// converting values like BodyParser. The tag name defaults to "query".
+//
+// Slice fields are filled from repeated keys like "?id=1&id=2", keys with
+// brackets like "?id[]=1&id[]=2" or "?id[0]=1&id[1]=2", or a single value
+// split on commas like "?id=1,2". The "explode" and "delim" tags change how
+// values are split:
+//
+//	type Filter struct {
+//		IDs    []int             `query:"id" explode:"false"` // ?id=1,2&id=3
+//		Tags   []string          `query:"tag" explode:"true"` // ?tag=a,b is one tag
+//		Sort   []string          `query:"sort" delim:"|"`     // ?sort=name|-created
+//		Filter map[string]string `query:"filter"`             // ?filter[status]=open
+//	}
 func QueryParser(req *http.Request, v interface{}, tag ...string) error {
 	var tagName string = "query"
 	if len(tag) > 0 {
@@ -518,7 +1566,83 @@ func QueryParser(req *http.Request, v interface{}, tag ...string) error {
 			dataMap[k] = v // array of values or empty array
 		}
 	}
-	return parseFormData(dataMap, v, time.UTC, tagName)
+	opts := newParseOptions(req, tagName)
+	opts.timezone = time.UTC
+	if err := parseFormData(dataMap, v, opts); err != nil {
+		return err
+	}
+	return validate(req, v)
+}
+
+// HeaderParser parses the request headers and stores the result in v,
+// which must be a pointer to a struct. Fields are bound with the "header" tag,
+// e.g `header:"X-Api-Version"`, matched case-insensitively.
+// Values are converted like BodyParser. Slice fields are filled from repeated
+// headers and comma-separated values, and time fields also accept http.TimeFormat.
+//
+//	type Meta struct {
+//		Version  int       `header:"X-Api-Version,required"`
+//		Accept   []string  `header:"Accept"`
+//		Modified time.Time `header:"If-Modified-Since"`
+//	}
+func HeaderParser(req *http.Request, v interface{}) error {
+	// Make sure v is a pointer to a struct
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return FormError{
+			Err:  fmt.Errorf("v must be a pointer to a struct"),
+			Kind: InvalidStructPointer,
+		}
+	}
+
+	data := make(map[string]interface{}, len(req.Header))
+	for k, values := range req.Header {
+		value := strings.Join(values, ", ")
+		if value == "" {
+			continue // Keep the default value, like empty form values
+		}
+		data[http.CanonicalHeaderKey(k)] = value
+	}
+
+	opts := newParseOptions(req, "header")
+	opts.layouts = append(slices.Clip(opts.layouts), http.TimeFormat)
+	opts.key = http.CanonicalHeaderKey
+	return parseStruct(data, rv.Elem(), opts)
+}
+
+// PathParser parses the path parameters of the request and stores the result
+// in v, which must be a pointer to a struct. Fields are bound with the "path"
+// tag to the values of req.PathValue, and converted like BodyParser.
+//
+//	type Params struct {
+//		OrgID  int    `path:"org_id,required"`
+//		PostID int64  `path:"post_id,required"`
+//		Slug   string `path:"slug"`
+//	}
+//
+//	r.Get("/orgs/{org_id}/posts/{post_id}/{slug}", func(w http.ResponseWriter, req *http.Request) {
+//		var params Params
+//		if err := gor.PathParser(req, &params); err != nil {
+//			gor.HandleError(w, req, err, http.StatusBadRequest)
+//			return
+//		}
+//	})
+func PathParser(req *http.Request, v interface{}) error {
+	// Make sure v is a pointer to a struct
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return FormError{
+			Err:  fmt.Errorf("v must be a pointer to a struct"),
+			Kind: InvalidStructPointer,
+		}
+	}
+
+	opts := newParseOptions(req, "path")
+	opts.value = func(_ map[string]interface{}, name string, _ []string) (interface{}, bool, error) {
+		value := req.PathValue(name)
+		return value, value != "", nil
+	}
+	return parseStruct(map[string]interface{}{}, rv.Elem(), opts)
 }
 
 // Parse time from string using specified timezone. If timezone is nil,
@@ -568,6 +1692,44 @@ func ParseTime(v string, timezone *time.Location) (time.Time, error) {
 	return parsedTime, nil
 }
 
+// Time layouts parsing Unix timestamps, for the "layout" tag and WithTimeLayouts.
+const (
+	TimeLayoutUnix      = "unix"      // Seconds since January 1, 1970 UTC
+	TimeLayoutUnixMilli = "unixmilli" // Milliseconds since January 1, 1970 UTC
+)
+
+// ParseTimeLayouts parses v with the first of layouts that matches, in the location loc.
+// Besides time.Parse layouts, TimeLayoutUnix and TimeLayoutUnixMilli parse Unix timestamps.
+func ParseTimeLayouts(v string, layouts []string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = DefaultTimezone
+	}
+
+	err := fmt.Errorf("no time layout to parse %q", v)
+	for _, layout := range layouts {
+		var t time.Time
+		switch layout {
+		case TimeLayoutUnix, TimeLayoutUnixMilli:
+			var n int64
+			n, err = strconv.ParseInt(v, 10, 64)
+			if err == nil {
+				if layout == TimeLayoutUnix {
+					t = time.Unix(n, 0)
+				} else {
+					t = time.UnixMilli(n)
+				}
+				return t.In(loc), nil
+			}
+		default:
+			t, err = time.ParseInLocation(layout, v, loc)
+			if err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, err
+}
+
 func ParseTimeFormat(value string, format string, timezone ...string) (time.Time, error) {
 	tz := "UTC"
 	if len(timezone) > 0 {
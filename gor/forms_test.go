@@ -3,10 +3,13 @@ package gor
 import (
 	"errors"
 	"fmt"
+	"math"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -399,6 +402,292 @@ func TestSetFieldMultipartFormRequired(t *testing.T) {
 	}
 }
 
+func TestBodyParserNestedStruct(t *testing.T) {
+	type Geo struct {
+		Lat float64 `form:"lat"`
+		Lng float64 `form:"lng"`
+	}
+
+	type Address struct {
+		Street string `form:"street,required"`
+		City   string
+		Geo    *Geo `form:"geo"`
+	}
+
+	type Person struct {
+		Name     string       `form:"name"`
+		Address  Address      `form:"address"`
+		Billing  *Address     `form:"billing"`
+		Birthday time.Time    `form:"birthday"`
+		Custom   CustomStruct `form:"custom"`
+	}
+
+	parse := func(form url.Values) (Person, error) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", ContentTypeUrlEncoded)
+
+		var p Person
+		err := BodyParser(req, &p)
+		return p, err
+	}
+
+	p, err := parse(url.Values{
+		"name":              {"alice"},
+		"address.street":    {"Main St"},
+		"address[city]":     {"Kampala"},
+		"address[geo][lat]": {"0.31"},
+		"address.geo.lng":   {"32.58"},
+		"birthday":          {"2000-01-02"},
+		"custom":            {"scanned"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Name != "alice" || p.Address.Street != "Main St" || p.Address.City != "Kampala" {
+		t.Errorf("unexpected person %+v", p)
+	}
+
+	if p.Address.Geo == nil || p.Address.Geo.Lat != 0.31 || p.Address.Geo.Lng != 32.58 {
+		t.Errorf("expected a nested pointer struct, got %+v", p.Address.Geo)
+	}
+
+	if p.Billing != nil {
+		t.Errorf("expected an optional pointer struct without keys to stay nil, got %+v", p.Billing)
+	}
+
+	if p.Birthday.Year() != 2000 || p.Custom.Field1 != "scanned" {
+		t.Errorf("expected time and FormScanner fields to be scanned, got %v %+v", p.Birthday, p.Custom)
+	}
+
+	_, err = parse(url.Values{"address.city": {"Kampala"}})
+	var formErr FormError
+	if !errors.As(err, &formErr) || formErr.Kind != RequiredFieldMissing || formErr.Field != "Address.Street" {
+		t.Errorf("expected Address.Street to be required, got %v", err)
+	}
+
+	_, err = parse(url.Values{"address.street": {"Main St"}, "billing.street": {"Side St"}, "billing[geo][lat]": {"north"}})
+	if !errors.As(err, &formErr) || formErr.Kind != ParseError || formErr.Field != "Billing.Geo.Lat" {
+		t.Errorf("expected a parse error of Billing.Geo.Lat, got %v", err)
+	}
+}
+
+func TestBodyParserMap(t *testing.T) {
+	type Phone struct {
+		Number string `form:"number,required"`
+	}
+
+	type Product struct {
+		Meta   map[string]string   `form:"meta,required"`
+		Stock  map[string]int      `form:"stock"`
+		Tags   map[string][]string `form:"tags"`
+		Phones map[string]Phone    `form:"phones"`
+		Extra  *map[string]float64 `form:"extra"`
+		Empty  map[string]string   `form:"empty"`
+	}
+
+	parse := func(form url.Values) (Product, error) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", ContentTypeUrlEncoded)
+
+		var p Product
+		err := BodyParser(req, &p)
+		return p, err
+	}
+
+	p, err := parse(url.Values{
+		"meta[color]":          {"red"},
+		"meta.size":            {"XL"},
+		"stock[kampala]":       {"3"},
+		"tags[a]":              {"x", "y"},
+		"phones[home][number]": {"0700"},
+		"phones.work.number":   {"0701"},
+		"extra":                {`{"discount": 0.5}`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(p.Meta, map[string]string{"color": "red", "size": "XL"}) {
+		t.Errorf("unexpected meta %v", p.Meta)
+	}
+
+	if p.Stock["kampala"] != 3 || !reflect.DeepEqual(p.Tags["a"], []string{"x", "y"}) {
+		t.Errorf("unexpected stock %v and tags %v", p.Stock, p.Tags)
+	}
+
+	if p.Phones["home"].Number != "0700" || p.Phones["work"].Number != "0701" {
+		t.Errorf("unexpected phones %v", p.Phones)
+	}
+
+	if p.Extra == nil || (*p.Extra)["discount"] != 0.5 {
+		t.Errorf("expected a map decoded from JSON, got %v", p.Extra)
+	}
+
+	if p.Empty != nil {
+		t.Errorf("expected a map without keys to stay nil, got %v", p.Empty)
+	}
+
+	var formErr FormError
+	_, err = parse(url.Values{"stock[kampala]": {"3"}})
+	if !errors.As(err, &formErr) || formErr.Kind != RequiredFieldMissing || formErr.Field != "Meta" {
+		t.Errorf("expected Meta to be required, got %v", err)
+	}
+
+	_, err = parse(url.Values{"meta[color]": {"red"}, "stock[kampala]": {"many"}})
+	if !errors.As(err, &formErr) || formErr.Kind != ParseError || formErr.Field != "Stock[kampala]" {
+		t.Errorf("expected a parse error of Stock[kampala], got %v", err)
+	}
+
+	_, err = parse(url.Values{"meta[color]": {"red"}, "phones[home][label]": {"x"}})
+	if !errors.As(err, &formErr) || formErr.Kind != RequiredFieldMissing || formErr.Field != "Phones[home].Number" {
+		t.Errorf("expected Phones[home].Number to be required, got %v", err)
+	}
+}
+
+type Pagination struct {
+	Page    int `form:"page" query:"page"`
+	PerPage int `form:"per_page" query:"per_page"`
+}
+
+type Sorting struct {
+	Sort string `form:"sort" query:"sort"`
+}
+
+func TestParserEmbeddedStruct(t *testing.T) {
+	type Search struct {
+		Pagination
+		*Sorting
+		Query string `form:"q" query:"q"`
+	}
+
+	form := url.Values{"q": {"go"}, "page": {"2"}, "per_page": {"50"}, "sort": {"name"}}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", ContentTypeUrlEncoded)
+
+	var body Search
+	if err := BodyParser(req, &body); err != nil {
+		t.Fatal(err)
+	}
+
+	if body.Query != "go" || body.Page != 2 || body.PerPage != 50 || body.Sorting == nil || body.Sort != "name" {
+		t.Errorf("unexpected body %+v", body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/?"+form.Encode(), nil)
+
+	var query Search
+	if err := QueryParser(req, &query); err != nil {
+		t.Fatal(err)
+	}
+
+	if query.Query != "go" || query.Page != 2 || query.PerPage != 50 || query.Sorting == nil || query.Sort != "name" {
+		t.Errorf("unexpected query %+v", query)
+	}
+}
+
+func TestParserTimeLayouts(t *testing.T) {
+	type Event struct {
+		Day     time.Time   `form:"day" query:"day" layout:"02/01/2006"`
+		Created *time.Time  `form:"created" query:"created" time_format:"unix"`
+		Starts  time.Time   `form:"starts" query:"starts"`
+		Dates   []time.Time `form:"dates" query:"dates"`
+	}
+
+	r := NewRouter(WithTimeLayouts("Jan 2 2006", TimeLayoutUnixMilli))
+	r.Post("/events", func(w http.ResponseWriter, req *http.Request) {
+		var event Event
+		if err := BodyParser(req, &event); err != nil {
+			t.Fatal(err)
+		}
+
+		if !event.Day.Equal(time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected day %v", event.Day)
+		}
+
+		if event.Created == nil || event.Created.Unix() != 1700000000 {
+			t.Errorf("unexpected created %v", event.Created)
+		}
+
+		if !event.Starts.Equal(time.Date(2024, 3, 15, 9, 30, 0, 0, time.UTC)) {
+			t.Errorf("expected datetime-local to still parse, got %v", event.Starts)
+		}
+
+		want := []time.Time{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), time.UnixMilli(1700000000000).UTC()}
+		if len(event.Dates) != 2 || !event.Dates[0].Equal(want[0]) || !event.Dates[1].Equal(want[1]) {
+			t.Errorf("expected fallback layouts to parse dates, got %v", event.Dates)
+		}
+	})
+
+	r.Get("/events", func(w http.ResponseWriter, req *http.Request) {
+		var event Event
+		err := QueryParser(req, &event)
+
+		var formErr FormError
+		if !errors.As(err, &formErr) || formErr.Field != "Day" {
+			t.Errorf("expected the layout of Day to be enforced, got %v", err)
+		}
+	})
+
+	form := url.Values{
+		"day":     {"15/03/2024"},
+		"created": {"1700000000"},
+		"starts":  {"2024-03-15T09:30"},
+		"dates":   {"Jan 2 2024", "1700000000000"},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", ContentTypeUrlEncoded)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/events?day=2024-03-15", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestHeaderParser(t *testing.T) {
+	type Meta struct {
+		Version  int       `header:"x-api-version,required"`
+		Accept   []string  `header:"Accept"`
+		Tags     []string  `header:"X-Tags"`
+		Modified time.Time `header:"If-Modified-Since"`
+		Missing  *string   `header:"X-Missing"`
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Api-Version", "2")
+	req.Header.Set("Accept", "text/html, application/json")
+	req.Header.Add("X-Tags", "a")
+	req.Header.Add("X-Tags", "b,c")
+	req.Header.Set("If-Modified-Since", "Wed, 21 Oct 2015 07:28:00 GMT")
+
+	var meta Meta
+	if err := HeaderParser(req, &meta); err != nil {
+		t.Fatal(err)
+	}
+
+	if meta.Version != 2 || meta.Missing != nil {
+		t.Errorf("unexpected meta %+v", meta)
+	}
+
+	if !reflect.DeepEqual(meta.Accept, []string{"text/html", "application/json"}) {
+		t.Errorf("unexpected accept %v", meta.Accept)
+	}
+
+	if !reflect.DeepEqual(meta.Tags, []string{"a", "b", "c"}) {
+		t.Errorf("unexpected tags %v", meta.Tags)
+	}
+
+	if !meta.Modified.Equal(time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)) {
+		t.Errorf("unexpected modified %v", meta.Modified)
+	}
+
+	req.Header.Del("X-Api-Version")
+	var formErr FormError
+	err := HeaderParser(req, &Meta{})
+	if !errors.As(err, &formErr) || formErr.Kind != RequiredFieldMissing || formErr.Field != "Version" {
+		t.Errorf("expected Version to be required, got %v", err)
+	}
+}
+
 // test application xml
 func TestBodyParserXML(t *testing.T) {
 	type TestStruct struct {
@@ -525,6 +814,38 @@ func TestQueryParserSlice(t *testing.T) {
 	}
 }
 
+func TestQueryParserArrays(t *testing.T) {
+	type Filter struct {
+		IDs     []int             `query:"id" explode:"false"`
+		Tags    []string          `query:"tag" explode:"true"`
+		Sort    []string          `query:"sort" delim:"|"`
+		Colors  []string          `query:"color"`
+		Sizes   []int             `query:"size"`
+		Filters map[string]string `query:"filter"`
+	}
+
+	query := "id=1,2&id=3&tag=a,b&tag=c&sort=name|-created&color[]=red&color[]=blue" +
+		"&size[1]=40&size[0]=38&filter[status]=open&filter[owner]=me"
+	req := httptest.NewRequest(http.MethodGet, "/items?"+query, nil)
+
+	var filter Filter
+	if err := QueryParser(req, &filter); err != nil {
+		t.Fatal(err)
+	}
+
+	want := Filter{
+		IDs:     []int{1, 2, 3},
+		Tags:    []string{"a,b", "c"},
+		Sort:    []string{"name", "-created"},
+		Colors:  []string{"red", "blue"},
+		Sizes:   []int{38, 40},
+		Filters: map[string]string{"status": "open", "owner": "me"},
+	}
+	if !reflect.DeepEqual(filter, want) {
+		t.Errorf("expected %+v, got %+v", want, filter)
+	}
+}
+
 func TestParseTime(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -672,3 +993,398 @@ func TestParseTimeFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestMaxMultipartMemory(t *testing.T) {
+	var got int64
+	handler := func(n int64) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			got = maxMultipartMemory(req, n)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options []RouterOption
+		perCall int64
+		want    int64
+	}{
+		{"default", nil, 0, DefaultMaxMultipartMemory},
+		{"router", []RouterOption{MaxMultipartMemory(1 << 20)}, 0, 1 << 20},
+		{"per call", []RouterOption{MaxMultipartMemory(1 << 20)}, 1024, 1024},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRouter(tt.options...)
+			r.Post("/", handler(tt.perCall))
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+
+	// Requests not served by gor.Router use the default.
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	if n := maxMultipartMemory(req, 0); n != DefaultMaxMultipartMemory {
+		t.Errorf("expected %d, got %d", DefaultMaxMultipartMemory, n)
+	}
+}
+
+func TestPathParser(t *testing.T) {
+	type Params struct {
+		OrgID  int    `path:"org_id,required"`
+		PostID *int64 `path:"post_id"`
+		Slug   string `path:"slug"`
+	}
+
+	var params Params
+	var parseErr error
+
+	r := NewRouter()
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		params = Params{}
+		parseErr = PathParser(req, &params)
+	}
+	r.Get("/orgs/{org_id}/posts/{post_id}/{slug}", handler)
+	r.Get("/posts/{post_id}", handler)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orgs/7/posts/42/hello", nil))
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+
+	if params.OrgID != 7 || params.PostID == nil || *params.PostID != 42 || params.Slug != "hello" {
+		t.Errorf("unexpected params %+v", params)
+	}
+
+	var formErr FormError
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orgs/x/posts/42/hello", nil))
+	if !errors.As(parseErr, &formErr) || formErr.Kind != ParseError || formErr.Field != "OrgID" {
+		t.Errorf("expected a parse error of OrgID, got %v", parseErr)
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts/42", nil))
+	if !errors.As(parseErr, &formErr) || formErr.Kind != RequiredFieldMissing || formErr.Field != "OrgID" {
+		t.Errorf("expected OrgID to be required, got %v", parseErr)
+	}
+}
+
+func TestBodyParserAllErrors(t *testing.T) {
+	type Address struct {
+		Zip int `form:"zip"`
+	}
+
+	type Order struct {
+		Name     string         `form:"name,required"`
+		Quantity int            `form:"quantity"`
+		Price    float64        `form:"price"`
+		Address  Address        `form:"address"`
+		Stock    map[string]int `form:"stock"`
+	}
+
+	form := url.Values{
+		"quantity":     {"many"},
+		"price":        {"12.5"},
+		"address[zip]": {"abc"},
+		"stock[x]":     {"y"},
+	}
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", ContentTypeUrlEncoded)
+		return req
+	}
+
+	var order Order
+	err := BodyParserWithOptions(newRequest(), &order, BodyParserOptions{AllErrors: true})
+
+	var formErrs FormErrors
+	if !errors.As(err, &formErrs) {
+		t.Fatalf("expected FormErrors, got %v", err)
+	}
+
+	fields := formErrs.Map()
+	for _, field := range []string{"Name", "Quantity", "Address.Zip", "Stock[x]"} {
+		if _, ok := fields[field]; !ok {
+			t.Errorf("expected an error for %s, got %v", field, fields)
+		}
+	}
+
+	if len(fields) != 4 || order.Price != 12.5 {
+		t.Errorf("expected the valid fields to be parsed, got %v and %+v", fields, order)
+	}
+
+	// errors.As still finds the first FormError.
+	var formErr FormError
+	if !errors.As(err, &formErr) || formErr.Field != "Name" {
+		t.Errorf("expected the first error to be Name, got %v", formErr)
+	}
+
+	// The first error is returned by default.
+	err = BodyParser(newRequest(), &Order{})
+	if errors.As(err, &formErrs) || !errors.As(err, &formErr) {
+		t.Errorf("expected a single FormError, got %v", err)
+	}
+
+	r := NewRouter(WithAllFormErrors(true))
+	r.Post("/", func(w http.ResponseWriter, req *http.Request) {
+		if err := BodyParser(req, &Order{}); !errors.As(err, &formErrs) || len(formErrs) != 4 {
+			t.Errorf("expected the router to collect all errors, got %v", err)
+		}
+	})
+	r.ServeHTTP(httptest.NewRecorder(), newRequest())
+}
+
+func TestBodyParserStrictJSON(t *testing.T) {
+	type Item struct {
+		Name string `json:"name"`
+		Tags []any  `json:"tags"`
+	}
+
+	parse := func(body string, opts BodyParserOptions) error {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", ContentTypeJSON)
+		return BodyParserWithOptions(req, &Item{}, opts)
+	}
+
+	lenient := BodyParserOptions{}
+	strict := BodyParserOptions{DisallowUnknownFields: true, DisallowTrailingData: true, MaxDepth: 3}
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr error
+	}{
+		{"Valid", `{"name": "pen", "tags": [["a"], "[[[["]} `, nil},
+		{"UnknownField", `{"name": "pen", "price": 3}`, nil},
+		{"TrailingData", `{"name": "pen"} {"name": "cap"}`, ErrJSONTrailingData},
+		{"TooDeep", `{"tags": [[["a"]]]}`, ErrJSONTooDeep},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := parse(tt.body, lenient); err != nil {
+				t.Errorf("expected lenient parsing to succeed, got %v", err)
+			}
+
+			err := parse(tt.body, strict)
+			var formErr FormError
+			switch {
+			case tt.name == "Valid" && err != nil:
+				t.Errorf("expected no error, got %v", err)
+			case tt.name != "Valid" && (!errors.As(err, &formErr) || formErr.Kind != ParseError):
+				t.Errorf("expected a parse error, got %v", err)
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestBodyParserMaxBodySize(t *testing.T) {
+	type Upload struct {
+		Name string `json:"name" form:"name"`
+	}
+
+	r := NewRouter(MaxBodySize(64))
+	r.Post("/", func(w http.ResponseWriter, req *http.Request) {
+		if err := BodyParser(req, &Upload{}); err != nil {
+			HandleError(w, req, err)
+		}
+	})
+
+	var multipartBody strings.Builder
+	mw := multipart.NewWriter(&multipartBody)
+	mw.WriteField("name", strings.Repeat("x", 100))
+	mw.Close()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"JSON", ContentTypeJSON, `{"name": "pen"}`, http.StatusOK},
+		{"LargeJSON", ContentTypeJSON, `{"name": "` + strings.Repeat("x", 100) + `"}`, http.StatusRequestEntityTooLarge},
+		{"LargeForm", ContentTypeUrlEncoded, "name=" + strings.Repeat("x", 100), http.StatusRequestEntityTooLarge},
+		{"LargeMultipart", mw.FormDataContentType(), multipartBody.String(), http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name": "pen"}`))
+	req.Header.Set("Content-Type", ContentTypeJSON)
+
+	var formErr FormError
+	err := BodyParserWithOptions(req, &Upload{}, BodyParserOptions{MaxBodySize: 4})
+	if !errors.As(err, &formErr) || formErr.Kind != RequestTooLarge {
+		t.Errorf("expected RequestTooLarge, got %v", err)
+	}
+}
+
+func TestBodyParserStructSlice(t *testing.T) {
+	type Item struct {
+		Name     string `form:"name,required"`
+		Quantity int    `form:"qty"`
+	}
+
+	type Invoice struct {
+		Customer string  `form:"customer"`
+		Items    []Item  `form:"items,required"`
+		Extras   []*Item `form:"extras"`
+	}
+
+	parse := func(form url.Values) (Invoice, error) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", ContentTypeUrlEncoded)
+
+		var invoice Invoice
+		err := BodyParser(req, &invoice)
+		return invoice, err
+	}
+
+	invoice, err := parse(url.Values{
+		"customer":       {"Jane"},
+		"items[10].name": {"Cap"},
+		"items[2].name":  {"Pen"},
+		"items[2].qty":   {"3"},
+		"items[0][name]": {"Ink"},
+		"extras[0].name": {"Bag"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Item{{Name: "Ink"}, {Name: "Pen", Quantity: 3}, {Name: "Cap"}}
+	if !reflect.DeepEqual(invoice.Items, want) {
+		t.Errorf("expected items %+v, got %+v", want, invoice.Items)
+	}
+
+	if len(invoice.Extras) != 1 || invoice.Extras[0].Name != "Bag" {
+		t.Errorf("unexpected extras %+v", invoice.Extras)
+	}
+
+	var formErr FormError
+	_, err = parse(url.Values{"items[0].name": {"Pen"}, "items[1].qty": {"x"}})
+	if !errors.As(err, &formErr) || formErr.Field != "Items[1].Name" {
+		t.Errorf("expected Items[1].Name to be required, got %v", err)
+	}
+
+	_, err = parse(url.Values{"customer": {"Jane"}})
+	if !errors.As(err, &formErr) || formErr.Kind != RequiredFieldMissing || formErr.Field != "Items" {
+		t.Errorf("expected Items to be required, got %v", err)
+	}
+}
+
+type Money struct {
+	cents int64
+}
+
+func TestRegisterFormConverter(t *testing.T) {
+	RegisterFormConverter(reflect.TypeOf(Money{}), func(value string) (any, error) {
+		amount, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return Money{cents: int64(math.Round(amount * 100))}, nil
+	})
+
+	type Order struct {
+		Total    Money   `form:"total"`
+		Discount *Money  `form:"discount"`
+		Prices   []Money `form:"prices"`
+	}
+
+	parse := func(form url.Values) (Order, error) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", ContentTypeUrlEncoded)
+
+		var order Order
+		err := BodyParser(req, &order)
+		return order, err
+	}
+
+	order, err := parse(url.Values{
+		"total":    {"12.50"},
+		"discount": {"1.25"},
+		"prices":   {"4", "8.5"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Order{
+		Total:    Money{cents: 1250},
+		Discount: &Money{cents: 125},
+		Prices:   []Money{{cents: 400}, {cents: 850}},
+	}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("expected %+v, got %+v", want, order)
+	}
+
+	var formErr FormError
+	if _, err = parse(url.Values{"total": {"free"}}); !errors.As(err, &formErr) || formErr.Field != "Total" {
+		t.Errorf("expected an error of Total, got %v", err)
+	}
+}
+
+func TestStructPlanCache(t *testing.T) {
+	type Search struct {
+		Query string `query:"q,required"`
+		Page  int    `query:"page"`
+	}
+
+	rt := reflect.TypeOf(Search{})
+	plans := structPlan(rt, "query")
+	if len(plans) != 2 || plans[0].tag != "q" || !plans[0].required || plans[1].tag != "page" {
+		t.Fatalf("unexpected plans %+v", plans)
+	}
+
+	if again := structPlan(rt, "query"); &again[0] != &plans[0] {
+		t.Error("expected the plans to be cached")
+	}
+
+	if form := structPlan(rt, "form"); form[0].tag != "query" {
+		t.Errorf("expected the plans of the form tag to use the field name, got %q", form[0].tag)
+	}
+
+	RegisterFormConverter(reflect.TypeOf(struct{ search Search }{}), func(value string) (any, error) {
+		return nil, nil
+	})
+	if again := structPlan(rt, "query"); &again[0] == &plans[0] {
+		t.Error("expected RegisterFormConverter to reset the cache")
+	}
+}
+
+func BenchmarkQueryParser(b *testing.B) {
+	type Search struct {
+		Query  string    `query:"q,required"`
+		Page   int       `query:"page"`
+		Tags   []string  `query:"tag"`
+		Since  time.Time `query:"since"`
+		Active *bool     `query:"active"`
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q=go&page=2&tag=a&tag=b&since=2024-08-20&active=true", nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var search Search
+		if err := QueryParser(req, &search); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
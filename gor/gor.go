@@ -15,6 +15,7 @@ package gor
 import (
 	"bufio"
 	"bytes"
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
@@ -24,24 +25,34 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var (
 	// Match only the root path with "/" contrary to the default behavior which matches everything.
 	// The default is true.
+	//
+	// Deprecated: Use the WithStrictHome router option.
+	// The global is only read by NewRouter as the default for new routers.
 	StrictHome = true
 
 	// Remove trailing slashes from the pattern (and req.URL.Path) except for the root path.
 	// This means that if you register "/test/" and a request is made to "/test" or "/test/",
 	// it will not match.
 	// The default is true.
+	//
+	// Deprecated: Use the WithNoTrailingSlash router option.
+	// The global is only read by NewRouter as the default for new routers.
 	NoTrailingSlash = true
 
 	// name of the template content block
@@ -62,17 +73,88 @@ type Map map[string]any
 //	ctx := req.Context().Value(gor.contextKey).(*gor.CTX)
 const contextKey = contextType("ctx")
 
-type route struct {
+// Route is a route registered with the Router.
+// It is returned by the registration methods so that it can be named.
+//
+//	r.Get("/users/{id}", showUser).Name("user.show")
+type Route struct {
+	name        string       // Name of the route for reverse URL generation
 	prefix      string       // contains the method and the path
+	method      string       // Http method
+	path        string       // Registered path pattern
 	middlewares []Middleware // Middlewares
 	handler     http.Handler // Route handler
+	router      *Router      // The router that owns the route
+	file        string       // File where the route was registered
+	line        int          // Line where the route was registered
+
+	// constraints on path wildcards, checked before the handler runs.
+	constraints []paramConstraint
+
+	// handler wrapped with route and global middlewares.
+	// Built on first request and reset when global middleware changes.
+	compiled atomic.Pointer[http.Handler]
+}
+
+// ServeHTTP implements http.Handler by calling the compiled middleware chain.
+// Requests whose path values do not satisfy the route constraints are not found.
+func (rt *Route) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	for _, c := range rt.constraints {
+		if !c.match(req.PathValue(c.name)) {
+			rt.router.notFound.ServeHTTP(w, req)
+			return
+		}
+	}
+
+	h := rt.compiled.Load()
+	if h == nil {
+		h = rt.compile()
+	}
+	(*h).ServeHTTP(w, req)
+}
+
+// Pattern returns the pattern the route was registered with, e.g "GET /users/{id}".
+func (rt *Route) Pattern() string {
+	return rt.prefix
+}
+
+// HandlerName returns the name of the route handler function.
+func (rt *Route) HandlerName() string {
+	return getFuncName(rt.handler)
+}
+
+// MatchedRoute returns the route matching the request,
+// or nil if no route matched or the request was not dispatched by gor.Router.
+func MatchedRoute(req *http.Request) *Route {
+	if ctx, ok := req.Context().Value(contextKey).(*CTX); ok {
+		return ctx.route
+	}
+	return nil
+}
+
+// compile chains the route middlewares and the global middlewares
+// around the handler and caches the result.
+func (rt *Route) compile() *http.Handler {
+	// chain the route middlewares
+	var h http.Handler
+	h = rt.router.chain(rt.middlewares, rt.handler)
+
+	// chain the global middlewares
+	h = rt.router.chain(rt.router.globalMiddlewares, h)
+
+	rt.compiled.Store(&h)
+	return &h
 }
 
 // Router is a simple router that implements the http.Handler interface
 type Router struct {
 	globalMiddlewares []Middleware      // Global middlewares
-	routes            map[string]*route // Routes mapped to their prefix
-	mux               *http.ServeMux    // ServeMux
+	routes            map[string]*Route // Routes mapped to their prefix
+	names             map[string]*Route // Named routes
+	methods           map[string]bool   // Methods of the registered routes
+	paths             map[string]*Route // Routes indexed by their path. GET routes take precedence.
+	mounts            []*Route          // Handlers registered without a method. e.g static file servers.
+	mux               matcher           // http.ServeMux or radix tree
 
 	// Configuration for templates
 
@@ -80,22 +162,81 @@ type Router struct {
 	template           *template.Template // All parsed templates
 	baseLayout         string             // Base layout for the templates(default is "")
 	contentBlock       string             // Content block for the templates(default is "Content")
+	layoutBlocks       []string           // Blocks of the page templates passed to the layouts
 	errorTemplate      string             // Error template. Passed "error", "status", "status_text" in its context.
+	errorTemplates     map[int]string     // Error templates by status, falling back to errorTemplate.
 	passContextToViews bool               // Pass the request context to the views
 
+	// Keys signing the cookies set by the router. See WithCookieKeys.
+	cookieKeys CookieKeys
+
+	// Called after a request whose client disconnected.
+	onClientDisconnect func(req *http.Request)
+
+	// Lifecycle hooks run outside the middleware chain.
+	onRequest  []func(req *http.Request)
+	onResponse []func(w *ResponseWriter, req *http.Request)
+
+	strictHome      bool // Match only the root path with "/"
+	noTrailingSlash bool // Remove trailing slashes from patterns and request paths
+
+	// Rules applied to assets served by Static and StaticFS.
+	assetRules []AssetRule
+
+	// Maximum bytes of a multipart form kept in memory by BodyParser.
+	maxMultipartMemory int64
+
+	// Maximum bytes of a request body read by BodyParser, 0 for no limit.
+	maxBodySize int64
+
+	// Fallback layouts of time fields parsed by BodyParser and QueryParser.
+	timeLayouts []string
+
+	// Validator of the structs parsed by BodyParser and QueryParser. See WithValidator.
+	validator Validator
+
+	// Collect the errors of all fields when parsing forms. See WithAllFormErrors.
+	allFormErrors bool
+
+	// Formatting of the JSON responses. See JSONIndent and JSONEscapeHTML.
+	jsonFormat jsonFormat
+
 	// groups
 	groups map[string]*Group // Groups mapped to their prefix
 
 	// Handler for 404 not found errors. Note that when this is called,
 	// The request parameters are not available, since they are populated by the http.ServeMux
 	// when the request is matched to a route. So calling r.PathValue() will return "".
+	// It runs behind the global middleware like any other route.
 	NotFoundHandler http.Handler
+
+	// ErrorHandler handles errors sent with SendError, HandleError and HandlerFuncE,
+	// and not found or method not allowed requests without a custom handler.
+	// If nil, DefaultErrorHandler is used.
+	ErrorHandler ErrorHandlerFunc
+
+	// Handler for 405 method not allowed errors. It is called when the path matches
+	// a route registered for other methods. The Allow header is set before it is called.
+	// If nil, the error is sent with the ErrorHandler.
+	// It runs behind the global middleware like any other route.
+	MethodNotAllowedHandler http.Handler
+
+	// notFound dispatches to NotFoundHandler through the global middleware.
+	notFound *Route
+
+	// methodNotAllowed dispatches to MethodNotAllowedHandler through the global middleware.
+	methodNotAllowed *Route
 }
 
 // CTX is the custom context passed inside the request context.
 // It carries a reference to the gor.Router and unexported fields
 // for tracking locals.
 //
+// CTX implements context.Context and is installed as the request context
+// by the Router. Values stored with SetContextValue are kept in its locals
+// and are visible to req.Context().Value(key) for the request and every
+// context derived from it, without mutating the *http.Request.
+//
 // It can be access from context with:
 //
 //	ctx := req.Context().Value(gor.ContextKey).(*gor.CTX)
@@ -104,21 +245,74 @@ type CTX struct {
 	localsMu *sync.RWMutex   // Mutex to syncronize access to the locals map
 	locals   map[any]any     // Locals for the templates
 	Router   *Router         // The router
+	route    *Route          // The route matching the request, nil if none matched
+}
+
+// Deadline implements context.Context.
+func (c *CTX) Deadline() (deadline time.Time, ok bool) {
+	return c.context.Deadline()
 }
 
+// Done implements context.Context.
+func (c *CTX) Done() <-chan struct{} {
+	return c.context.Done()
+}
+
+// Err implements context.Context.
+func (c *CTX) Err() error {
+	return c.context.Err()
+}
+
+// Value implements context.Context.
+// It returns the CTX itself for the gor context key, then values from
+// locals and finally falls back to the parent request context.
+func (c *CTX) Value(key any) any {
+	if key == contextKey {
+		return c
+	}
+
+	c.localsMu.RLock()
+	v, ok := c.locals[key]
+	c.localsMu.RUnlock()
+	if ok {
+		return v
+	}
+	return c.context.Value(key)
+}
+
+// ResponseWriter wraps the http.ResponseWriter of the requests served by
+// gor.Router to track the status and size of the response.
+//
+// It implements http.Flusher, http.Hijacker, http.Pusher and io.ReaderFrom,
+// and the SetReadDeadline and SetWriteDeadline methods of http.ResponseController,
+// by forwarding to the underlying writer. Methods the underlying writer does
+// not support return an error wrapping http.ErrNotSupported, or do nothing for Flush.
+// Unwrap gives http.ResponseController access to the other methods.
 type ResponseWriter struct {
 	http.ResponseWriter     // The embedded response writer.
 	status              int // response status code
 
-	size int
+	size int64 // bytes of the body written
 
 	// track if status already sent
 	statusSent bool
+
+	// Formatting of the JSON responses of the request.
+	jsonFormat jsonFormat
+
+	// track if the connection was hijacked
+	hijacked bool
 }
 
 // WriteHeader sends an HTTP response header with the provided status code.
+// Only the first call has an effect. Superfluous calls are logged with the
+// file and line of the caller to help locate handlers that write twice.
 func (w *ResponseWriter) WriteHeader(status int) {
 	if w.statusSent {
+		if file, line, ok := relevantCaller(); ok {
+			log.Printf("gor: superfluous response.WriteHeader(%d) call from %s:%d (status %d already sent)",
+				status, file, line, w.status)
+		}
 		return
 	}
 	w.status = status
@@ -133,7 +327,7 @@ func (rw *ResponseWriter) Write(b []byte) (int, error) {
 	}
 
 	size, err := rw.ResponseWriter.Write(b)
-	rw.size += size
+	rw.size += int64(size)
 	return size, err
 }
 
@@ -142,6 +336,58 @@ func (w *ResponseWriter) Status() int {
 	return w.status
 }
 
+// Size returns the number of bytes of the response body written so far.
+func (w *ResponseWriter) Size() int {
+	return int(w.size)
+}
+
+// BytesWritten returns the number of bytes of the response body written so far,
+// including the bytes copied by ReadFrom.
+func (w *ResponseWriter) BytesWritten() int64 {
+	return w.size
+}
+
+// Written reports whether the response header has already been sent,
+// by WriteHeader or the first Write. Middlewares use it to detect whether
+// a handler already responded. Once true, calls to WriteHeader have no effect.
+func (w *ResponseWriter) Written() bool {
+	return w.statusSent
+}
+
+// headerWritten reports whether the header of w, or of a ResponseWriter it wraps,
+// has already been sent. Writers that do not track this are assumed unwritten.
+func headerWritten(w io.Writer) bool {
+	for {
+		switch rw := w.(type) {
+		case interface{ Written() bool }:
+			return rw.Written()
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+// relevantCaller returns the file and line of the first caller
+// outside of the gor response writers and net/http.
+func relevantCaller() (string, int, bool) {
+	pc := make([]uintptr, 16)
+	n := runtime.Callers(3, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "net/http.") &&
+			!strings.HasSuffix(frame.Function, "gor.(*ResponseWriter).WriteHeader") &&
+			!strings.HasSuffix(frame.Function, "gor.(*redirectWriter).WriteHeader") {
+			return frame.File, frame.Line, true
+		}
+		if !more {
+			return "", 0, false
+		}
+	}
+}
+
 // Flush sends any buffered data to the client.
 func (w *ResponseWriter) Flush() {
 	if f, ok := w.ResponseWriter.(http.Flusher); ok {
@@ -149,12 +395,26 @@ func (w *ResponseWriter) Flush() {
 	}
 }
 
-// Push initiates an HTTP/2 server push.
+// Push initiates an HTTP/2 server push. It returns http.ErrNotSupported
+// if the underlying writer does not support server push.
 // See https://pkg.go.dev/net/http#Pusher.Push
-func (w *ResponseWriter) Push(target string, opts *http.PushOptions) {
+func (w *ResponseWriter) Push(target string, opts *http.PushOptions) error {
 	if f, ok := w.ResponseWriter.(http.Pusher); ok {
-		f.Push(target, opts)
+		return f.Push(target, opts)
 	}
+	return http.ErrNotSupported
+}
+
+// SetReadDeadline sets the deadline for reading the request body,
+// e.g to allow a slow upload on a single route. See http.ResponseController.
+func (w *ResponseWriter) SetReadDeadline(deadline time.Time) error {
+	return http.NewResponseController(w.ResponseWriter).SetReadDeadline(deadline)
+}
+
+// SetWriteDeadline sets the deadline for writing the response,
+// e.g to extend it for a long download. See http.ResponseController.
+func (w *ResponseWriter) SetWriteDeadline(deadline time.Time) error {
+	return http.NewResponseController(w.ResponseWriter).SetWriteDeadline(deadline)
 }
 
 // Hijack lets the caller take over the connection.
@@ -162,7 +422,11 @@ func (w *ResponseWriter) Push(target string, opts *http.PushOptions) {
 // see https://pkg.go.dev/net/http#Hijacker.Hijack
 func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
-		return h.Hijack()
+		conn, rw, err := h.Hijack()
+		if err == nil {
+			w.hijacked = true
+		}
+		return conn, rw, err
 	}
 	return nil, nil, fmt.Errorf("http.Hijacker is not implemented")
 }
@@ -177,11 +441,13 @@ func (rw *ResponseWriter) ReadFrom(r io.Reader) (n int64, err error) {
 	}
 
 	n, err = io.Copy(rw.ResponseWriter, r)
-	rw.size += int(n)
+	rw.size += n
 	return
 }
 
-// Satisfy http.ResponseController support (Go 1.20+)
+// Unwrap returns the underlying http.ResponseWriter, so that
+// http.NewResponseController reaches its Flush, SetReadDeadline,
+// SetWriteDeadline and EnableFullDuplex methods through the wrapper.
 func (rw *ResponseWriter) Unwrap() http.ResponseWriter {
 	return rw.ResponseWriter
 }
@@ -192,10 +458,14 @@ type RouterOption func(*Router)
 // NewRouter creates a new router with the given options.
 // The router wraps the http.DefaultServeMux and adds routing and middleware
 // capabilities.
+// It panics if the base layout or the error templates are invalid. See VerifyTemplates.
 func NewRouter(options ...RouterOption) *Router {
 	r := &Router{
 		mux:                http.NewServeMux(),
-		routes:             make(map[string]*route),
+		routes:             make(map[string]*Route),
+		names:              make(map[string]*Route),
+		methods:            make(map[string]bool),
+		paths:              make(map[string]*Route),
 		passContextToViews: false,
 		baseLayout:         "",
 		contentBlock:       contentBlock,
@@ -203,72 +473,306 @@ func NewRouter(options ...RouterOption) *Router {
 		groups:             make(map[string]*Group),
 		globalMiddlewares:  []Middleware{},
 		template:           nil,
+		maxMultipartMemory: DefaultMaxMultipartMemory,
+		strictHome:         StrictHome,
+		noTrailingSlash:    NoTrailingSlash,
+	}
+
+	r.notFound = &Route{
+		router:  r,
+		handler: http.HandlerFunc(r.handleNotFound),
+	}
+
+	r.methodNotAllowed = &Route{
+		router:  r,
+		handler: http.HandlerFunc(r.handleMethodNotAllowed),
 	}
 
 	for _, option := range options {
 		option(r)
 	}
+
+	if r.template != nil {
+		if err := r.verifyConfiguredTemplates(); err != nil {
+			panic(err)
+		}
+	}
 	return r
 }
 
+// MaxMultipartMemory sets the maximum number of bytes of a multipart form
+// that BodyParser keeps in memory. The remainder, including file parts, is
+// stored in temporary files on disk. The default is DefaultMaxMultipartMemory.
+//
+// Example:
+//
+//	r := gor.NewRouter(gor.MaxMultipartMemory(8 << 20))
+func MaxMultipartMemory(n int64) RouterOption {
+	return func(r *Router) {
+		if n > 0 {
+			r.maxMultipartMemory = n
+		}
+	}
+}
+
+// MaxBodySize sets the maximum number of bytes of a request body read by
+// BodyParser, including multipart files. Larger bodies fail with a FormError
+// of kind RequestTooLarge, which HandleError sends with 413 Request Entity Too Large.
+// There is no limit by default.
+//
+// Example:
+//
+//	r := gor.NewRouter(gor.MaxBodySize(10 << 20))
+func MaxBodySize(n int64) RouterOption {
+	return func(r *Router) {
+		r.maxBodySize = n
+	}
+}
+
+// WithTimeLayouts sets the layouts tried on time values of forms and queries
+// that ParseTime cannot parse, such as TimeLayoutUnix or "02/01/2006".
+//
+// Example:
+//
+//	r := gor.NewRouter(gor.WithTimeLayouts("02/01/2006", gor.TimeLayoutUnix))
+func WithTimeLayouts(layouts ...string) RouterOption {
+	return func(r *Router) {
+		r.timeLayouts = layouts
+	}
+}
+
+// WithAllFormErrors sets whether BodyParser, QueryParser and the other parsers
+// return FormErrors listing every invalid field instead of the first FormError,
+// e.g to re-render a form with all its errors at once.
+func WithAllFormErrors(all bool) RouterOption {
+	return func(r *Router) {
+		r.allFormErrors = all
+	}
+}
+
+// JSONIndent sets the indentation of the JSON responses sent by SendJSON and
+// SendJSONError, like json.MarshalIndent. If enabled is false, only the
+// responses of requests with the "pretty" query parameter, e.g "?pretty=1",
+// are indented, so that debugging consumers can read them without tools.
+// Indented JSON is encoded with encoding/json instead of JSONCodec.
+//
+// Example:
+//
+//	r := gor.NewRouter(gor.JSONIndent(false, "", "  "))
+func JSONIndent(enabled bool, prefix, indent string) RouterOption {
+	return func(r *Router) {
+		r.jsonFormat.indent = enabled
+		r.jsonFormat.pretty = !enabled
+		r.jsonFormat.prefix = prefix
+		r.jsonFormat.indentStr = indent
+	}
+}
+
+// JSONEscapeHTML sets whether the JSON responses sent by SendJSON and
+// SendJSONError escape <, > and & in strings, the default of encoding/json.
+// JSON without HTML escaping is encoded with encoding/json instead of JSONCodec.
+func JSONEscapeHTML(escape bool) RouterOption {
+	return func(r *Router) {
+		r.jsonFormat.noEscapeHTML = !escape
+	}
+}
+
+// WithStrictHome sets whether "/" matches only the root path
+// instead of every path. The default is the value of gor.StrictHome (true).
+func WithStrictHome(strict bool) RouterOption {
+	return func(r *Router) {
+		r.strictHome = strict
+	}
+}
+
+// WithNoTrailingSlash sets whether trailing slashes are removed from patterns
+// and request paths, except for the root path.
+// The default is the value of gor.NoTrailingSlash (true).
+func WithNoTrailingSlash(noTrailingSlash bool) RouterOption {
+	return func(r *Router) {
+		r.noTrailingSlash = noTrailingSlash
+	}
+}
+
+// OnClientDisconnect registers fn to be called after the handler returns
+// for requests whose client disconnected or canceled the request.
+// The request context is still valid when fn is called. See ClientGone.
+func OnClientDisconnect(fn func(req *http.Request)) RouterOption {
+	return func(r *Router) {
+		r.onClientDisconnect = fn
+	}
+}
+
+// OnRequest registers fn to be called for every request before it is routed.
+// Hooks run outside the middleware chain, in the order they are registered,
+// and also see requests that match no route. Use them for metrics,
+// request counting or draining logic.
+// OnRequest should be called before the router starts serving requests.
+func (r *Router) OnRequest(fn func(req *http.Request)) {
+	r.onRequest = append(r.onRequest, fn)
+}
+
+// OnResponse registers fn to be called for every request after the handler returns.
+// The writer reports the status and size of the response. See OnRequest.
+func (r *Router) OnResponse(fn func(w *ResponseWriter, req *http.Request)) {
+	r.onResponse = append(r.onResponse, fn)
+}
+
 // Apply a global middleware to all routes.
+// Middleware chains are built on the first request to a route, so global middleware
+// applies to all routes regardless of whether Use is called before or after registration.
+// Use should be called before the router starts serving requests.
 func (r *Router) Use(middlewares ...Middleware) {
 	r.globalMiddlewares = append(r.globalMiddlewares, middlewares...)
+
+	// Invalidate compiled chains.
+	for _, route := range r.routes {
+		route.compiled.Store(nil)
+	}
+
+	for _, route := range r.mounts {
+		route.compiled.Store(nil)
+	}
+	r.notFound.compiled.Store(nil)
+	r.methodNotAllowed.compiled.Store(nil)
 }
 
-var ctxPool = sync.Pool{
-	New: func() interface{} {
-		return &CTX{
-			localsMu: &sync.RWMutex{},
-			locals:   make(map[any]any),
-		}
+// writerPool reuses the ResponseWriter wrappers between requests.
+// As with net/http, handlers must not use the writer after they return.
+var writerPool = sync.Pool{
+	New: func() any {
+		return &ResponseWriter{}
 	},
 }
 
+// reset clears the writer state for a request writing to rw.
+func (w *ResponseWriter) reset(rw http.ResponseWriter) {
+	w.ResponseWriter = rw
+	w.status = http.StatusOK
+	w.size = 0
+	w.statusSent = false
+	w.jsonFormat = jsonFormat{}
+	w.hijacked = false
+}
+
 // Implementation for http.Handler.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	writer := writerPool.Get().(*ResponseWriter)
+	writer.reset(w)
+	writer.jsonFormat = r.jsonFormat.forRequest(req)
+
+	// The CTX is the request context, so it is allocated per request: contexts
+	// derived from it, e.g by http.Client or Detach, may outlive the request.
+	ctx := &CTX{
+		context:  req.Context(),
+		localsMu: &sync.RWMutex{},
+		locals:   make(map[any]any),
+		Router:   r,
+	}
+
+	// Derive a request carrying the CTX. The caller's request is never mutated.
+	req = req.WithContext(ctx)
+
 	// if no trailing slash is allowed, remove it
-	if NoTrailingSlash && req.URL.Path != "/" {
-		req.URL.Path = strings.TrimSuffix(req.URL.Path, "/")
+	if r.noTrailingSlash && req.URL.Path != "/" && strings.HasSuffix(req.URL.Path, "/") {
+		u := *req.URL
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		u.RawPath = ""
+		req.URL = &u
 	}
 
-	writer := &ResponseWriter{
-		ResponseWriter: w,
-		status:         http.StatusOK,
+	h, pattern := r.mux.Handler(req)
+	if pattern != "" {
+		ctx.route, _ = h.(*Route)
 	}
 
-	// Get a context from the pool
-	ctx := ctxPool.Get().(*CTX)
-	ctx.context = req.Context()
-	ctx.Router = r
+	for _, fn := range r.onRequest {
+		fn(req)
+	}
 
-	defer func() {
-		// Reset the context
-		ctx.context = nil
-		ctx.Router = nil
+	// Call the NotFoundHandler or MethodNotAllowedHandler if no route is found
+	if pattern != "" {
+		r.mux.ServeHTTP(writer, req)
+	} else if allowed := r.allowedMethods(req); allowed != "" {
+		writer.Header().Set("Allow", allowed)
+		r.methodNotAllowed.ServeHTTP(writer, req)
+	} else {
+		r.notFound.ServeHTTP(writer, req)
+	}
 
-		for k := range ctx.locals {
-			delete(ctx.locals, k)
-		}
-		ctxPool.Put(ctx)
-	}()
+	for _, fn := range r.onResponse {
+		fn(writer, req)
+	}
 
-	// set the context
-	valueContext := context.WithValue(req.Context(), contextKey, ctx)
-	*req = *req.WithContext(valueContext)
+	if r.onClientDisconnect != nil && ClientGone(req) {
+		r.onClientDisconnect(req)
+	}
 
-	// Call the NotFoundHandler if no route is found
-	_, pattern := r.mux.Handler(req)
-	if pattern == "" {
-		if r.NotFoundHandler != nil {
-			r.NotFoundHandler.ServeHTTP(writer, req)
+	// Release the writer only if the request completed: a hijacked connection
+	// may still be served with it, and a panic may leave it in use. Handlers
+	// wrapped by WithRouteTimeout write to their own buffer, not to this writer,
+	// so they can outlive the request.
+	if !writer.hijacked {
+		writer.reset(nil)
+		writerPool.Put(writer)
+	}
+}
+
+// handleNotFound calls the NotFoundHandler of the group containing the path or the router if set.
+// Otherwise the error is sent with the router's error pipeline.
+func (r *Router) handleNotFound(w http.ResponseWriter, req *http.Request) {
+	if len(r.groups) > 0 {
+		g := r.groupFor(req.URL.Path, func(g *Group) bool { return g.NotFoundHandler != nil })
+		if g != nil {
+			g.NotFoundHandler.ServeHTTP(w, req)
 			return
 		}
-		w.WriteHeader(http.StatusNotFound)
+	}
+
+	if r.NotFoundHandler != nil {
+		r.NotFoundHandler.ServeHTTP(w, req)
+		return
+	}
+	r.HandleError(w, req, errNotFound, http.StatusNotFound)
+}
+
+// handleMethodNotAllowed calls the MethodNotAllowedHandler if set.
+// Otherwise the error is sent with the router's error pipeline.
+func (r *Router) handleMethodNotAllowed(w http.ResponseWriter, req *http.Request) {
+	if r.MethodNotAllowedHandler != nil {
+		r.MethodNotAllowedHandler.ServeHTTP(w, req)
 		return
 	}
+	r.HandleError(w, req, errMethodNotAllowed, http.StatusMethodNotAllowed)
+}
 
-	r.mux.ServeHTTP(writer, req)
+// allMethods lists the HTTP methods in the order they are reported in the Allow header.
+var allMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
+
+// allowedMethods returns the comma separated methods of the registered routes
+// matching the request path, or "" if the path matches no route.
+// It is only called for requests that did not match a route.
+func (r *Router) allowedMethods(req *http.Request) string {
+	var allowed []string
+	probe := *req
+
+	for _, method := range allMethods {
+		if !r.methods[method] {
+			continue
+		}
+
+		probe.Method = method
+		if _, pattern := r.mux.Handler(&probe); pattern != "" {
+			allowed = append(allowed, method)
+			if method == http.MethodGet && !r.methods[http.MethodHead] {
+				allowed = append(allowed, http.MethodHead)
+			}
+		}
+	}
+	return strings.Join(allowed, ", ")
 }
 
 // chain of middlewares
@@ -301,129 +805,298 @@ func (r *CTX) Get(key any) any {
 	return r.locals[key]
 }
 
+// Detach returns a context for background work started while handling req.
+// It carries a copy of the request locals and the request context values,
+// but it is never canceled and has no deadline, so it remains usable after
+// the request completes.
+//
+// Values set on the detached context are not visible to the request.
+func Detach(req *http.Request) context.Context {
+	c, ok := req.Context().Value(contextKey).(*CTX)
+	if !ok {
+		return context.WithoutCancel(req.Context())
+	}
+
+	c.localsMu.RLock()
+	locals := make(map[any]any, len(c.locals))
+	for k, v := range c.locals {
+		locals[k] = v
+	}
+	c.localsMu.RUnlock()
+
+	return &CTX{
+		context:  context.WithoutCancel(c.context),
+		localsMu: &sync.RWMutex{},
+		locals:   locals,
+		Router:   c.Router,
+	}
+}
+
 // registerRoute registers a route with the router.
-func (r *Router) registerRoute(method, path string, handler http.HandlerFunc, middlewares []Middleware) {
-	if StrictHome && path == "/" {
+// Wildcards may carry constraints, e.g "/users/{id:int}". See parseConstraints.
+// An empty method matches all methods.
+func (r *Router) registerRoute(method, path string, handler http.Handler, middlewares []Middleware) *Route {
+	path, constraints := parseConstraints(path)
+
+	if r.strictHome && path == "/" {
 		path = path + "{$}" // Match only the root path
 	}
 
 	// remove trailing slashes
-	if NoTrailingSlash && path != "/" {
+	if r.noTrailingSlash && path != "/" {
 		path = strings.TrimSuffix(path, "/")
 	}
 
-	prefix := fmt.Sprintf("%s %s", method, path)
-
-	// chain the route middlewares
-	var h http.Handler
-	h = r.chain(middlewares, handler)
+	prefix := path
+	if method != "" {
+		prefix = method + " " + path
+	}
 
-	// chain the global middlewares
-	h = r.chain(r.globalMiddlewares, h)
+	// The chain is compiled on the first request. Clone the middlewares so that
+	// routes registered later on a group cannot overwrite them through a shared
+	// backing array.
+	newRoute := &Route{
+		prefix:      prefix,
+		method:      method,
+		path:        path,
+		middlewares: slices.Clone(middlewares),
+		handler:     handler,
+		router:      r,
+		constraints: constraints,
+	}
+	newRoute.file, newRoute.line = registrationCaller()
 
-	newRoute := &route{prefix: prefix, middlewares: middlewares, handler: h}
+	if existing := r.conflictingRoute(newRoute); existing != nil {
+		panic(fmt.Sprintf("gor: route %q (%s registered at %s:%d) conflicts with route %q (%s registered at %s:%d)",
+			newRoute.prefix, getFuncName(handler), newRoute.file, newRoute.line,
+			existing.prefix, getFuncName(existing.handler), existing.file, existing.line))
+	}
 
 	// add the route to the routes map
 	r.routes[prefix] = newRoute
+	r.methods[method] = true
+
+	// index the route by its path. The root path is indexed as "/" even with StrictHome.
+	key := path
+	if key != "/" {
+		key = strings.TrimSuffix(key, "{$}")
+	}
+	if _, ok := r.paths[key]; !ok || method == http.MethodGet {
+		r.paths[key] = newRoute
+	}
+
+	r.mux.Handle(prefix, newRoute)
+	return newRoute
+}
 
-	r.mux.Handle(prefix, h)
+// conflictingRoute returns the registered route whose pattern matches exactly
+// the same requests as rt. Wildcard names are ignored, so "/users/{id}"
+// conflicts with "/users/{name}" for the same method.
+func (r *Router) conflictingRoute(rt *Route) *Route {
+	if existing, ok := r.routes[rt.prefix]; ok {
+		return existing
+	}
+
+	shape := patternShape(rt.path)
+	for _, existing := range r.routes {
+		if existing.method == rt.method && patternShape(existing.path) == shape {
+			return existing
+		}
+	}
+	return nil
+}
+
+// patternShape replaces wildcard names in path with empty names,
+// keeping the "..." suffix of multi segment wildcards and {$}.
+func patternShape(path string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			b.WriteString(path)
+			return b.String()
+		}
+
+		end := strings.IndexByte(path[start:], '}')
+		if end < 0 {
+			b.WriteString(path)
+			return b.String()
+		}
+		end += start
+
+		b.WriteString(path[:start])
+		switch name := path[start+1 : end]; {
+		case name == "$":
+			b.WriteString("{$}")
+		case strings.HasSuffix(name, "..."):
+			b.WriteString("{...}")
+		default:
+			b.WriteString("{}")
+		}
+		path = path[end+1:]
+	}
+}
+
+// gorPackage is the import path prefix of functions in this package.
+var gorPackage = reflect.TypeOf(Route{}).PkgPath() + "."
+
+// registrationCaller returns the file and line of the first caller
+// outside this package, i.e the code that registered the route.
+func registrationCaller() (string, int) {
+	pc := make([]uintptr, 16)
+	n := runtime.Callers(2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, gorPackage) {
+			return frame.File, frame.Line
+		}
+		if !more {
+			return "", 0
+		}
+	}
+}
+
+// mount registers a handler for a ServeMux pattern without a method.
+// e.g a static file server at prefix "/static/".
+// The handler is wrapped with the middlewares and global middlewares.
+func (r *Router) mount(pattern string, handler http.Handler, middlewares ...Middleware) {
+	newRoute := &Route{
+		prefix:      pattern,
+		path:        pattern,
+		middlewares: slices.Clone(middlewares),
+		handler:     handler,
+		router:      r,
+	}
+
+	r.mounts = append(r.mounts, newRoute)
+	r.mux.Handle(pattern, newRoute)
+}
+
+// Handle registers handler for requests with method to path.
+// Use it to mount existing http.Handler values such as file servers,
+// reverse proxies or promhttp.Handler without wrapping them in closures.
+// An empty method matches all methods.
+//
+//	r.Handle(http.MethodGet, "/metrics", promhttp.Handler())
+func (r *Router) Handle(method, path string, handler http.Handler, middlewares ...Middleware) *Route {
+	return r.registerRoute(method, path, handler, middlewares)
+}
+
+// HandleFunc registers handler for requests with method to path. See Handle.
+func (r *Router) HandleFunc(method, path string, handler http.HandlerFunc, middlewares ...Middleware) *Route {
+	return r.registerRoute(method, path, handler, middlewares)
+}
+
+// OAuthHandler is a login flow with an authorization server,
+// such as an oauth.Provider of gor/auth/oauth.
+type OAuthHandler interface {
+	LoginHandler() http.Handler    // Redirects to the authorization server
+	CallbackHandler() http.Handler // Completes the login when the server redirects back
+}
+
+// OAuth registers the login flow of h under prefix: GET prefix starts the login
+// and GET prefix+"/callback" completes it, which must be the redirect URL
+// registered with the authorization server.
+//
+//	r.OAuth("/auth/google", oauth.Google(clientID, clientSecret, "https://example.com/auth/google/callback"))
+func (r *Router) OAuth(prefix string, h OAuthHandler, middlewares ...Middleware) {
+	r.registerRoute(http.MethodGet, prefix, h.LoginHandler(), middlewares)
+	r.registerRoute(http.MethodGet, prefix+"/callback", h.CallbackHandler(), middlewares)
 }
 
 // GET request.
-func (r *Router) Get(path string, handler http.HandlerFunc, middlewares ...Middleware) {
-	r.registerRoute(http.MethodGet, path, handler, middlewares)
+func (r *Router) Get(path string, handler http.HandlerFunc, middlewares ...Middleware) *Route {
+	return r.registerRoute(http.MethodGet, path, handler, middlewares)
 }
 
 // POST request.
-func (r *Router) Post(path string, handler http.HandlerFunc, middlewares ...Middleware) {
-	r.registerRoute(http.MethodPost, path, handler, middlewares)
+func (r *Router) Post(path string, handler http.HandlerFunc, middlewares ...Middleware) *Route {
+	return r.registerRoute(http.MethodPost, path, handler, middlewares)
 }
 
 // PUT request.
-func (r *Router) Put(path string, handler http.HandlerFunc, middlewares ...Middleware) {
-	r.registerRoute(http.MethodPut, path, handler, middlewares)
+func (r *Router) Put(path string, handler http.HandlerFunc, middlewares ...Middleware) *Route {
+	return r.registerRoute(http.MethodPut, path, handler, middlewares)
 }
 
 // PATCH request.
-func (r *Router) Patch(path string, handler http.HandlerFunc, middlewares ...Middleware) {
-	r.registerRoute(http.MethodPatch, path, handler, middlewares)
+func (r *Router) Patch(path string, handler http.HandlerFunc, middlewares ...Middleware) *Route {
+	return r.registerRoute(http.MethodPatch, path, handler, middlewares)
 }
 
 // DELETE request.
-func (r *Router) Delete(path string, handler http.HandlerFunc, middlewares ...Middleware) {
-	r.registerRoute(http.MethodDelete, path, handler, middlewares)
+func (r *Router) Delete(path string, handler http.HandlerFunc, middlewares ...Middleware) *Route {
+	return r.registerRoute(http.MethodDelete, path, handler, middlewares)
 }
 
 // OPTIONS. This may not be necessary as registering GET request automatically registers OPTIONS.
-func (r *Router) Options(path string, handler http.HandlerFunc, middlewares ...Middleware) {
-	r.registerRoute(http.MethodOptions, path, handler, middlewares)
+func (r *Router) Options(path string, handler http.HandlerFunc, middlewares ...Middleware) *Route {
+	return r.registerRoute(http.MethodOptions, path, handler, middlewares)
 }
 
 // HEAD request.
-func (r *Router) Head(path string, handler http.HandlerFunc, middlewares ...Middleware) {
-	r.registerRoute(http.MethodHead, path, handler, middlewares)
+func (r *Router) Head(path string, handler http.HandlerFunc, middlewares ...Middleware) *Route {
+	return r.registerRoute(http.MethodHead, path, handler, middlewares)
 }
 
 // TRACE http request.
-func (r *Router) Trace(path string, handler http.HandlerFunc, middlewares ...Middleware) {
-	r.registerRoute(http.MethodTrace, path, handler, middlewares)
+func (r *Router) Trace(path string, handler http.HandlerFunc, middlewares ...Middleware) *Route {
+	return r.registerRoute(http.MethodTrace, path, handler, middlewares)
 }
 
 // CONNECT http request.
-func (r *Router) Connect(path string, handler http.HandlerFunc, middlewares ...Middleware) {
-	r.registerRoute(http.MethodConnect, path, handler, middlewares)
+func (r *Router) Connect(path string, handler http.HandlerFunc, middlewares ...Middleware) *Route {
+	return r.registerRoute(http.MethodConnect, path, handler, middlewares)
 }
 
 // Serve static assests at prefix in the directory dir.
 // e.g r.Static("/static", "static").
 // This method will strip the prefix from the URL path.
-// To serve minified assets(JS and CSS) if present, call gor.ServeMinifiedAssetsIfPresent=true.
+// Asset rules configured with WithAssetRules are applied. e.g to serve
+// minified assets(JS and CSS) if present, use gor.WithAssetRules(gor.MinifiedAssets).
 // To enable caching, provide maxAge seconds for cache duration.
 func (r *Router) Static(prefix, dir string, maxAge ...int) {
+	prefix = withTrailingSlash(prefix)
+	r.mount(prefix, r.staticHandler(prefix, dir, maxAge...))
+}
+
+// withTrailingSlash appends "/" to prefix if missing so that it matches a subtree.
+func withTrailingSlash(prefix string) string {
 	if !strings.HasSuffix(prefix, "/") {
 		prefix = prefix + "/"
 	}
+	return prefix
+}
 
+// staticHandler serves the files in dir for requests under prefix.
+func (r *Router) staticHandler(prefix, dir string, maxAge ...int) http.Handler {
 	cacheDuration := 0
 	if len(maxAge) > 0 {
 		cacheDuration = maxAge[0]
 	}
 
+	rules := r.assetRulesFor()
+
 	var h = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		path := filepath.Join(dir, strings.TrimPrefix(req.URL.Path, prefix))
+		name := "/" + strings.TrimPrefix(req.URL.Path, prefix)
+		path := filepath.Join(dir, filepath.FromSlash(name))
 
-		setCacheHeaders := func() {
-			if cacheDuration > 0 {
-				// Set cache control headers with the specified maxAge
-				w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(cacheDuration))
+		for _, rule := range rules {
+			if alt, ok := rule(name); ok && filePathExists(filepath.Join(dir, filepath.FromSlash(alt))) {
+				path = filepath.Join(dir, filepath.FromSlash(alt))
+				break
 			}
 		}
 
-		if ServeMinifiedAssetsIfPresent {
-			stat, err := os.Stat(path)
-			if err != nil || stat.IsDir() {
-				http.NotFound(w, req)
-				return
-			}
-
-			if strings.HasSuffix(path, ".js") || strings.HasSuffix(path, ".css") {
-				// Check for the minified version of the file
-				minifiedPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".min" + filepath.Ext(path)
-				if filePathExists(minifiedPath) {
-					http.ServeFile(w, req, minifiedPath)
-					setCacheHeaders()
-					return
-				}
-			}
+		if cacheDuration > 0 {
+			// Set cache control headers with the specified maxAge
+			w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(cacheDuration))
 		}
 
-		setCacheHeaders()
-
 		http.ServeFile(w, req, path)
-
 	})
-
-	r.mux.Handle(prefix, r.chain(r.globalMiddlewares, h))
+	return h
 }
 
 func filePathExists(name string) bool {
@@ -437,33 +1110,18 @@ func (r *Router) File(path, file string) {
 		http.ServeFile(w, req, file)
 	}
 
-	handler := r.chain(r.globalMiddlewares, hf)
-	r.Get(path, handler.ServeHTTP)
+	r.Get(path, hf)
 }
 
 func (r *Router) FileFS(fs http.FileSystem, prefix, path string) {
-	r.Get(prefix, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		f, err := fs.Open(path)
-		if err != nil {
-			http.NotFound(w, req)
-			return
-		}
-		defer f.Close()
-
-		stat, err := f.Stat()
-		if err != nil || stat.IsDir() {
-			http.NotFound(w, req)
-			return
-		}
-
-		w.WriteHeader(http.StatusOK)
-		http.ServeContent(w, req, path, stat.ModTime(), f)
-	}))
+	r.Get(prefix, fileFSHandler(fs, path, nil))
 }
 
-// Serve favicon.ico from the file system fs at path.
-func (r *Router) FaviconFS(fs http.FileSystem, path string) {
-	var handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+// fileFSHandler serves the file at path in fs with http.ServeContent,
+// which handles Range, If-Modified-Since and the other conditional headers.
+// The header is added to the response if the file exists.
+func fileFSHandler(fs http.FileSystem, path string, header http.Header) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
 		f, err := fs.Open(path)
 		if err != nil {
 			http.NotFound(w, req)
@@ -477,42 +1135,21 @@ func (r *Router) FaviconFS(fs http.FileSystem, path string) {
 			return
 		}
 
-		var data = make([]byte, stat.Size())
-		_, err = f.Read(data)
-		if err != nil {
-			http.NotFound(w, req)
-			return
-		}
-
-		w.Header().Set("Content-Type", "image/x-icon")
-		w.Header().Set("Cache-Control", "public, max-age=31536000")
-		w.Header().Set("Content-Length", fmt.Sprintf("%d", stat.Size()))
-		w.Header().Set("Content-Disposition", "inline; filename=favicon.ico")
-		w.WriteHeader(http.StatusOK)
-		w.Write(data)
-	})
-
-	r.Get("/favicon.ico", handler)
-}
-
-type minifiedFS struct {
-	http.FileSystem
-}
-
-func (mfs *minifiedFS) Open(name string) (http.File, error) {
-	// Check if the requested file is a .js or .css file
-	if strings.HasSuffix(name, ".js") || strings.HasSuffix(name, ".css") {
-		// Check for the minified version of the file
-		minifiedName := strings.TrimSuffix(name, filepath.Ext(name)) + ".min" + filepath.Ext(name)
-
-		// Return minified file if available.
-		if f, err := mfs.FileSystem.Open(minifiedName); err == nil {
-			return f, nil
+		for key, values := range header {
+			w.Header()[key] = slices.Clone(values)
 		}
+		http.ServeContent(w, req, path, stat.ModTime(), f)
 	}
+}
 
-	// If no minified version is found, serve the original file
-	return mfs.FileSystem.Open(name)
+// Serve favicon.ico from the file system fs at path.
+// Conditional and range requests are handled by http.ServeContent.
+func (r *Router) FaviconFS(fs http.FileSystem, path string) {
+	r.Get("/favicon.ico", fileFSHandler(fs, path, http.Header{
+		"Content-Type":        {"image/x-icon"},
+		"Cache-Control":       {"public, max-age=31536000"},
+		"Content-Disposition": {"inline; filename=favicon.ico"},
+	}))
 }
 
 // Serve minified Javascript and CSS if present instead of original file.
@@ -520,6 +1157,9 @@ func (mfs *minifiedFS) Open(name string) (http.File, error) {
 // e.g /static/js/main.js will serve /static/js/main.min.js if present.
 // Default is false.
 // This is important since we maintain the same script sources in our templates/html.
+//
+// Deprecated: Use the WithAssetRules router option with MinifiedAssets.
+// The global is only consulted by routers without asset rules.
 var ServeMinifiedAssetsIfPresent = false
 
 // Like Static but for http.FileSystem.
@@ -527,14 +1167,20 @@ var ServeMinifiedAssetsIfPresent = false
 //
 //	mux.StaticFS("/static", http.FS(staticFs))
 //
+// Asset rules configured with WithAssetRules are applied unless fs
+// was created with AssetFS.
 // To enable caching, provide maxAge seconds for cache duration.
 func (r *Router) StaticFS(prefix string, fs http.FileSystem, maxAge ...int) {
-	if !strings.HasSuffix(prefix, "/") {
-		prefix = prefix + "/"
-	}
+	r.mount(withTrailingSlash(prefix), r.staticFSHandler(fs, maxAge...))
+}
 
-	if ServeMinifiedAssetsIfPresent {
-		fs = &minifiedFS{fs}
+// staticFSHandler serves the files in fs.
+func (r *Router) staticFSHandler(fs http.FileSystem, maxAge ...int) http.Handler {
+	// Apply the router asset rules unless the mount has its own.
+	if rules := r.assetRulesFor(); len(rules) > 0 {
+		if _, ok := fs.(*assetFS); !ok {
+			fs = AssetFS(fs, rules...)
+		}
 	}
 
 	cacheDuration := 0
@@ -550,10 +1196,7 @@ func (r *Router) StaticFS(prefix string, fs http.FileSystem, maxAge ...int) {
 		}
 		http.FileServer(fs).ServeHTTP(w, r)
 	})
-
-	// Apply global middleware
-	finalHandler := r.chain(r.globalMiddlewares, handler)
-	r.mux.Handle(prefix, finalHandler)
+	return handler
 }
 
 // creates a new http.FileSystem from the embed.FS
@@ -581,6 +1224,11 @@ type SPAOptions struct {
 // The default entrypoint is "index.html" i.e buildPath/index.html.
 // You can change the entrypoint with options. Passed options override all defaults.
 func (r *Router) SPAHandler(frontendFS fs.FS, path string, buildPath string, options ...SPAOptions) {
+	r.mount(path, spaHandler(frontendFS, buildPath, options...))
+}
+
+// spaHandler serves the single page application in buildPath.
+func spaHandler(frontendFS fs.FS, buildPath string, options ...SPAOptions) http.Handler {
 	var (
 		indexFile    = "index.html"
 		cacheControl string
@@ -610,11 +1258,9 @@ func (r *Router) SPAHandler(frontendFS fs.FS, path string, buildPath string, opt
 		panic("Unable to read contents of " + indexFile)
 	}
 
-	// Apply global middleware
-	fsHandler := http.FileServer(buildFS(frontendFS, buildPath))
-	handler := r.chain(r.globalMiddlewares, fsHandler)
+	handler := http.FileServer(buildFS(frontendFS, buildPath))
 
-	r.mux.HandleFunc(path, func(w http.ResponseWriter, req *http.Request) {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		// check skip.
 		for _, s := range skip {
 			if s == req.URL.Path {
@@ -688,22 +1334,34 @@ func (r *Router) renderErrorTemplate(w http.ResponseWriter, err error, status ..
 		statusCode = status[0]
 	}
 
-	// send the error
-	w.Header().Set("Content-Type", ContentTypeHTML)
+	// The status can not be changed once the header is sent.
+	if headerWritten(w) {
+		log.Printf("gor: response already written, dropping error: %v\n", err)
+		return
+	}
 
-	if r.errorTemplate != "" {
-		err = r.renderTemplate(w, r.errorTemplate, Map{
+	if name := r.errorTemplateFor(statusCode); name != "" {
+		tmplErr := r.renderTemplate(w, name, Map{
 			"status":      statusCode,
 			"status_text": http.StatusText(statusCode),
 			"error":       err,
-		})
-		if err != nil {
-			log.Println(err)
+		}, statusCode)
+		if tmplErr == nil {
+			return
 		}
-	} else {
-		w.Write([]byte(err.Error()))
+		log.Println(tmplErr)
 	}
-	w.WriteHeader(statusCode)
+
+	// send the error
+	writeErrorHTML(w, err, statusCode)
+}
+
+// errorTemplateFor returns the error template of status, or the ErrorTemplate.
+func (r *Router) errorTemplateFor(status int) string {
+	if name, ok := r.errorTemplates[status]; ok {
+		return name
+	}
+	return r.errorTemplate
 }
 
 func (r *Router) RenderError(w http.ResponseWriter, err error, status ...int) {
@@ -711,59 +1369,323 @@ func (r *Router) RenderError(w http.ResponseWriter, err error, status ...int) {
 }
 
 // =========== TEMPLATE FUNCTIONS ===========
-func (r *Router) renderTemplate(w io.Writer, name string, data Map) error {
+
+// renderTemplate executes the template name inside the base layout, if any, and writes it to w.
+// The page is rendered into a buffer first, so that nothing is written if a template fails.
+func (r *Router) renderTemplate(w io.Writer, name string, data Map, status ...int) error {
+	page := getBuffer()
+	defer putBuffer(page)
+
+	if err := r.executePage(page, name, data, r.baseLayouts(), r.layoutBlocks); err != nil {
+		return err
+	}
+	return writeHTML(w, page.Bytes(), status...)
+}
+
+// baseLayouts returns the base layout, if any.
+func (r *Router) baseLayouts() []string {
+	if r.baseLayout == "" {
+		return nil
+	}
+	return []string{r.baseLayout}
+}
+
+// executePage executes the template name into page, inside the layouts from
+// the innermost to the outermost. Each layout is passed the output of the
+// previous one as its content block, and the blocks defined by the page.
+func (r *Router) executePage(page *bytes.Buffer, name string, data Map, layouts, blocks []string) error {
 	// if name is missing the extension, add it(assume it's an html file)
-	if filepath.Ext(name) == "" {
-		name = name + ".html"
+	name = pageName(name)
+
+	// Without a layout, the template is the whole page.
+	if len(layouts) == 0 || r.contentBlock == "" {
+		return r.template.ExecuteTemplate(page, name, data)
 	}
 
-	buf := new(bytes.Buffer)
-	err := r.template.ExecuteTemplate(buf, name, data)
-	if err != nil {
-		log.Printf("Error rendering template: %s\n", err)
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := r.template.ExecuteTemplate(buf, name, data); err != nil {
 		return err
 	}
 
-	content := buf.String()
+	for _, block := range blocks {
+		if r.template.Lookup(name+":"+block) == nil {
+			if _, ok := data[block]; !ok {
+				data[block] = template.HTML("")
+			}
+			continue
+		}
 
-	finalBuf := new(bytes.Buffer)
-	data[r.contentBlock] = template.HTML(content)
-	err = r.template.ExecuteTemplate(finalBuf, r.baseLayout, data)
+		blockBuf := getBuffer()
+		err := r.template.ExecuteTemplate(blockBuf, name+":"+block, data)
+		data[block] = template.HTML(blockBuf.String())
+		putBuffer(blockBuf)
 
-	if err != nil {
-		log.Printf("Error rendering template: %s\n", err)
-		return err
+		if err != nil {
+			return err
+		}
 	}
 
-	if writer, ok := w.(http.ResponseWriter); ok {
-		writer.Header().Set("Content-Type", ContentTypeHTML)
-		writer.WriteHeader(http.StatusOK)
+	for i, layout := range layouts {
+		data[r.contentBlock] = template.HTML(buf.String())
+		if i == len(layouts)-1 {
+			return r.template.ExecuteTemplate(page, layout, data)
+		}
+
+		buf.Reset()
+		if err := r.template.ExecuteTemplate(buf, layout, data); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// writeHTML writes the rendered html to w.
+// If w is an http.ResponseWriter whose header has not been written yet,
+// the content type and status are set first. The status defaults to 200.
+// A status written earlier by the handler is never overridden.
+func writeHTML(w io.Writer, html []byte, status ...int) error {
+	if writer, ok := w.(http.ResponseWriter); ok && !headerWritten(writer) {
+		statusCode := http.StatusOK
+		if len(status) > 0 {
+			statusCode = status[0]
+		}
 
-	_, err = w.Write(finalBuf.Bytes())
+		if writer.Header().Get("Content-Type") == "" {
+			writer.Header().Set("Content-Type", ContentTypeHTML)
+		}
+		writer.WriteHeader(statusCode)
+	}
+
+	_, err := w.Write(html)
 	return err
 }
 
+// RenderOptions configures the response of RenderWithOptions.
+type RenderOptions struct {
+	// Status is the status code of the response. Defaults to 200.
+	Status int
+
+	// Headers are added to the response once the page is rendered.
+	// They are not sent with the error page if a template fails.
+	Headers http.Header
+
+	// Layouts, if set, replaces the layouts of the page, from the innermost
+	// to the base layout, e.g []string{"layouts/admin.html", "layouts/base.html"}.
+	// By default, the page is rendered in the Layout of the groups containing
+	// the request path, then in the base layout.
+	Layouts []string
+
+	// Blocks are rendered from the page in addition to the LayoutBlocks of the
+	// Router and the Blocks of the groups.
+	Blocks []string
+}
+
 // Render the template tmpl with the data. If no template is configured, Render will panic.
 // data is a map such that it can be extended with
 // the request context keys if passContextToViews is set to true.
+// The flash messages of the request are consumed and passed as "flashes",
+// unless data already has that key. See Flash. Likewise, the old input of the
+// request is consumed and passed as "old" and "errors". See WithOldInput.
 // If a file extension is missing, it will be appended as ".html".
-func (r *Router) Render(w io.Writer, req *http.Request, name string, data Map) {
+//
+// The response is sent with the optional status code, or 200 if none is given.
+// If the handler already wrote the header, its status is kept.
+// The page is rendered before anything is written, so a failing template sends
+// a 500 Internal Server Error through the error template instead of a partial page.
+func (r *Router) Render(w io.Writer, req *http.Request, name string, data Map, status ...int) {
+	var opts RenderOptions
+	if len(status) > 0 {
+		opts.Status = status[0]
+	}
+	r.RenderWithOptions(w, req, name, data, opts)
+}
+
+// RenderStatus renders the template like Render, with the status code,
+// e.g to re-display a form with http.StatusUnprocessableEntity.
+func (r *Router) RenderStatus(w io.Writer, req *http.Request, code int, name string, data Map) {
+	r.RenderWithOptions(w, req, name, data, RenderOptions{Status: code})
+}
+
+// RenderWithOptions renders the template like Render, with the status and
+// headers of opts:
+//
+//	r.RenderWithOptions(w, req, "products/edit", data, gor.RenderOptions{
+//		Status:  http.StatusUnprocessableEntity,
+//		Headers: http.Header{"Cache-Control": {"no-store"}},
+//	})
+func (r *Router) RenderWithOptions(w io.Writer, req *http.Request, name string, data Map, opts RenderOptions) {
 	if r.template == nil {
 		panic("No template is configured")
 	}
 
-	writeError := func(err error) {
-		if err != nil {
-			log.Println(err)
-			if writer, ok := w.(http.ResponseWriter); ok {
-				writer.Header().Set("Content-Type", ContentTypeHTML)
-				writer.WriteHeader(http.StatusInternalServerError)
-				writer.Write([]byte(err.Error()))
+	_, hasFlashes := data[flashesKey]
+	_, hasOld := data[oldValuesKey]
+	_, hasErrors := data[oldErrorsKey]
+	data = r.viewData(req, data)
+	writer, isResponseWriter := w.(http.ResponseWriter)
+
+	// inject the flash messages, consuming them
+	if isResponseWriter && !hasFlashes {
+		data[flashesKey] = Flashes(writer, req)
+	}
+
+	// inject the old input, consuming it. "old" is never nil,
+	// so templates can use {{ .old.name }} on any page.
+	if isResponseWriter && !hasOld {
+		values, errs := OldInput(writer, req)
+		if values == nil {
+			values = map[string]string{}
+		}
+		data[oldValuesKey] = values
+
+		if !hasErrors && errs != nil {
+			data[oldErrorsKey] = errs
+		}
+	}
+
+	layouts, blocks := r.layoutsFor(req.URL.Path)
+	if opts.Layouts != nil {
+		layouts = opts.Layouts
+	}
+	blocks = append(slices.Clip(r.layoutBlocks), append(blocks, opts.Blocks...)...)
+
+	page := getBuffer()
+	defer putBuffer(page)
+
+	// Failures are sent with the error template and status 500,
+	// unless the handler already wrote the header.
+	if err := r.executePage(page, name, data, layouts, blocks); err != nil {
+		log.Printf("gor: error rendering template %s: %v\n", name, err)
+		if isResponseWriter {
+			r.renderErrorTemplate(writer, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if isResponseWriter && !headerWritten(writer) {
+		for key, values := range opts.Headers {
+			for _, value := range values {
+				writer.Header().Add(key, value)
 			}
 		}
 	}
 
+	var status []int
+	if opts.Status != 0 {
+		status = append(status, opts.Status)
+	}
+
+	if err := writeHTML(w, page.Bytes(), status...); err != nil {
+		log.Printf("gor: error writing template %s: %v\n", name, err)
+	}
+}
+
+// RenderString renders the template name inside the base layout, like Render,
+// and returns the page, e.g for email bodies or HTML fragments sent over websockets.
+// If no template is configured, RenderString will panic.
+func (r *Router) RenderString(name string, data Map) (string, error) {
+	page, err := r.RenderBytes(name, data)
+	return string(page), err
+}
+
+// RenderBytes renders the template name inside the base layout, like Render,
+// and returns the page. If no template is configured, RenderBytes will panic.
+func (r *Router) RenderBytes(name string, data Map) ([]byte, error) {
+	if r.template == nil {
+		panic("No template is configured")
+	}
+
+	if data == nil {
+		data = Map{}
+	}
+
+	page := getBuffer()
+	defer putBuffer(page)
+
+	if err := r.executePage(page, name, data, r.baseLayouts(), r.layoutBlocks); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(page.Bytes()), nil
+}
+
+// RenderBlock renders the block defined in the page template name, without
+// the layouts, e.g to update a table row with HTMX:
+//
+//	{{/* users/list.html */}}
+//	<table>{{ range .users }}{{ template "users/list.html:row" . }}{{ end }}</table>
+//	{{ define "users/list.html:row" }}<tr><td>{{ .Name }}</td></tr>{{ end }}
+//
+//	r.RenderBlock(w, req, "users/list.html", "row", gor.Map{"Name": user.Name})
+//
+// The block is the template named "<page>:<block>", like the LayoutBlocks,
+// or else the template named block, since names defined in a template are
+// shared by all templates. The data is extended with the request context
+// like Render, but the flash messages are not consumed.
+// A failing block sends a 500 Internal Server Error through the error template.
+func (r *Router) RenderBlock(w io.Writer, req *http.Request, name, block string, data Map, status ...int) {
+	if r.template == nil {
+		panic("No template is configured")
+	}
+
+	data = r.viewData(req, data)
+	writer, isResponseWriter := w.(http.ResponseWriter)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := r.executeBlock(buf, name, block, data); err != nil {
+		log.Printf("gor: error rendering block %s of template %s: %v\n", block, name, err)
+		if isResponseWriter {
+			r.renderErrorTemplate(writer, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := writeHTML(w, buf.Bytes(), status...); err != nil {
+		log.Printf("gor: error writing block %s of template %s: %v\n", block, name, err)
+	}
+}
+
+// executeBlock executes the block of the page template name into buf.
+func (r *Router) executeBlock(buf *bytes.Buffer, name, block string, data Map) error {
+	// if name is missing the extension, add it(assume it's an html file)
+	name = pageName(name)
+
+	if r.template.Lookup(name) == nil {
+		return fmt.Errorf("gor: template %q is not defined", name)
+	}
+
+	if r.template.Lookup(name+":"+block) != nil {
+		return r.template.ExecuteTemplate(buf, name+":"+block, data)
+	}
+
+	if r.template.Lookup(block) == nil {
+		return fmt.Errorf("gor: block %q of template %q is not defined", block, name)
+	}
+	return r.template.ExecuteTemplate(buf, block, data)
+}
+
+// viewData returns data, extended with the request context
+// if passContextToViews is set, the locale and the CSRF token of the request.
+func (r *Router) viewData(req *http.Request, data Map) Map {
+	if data == nil {
+		data = Map{}
+	}
+
+	if _, ok := data[string(localeKey)]; !ok {
+		if locale := Locale(req); locale != "" {
+			data[string(localeKey)] = locale
+		}
+	}
+
+	if _, ok := data[csrfTokenKey]; !ok {
+		if token := CSRFToken(req); token != "" {
+			data[csrfTokenKey] = token
+		}
+	}
+
 	// pass the request context to the views
 	if r.passContextToViews {
 		ctx, ok := req.Context().Value(contextKey).(*CTX)
@@ -773,28 +1695,43 @@ func (r *Router) Render(w io.Writer, req *http.Request, name string, data Map) {
 			}
 		}
 	}
-
-	// if baseLayout and contentBlock are set, render the template with the base layout
-	if r.baseLayout != "" && r.contentBlock != "" {
-		err := r.renderTemplate(w, name, data)
-		writeError(err)
-		return
-	}
-
-	err := r.template.ExecuteTemplate(w, name, data)
-	writeError(err)
-
+	return data
 }
 
 // Render a template of given name and pass the data to it.
 // Make sure you are using gor.Router. Otherwise this function will panic.
 // If a file extension is missing, it will be appended as ".html".
-func Render(w io.Writer, req *http.Request, name string, data Map) {
+// An optional status code may be given and defaults to 200.
+func Render(w io.Writer, req *http.Request, name string, data Map, status ...int) {
+	routerFromRequest(req).Render(w, req, name, data, status...)
+}
+
+// RenderStatus renders a template with the status code.
+// It is an alias for gor.Router.RenderStatus.
+func RenderStatus(w io.Writer, req *http.Request, code int, name string, data Map) {
+	routerFromRequest(req).RenderStatus(w, req, code, name, data)
+}
+
+// RenderBlock renders a block defined in a page template.
+// It is an alias for gor.Router.RenderBlock.
+func RenderBlock(w io.Writer, req *http.Request, name, block string, data Map, status ...int) {
+	routerFromRequest(req).RenderBlock(w, req, name, block, data, status...)
+}
+
+// RenderWithOptions renders a template with the status and headers of opts.
+// It is an alias for gor.Router.RenderWithOptions.
+func RenderWithOptions(w io.Writer, req *http.Request, name string, data Map, opts RenderOptions) {
+	routerFromRequest(req).RenderWithOptions(w, req, name, data, opts)
+}
+
+// routerFromRequest returns the router of the request.
+// It panics if the request is not handled by gor.Router.
+func routerFromRequest(req *http.Request) *Router {
 	ctx, ok := req.Context().Value(contextKey).(*CTX)
 	if !ok {
 		panic("You are not using gor.Router. You cannot use this function")
 	}
-	ctx.Router.Render(w, req, name, data)
+	return ctx.Router
 }
 
 // Execute a standalone template without a layout.
@@ -897,51 +1834,162 @@ func (r *Router) GetContextValue(req *http.Request, key any) interface{} {
 	return GetContextValue(req, key)
 }
 
-func (r *Router) RedirectRoute(w http.ResponseWriter, req *http.Request, pathname string, status ...int) {
-	var statusCode = http.StatusSeeOther
-	if len(status) > 0 {
-		statusCode = status[0]
+// RedirectRoute redirects the client to the route named name.
+// args are the route parameters, a Map or key/value pairs as accepted by Router.URL,
+// optionally followed by the status code. The default status is 303 See Other.
+//
+//	r.RedirectRoute(w, req, "user.show", gor.Map{"id": 5})
+//	r.RedirectRoute(w, req, "user.show", "id", 5, http.StatusFound)
+//
+// For compatibility, name may also be the registered path of an unnamed route.
+func (r *Router) RedirectRoute(w http.ResponseWriter, req *http.Request, name string, args ...any) {
+	params, status := redirectArgs(args)
+
+	var location string
+	var err error
+	if _, ok := r.names[name]; ok {
+		location, err = r.URL(name, params...)
+	} else if route, ok := r.paths[name]; ok {
+		var values map[string]string
+		if values, err = urlParams(params); err == nil {
+			location, err = buildPath(route.path, values)
+		}
+	} else {
+		err = fmt.Errorf("gor: no route named %q", name)
 	}
 
-	// find the mathing route
-	var handler http.Handler
+	if err != nil {
+		r.HandleError(w, req, err, http.StatusNotFound)
+		return
+	}
+	http.Redirect(w, req, location, status)
+}
 
-	for _, route := range r.routes {
-		// split prefix into method and path
-		parts := strings.Split(route.prefix, " ")
-		name := strings.TrimSpace(parts[1])
-		if name == pathname {
-			handler = route.handler
-			break
+// redirectArgs splits the arguments of RedirectRoute into the url parameters and the status.
+// A trailing int is the status if the remaining arguments are valid parameters.
+func redirectArgs(args []any) ([]any, int) {
+	n := len(args)
+	if n == 0 {
+		return nil, http.StatusSeeOther
+	}
+
+	status, ok := args[n-1].(int)
+	if !ok {
+		return args, http.StatusSeeOther
+	}
+
+	params := args[:n-1]
+	if len(params) == 1 {
+		switch params[0].(type) {
+		case Map, map[string]string:
+			return params, status
 		}
 	}
 
-	if handler == nil {
-		http.Error(w, "404 page not found", http.StatusNotFound)
-		return
+	if len(params)%2 == 0 {
+		return params, status
+	}
+	return args, http.StatusSeeOther
+}
+
+// Lookup returns the handler registered for method and path.
+// path is first looked up as a registered pattern e.g "/users/{id}".
+// Otherwise it is matched as a request path e.g "/users/10".
+// The returned handler is wrapped with the route and global middlewares.
+//
+// Note that path values are only populated when the request is dispatched
+// with Router.ServeHTTP.
+func (r *Router) Lookup(method, path string) (http.Handler, bool) {
+	if route, ok := r.routes[method+" "+path]; ok {
+		return route, true
 	}
 
-	w.WriteHeader(statusCode)
-	handler.ServeHTTP(w, req)
+	req := &http.Request{Method: method, URL: &url.URL{Path: path}, Header: http.Header{}}
+	handler, pattern := r.mux.Handler(req)
+	if pattern == "" {
+		return nil, false
+	}
+	return handler, true
 }
 
 type routeInfo struct {
-	Method string // Http method.
-	Path   string // Registered pattern.
-	Name   string // Function name for the handler.
+	Method      string   `json:"method"`      // Http method.
+	Path        string   `json:"path"`        // Registered pattern.
+	Name        string   `json:"name"`        // Function name for the handler.
+	RouteName   string   `json:"route_name"`  // Name given with Route.Name.
+	Middlewares []string `json:"middlewares"` // Function names of the route middlewares.
 }
 
+// GetRegisteredRoutes returns the registered routes sorted by path and method.
 func (r *Router) GetRegisteredRoutes() []routeInfo {
 	var routes []routeInfo
 	for _, route := range r.routes {
-		parts := strings.SplitN(route.prefix, " ", 2)
-		routes = append(routes, routeInfo{Method: parts[0], Path: parts[1], Name: getFuncName(route.handler)})
+		middlewares := make([]string, len(route.middlewares))
+		for i, mw := range route.middlewares {
+			middlewares[i] = getFuncName(mw)
+		}
+
+		routes = append(routes, routeInfo{
+			Method:      route.method,
+			Path:        route.path,
+			Name:        getFuncName(route.handler),
+			RouteName:   route.name,
+			Middlewares: middlewares,
+		})
 	}
+
+	slices.SortFunc(routes, func(a, b routeInfo) int {
+		return cmp.Or(strings.Compare(a.Path, b.Path), strings.Compare(a.Method, b.Method))
+	})
 	return routes
 }
 
+// getFuncName returns the name of function f, or its type if f is not a function.
 func getFuncName(f interface{}) string {
-	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func {
+		return fmt.Sprintf("%T", f)
+	}
+	return runtime.FuncForPC(v.Pointer()).Name()
+}
+
+// routeListTemplate renders the registered routes as an html table.
+var routeListTemplate = template.Must(template.New("routes").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Routes</title></head>
+<body>
+<table border="1" cellpadding="4">
+<tr><th>Method</th><th>Pattern</th><th>Name</th><th>Handler</th><th>Middlewares</th></tr>
+{{ range . }}<tr><td>{{ .Method }}</td><td>{{ .Path }}</td><td>{{ .RouteName }}</td><td>{{ .Name }}</td><td>{{ range .Middlewares }}{{ . }}<br>{{ end }}</td></tr>
+{{ end }}</table>
+</body>
+</html>`))
+
+// EnableRouteList serves the registered routes at path for debugging.
+// Clients accepting JSON receive the output of GetRegisteredRoutes,
+// others an html table of the method, pattern, handler and middleware names.
+//
+// The list exposes the structure of the application. Protect it with middleware
+// in production, e.g:
+//
+//	r.EnableRouteList("/_routes", auth.BasicAuth("admin", "secret"))
+func (r *Router) EnableRouteList(path string, middlewares ...Middleware) *Route {
+	return r.Get(path, func(w http.ResponseWriter, req *http.Request) {
+		routes := r.GetRegisteredRoutes()
+		if acceptsJSON(req) {
+			SendJSON(w, routes)
+			return
+		}
+
+		buf := getBuffer()
+		defer putBuffer(buf)
+
+		if err := routeListTemplate.Execute(buf, routes); err != nil {
+			HandleError(w, req, err)
+			return
+		}
+		writeHTML(w, buf.Bytes())
+	}, middlewares...)
 }
 
 func ClientIPAddress(r *http.Request) (string, error) {
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
@@ -16,6 +18,7 @@ import (
 	"strings"
 	"testing"
 	"text/template"
+	"time"
 
 	"github.com/abiiranathan/gor/gor"
 )
@@ -103,6 +106,132 @@ func TestRouterNotFound(t *testing.T) {
 	}
 }
 
+func TestRouterNotFoundMiddleware(t *testing.T) {
+	r := gor.NewRouter()
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if gor.GetContextValue(req, "user") != "john" {
+			t.Error("expected context values set by middleware in NotFoundHandler")
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("custom not found"))
+	})
+
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Global", "true")
+			gor.SetContextValue(req, "user", "john")
+			next.ServeHTTP(w, req)
+		})
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/notfound", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+
+	if w.Header().Get("X-Global") != "true" {
+		t.Error("expected global middleware to run for not found requests")
+	}
+
+	if w.Body.String() != "custom not found" {
+		t.Errorf("expected custom not found body, got %q", w.Body.String())
+	}
+}
+
+func TestRouterErrorHandler(t *testing.T) {
+	r := gor.NewRouter()
+
+	var gotStatus int
+	r.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error, status int) {
+		gotStatus = status
+		gor.DefaultErrorHandler(w, req, err, status)
+	}
+
+	r.Get("/error", func(w http.ResponseWriter, req *http.Request) {
+		gor.SendError(w, req, io.ErrUnexpectedEOF, http.StatusBadGateway)
+	})
+
+	r.Post("/bind", gor.HandlerFuncE(func(w http.ResponseWriter, req *http.Request) error {
+		var u User
+		return gor.BodyParser(req, u)
+	}).ServeHTTP)
+
+	tests := []struct {
+		method string
+		path   string
+		accept string
+		status int
+		body   string
+	}{
+		{"GET", "/error", "", http.StatusBadGateway, "unexpected EOF"},
+		{"GET", "/error", "application/json", http.StatusBadGateway, `{"error":"unexpected EOF"}`},
+		{"POST", "/bind", "application/json", http.StatusBadRequest, `"error":"BodyParser error`},
+		{"GET", "/notfound", "application/json", http.StatusNotFound, `{"error":"Not Found"}`},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status || gotStatus != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d (handler got %d)", tt.method, tt.path, tt.status, w.Code, gotStatus)
+		}
+
+		if !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s %s: expected body to contain %q, got %q", tt.method, tt.path, tt.body, w.Body.String())
+		}
+	}
+}
+
+func TestRouterMethodNotAllowed(t *testing.T) {
+	r := gor.NewRouter()
+	h := func(w http.ResponseWriter, req *http.Request) {}
+	r.Get("/users/{id}", h)
+	r.Delete("/users/{id}", h)
+	r.Post("/posts", h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/users/1", nil))
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", w.Code)
+	}
+
+	if allow := w.Header().Get("Allow"); allow != "GET, HEAD, DELETE" {
+		t.Errorf("expected Allow header %q, got %q", "GET, HEAD, DELETE", allow)
+	}
+
+	var allow string
+	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		allow = w.Header().Get("Allow")
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/posts", nil))
+
+	if w.Code != http.StatusTeapot {
+		t.Errorf("expected custom handler status 418, got %d", w.Code)
+	}
+
+	if allow != "POST" {
+		t.Errorf("expected Allow header POST in handler, got %q", allow)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
 // Use a derived type. Form processing should still pass.
 type Age int
 
@@ -421,6 +550,38 @@ func TestRouterChainMiddleware(t *testing.T) {
 	}
 }
 
+// global middleware registered after the routes must still apply, in order.
+func TestRouterLateGlobalMiddleware(t *testing.T) {
+	r := gor.NewRouter()
+	r.Get("/late", func(w http.ResponseWriter, req *http.Request) {
+		gor.SendString(w, w.Header().Get("X-Order"))
+	})
+
+	// serve once before adding middleware to make sure compiled chains are invalidated.
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/late", nil))
+
+	for _, name := range []string{"first", "second"} {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Add("X-Order", name)
+				next.ServeHTTP(w, req)
+			})
+		})
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/late", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Body.String() != "first" {
+		t.Errorf("expected first, got %s", w.Body.String())
+	}
+
+	if got := w.Header().Values("X-Order"); len(got) != 2 || got[1] != "second" {
+		t.Errorf("expected [first second], got %v", got)
+	}
+}
+
 // test render with a base layout
 func TestRouterRenderWithBaseLayout(t *testing.T) {
 	templ, err := gor.ParseTemplatesRecursive("../cmd/server/templates",
@@ -462,6 +623,54 @@ func TestRouterRenderWithBaseLayout(t *testing.T) {
 
 }
 
+func TestRouterRenderStatus(t *testing.T) {
+	templ, err := gor.ParseTemplatesRecursive("../cmd/server/templates",
+		template.FuncMap{"upper": strings.ToUpper}, ".html")
+
+	if err != nil {
+		panic(err)
+	}
+
+	r := gor.NewRouter(
+		gor.BaseLayout("base.html"),
+		gor.ContentBlock("Content"),
+		gor.WithTemplates(templ),
+	)
+
+	data := gor.Map{"Title": "Home Page", "Body": "Welcome to the home page"}
+
+	r.Get("/missing", func(w http.ResponseWriter, req *http.Request) {
+		r.Render(w, req, "home.html", data, http.StatusNotFound)
+	})
+
+	r.Get("/created", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		r.Render(w, req, "home.html", data)
+	})
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/missing", http.StatusNotFound},
+		{"/created", http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", tt.path, nil)
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, w.Code)
+		}
+
+		if w.Body.Len() == 0 {
+			t.Errorf("%s: expected a rendered body", tt.path)
+		}
+	}
+}
+
 func CopyDir(src, dst string) error {
 	// create the destination directory
 	err := os.MkdirAll(dst, 0755)
@@ -544,6 +753,308 @@ func TestRouterStatic(t *testing.T) {
 
 }
 
+func TestRouterAssetRules(t *testing.T) {
+	dirname := t.TempDir()
+
+	files := map[string]string{
+		"app.js":            "app",
+		"app.min.js":        "app.min",
+		"style.css":         "style",
+		"dist/style.css":    "dist style",
+		"static/app.js":     "fs app",
+		"static/app.min.js": "fs app.min",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dirname, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := gor.NewRouter(gor.WithAssetRules(gor.AssetsFromDir("dist"), gor.MinifiedAssets))
+	r.Static("/assets", dirname)
+	r.StaticFS("/static", http.Dir(dirname))
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/assets/app.js", "app.min"},
+		{"/assets/style.css", "dist style"},
+		{"/static/app.js", "fs app.min"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", tt.path, nil)
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d", tt.path, w.Code)
+		}
+
+		if w.Body.String() != tt.body {
+			t.Errorf("%s: expected %q, got %q", tt.path, tt.body, w.Body.String())
+		}
+	}
+
+	// A mount with its own rules ignores the router rules.
+	r = gor.NewRouter(gor.WithAssetRules(gor.MinifiedAssets))
+	r.StaticFS("/static", gor.AssetFS(http.Dir(dirname)))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/static/app.js", nil))
+	if w.Body.String() != "fs app" {
+		t.Errorf("expected %q, got %q", "fs app", w.Body.String())
+	}
+}
+
+func TestRouterPathOptions(t *testing.T) {
+	t.Parallel()
+
+	strict := gor.NewRouter()
+	loose := gor.NewRouter(gor.WithStrictHome(false), gor.WithNoTrailingSlash(false))
+
+	for _, r := range []*gor.Router{strict, loose} {
+		r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+			gor.SendString(w, "home")
+		})
+
+		r.Get("/users/", func(w http.ResponseWriter, req *http.Request) {
+			gor.SendString(w, "users")
+		})
+	}
+
+	tests := []struct {
+		router *gor.Router
+		path   string
+		status int
+	}{
+		{strict, "/other", http.StatusNotFound},
+		{strict, "/users", http.StatusOK},
+		{strict, "/users/", http.StatusOK},
+		{loose, "/other", http.StatusOK},
+		{loose, "/users/", http.StatusOK},
+		{loose, "/users/10", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", tt.path, nil)
+		tt.router.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, w.Code)
+		}
+
+		if req.URL.Path != tt.path {
+			t.Errorf("ServeHTTP modified the request path to %q", req.URL.Path)
+		}
+	}
+}
+
+func TestRouterOnClientDisconnect(t *testing.T) {
+	var disconnected []string
+	r := gor.NewRouter(gor.OnClientDisconnect(func(req *http.Request) {
+		disconnected = append(disconnected, req.URL.Path)
+	}))
+
+	r.Get("/slow", func(w http.ResponseWriter, req *http.Request) {
+		if !gor.ClientGone(req) {
+			t.Error("expected ClientGone to be true")
+		}
+	})
+
+	r.Get("/fast", func(w http.ResponseWriter, req *http.Request) {
+		if gor.ClientGone(req) {
+			t.Error("expected ClientGone to be false")
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil).WithContext(ctx))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fast", nil))
+
+	if len(disconnected) != 1 || disconnected[0] != "/slow" {
+		t.Errorf("expected hook to be called for /slow only, got %v", disconnected)
+	}
+}
+
+func TestRouterURL(t *testing.T) {
+	r := gor.NewRouter()
+	h := func(w http.ResponseWriter, req *http.Request) {}
+
+	r.Get("/", h).Name("home")
+	r.Get("/users/{id}", h).Name("user.show")
+	r.Get("/files/{path...}", h).Name("files")
+	r.Group("/api").Get("/posts/{slug}/comments/{id}", h).Name("api.comment")
+
+	tests := []struct {
+		name   string
+		params []any
+		want   string
+	}{
+		{"home", nil, "/"},
+		{"user.show", []any{"id", 10}, "/users/10"},
+		{"user.show", []any{gor.Map{"id": "a b"}}, "/users/a%20b"},
+		{"user.show", []any{"id", 10, "tab", "posts"}, "/users/10?tab=posts"},
+		{"files", []any{"path", "css/main.css"}, "/files/css/main.css"},
+		{"api.comment", []any{"slug", "hello", "id", 3}, "/api/posts/hello/comments/3"},
+	}
+
+	for _, tt := range tests {
+		got, err := r.URL(tt.name, tt.params...)
+		if err != nil {
+			t.Errorf("URL(%q): unexpected error: %v", tt.name, err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("URL(%q): expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+
+	if _, err := r.URL("user.show"); err == nil {
+		t.Error("expected an error for a missing parameter")
+	}
+
+	if _, err := r.URL("unknown"); err == nil {
+		t.Error("expected an error for an unknown route")
+	}
+}
+
+func TestRouterURLTemplateFunc(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "link.html"), []byte(`{{ url "user.show" "id" .ID }}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templ, err := gor.ParseTemplatesRecursive(dir, template.FuncMap{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gor.NewRouter(gor.WithTemplates(templ))
+	r.Get("/users/{id}", func(w http.ResponseWriter, req *http.Request) {}).Name("user.show")
+
+	buf := new(bytes.Buffer)
+	if err := r.ExecuteTemplate(buf, "link.html", gor.Map{"ID": 7}); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "/users/7" {
+		t.Errorf("expected /users/7, got %q", buf.String())
+	}
+}
+
+func TestRouterParamConstraints(t *testing.T) {
+	r := gor.NewRouter()
+	h := func(w http.ResponseWriter, req *http.Request) {
+		gor.SendString(w, req.PathValue("id")+req.PathValue("slug"))
+	}
+
+	r.Get("/users/{id:int}", h)
+	r.Get("/posts/{slug:[a-z-]+}", h)
+	r.Get("/codes/{id:[0-9]{3}}", h)
+	r.Get("/items/{id:uuid}", h)
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/users/10", http.StatusOK},
+		{"/users/abc", http.StatusNotFound},
+		{"/posts/hello-world", http.StatusOK},
+		{"/posts/Hello", http.StatusNotFound},
+		{"/codes/123", http.StatusOK},
+		{"/codes/1234", http.StatusNotFound},
+		{"/items/6ba7b810-9dad-11d1-80b4-00c04fd430c8", http.StatusOK},
+		{"/items/10", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", tt.path, nil)
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, w.Code)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an invalid regular expression")
+		}
+	}()
+	r.Get("/bad/{id:[}", h)
+}
+
+func TestRouterEnableRouteList(t *testing.T) {
+	r := gor.NewRouter()
+	r.Get("/users/{id}", func(w http.ResponseWriter, req *http.Request) {}).Name("user.show")
+
+	denied := 0
+	r.EnableRouteList("/_routes", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("X-Token") != "secret" {
+				denied++
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/_routes", nil))
+	if w.Code != http.StatusUnauthorized || denied != 1 {
+		t.Fatalf("expected the route list to be protected, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/_routes", nil)
+	req.Header.Set("X-Token", "secret")
+	req.Header.Set("Accept", "application/json")
+	r.ServeHTTP(w, req)
+
+	var routes []struct {
+		Method      string   `json:"method"`
+		Path        string   `json:"path"`
+		RouteName   string   `json:"route_name"`
+		Middlewares []string `json:"middlewares"`
+	}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &routes); err != nil {
+		t.Fatalf("invalid json %q: %v", w.Body.String(), err)
+	}
+
+	if len(routes) != 2 || routes[0].Path != "/_routes" || len(routes[0].Middlewares) != 1 {
+		t.Fatalf("unexpected routes: %+v", routes)
+	}
+
+	if routes[1].Path != "/users/{id}" || routes[1].RouteName != "user.show" {
+		t.Errorf("unexpected route: %+v", routes[1])
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/_routes", nil)
+	req.Header.Set("X-Token", "secret")
+	r.ServeHTTP(w, req)
+
+	if !strings.Contains(w.Body.String(), "<td>/users/{id}</td>") {
+		t.Errorf("expected an html table, got %q", w.Body.String())
+	}
+}
+
 func TestRouterFile(t *testing.T) {
 	// create a temporary directory for the views
 	dirname, err := os.MkdirTemp("", "static")
@@ -653,9 +1164,271 @@ func TestRouterGroupMiddleware(t *testing.T) {
 	}
 }
 
-// test nested groups
-func TestRouterNestedGroup(t *testing.T) {
-	r := gor.NewRouter()
+func TestRouterGroupRouteMiddleware(t *testing.T) {
+	tag := func(name string) gor.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Add("X-Chain", name)
+				next.ServeHTTP(w, req)
+			})
+		}
+	}
+
+	r := gor.NewRouter()
+	g := r.Group("/a", tag("g1"))
+	g.Use(tag("g2"))
+	g.Use(tag("g3")) // leaves spare capacity in the group middlewares
+
+	handler := func(w http.ResponseWriter, req *http.Request) {}
+	g.Get("/x", handler, tag("x"))
+	g.Get("/y", handler, tag("y"))
+
+	for path, want := range map[string]string{"/a/x": "g1,g2,g3,x", "/a/y": "g1,g2,g3,y"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+
+		if got := strings.Join(w.Header().Values("X-Chain"), ","); got != want {
+			t.Errorf("%s: expected middlewares %s, got %s", path, want, got)
+		}
+	}
+}
+
+func TestRouterGroupStatic(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"app.js":          "app",
+		"dist/index.html": "index",
+		"dist/main.js":    "main",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := gor.NewRouter()
+	admin := r.Group("/admin", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Admin", "true")
+			next.ServeHTTP(w, req)
+		})
+	})
+
+	admin.Static("/assets", dir)
+	admin.FileFS(http.Dir(dir), "/app.js", "app.js")
+	admin.SPAHandler(os.DirFS(dir), "/app/", "dist")
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/admin/assets/app.js", http.StatusOK, "app"},
+		{"/admin/app.js", http.StatusOK, "app"},
+		{"/admin/app/main.js", http.StatusOK, "main"},
+		{"/admin/app/dashboard", http.StatusAccepted, "index"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, w.Code)
+		}
+
+		if w.Body.String() != tt.body {
+			t.Errorf("%s: expected %q, got %q", tt.path, tt.body, w.Body.String())
+		}
+
+		if w.Header().Get("X-Admin") != "true" {
+			t.Errorf("%s: expected the group middleware to run", tt.path)
+		}
+	}
+}
+
+func TestRouterHTTPError(t *testing.T) {
+	errMissing := errors.New("missing")
+
+	r := gor.NewRouter()
+	r.Get("/users/{id}", gor.HandlerFuncE(func(w http.ResponseWriter, req *http.Request) error {
+		return gor.Errorf(http.StatusNotFound, "user %s: %w", req.PathValue("id"), errMissing)
+	}).ServeHTTP)
+	r.Get("/quota", gor.HandlerFuncE(func(w http.ResponseWriter, req *http.Request) error {
+		return gor.NewError(http.StatusTooManyRequests, "quota exceeded").WithDetails(gor.Map{"retry_after": 30})
+	}).ServeHTTP)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/7", nil))
+	if w.Code != http.StatusNotFound || w.Body.String() != "user 7: missing" {
+		t.Errorf("expected 404 user 7: missing, got %d %q", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/quota", nil)
+	req.Header.Set("Accept", gor.ContentTypeJSON)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected status 429, got %d", w.Code)
+	}
+
+	var body struct {
+		Error   string         `json:"error"`
+		Details map[string]int `json:"details"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	if body.Error != "quota exceeded" || body.Details["retry_after"] != 30 {
+		t.Errorf("unexpected body %+v", body)
+	}
+
+	err := gor.Errorf(http.StatusBadGateway, "upstream: %w", errMissing)
+	if !errors.Is(err, errMissing) || err.Status() != http.StatusBadGateway {
+		t.Errorf("expected the error to wrap errMissing with status 502, got %v", err)
+	}
+
+	if msg := gor.NewError(http.StatusForbidden, "").Error(); msg != "Forbidden" {
+		t.Errorf("expected the status text, got %q", msg)
+	}
+}
+
+func TestRouterGroupErrorHandlers(t *testing.T) {
+	r := gor.NewRouter()
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		gor.SendString(w, "html not found")
+	})
+
+	api := r.Group("/api")
+	api.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gor.SendJSONError(w, gor.Map{"error": "not found"}, http.StatusNotFound)
+	})
+	api.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error, status int) {
+		gor.SendJSONError(w, gor.Map{"error": err.Error()}, status)
+	}
+
+	// Nested groups inherit the handlers of their parent.
+	v1 := api.Group("/v1")
+	v1.Get("/fail", func(w http.ResponseWriter, req *http.Request) {
+		gor.SendError(w, req, io.ErrUnexpectedEOF, http.StatusBadRequest)
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/missing", http.StatusNotFound, "html not found"},
+		{"/api/missing", http.StatusNotFound, `{"error":"not found"}`},
+		{"/api/v1/missing", http.StatusNotFound, `{"error":"not found"}`},
+		{"/api/v1/fail", http.StatusBadRequest, `{"error":"unexpected EOF"}`},
+		{"/apix", http.StatusNotFound, "html not found"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, w.Code)
+		}
+
+		if got := strings.TrimSpace(w.Body.String()); got != tt.body {
+			t.Errorf("%s: expected %q, got %q", tt.path, tt.body, got)
+		}
+	}
+}
+
+func TestRouterRouteTimeout(t *testing.T) {
+	r := gor.NewRouter()
+
+	r.Get("/slow", func(w http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+		gor.SendString(w, "too late")
+	}, gor.WithRouteTimeout(10*time.Millisecond))
+
+	r.Get("/fast", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Fast", "true")
+		w.WriteHeader(http.StatusCreated)
+		gor.SendString(w, "fast")
+	}, gor.WithRouteTimeout(time.Second))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/slow", nil)
+	req.Header.Set("Accept", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+
+	if !strings.Contains(w.Body.String(), gor.ErrTimeout.Error()) {
+		t.Errorf("expected timeout error in body, got %q", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+
+	if w.Code != http.StatusCreated || w.Body.String() != "fast" || w.Header().Get("X-Fast") != "true" {
+		t.Errorf("expected buffered response to be sent, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestRouterRouteTimeoutOrphanedHandler(t *testing.T) {
+	r := gor.NewRouter()
+
+	type result struct {
+		id  any
+		err error
+	}
+	results := make(chan result, 1)
+	release := make(chan struct{})
+
+	r.Get("/slow/{id}", func(w http.ResponseWriter, req *http.Request) {
+		gor.SetContextValue(req, "id", req.PathValue("id"))
+		<-req.Context().Done()
+		<-release
+
+		// The handler outlived the request, while other requests were served.
+		_, err := w.Write([]byte("too late"))
+		results <- result{req.Context().Value("id"), err}
+	}, gor.WithRouteTimeout(10*time.Millisecond))
+
+	r.Get("/fast/{id}", func(w http.ResponseWriter, req *http.Request) {
+		gor.SetContextValue(req, "id", req.PathValue("id"))
+		gor.SendString(w, req.PathValue("id"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/slow/1", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503, got %d", w.Code)
+	}
+
+	for i := 2; i < 10; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", fmt.Sprintf("/fast/%d", i), nil))
+	}
+	close(release)
+
+	res := <-results
+	if res.id != "1" {
+		t.Errorf("expected the locals of the timed out request, got %v", res.id)
+	}
+
+	if !errors.Is(res.err, http.ErrHandlerTimeout) {
+		t.Errorf("expected http.ErrHandlerTimeout, got %v", res.err)
+	}
+}
+
+// test nested groups
+func TestRouterNestedGroup(t *testing.T) {
+	r := gor.NewRouter()
 	admin := r.Group("/admin")
 	users := admin.Group("/users")
 
@@ -717,6 +1490,306 @@ func TestRouterRedirectRoute(t *testing.T) {
 		t.Errorf("expected status 302, got %d", w.Code)
 	}
 
+	if loc := w.Header().Get("Location"); loc != "/redirect_route2" {
+		t.Errorf("expected Location /redirect_route2, got %q", loc)
+	}
+}
+
+func TestRouterRedirectNamedRoute(t *testing.T) {
+	r := gor.NewRouter()
+	r.Get("/users/{id}", func(w http.ResponseWriter, req *http.Request) {}).Name("user.show")
+
+	r.Post("/users", func(w http.ResponseWriter, req *http.Request) {
+		r.RedirectRoute(w, req, "user.show", gor.Map{"id": 5})
+	})
+
+	r.Get("/me", func(w http.ResponseWriter, req *http.Request) {
+		r.RedirectRoute(w, req, "user.show", "id", 1, http.StatusTemporaryRedirect)
+	})
+
+	tests := []struct {
+		method   string
+		path     string
+		status   int
+		location string
+	}{
+		{"POST", "/users", http.StatusSeeOther, "/users/5"},
+		{"GET", "/me", http.StatusTemporaryRedirect, "/users/1"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, w.Code)
+		}
+
+		if loc := w.Header().Get("Location"); loc != tt.location {
+			t.Errorf("%s: expected Location %q, got %q", tt.path, tt.location, loc)
+		}
+	}
+}
+
+// test mounting http.Handler values with Handle
+func TestRouterHandle(t *testing.T) {
+	r := gor.NewRouter()
+	r.Handle(http.MethodGet, "/metrics", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gor.SendString(w, "metrics")
+	}))
+	r.Handle("", "/any", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gor.SendString(w, req.Method)
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{"GET", "/metrics", http.StatusOK, "metrics"},
+		{"POST", "/metrics", http.StatusMethodNotAllowed, ""},
+		{"GET", "/any", http.StatusOK, "GET"},
+		{"DELETE", "/any", http.StatusOK, "DELETE"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.status, w.Code)
+		}
+
+		if tt.body != "" && w.Body.String() != tt.body {
+			t.Errorf("%s %s: expected body %q, got %q", tt.method, tt.path, tt.body, w.Body.String())
+		}
+	}
+}
+
+func showUser(w http.ResponseWriter, req *http.Request)   {}
+func updateUser(w http.ResponseWriter, req *http.Request) {}
+
+// test that conflicting registrations panic naming both handlers
+func TestRouterRouteConflict(t *testing.T) {
+	tests := []struct {
+		first, second string
+	}{
+		{"/users/{id}", "/users/{id}"},
+		{"/users/{id}", "/users/{name}"},
+		{"/files/{path...}", "/files/{rest...}"},
+	}
+
+	for _, tt := range tests {
+		func() {
+			r := gor.NewRouter()
+			r.Get(tt.first, showUser)
+
+			defer func() {
+				msg, _ := recover().(string)
+				if !strings.Contains(msg, "showUser") || !strings.Contains(msg, "updateUser") {
+					t.Errorf("%s and %s: expected panic naming both handlers, got %q", tt.first, tt.second, msg)
+				}
+				if !strings.Contains(msg, "gor_test.go") {
+					t.Errorf("%s and %s: expected panic with the call site, got %q", tt.first, tt.second, msg)
+				}
+			}()
+			r.Get(tt.second, updateUser)
+		}()
+	}
+
+	// Different methods and shapes do not conflict
+	r := gor.NewRouter()
+	r.Get("/users/{id}", showUser)
+	r.Post("/users/{id}", updateUser)
+	r.Get("/users/{id}/edit", updateUser)
+}
+
+// test request and response lifecycle hooks
+func TestRouterLifecycleHooks(t *testing.T) {
+	r := gor.NewRouter()
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		gor.SendString(w, "home")
+	})
+
+	var events []string
+	r.OnRequest(func(req *http.Request) {
+		events = append(events, "request "+req.URL.Path)
+	})
+	r.OnResponse(func(w *gor.ResponseWriter, req *http.Request) {
+		events = append(events, fmt.Sprintf("response %s %d %d", req.URL.Path, w.Status(), w.Size()))
+	})
+
+	for _, path := range []string{"/", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %v", events)
+	}
+
+	if events[0] != "request /" || events[1] != "response / 200 4" {
+		t.Errorf("unexpected events for /: %v", events[:2])
+	}
+
+	if events[2] != "request /missing" || !strings.HasPrefix(events[3], "response /missing 404") {
+		t.Errorf("unexpected events for /missing: %v", events[2:])
+	}
+}
+
+// test route lookup by pattern and by request path
+func TestRouterLookup(t *testing.T) {
+	r := gor.NewRouter()
+	r.Get("/users/{id}", func(w http.ResponseWriter, req *http.Request) {
+		gor.SendString(w, "user")
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		found  bool
+	}{
+		{"GET", "/users/{id}", true},
+		{"GET", "/users/10", true},
+		{"POST", "/users/10", false},
+		{"GET", "/notfound", false},
+	}
+
+	for _, tt := range tests {
+		h, ok := r.Lookup(tt.method, tt.path)
+		if ok != tt.found {
+			t.Errorf("Lookup(%s, %s): expected found=%v, got %v", tt.method, tt.path, tt.found, ok)
+		}
+
+		if ok && h == nil {
+			t.Errorf("Lookup(%s, %s): expected a handler", tt.method, tt.path)
+		}
+	}
+}
+
+func TestResponseWriterBytesWritten(t *testing.T) {
+	r := gor.NewRouter()
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		rw := w.(*gor.ResponseWriter)
+		if rw.BytesWritten() != 0 || rw.Written() {
+			t.Error("expected nothing to be written yet")
+		}
+
+		w.Write([]byte("hello "))
+		if !rw.Written() {
+			t.Error("expected Written() to be true after Write")
+		}
+
+		io.Copy(w, strings.NewReader("world"))
+		if rw.BytesWritten() != 11 || rw.Size() != 11 {
+			t.Errorf("expected 11 bytes written, got %d", rw.BytesWritten())
+		}
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+// deadlineRecorder records the deadlines set by http.ResponseController.
+type deadlineRecorder struct {
+	*httptest.ResponseRecorder
+	writeDeadline time.Time
+	fullDuplex    bool
+}
+
+func (d *deadlineRecorder) SetWriteDeadline(deadline time.Time) error {
+	d.writeDeadline = deadline
+	return nil
+}
+
+func (d *deadlineRecorder) EnableFullDuplex() error {
+	d.fullDuplex = true
+	return nil
+}
+
+func TestResponseWriterResponseController(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+
+	r := gor.NewRouter()
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(deadline); err != nil {
+			t.Errorf("SetWriteDeadline: %v", err)
+		}
+
+		if err := rc.EnableFullDuplex(); err != nil {
+			t.Errorf("EnableFullDuplex: %v", err)
+		}
+	})
+
+	w := &deadlineRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !w.writeDeadline.Equal(deadline) || !w.fullDuplex {
+		t.Errorf("expected the controller to reach the underlying writer, got %+v", w)
+	}
+}
+
+func TestResponseWriterInterfaces(t *testing.T) {
+	r := gor.NewRouter()
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		pusher, ok := w.(http.Pusher)
+		if !ok {
+			t.Fatal("expected gor.ResponseWriter to implement http.Pusher")
+		}
+
+		if err := pusher.Push("/app.js", nil); !errors.Is(err, http.ErrNotSupported) {
+			t.Errorf("expected http.ErrNotSupported, got %v", err)
+		}
+
+		rw := w.(*gor.ResponseWriter)
+		if err := rw.SetReadDeadline(time.Now()); !errors.Is(err, http.ErrNotSupported) {
+			t.Errorf("expected http.ErrNotSupported, got %v", err)
+		}
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	deadline := time.Now().Add(time.Minute)
+	r = gor.NewRouter()
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		if err := w.(*gor.ResponseWriter).SetWriteDeadline(deadline); err != nil {
+			t.Errorf("SetWriteDeadline: %v", err)
+		}
+	})
+
+	w := &deadlineRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !w.writeDeadline.Equal(deadline) {
+		t.Errorf("expected the write deadline to be set, got %v", w.writeDeadline)
+	}
+}
+
+func TestRouterSendErrorAfterWrite(t *testing.T) {
+	r := gor.NewRouter()
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		if w.(*gor.ResponseWriter).Written() {
+			t.Error("expected Written() to be false before writing")
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		if !w.(*gor.ResponseWriter).Written() {
+			t.Error("expected Written() to be true after WriteHeader")
+		}
+
+		gor.SendError(w, req, io.ErrUnexpectedEOF, http.StatusBadRequest)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	if w.Body.Len() != 0 {
+		t.Errorf("expected empty body, got %q", w.Body.String())
+	}
 }
 
 /*
@@ -878,7 +1951,42 @@ func BenchmarkRouterFullCycle(b *testing.B) {
 	}
 }
 
-func TestRouterExecuteTemplate(t *testing.T) {
+// benchmark allocations per request on the ServeHTTP hot path.
+func BenchmarkRouterAllocs(b *testing.B) {
+	r := gor.NewRouter()
+	r.Get("/benchmark-allocs", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/benchmark-allocs", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.ServeHTTP(w, req)
+	}
+}
+
+// benchmark allocations per request with concurrent requests.
+func BenchmarkRouterAllocsParallel(b *testing.B) {
+	r := gor.NewRouter()
+	r.Get("/benchmark-allocs", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/benchmark-allocs", nil)
+		for pb.Next() {
+			r.ServeHTTP(w, req)
+		}
+	})
+}
+
+func TestRouterExecuteTemplate(t *testing.T) {
 	templ, err := gor.ParseTemplatesRecursive("../cmd/server/templates",
 		template.FuncMap{"upper": strings.ToUpper}, ".html")
 
@@ -1040,3 +2148,778 @@ func TestRouterFaviconFS(t *testing.T) {
 		t.Errorf("expected hello world, got %s", string(data))
 	}
 }
+
+func TestRouterFileFSRange(t *testing.T) {
+	dirname := t.TempDir()
+	err := os.WriteFile(filepath.Join(dirname, "favicon.ico"), []byte("hello world"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gor.NewRouter()
+	r.FileFS(http.Dir(dirname), "/file", "favicon.ico")
+	r.FaviconFS(http.Dir(dirname), "favicon.ico")
+
+	for _, path := range []string{"/file", "/favicon.ico"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Range", "bytes=0-4")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusPartialContent || w.Body.String() != "hello" {
+			t.Errorf("GET %s with Range: expected 206 hello, got %d %q", path, w.Code, w.Body.String())
+		}
+
+		lastModified := w.Header().Get("Last-Modified")
+		if lastModified == "" {
+			t.Fatalf("GET %s: expected a Last-Modified header", path)
+		}
+
+		req = httptest.NewRequest("GET", path, nil)
+		req.Header.Set("If-Modified-Since", lastModified)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("GET %s with If-Modified-Since: expected 304, got %d %q", path, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/favicon.ico", nil))
+	if ct := w.Header().Get("Content-Type"); ct != "image/x-icon" {
+		t.Errorf("expected content type image/x-icon, got %q", ct)
+	}
+}
+
+func TestRouterErrorTemplates(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"error.html": `error {{ .status }}: {{ .error }}`,
+		"404.html":   `not found: {{ .error }}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templ, err := gor.ParseTemplatesRecursive(dir, template.FuncMap{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gor.NewRouter(
+		gor.WithTemplates(templ),
+		gor.ErrorTemplate("error.html"),
+		gor.ErrorTemplates(map[int]string{http.StatusNotFound: "404.html"}),
+	)
+	r.Get("/fail", func(w http.ResponseWriter, req *http.Request) {
+		gor.HandleError(w, req, errors.New("boom"), http.StatusBadGateway)
+	})
+	r.Get("/user", func(w http.ResponseWriter, req *http.Request) {
+		gor.HandleError(w, req, errors.New("no user"), http.StatusNotFound)
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/fail", http.StatusBadGateway, "error 502: boom"},
+		{"/user", http.StatusNotFound, "not found: no user"},
+		{"/missing", http.StatusNotFound, "not found: Not Found"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("GET %s: expected %d %q, got %d %q", tt.path, tt.status, tt.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestRouterRenderFailure(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.html":    `<main>{{ .Content }}</main>{{ if .broken_layout }}{{ index .items 5 }}{{ end }}`,
+		"partial.html": `<h1>{{ .title }}</h1>{{ index .items 5 }}`,
+		"ok.html":      `<h1>{{ .title }}</h1>`,
+		"error.html":   `error {{ .status }}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templ, err := gor.ParseTemplatesRecursive(dir, template.FuncMap{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRouter := func(opts ...gor.RouterOption) *gor.Router {
+		opts = append(opts, gor.WithTemplates(templ), gor.BaseLayout("base.html"), gor.ContentBlock("Content"))
+		r := gor.NewRouter(opts...)
+		r.Get("/partial", func(w http.ResponseWriter, req *http.Request) {
+			r.Render(w, req, "partial.html", gor.Map{"title": "Hi", "items": []int{1}})
+		})
+		r.Get("/layout", func(w http.ResponseWriter, req *http.Request) {
+			r.Render(w, req, "ok.html", gor.Map{"title": "Hi", "items": []int{1}, "broken_layout": true})
+		})
+		r.Get("/ok", func(w http.ResponseWriter, req *http.Request) {
+			r.Render(w, req, "ok", gor.Map{"title": "Hi"}, http.StatusUnprocessableEntity)
+		})
+		return r
+	}
+
+	tests := []struct {
+		router *gor.Router
+		path   string
+		status int
+		body   string
+	}{
+		{newRouter(gor.ErrorTemplate("error.html")), "/partial", http.StatusInternalServerError, "<main>error 500</main>"},
+		{newRouter(gor.ErrorTemplate("error.html")), "/layout", http.StatusInternalServerError, "<main>error 500</main>"},
+		{newRouter(), "/ok", http.StatusUnprocessableEntity, "<main><h1>Hi</h1></main>"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		tt.router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("GET %s: expected %d %q, got %d %q", tt.path, tt.status, tt.body, w.Code, w.Body.String())
+		}
+	}
+
+	// Without an error template, the error message is sent, without the partial page.
+	w := httptest.NewRecorder()
+	newRouter().ServeHTTP(w, httptest.NewRequest("GET", "/partial", nil))
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "<h1>") {
+		t.Errorf("expected a 500 without the partial page, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestRouterRenderWithOptions(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "form.html"), []byte(`<form>{{ .error }}</form>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	templ, err := gor.ParseTemplatesRecursive(dir, template.FuncMap{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gor.NewRouter(gor.WithTemplates(templ))
+	r.Post("/status", func(w http.ResponseWriter, req *http.Request) {
+		gor.RenderStatus(w, req, http.StatusUnprocessableEntity, "form", gor.Map{"error": "invalid"})
+	})
+	r.Get("/options", func(w http.ResponseWriter, req *http.Request) {
+		r.RenderWithOptions(w, req, "form", gor.Map{"error": "missing"}, gor.RenderOptions{
+			Status:  http.StatusNotFound,
+			Headers: http.Header{"Cache-Control": {"no-store"}},
+		})
+	})
+	r.Get("/missing", func(w http.ResponseWriter, req *http.Request) {
+		r.RenderWithOptions(w, req, "missing", nil, gor.RenderOptions{
+			Headers: http.Header{"Cache-Control": {"max-age=3600"}},
+		})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/status", nil))
+	if w.Code != http.StatusUnprocessableEntity || w.Body.String() != "<form>invalid</form>" {
+		t.Errorf("expected 422 with the form, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/options", nil))
+	if w.Code != http.StatusNotFound || w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("expected 404 with Cache-Control no-store, got %d %q", w.Code, w.Header().Get("Cache-Control"))
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != gor.ContentTypeHTML {
+		t.Errorf("expected content type %s, got %s", gor.ContentTypeHTML, ct)
+	}
+
+	// Headers are not sent with the error page.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/missing", nil))
+	if w.Code != http.StatusInternalServerError || w.Header().Get("Cache-Control") != "" {
+		t.Errorf("expected 500 without Cache-Control, got %d %q", w.Code, w.Header().Get("Cache-Control"))
+	}
+}
+
+func TestRouterRenderString(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.html":  `<html>{{ .Content }}</html>`,
+		"email.html": `<p>Hello {{ .name }}</p>`,
+		"fail.html":  `{{ index .items 1 }}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templ, err := gor.ParseTemplatesRecursive(dir, template.FuncMap{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gor.NewRouter(gor.WithTemplates(templ), gor.BaseLayout("base.html"), gor.ContentBlock("Content"))
+
+	page, err := r.RenderString("email", gor.Map{"name": "Ann"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if page != "<html><p>Hello Ann</p></html>" {
+		t.Errorf("unexpected page %q", page)
+	}
+
+	b, err := r.RenderBytes("email.html", gor.Map{"name": "Bob"})
+	if err != nil || string(b) != "<html><p>Hello Bob</p></html>" {
+		t.Errorf("unexpected page %q: %v", b, err)
+	}
+
+	if _, err := r.RenderString("fail", gor.Map{"items": []int{}}); err == nil {
+		t.Error("expected an error for a failing template")
+	}
+}
+
+func TestRouterNestedLayouts(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.html":  `<head>{{ .Head }}</head><body>{{ .Content }}{{ .Scripts }}</body>`,
+		"admin.html": `<nav>{{ .Sidebar }}</nav><main>{{ .Content }}</main>`,
+		"users.html": `<ul></ul>{{ define "users.html:Scripts" }}<script></script>{{ end }}{{ define "users.html:Sidebar" }}users{{ end }}`,
+		"print.html": `<article>{{ .Content }}</article>`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templ, err := gor.ParseTemplatesRecursive(dir, template.FuncMap{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gor.NewRouter(
+		gor.WithTemplates(templ),
+		gor.BaseLayout("base.html"),
+		gor.ContentBlock("Content"),
+		gor.LayoutBlocks("Head", "Scripts"),
+	)
+
+	r.Get("/users", func(w http.ResponseWriter, req *http.Request) {
+		r.Render(w, req, "users", nil)
+	})
+
+	admin := r.Group("/admin")
+	admin.Layout = "admin.html"
+	admin.Blocks = []string{"Sidebar"}
+	admin.Get("/users", func(w http.ResponseWriter, req *http.Request) {
+		r.Render(w, req, "users", nil)
+	})
+	admin.Get("/print", func(w http.ResponseWriter, req *http.Request) {
+		r.RenderWithOptions(w, req, "users", nil, gor.RenderOptions{Layouts: []string{"print.html"}})
+	})
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/users", "<head></head><body><ul></ul><script></script></body>"},
+		{"/admin/users", "<head></head><body><nav>users</nav><main><ul></ul></main><script></script></body>"},
+		{"/admin/print", "<article><ul></ul></article>"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != tt.body {
+			t.Errorf("GET %s: expected %q, got %d %q", tt.path, tt.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestRouterRenderBlock(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.html": `<body>{{ .Content }}</body>`,
+		"list.html": `<table>{{ range .users }}{{ template "list.html:row" . }}{{ end }}</table>` +
+			`{{ define "list.html:row" }}<tr><td>{{ .Name }}</td></tr>{{ end }}{{ define "count" }}{{ len .users }}{{ end }}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templ, err := gor.ParseTemplatesRecursive(dir, template.FuncMap{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gor.NewRouter(gor.WithTemplates(templ), gor.BaseLayout("base.html"), gor.ContentBlock("Content"))
+	r.Get("/row", func(w http.ResponseWriter, req *http.Request) {
+		gor.RenderBlock(w, req, "list", "row", gor.Map{"Name": "Ann"})
+	})
+	r.Get("/count", func(w http.ResponseWriter, req *http.Request) {
+		r.RenderBlock(w, req, "list.html", "count", gor.Map{"users": []int{1, 2}}, http.StatusCreated)
+	})
+	r.Get("/missing", func(w http.ResponseWriter, req *http.Request) {
+		r.RenderBlock(w, req, "list.html", "missing", nil)
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/row", http.StatusOK, "<tr><td>Ann</td></tr>"},
+		{"/count", http.StatusCreated, "2"},
+		{"/missing", http.StatusInternalServerError, `gor: block &#34;missing&#34; of template &#34;list.html&#34; is not defined`},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("GET %s: expected %d %q, got %d %q", tt.path, tt.status, tt.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestRouterVerifyTemplates(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.html":      `<main>{{ .Content }}</main>`,
+		"nocontent.html": `<main></main>`,
+		"partial.html":   `<div>{{ template "header.html" . }}</div>`,
+		"header.html":    `<header>{{ .Content }}</header>`,
+		"home.html":      `<h1>{{ .user.Name }}</h1>`,
+		"broken.html":    `<h1>{{ template "missing" . }}</h1>`,
+		"error.html":     `{{ .error }}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templ, err := gor.ParseTemplatesRecursive(dir, template.FuncMap{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gor.NewRouter(gor.WithTemplates(templ), gor.BaseLayout("base.html"), gor.ContentBlock("Content"), gor.ErrorTemplate("error"))
+
+	// Errors of the empty data are ignored.
+	if err := r.VerifyTemplates("home"); err != nil {
+		t.Errorf("expected valid templates, got %v", err)
+	}
+
+	err = r.VerifyTemplates("broken", "missing")
+	if err == nil || !strings.Contains(err.Error(), `"broken.html"`) || !strings.Contains(err.Error(), `"missing.html" is not defined`) {
+		t.Errorf("expected errors for broken.html and missing.html, got %v", err)
+	}
+
+	// The content block may be used by a template called from the layout.
+	admin := r.Group("/admin")
+	admin.Layout = "partial.html"
+	if err := r.VerifyTemplates(); err != nil {
+		t.Errorf("expected valid layouts, got %v", err)
+	}
+
+	admin.Layout = "nocontent.html"
+	if err := r.VerifyTemplates(); err == nil || !strings.Contains(err.Error(), "does not use the content block") {
+		t.Errorf("expected an error for a layout without the content block, got %v", err)
+	}
+
+	for _, opts := range [][]gor.RouterOption{
+		{gor.BaseLayout("missing.html")},
+		{gor.BaseLayout("nocontent.html")},
+		{gor.ErrorTemplates(map[int]string{404: "404.html"})},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected NewRouter to panic")
+				}
+			}()
+			gor.NewRouter(append(opts, gor.WithTemplates(templ))...)
+		}()
+	}
+}
+
+func TestTemplateForm(t *testing.T) {
+	type Address struct {
+		City string `form:"city" validate:"required"`
+	}
+
+	type Signup struct {
+		Email    string    `form:"email" validate:"required,email" label:"Email address"`
+		Age      int       `form:"age" validate:"min=18"`
+		Plan     string    `form:"plan" validate:"oneof=free pro"`
+		Bio      string    `form:"bio" input:"textarea"`
+		Birthday time.Time `form:"birthday"`
+		Terms    bool      `form:"terms"`
+		Token    string    `form:"token" input:"hidden"`
+		Address  Address   `form:"address"`
+		Internal string    `form:"-"`
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "signup.html"), []byte(`<form>{{ form . }}</form>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	templ, err := gor.ParseTemplatesRecursive(dir, template.FuncMap{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gor.NewRouter(gor.WithTemplates(templ))
+	r.Post("/signup", func(w http.ResponseWriter, req *http.Request) {
+		var signup Signup
+		err := gor.BodyParser(req, &signup)
+		r.Render(w, req, "signup", gor.Map{"form": &signup, "errors": err}, http.StatusUnprocessableEntity)
+	})
+
+	form := url.Values{
+		"email":    {"not an email"},
+		"age":      {"12"},
+		"plan":     {"pro"},
+		"bio":      {"<b>hi</b>"},
+		"birthday": {"2000-01-02"},
+		"terms":    {"true"},
+		"token":    {"abc"},
+	}
+
+	req := httptest.NewRequest("POST", "/signup", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`<label for="email" class="block text-base font-medium text-gray-800 mb-1">Email address</label>`,
+		`type="email"`,
+		`value="not an email"`,
+		`must be a valid email address`,
+		`type="number"`,
+		`min="18"`,
+		`must be at least 18`,
+		`<option value="pro" selected>pro</option>`,
+		`&lt;b&gt;hi&lt;/b&gt;</textarea>`,
+		`type="date"`,
+		`value="2000-01-02"`,
+		`name="terms" value="true"`,
+		`checked`,
+		`<input type="hidden" name="token" value="abc">`,
+		`name="address.city"`,
+		`<label for="address_city" class="block text-base font-medium text-gray-800 mb-1">City</label>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected the form to contain %q", want)
+		}
+	}
+
+	if strings.Contains(body, "internal") || strings.Contains(body, "Internal") {
+		t.Error("expected fields tagged form:\"-\" to be skipped")
+	}
+
+	if strings.Count(body, "is required") != 1 {
+		t.Errorf("expected the error of the nested field, got %s", body)
+	}
+}
+
+func TestComponentTheme(t *testing.T) {
+	dir := t.TempDir()
+	page := `{{ define "help" }}<small>{{ .hint }}</small>{{ end }}` +
+		`{{ template "input" Props "name" "email" "label" "Email" "children" (slot "help" .) }}` +
+		`{{ template "input" Props "name" "code" "label" "Code" "class" "code-input" "wrapper_class" "row" }}` +
+		`{{ template "button" Props "variant" "danger" "children" (slot "help" .) }}`
+	if err := os.WriteFile(filepath.Join(dir, "page.html"), []byte(page), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	templ, err := gor.ParseTemplatesRecursive(dir, template.FuncMap{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gor.RegisterComponentTheme(map[string]string{
+		"field":         "mb-3",
+		"label":         "form-label",
+		"input":         "form-control",
+		"button.danger": "btn btn-danger",
+	})
+	defer gor.RegisterComponentTheme(map[string]string{
+		"field":         "mb-4",
+		"label":         "block text-base font-medium text-gray-800 mb-1",
+		"input":         "py-2 px-3 mt-1 block w-full rounded-md border border-gray-300 shadow-sm focus:border-indigo-300 focus:ring focus:ring-indigo-200 focus:ring-opacity-50",
+		"button.danger": "whitespace-nowrap inline-flex items-center px-4 py-2 border border-transparent text-base font-medium rounded-md shadow-sm text-white bg-red-600 hover:bg-red-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-red-500",
+	})
+
+	r := gor.NewRouter(gor.WithTemplates(templ))
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		r.Render(w, req, "page", gor.Map{"hint": "We never share it"})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`<div class="mb-3">`,
+		`<label for="email" class="form-label">Email</label>`,
+		`class="form-control"`,
+		`<small>We never share it</small>`,
+		`<div class="row">`,
+		`class="code-input"`,
+		`class="btn btn-danger `,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected the page to contain %q, got %s", want, body)
+		}
+	}
+
+	if strings.Contains(body, "tailwind") || strings.Contains(body, "rounded-md") {
+		t.Errorf("expected no default classes, got %s", body)
+	}
+}
+
+func TestRouterFlash(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "page.html"), []byte(`{{ template "flashes" . }}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templ, err := gor.ParseTemplatesRecursive(dir, template.FuncMap{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gor.NewRouter(gor.WithTemplates(templ), gor.WithCookieKeys([]byte("secret")))
+	r.Post("/save", func(w http.ResponseWriter, req *http.Request) {
+		gor.Flash(w, req, "success", "Saved!")
+		gor.Flash(w, req, "error", "<b>Oops</b>")
+		gor.Redirect(w, req, "/page")
+	})
+	r.Get("/page", func(w http.ResponseWriter, req *http.Request) {
+		gor.Render(w, req, "page.html", gor.Map{})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/save", nil))
+
+	cookies := w.Result().Cookies()
+	if len(cookies) == 0 {
+		t.Fatal("expected a flash cookie")
+	}
+
+	req := httptest.NewRequest("GET", "/page", nil)
+	req.AddCookie(cookies[len(cookies)-1])
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, "Saved!") || !strings.Contains(body, "&lt;b&gt;Oops&lt;/b&gt;") {
+		t.Errorf("expected both flash messages, got %q", body)
+	}
+
+	cleared := w.Result().Cookies()
+	if len(cleared) != 1 || cleared[0].MaxAge >= 0 {
+		t.Errorf("expected the flash cookie to be cleared, got %v", cleared)
+	}
+
+	// A forged cookie is ignored.
+	req = httptest.NewRequest("GET", "/page", nil)
+	req.AddCookie(&http.Cookie{Name: gor.FlashCookieName, Value: "W3siYyI6ImVycm9yIiwibSI6ImZvcmdlZCJ9XQ"})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if strings.Contains(w.Body.String(), "forged") {
+		t.Error("expected a forged flash cookie to be ignored")
+	}
+}
+
+func TestRouterOldInput(t *testing.T) {
+	dir := t.TempDir()
+	page := `<p>{{ .old.name }}|{{ .errors.Name }}|{{ .errors.Age }}</p>{{ form . }}`
+	if err := os.WriteFile(filepath.Join(dir, "signup.html"), []byte(page), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	templ, err := gor.ParseTemplatesRecursive(dir, template.FuncMap{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type Signup struct {
+		Name     string `form:"name" validate:"min=3"`
+		Age      int    `form:"age" validate:"min=18"`
+		Password string `form:"password" input:"password"`
+		Terms    bool   `form:"terms"`
+	}
+
+	for _, keys := range [][]byte{nil, []byte("secret")} {
+		var options []gor.RouterOption
+		if keys != nil {
+			options = append(options, gor.WithCookieKeys(keys))
+		}
+
+		r := gor.NewRouter(append(options, gor.WithTemplates(templ))...)
+		r.Post("/signup", func(w http.ResponseWriter, req *http.Request) {
+			var signup Signup
+			err := gor.BodyParser(req, &signup)
+			if err == nil {
+				t.Error("expected validation errors")
+			}
+
+			if err := gor.WithOldInput(w, req, err); err != nil {
+				t.Error(err)
+			}
+			gor.Redirect(w, req, "/signup", http.StatusSeeOther)
+		})
+		r.Get("/signup", func(w http.ResponseWriter, req *http.Request) {
+			r.Render(w, req, "signup", gor.Map{"form": Signup{}})
+		})
+
+		body := strings.NewReader("name=<b>&age=12&password=hunter2&terms=on")
+		req := httptest.NewRequest("POST", "/signup", body)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		cookies := w.Result().Cookies()
+		if w.Code != http.StatusSeeOther || len(cookies) != 1 {
+			t.Fatalf("expected a redirect with the old input cookie, got %d %v", w.Code, cookies)
+		}
+
+		req = httptest.NewRequest("GET", "/signup", nil)
+		req.AddCookie(cookies[0])
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		page := w.Body.String()
+		if !strings.HasPrefix(page, "<p>&lt;b&gt;|") || !strings.Contains(page, "|must be at least 18</p>") {
+			t.Errorf("expected the old input and errors, got %q", page)
+		}
+
+		for _, want := range []string{`value="&lt;b&gt;"`, `value="12"`, `checked`} {
+			if !strings.Contains(page, want) {
+				t.Errorf("expected %s in the form, got %q", want, page)
+			}
+		}
+
+		if strings.Contains(page, "hunter2") {
+			t.Errorf("expected the password not to be kept, got %q", page)
+		}
+
+		if cleared := w.Result().Cookies(); len(cleared) != 1 || cleared[0].MaxAge >= 0 {
+			t.Errorf("expected the old input cookie to be cleared, got %v", cleared)
+		}
+
+		// Without old input, "old" is empty.
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/signup", nil))
+		if !strings.HasPrefix(w.Body.String(), "<p>||</p>") || len(w.Result().Cookies()) != 0 {
+			t.Errorf("expected no old input, got %q", w.Body.String())
+		}
+	}
+}
+
+func TestRouterPagination(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "list.html"), []byte(`{{ template "pagination" . }}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	templ, err := gor.ParseTemplatesRecursive(dir, template.FuncMap{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gor.NewRouter(gor.WithTemplates(templ))
+	r.Get("/users", func(w http.ResponseWriter, req *http.Request) {
+		page, perPage := gor.ParsePagination(req, 10, 100)
+		r.Render(w, req, "list", gor.Map{"pagination": gor.NewPaginator(req, int64(gor.QueryInt(req, "total")), page, perPage)})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users?total=95&page=1&q=a+b", nil))
+	body := w.Body.String()
+
+	for _, want := range []string{
+		`aria-disabled="true">&laquo; Previous</span>`,
+		`aria-current="page">1</span>`,
+		`<a href="/users?page=2&amp;per_page=10&amp;q=a&#43;b&amp;total=95"`,
+		`&hellip;`,
+		`>10</a>`,
+		`rel="next"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in the pager, got %s", want, body)
+		}
+	}
+
+	// A single page renders nothing.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users?total=5", nil))
+	if strings.TrimSpace(w.Body.String()) != "" {
+		t.Errorf("expected no pager, got %q", w.Body.String())
+	}
+}
+
+func TestRouterSignURL(t *testing.T) {
+	r := gor.NewRouter(gor.WithCookieKeys([]byte("new key"), []byte("old key")))
+	r.Get("/files/{id}", func(w http.ResponseWriter, req *http.Request) {
+		gor.SendString(w, req.PathValue("id"))
+	}, gor.VerifySignedURL).Name("file")
+
+	link, err := r.SignURL("file", gor.Map{"id": 7, "name": "report.pdf"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldLink, err := gor.SignPath("/files/7", gor.CookieKeys{[]byte("old key")}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired, err := gor.SignPath("/files/7", gor.CookieKeys{[]byte("new key")}, time.Nanosecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		url    string
+		status int
+	}{
+		{"signed", link, http.StatusOK},
+		{"rotated key", oldLink, http.StatusOK},
+		{"tampered path", strings.Replace(link, "/files/7", "/files/8", 1), http.StatusForbidden},
+		{"tampered query", strings.Replace(link, "report.pdf", "secret.pdf", 1), http.StatusForbidden},
+		{"unsigned", "/files/7", http.StatusForbidden},
+		{"expired", expired, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, w.Code)
+		}
+	}
+}
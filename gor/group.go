@@ -1,19 +1,42 @@
 package gor
 
-import "net/http"
+import (
+	"io/fs"
+	"net/http"
+	"slices"
+	"strings"
+)
 
 // Group is a collection of routes with a common prefix.
 type Group struct {
 	prefix      string       // Group prefix
 	middlewares []Middleware // Middlewares specific to this group
 	router      *Router      // The router
+
+	// Handler for 404 not found errors for paths under the group prefix.
+	// If nil, the handler of the closest parent group or the Router is used.
+	NotFoundHandler http.Handler
+
+	// ErrorHandler handles errors for requests under the group prefix.
+	// e.g an /api group can return JSON errors while the Router renders error templates.
+	// If nil, the handler of the closest parent group or the Router is used.
+	ErrorHandler ErrorHandlerFunc
+
+	// Layout is the section layout of the pages rendered under the group prefix.
+	// It is rendered with the page as its content block, and is itself the
+	// content of the layout of the parent group or the base layout.
+	Layout string
+
+	// Blocks are the blocks rendered from the pages under the group prefix,
+	// in addition to the LayoutBlocks of the Router. See LayoutBlocks.
+	Blocks []string
 }
 
 // Group creates a new group with the given prefix and options.
 func (r *Router) Group(prefix string, middlewares ...Middleware) *Group {
 	group := &Group{
 		prefix:      prefix,
-		middlewares: middlewares,
+		middlewares: slices.Clone(middlewares),
 		router:      r,
 	}
 
@@ -21,37 +44,130 @@ func (r *Router) Group(prefix string, middlewares ...Middleware) *Group {
 	return group
 }
 
+// groupFor returns the group with the longest prefix containing path
+// for which has returns true, or nil if there is none.
+func (r *Router) groupFor(path string, has func(g *Group) bool) *Group {
+	var found *Group
+	for prefix, g := range r.groups {
+		if !has(g) || (found != nil && len(prefix) <= len(found.prefix)) {
+			continue
+		}
+
+		prefix = strings.TrimSuffix(prefix, "/")
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			found = g
+		}
+	}
+	return found
+}
+
+// layoutsFor returns the layouts of the pages rendered for path, from the
+// layout of the innermost group to the base layout, and the blocks of the groups.
+func (r *Router) layoutsFor(path string) (layouts, blocks []string) {
+	var groups []*Group
+	for prefix, g := range r.groups {
+		if g.Layout == "" && len(g.Blocks) == 0 {
+			continue
+		}
+
+		prefix = strings.TrimSuffix(prefix, "/")
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			groups = append(groups, g)
+		}
+	}
+
+	slices.SortFunc(groups, func(a, b *Group) int {
+		return len(b.prefix) - len(a.prefix)
+	})
+
+	for _, g := range groups {
+		if g.Layout != "" {
+			layouts = append(layouts, g.Layout)
+		}
+		blocks = append(blocks, g.Blocks...)
+	}
+
+	if r.baseLayout != "" {
+		layouts = append(layouts, r.baseLayout)
+	}
+	return layouts, blocks
+}
+
 // Use adds middlewares to the group.
 func (g *Group) Use(middlewares ...Middleware) {
 	g.middlewares = append(g.middlewares, middlewares...)
 }
 
+// Handle registers handler for requests with method to path within the group.
+// See Router.Handle.
+func (g *Group) Handle(method, path string, handler http.Handler, middlewares ...Middleware) *Route {
+	return g.router.registerRoute(method, g.prefix+path, handler, append(g.middlewares, middlewares...))
+}
+
+// HandleFunc registers handler for requests with method to path within the group.
+// See Router.Handle.
+func (g *Group) HandleFunc(method, path string, handler http.HandlerFunc, middlewares ...Middleware) *Route {
+	return g.router.registerRoute(method, g.prefix+path, handler, append(g.middlewares, middlewares...))
+}
+
+// OAuth registers the login flow of h under prefix within the group. See Router.OAuth.
+func (g *Group) OAuth(prefix string, h OAuthHandler, middlewares ...Middleware) {
+	g.router.OAuth(g.prefix+prefix, h, append(g.middlewares, middlewares...)...)
+}
+
 // GET request.
-func (g *Group) Get(path string, handler http.HandlerFunc, middlewares ...Middleware) {
-	g.router.registerRoute(http.MethodGet, g.prefix+path, handler, append(g.middlewares, middlewares...))
+func (g *Group) Get(path string, handler http.HandlerFunc, middlewares ...Middleware) *Route {
+	return g.router.registerRoute(http.MethodGet, g.prefix+path, handler, append(g.middlewares, middlewares...))
 }
 
 // POST request.
-func (g *Group) Post(path string, handler http.HandlerFunc, middlewares ...Middleware) {
-	g.router.registerRoute(http.MethodPost, g.prefix+path, handler, append(g.middlewares, middlewares...))
+func (g *Group) Post(path string, handler http.HandlerFunc, middlewares ...Middleware) *Route {
+	return g.router.registerRoute(http.MethodPost, g.prefix+path, handler, append(g.middlewares, middlewares...))
 }
 
 // PUT request.
-func (g *Group) Put(path string, handler http.HandlerFunc, middlewares ...Middleware) {
-	g.router.registerRoute(http.MethodPut, g.prefix+path, handler, append(g.middlewares, middlewares...))
+func (g *Group) Put(path string, handler http.HandlerFunc, middlewares ...Middleware) *Route {
+	return g.router.registerRoute(http.MethodPut, g.prefix+path, handler, append(g.middlewares, middlewares...))
 }
 
 // PATCH request.
-func (g *Group) Patch(path string, handler http.HandlerFunc, middlewares ...Middleware) {
-	g.router.registerRoute(http.MethodPatch, g.prefix+path, handler, append(g.middlewares, middlewares...))
+func (g *Group) Patch(path string, handler http.HandlerFunc, middlewares ...Middleware) *Route {
+	return g.router.registerRoute(http.MethodPatch, g.prefix+path, handler, append(g.middlewares, middlewares...))
 }
 
 // DELETE request.
-func (g *Group) Delete(path string, handler http.HandlerFunc, middlewares ...Middleware) {
-	g.router.registerRoute(http.MethodDelete, g.prefix+path, handler, append(g.middlewares, middlewares...))
+func (g *Group) Delete(path string, handler http.HandlerFunc, middlewares ...Middleware) *Route {
+	return g.router.registerRoute(http.MethodDelete, g.prefix+path, handler, append(g.middlewares, middlewares...))
 }
 
 // Creates a nested group with the given prefix and middleware.
 func (g *Group) Group(prefix string, middlewares ...Middleware) *Group {
 	return g.router.Group(g.prefix+prefix, append(g.middlewares, middlewares...)...)
 }
+
+// Serve static assests at prefix in the directory dir with the group middleware.
+// The group prefix and prefix are stripped from the URL path. See Router.Static.
+func (g *Group) Static(prefix, dir string, maxAge ...int) {
+	prefix = withTrailingSlash(g.prefix + prefix)
+	g.router.mount(prefix, g.router.staticHandler(prefix, dir, maxAge...), g.middlewares...)
+}
+
+// Like Static but for http.FileSystem. See Router.StaticFS.
+// As with Router.StaticFS, the prefix is not stripped from the URL path.
+func (g *Group) StaticFS(prefix string, fs http.FileSystem, maxAge ...int) {
+	g.router.mount(withTrailingSlash(g.prefix+prefix), g.router.staticFSHandler(fs, maxAge...), g.middlewares...)
+}
+
+// Serve the file at path in fs for GET requests to prefix. See Router.FileFS.
+func (g *Group) FileFS(fs http.FileSystem, prefix, path string) *Route {
+	return g.Get(prefix, fileFSHandler(fs, path, nil))
+}
+
+// Serve a single page application at path within the group. See Router.SPAHandler.
+// The group prefix and path are stripped before the request reaches the SPA handler,
+// so the application is served as if it was mounted at "/".
+func (g *Group) SPAHandler(frontendFS fs.FS, path string, buildPath string, options ...SPAOptions) {
+	mountPath := withTrailingSlash(g.prefix + path)
+	handler := http.StripPrefix(strings.TrimSuffix(mountPath, "/"), spaHandler(frontendFS, buildPath, options...))
+	g.router.mount(mountPath, handler, g.middlewares...)
+}
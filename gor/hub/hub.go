@@ -0,0 +1,267 @@
+// Package hub implements a broadcast hub that fans out messages published
+// to a topic to all of its subscribers, including Server-Sent Events and
+// WebSocket clients.
+//
+//	h := hub.New()
+//	r.Get("/events/{room}", func(w http.ResponseWriter, req *http.Request) {
+//		h.ServeSSE(w, req, req.PathValue("room"))
+//	})
+//	r.Get("/ws/{room}", func(w http.ResponseWriter, req *http.Request) {
+//		h.ServeWebSocket(w, req, req.PathValue("room"))
+//	})
+//
+//	h.Publish("lobby", []byte("hello"))
+//
+// Each subscriber has a buffered channel. Subscribers that fall behind
+// and fill their buffer are evicted instead of blocking the publisher.
+package hub
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/abiiranathan/gor/gor"
+	"golang.org/x/net/websocket"
+)
+
+// DefaultBufferSize is the number of messages buffered per subscriber.
+const DefaultBufferSize = 16
+
+// Hub fans out messages to the subscribers of a topic.
+// It is safe for concurrent use.
+type Hub struct {
+	mu         sync.RWMutex
+	topics     map[string]map[*subscriber]struct{}
+	bufferSize int
+	onEvict    func(topic string)
+	closed     bool
+}
+
+// subscriber is a single subscription to a topic.
+type subscriber struct {
+	ch   chan []byte
+	once sync.Once
+}
+
+// close closes the subscriber channel once.
+func (s *subscriber) close() {
+	s.once.Do(func() { close(s.ch) })
+}
+
+// Option configures a Hub.
+type Option func(*Hub)
+
+// WithBufferSize sets the number of messages buffered per subscriber.
+// A subscriber whose buffer is full when a message is published is evicted.
+func WithBufferSize(size int) Option {
+	return func(h *Hub) {
+		if size > 0 {
+			h.bufferSize = size
+		}
+	}
+}
+
+// OnEvict registers fn to be called when a slow subscriber of topic is evicted.
+func OnEvict(fn func(topic string)) Option {
+	return func(h *Hub) {
+		h.onEvict = fn
+	}
+}
+
+// New creates a new Hub.
+func New(options ...Option) *Hub {
+	h := &Hub{
+		topics:     make(map[string]map[*subscriber]struct{}),
+		bufferSize: DefaultBufferSize,
+	}
+
+	for _, option := range options {
+		option(h)
+	}
+	return h
+}
+
+// Subscribe subscribes to topic. It returns a channel that receives the messages
+// published to topic and a function that cancels the subscription.
+// The channel is closed when the subscription is canceled, when the subscriber
+// is evicted for being too slow or when the hub is closed.
+func (h *Hub) Subscribe(topic string) (<-chan []byte, func()) {
+	s := &subscriber{ch: make(chan []byte, h.bufferSize)}
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		s.close()
+		return s.ch, func() {}
+	}
+
+	subs, ok := h.topics[topic]
+	if !ok {
+		subs = make(map[*subscriber]struct{})
+		h.topics[topic] = subs
+	}
+	subs[s] = struct{}{}
+	h.mu.Unlock()
+
+	return s.ch, func() { h.remove(topic, s) }
+}
+
+// remove removes s from topic and closes its channel.
+// It reports whether s was still subscribed.
+func (h *Hub) remove(topic string, s *subscriber) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subs, ok := h.topics[topic]
+	if !ok {
+		return false
+	}
+
+	if _, ok := subs[s]; !ok {
+		return false
+	}
+
+	delete(subs, s)
+	if len(subs) == 0 {
+		delete(h.topics, topic)
+	}
+	s.close()
+	return true
+}
+
+// Publish sends msg to all subscribers of topic without blocking.
+// Subscribers whose buffer is full are evicted.
+// It returns the number of subscribers that received the message.
+func (h *Hub) Publish(topic string, msg []byte) int {
+	var sent int
+	var slow []*subscriber
+
+	h.mu.RLock()
+	for s := range h.topics[topic] {
+		select {
+		case s.ch <- msg:
+			sent++
+		default:
+			slow = append(slow, s)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, s := range slow {
+		if h.remove(topic, s) && h.onEvict != nil {
+			h.onEvict(topic)
+		}
+	}
+	return sent
+}
+
+// Subscribers returns the number of subscribers of topic.
+func (h *Hub) Subscribers(topic string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.topics[topic])
+}
+
+// Close closes the channels of all subscribers. Subsequent subscriptions
+// receive closed channels and published messages are dropped.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for topic, subs := range h.topics {
+		for s := range subs {
+			s.close()
+		}
+		delete(h.topics, topic)
+	}
+	h.closed = true
+}
+
+// ServeSSE subscribes the client to topic and streams the published messages
+// as Server-Sent Events until the client disconnects or is evicted.
+// Messages containing newlines are sent as multi-line data fields.
+func (h *Hub) ServeSSE(w http.ResponseWriter, req *http.Request, topic string) {
+	ch, unsubscribe := h.Subscribe(topic)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", gor.ContentTypeEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			if err := writeEvent(w, msg); err != nil {
+				return
+			}
+
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeEvent writes msg as a single Server-Sent Event.
+func writeEvent(w http.ResponseWriter, msg []byte) error {
+	var b strings.Builder
+	for _, line := range strings.Split(string(msg), "\n") {
+		b.WriteString("data: ")
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	_, err := w.Write([]byte(b.String()))
+	return err
+}
+
+// ServeWebSocket upgrades the connection to a WebSocket, subscribes it to topic
+// and sends each published message as a text frame until the client disconnects
+// or is evicted. Messages sent by the client are discarded.
+// The Origin header must match the Host, as with websocket.Handler.
+func (h *Hub) ServeWebSocket(w http.ResponseWriter, req *http.Request, topic string) {
+	websocket.Handler(func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		ch, unsubscribe := h.Subscribe(topic)
+		defer unsubscribe()
+
+		// Read until the client closes the connection.
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			var discard []byte
+			for websocket.Message.Receive(ws, &discard) == nil {
+			}
+		}()
+
+		for {
+			select {
+			case <-done:
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+
+				if err := websocket.Message.Send(ws, string(msg)); err != nil {
+					return
+				}
+			}
+		}
+	}).ServeHTTP(w, req)
+}
@@ -0,0 +1,173 @@
+package hub_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/abiiranathan/gor/gor/hub"
+	"golang.org/x/net/websocket"
+)
+
+func TestHubPublish(t *testing.T) {
+	h := hub.New()
+
+	a, cancelA := h.Subscribe("room")
+	b, cancelB := h.Subscribe("room")
+	defer cancelA()
+	defer cancelB()
+
+	if n := h.Publish("room", []byte("hello")); n != 2 {
+		t.Fatalf("expected 2 receivers, got %d", n)
+	}
+
+	for _, ch := range []<-chan []byte{a, b} {
+		if msg := <-ch; string(msg) != "hello" {
+			t.Errorf("expected hello, got %q", msg)
+		}
+	}
+
+	if n := h.Publish("other", []byte("hello")); n != 0 {
+		t.Errorf("expected 0 receivers for other topic, got %d", n)
+	}
+
+	cancelA()
+	if _, ok := <-a; ok {
+		t.Error("expected channel to be closed after cancel")
+	}
+
+	if n := h.Subscribers("room"); n != 1 {
+		t.Errorf("expected 1 subscriber, got %d", n)
+	}
+}
+
+func TestHubEvictSlowSubscriber(t *testing.T) {
+	var evicted string
+	h := hub.New(hub.WithBufferSize(1), hub.OnEvict(func(topic string) {
+		evicted = topic
+	}))
+
+	ch, cancel := h.Subscribe("room")
+	defer cancel()
+
+	h.Publish("room", []byte("1"))
+	h.Publish("room", []byte("2"))
+
+	if evicted != "room" {
+		t.Errorf("expected slow subscriber to be evicted from room")
+	}
+
+	if msg := <-ch; string(msg) != "1" {
+		t.Errorf("expected buffered message 1, got %q", msg)
+	}
+
+	if _, ok := <-ch; ok {
+		t.Error("expected channel to be closed after eviction")
+	}
+
+	if n := h.Subscribers("room"); n != 0 {
+		t.Errorf("expected 0 subscribers, got %d", n)
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	h := hub.New()
+	ch, _ := h.Subscribe("room")
+	h.Close()
+
+	if _, ok := <-ch; ok {
+		t.Error("expected channel to be closed after Close")
+	}
+
+	ch, _ = h.Subscribe("room")
+	if _, ok := <-ch; ok {
+		t.Error("expected closed channel after Close")
+	}
+}
+
+// waitForSubscribers waits until topic has n subscribers.
+func waitForSubscribers(t *testing.T, h *hub.Hub, topic string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for h.Subscribers(topic) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d subscribers", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHubServeSSE(t *testing.T) {
+	h := hub.New()
+	r := gor.NewRouter()
+	r.Get("/events/{room}", func(w http.ResponseWriter, req *http.Request) {
+		h.ServeSSE(w, req, req.PathValue("room"))
+	})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/events/lobby")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if ct := res.Header.Get("Content-Type"); ct != gor.ContentTypeEventStream {
+		t.Errorf("expected content type %s, got %s", gor.ContentTypeEventStream, ct)
+	}
+
+	waitForSubscribers(t, h, "lobby", 1)
+	h.Publish("lobby", []byte("line1\nline2"))
+
+	reader := bufio.NewReader(res.Body)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+
+	expected := []string{"data: line1", "data: line2", ""}
+	if strings.Join(lines, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected event %q, got %q", expected, lines)
+	}
+}
+
+func TestHubServeWebSocket(t *testing.T) {
+	h := hub.New()
+	r := gor.NewRouter()
+	r.Get("/ws/{room}", func(w http.ResponseWriter, req *http.Request) {
+		h.ServeWebSocket(w, req, req.PathValue("room"))
+	})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/lobby"
+	ws, err := websocket.Dial(url, "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSubscribers(t, h, "lobby", 1)
+	h.Publish("lobby", []byte("hello"))
+
+	var msg string
+	if err := websocket.Message.Receive(ws, &msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg != "hello" {
+		t.Errorf("expected hello, got %q", msg)
+	}
+
+	ws.Close()
+	waitForSubscribers(t, h, "lobby", 0)
+}
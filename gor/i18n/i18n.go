@@ -0,0 +1,343 @@
+// Package i18n translates the messages of gor applications.
+//
+// A Bundle holds the messages of every locale, loaded from JSON or TOML files
+// named after their locale, e.g "locales/fr.json" or "locales/pt-BR.toml".
+// Nested objects are flattened into dotted keys, and objects of plural forms
+// are selected with the plural rules of the locale:
+//
+//	{
+//		"home": {"title": "Bienvenue, %s"},
+//		"cart": {"items": {"zero": "Panier vide", "one": "%d article", "other": "%d articles"}}
+//	}
+//
+// The middleware detects the locale of each request and passes it to
+// gor.Translate, Render and the validation errors of BodyParser:
+//
+//	bundle := i18n.NewBundle("en")
+//	if err := bundle.LoadDir("locales"); err != nil {
+//		log.Fatal(err)
+//	}
+//
+//	templates, err := gor.ParseTemplatesRecursive("templates", bundle.FuncMap())
+//	r := gor.NewRouter(gor.WithTemplates(templates))
+//	r.Use(bundle.Middleware())
+//
+//	r.Get("/cart", func(w http.ResponseWriter, req *http.Request) {
+//		gor.SendString(w, gor.Translate(req, "cart.items", 3))
+//	})
+//
+// Templates translate messages with the "T" function, passed the page data:
+//
+//	<h1>{{ T . "home.title" .name }}</h1>
+//
+// The validation errors are translated with the keys "validation.<rule>",
+// e.g "validation.required" or "validation.min_characters". See gor.SetLocale.
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/abiiranathan/gor/gor"
+)
+
+// Bundle holds the messages of every locale.
+// It is safe for concurrent use.
+type Bundle struct {
+	mu            sync.RWMutex
+	defaultLocale string
+	messages      map[string]map[string]message // Messages by locale and key
+}
+
+// message is a translated message, with its plural forms keyed by their
+// category, or the message itself as "other".
+type message map[string]string
+
+// NewBundle returns an empty Bundle. Messages missing from a locale are
+// looked up in defaultLocale, e.g "en".
+func NewBundle(defaultLocale string) *Bundle {
+	return &Bundle{
+		defaultLocale: Canonical(defaultLocale),
+		messages:      make(map[string]map[string]message),
+	}
+}
+
+// DefaultLocale returns the default locale of the bundle.
+func (b *Bundle) DefaultLocale() string {
+	return b.defaultLocale
+}
+
+// Locales returns the locales with messages, sorted.
+func (b *Bundle) Locales() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	locales := make([]string, 0, len(b.messages))
+	for locale := range b.messages {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// AddMessages adds the messages of locale. Values are strings, objects of
+// plural forms, or objects of nested messages whose keys are joined with dots.
+// Messages replace the existing messages with the same key.
+func (b *Bundle) AddMessages(locale string, messages map[string]any) error {
+	flat := make(map[string]message)
+	if err := flatten(flat, "", messages); err != nil {
+		return fmt.Errorf("i18n: locale %s: %w", locale, err)
+	}
+
+	locale = Canonical(locale)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.messages[locale] == nil {
+		b.messages[locale] = make(map[string]message, len(flat))
+	}
+
+	for key, msg := range flat {
+		b.messages[locale][key] = msg
+	}
+	return nil
+}
+
+// ParseJSON adds the messages of locale from a JSON object. See AddMessages.
+func (b *Bundle) ParseJSON(locale string, data []byte) error {
+	var messages map[string]any
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return fmt.Errorf("i18n: locale %s: %w", locale, err)
+	}
+	return b.AddMessages(locale, messages)
+}
+
+// ParseTOML adds the messages of locale from a TOML document of strings,
+// tables and inline tables. See AddMessages.
+func (b *Bundle) ParseTOML(locale string, data []byte) error {
+	messages, err := parseTOML(string(data))
+	if err != nil {
+		return fmt.Errorf("i18n: locale %s: %w", locale, err)
+	}
+	return b.AddMessages(locale, messages)
+}
+
+// LoadFS adds the messages of the .json and .toml files of the directory dir
+// of fsys. The name of each file is its locale, e.g "fr.json" or "pt-BR.toml".
+func (b *Bundle) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("i18n: %w", err)
+	}
+
+	for _, entry := range entries {
+		ext := path.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".json" && ext != ".toml") {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("i18n: %w", err)
+		}
+
+		locale := strings.TrimSuffix(entry.Name(), ext)
+		if ext == ".json" {
+			err = b.ParseJSON(locale, data)
+		} else {
+			err = b.ParseTOML(locale, data)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadDir adds the messages of the .json and .toml files of the directory dir.
+// See LoadFS.
+func (b *Bundle) LoadDir(dir string) error {
+	return b.LoadFS(os.DirFS(dir), ".")
+}
+
+// Translate returns the message of key in locale, formatted with args, and
+// false if there is no message for key. It implements gor.Translator.
+//
+// Messages missing from a regional locale like "fr-CA" are looked up in its
+// language "fr", then in the default locale. Plural forms are selected by the
+// first numeric argument, and messages containing verbs are formatted with
+// fmt.Sprintf.
+func (b *Bundle) Translate(locale, key string, args ...any) (string, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, candidate := range b.fallbacks(Canonical(locale)) {
+		msg, ok := b.messages[candidate][key]
+		if !ok {
+			continue
+		}
+
+		text := msg["other"]
+		if n, ok := pluralCount(args); ok && len(msg) > 1 {
+			if form, ok := msg["zero"]; ok && n == 0 {
+				text = form
+			} else if form, ok := msg[PluralCategory(candidate, n)]; ok {
+				text = form
+			}
+		}
+
+		if len(args) > 0 && strings.Contains(text, "%") {
+			text = fmt.Sprintf(text, args...)
+		}
+		return text, true
+	}
+	return "", false
+}
+
+// T returns the message of key in locale, formatted with args, or key if
+// there is no message. See Translate.
+func (b *Bundle) T(locale, key string, args ...any) string {
+	if text, ok := b.Translate(locale, key, args...); ok {
+		return text
+	}
+	return key
+}
+
+// FuncMap returns the "T" template function, which translates a message in
+// the locale of the page data, set by Render from the locale of the request:
+//
+//	{{ T . "cart.items" .count }}
+func (b *Bundle) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"T": func(data any, key string, args ...any) string {
+			var locale string
+			switch d := data.(type) {
+			case gor.Map:
+				locale, _ = d["locale"].(string)
+			case map[string]any:
+				locale, _ = d["locale"].(string)
+			}
+			return b.T(locale, key, args...)
+		},
+	}
+}
+
+// Match returns the locale of the bundle best matching tags, in order of
+// preference, or "" if none matches. A tag matches the locale itself, its
+// language, or another region of its language, e.g "fr-CA" matches "fr".
+func (b *Bundle) Match(tags ...string) string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, tag := range tags {
+		tag = Canonical(tag)
+		if _, ok := b.messages[tag]; ok {
+			return tag
+		}
+
+		lang := language(tag)
+		if _, ok := b.messages[lang]; ok {
+			return lang
+		}
+
+		var regional []string
+		for locale := range b.messages {
+			if language(locale) == lang {
+				regional = append(regional, locale)
+			}
+		}
+
+		if len(regional) > 0 {
+			sort.Strings(regional)
+			return regional[0]
+		}
+	}
+	return ""
+}
+
+// fallbacks returns the locales in which to look up the messages of locale.
+func (b *Bundle) fallbacks(locale string) []string {
+	locales := []string{locale}
+	if lang := language(locale); lang != locale {
+		locales = append(locales, lang)
+	}
+
+	if b.defaultLocale != locale {
+		locales = append(locales, b.defaultLocale)
+	}
+	return locales
+}
+
+// Canonical returns the canonical form of a locale, with a lowercase
+// language and an uppercase region, e.g "pt_br" is "pt-BR".
+func Canonical(locale string) string {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) == 2 {
+			parts[i] = strings.ToUpper(parts[i])
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// language returns the language of locale, e.g "pt" for "pt-BR".
+func language(locale string) string {
+	lang, _, _ := strings.Cut(locale, "-")
+	return lang
+}
+
+// pluralCategories are the plural forms of messages.
+var pluralCategories = map[string]bool{
+	"zero": true, "one": true, "two": true, "few": true, "many": true, "other": true,
+}
+
+// flatten adds the messages of m to flat, prefixing their keys with prefix.
+func flatten(flat map[string]message, prefix string, m map[string]any) error {
+	for key, value := range m {
+		key = prefix + key
+		switch v := value.(type) {
+		case string:
+			flat[key] = message{"other": v}
+		case map[string]any:
+			if forms, ok := pluralForms(v); ok {
+				flat[key] = forms
+				continue
+			}
+
+			if err := flatten(flat, key+".", v); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("message %q must be a string or an object, got %T", key, value)
+		}
+	}
+	return nil
+}
+
+// pluralForms returns the plural forms of m, if its keys are plural
+// categories with string values and include "other".
+func pluralForms(m map[string]any) (message, bool) {
+	if _, ok := m["other"]; !ok {
+		return nil, false
+	}
+
+	forms := make(message, len(m))
+	for category, value := range m {
+		text, ok := value.(string)
+		if !ok || !pluralCategories[category] {
+			return nil, false
+		}
+		forms[category] = text
+	}
+	return forms, true
+}
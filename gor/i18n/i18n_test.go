@@ -0,0 +1,248 @@
+package i18n_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/abiiranathan/gor/gor/i18n"
+)
+
+func newBundle(t *testing.T) *i18n.Bundle {
+	t.Helper()
+
+	fsys := fstest.MapFS{
+		"locales/en.json": {Data: []byte(`{
+			"home": {"title": "Welcome, %s"},
+			"cart": {"items": {"zero": "Your cart is empty", "one": "%d item", "other": "%d items"}},
+			"validation": {"required": "is required"}
+		}`)},
+		"locales/fr.toml": {Data: []byte(`
+# French messages
+[home]
+title = "Bienvenue, %s"
+
+[cart]
+items = { one = "%d article", other = "%d articles" }
+
+[validation]
+required = 'est obligatoire'
+min_characters = "doit contenir au moins %d caractères"
+min = "doit être au moins %d"
+`)},
+		"locales/ru.json": {Data: []byte(`{"cart": {"items": {"one": "%d товар", "few": "%d товара", "many": "%d товаров", "other": "%d товара"}}}`)},
+	}
+
+	b := i18n.NewBundle("en")
+	if err := b.LoadFS(fsys, "locales"); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestBundleTranslate(t *testing.T) {
+	b := newBundle(t)
+
+	if locales := b.Locales(); !reflect.DeepEqual(locales, []string{"en", "fr", "ru"}) {
+		t.Errorf("unexpected locales %v", locales)
+	}
+
+	tests := []struct {
+		locale string
+		key    string
+		args   []any
+		want   string
+	}{
+		{"en", "home.title", []any{"Ann"}, "Welcome, Ann"},
+		{"fr", "home.title", []any{"Ann"}, "Bienvenue, Ann"},
+		{"fr-CA", "home.title", []any{"Ann"}, "Bienvenue, Ann"},
+		{"en", "cart.items", []any{0}, "Your cart is empty"},
+		{"en", "cart.items", []any{1}, "1 item"},
+		{"en", "cart.items", []any{2}, "2 items"},
+		{"fr", "cart.items", []any{0}, "0 article"},
+		{"fr", "cart.items", []any{2}, "2 articles"},
+		{"ru", "cart.items", []any{1}, "1 товар"},
+		{"ru", "cart.items", []any{3}, "3 товара"},
+		{"ru", "cart.items", []any{11}, "11 товаров"},
+		{"ru", "cart.items", []any{22}, "22 товара"},
+		{"ru", "home.title", []any{"Ann"}, "Welcome, Ann"},    // default locale
+		{"fr", "validation.required", nil, "est obligatoire"}, // literal string
+		{"de", "missing.key", nil, "missing.key"},
+	}
+
+	for _, tt := range tests {
+		if got := b.T(tt.locale, tt.key, tt.args...); got != tt.want {
+			t.Errorf("T(%s, %s, %v): expected %q, got %q", tt.locale, tt.key, tt.args, tt.want, got)
+		}
+	}
+
+	if _, ok := b.Translate("en", "missing.key"); ok {
+		t.Error("expected no message for a missing key")
+	}
+}
+
+func TestBundleLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "pt_BR.toml"), []byte("greeting = \"\"\"\nOlá\\tmundo\"\"\""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := i18n.NewBundle("en")
+	if err := b.LoadDir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := b.T("pt-br", "greeting"); got != "Olá\tmundo" {
+		t.Errorf("unexpected message %q", got)
+	}
+
+	if err := b.ParseTOML("en", []byte("count = 3")); err == nil {
+		t.Error("expected an error for a value that is not a string")
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tags := i18n.ParseAcceptLanguage("en;q=0.8, fr-CH, *;q=0.5, fr;q=0.9, de;q=0")
+	if !reflect.DeepEqual(tags, []string{"fr-CH", "fr", "en"}) {
+		t.Errorf("unexpected tags %v", tags)
+	}
+
+	b := newBundle(t)
+	if locale := b.Match(tags...); locale != "fr" {
+		t.Errorf("expected fr, got %s", locale)
+	}
+
+	if locale := b.Match("de", "ru-UA"); locale != "ru" {
+		t.Errorf("expected ru, got %s", locale)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	b := newBundle(t)
+
+	r := gor.NewRouter()
+	r.Use(b.Middleware())
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		gor.SendString(w, gor.Locale(req)+": "+gor.Translate(req, "cart.items", 2))
+	})
+
+	tests := []struct {
+		name   string
+		query  string
+		cookie string
+		header string
+		want   string
+	}{
+		{"default", "", "", "", "en: 2 items"},
+		{"header", "", "", "de, fr;q=0.8", "fr: 2 articles"},
+		{"cookie", "", "ru", "fr", "ru: 2 товара"},
+		{"query", "?lang=fr", "ru", "en", "fr: 2 articles"},
+		{"unsupported", "?lang=de", "", "", "en: 2 items"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/"+tt.query, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "lang", Value: tt.cookie})
+			}
+
+			if tt.header != "" {
+				req.Header.Set("Accept-Language", tt.header)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Body.String() != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, w.Body.String())
+			}
+
+			if lang := w.Header().Get("Content-Language"); !strings.HasPrefix(tt.want, lang+":") {
+				t.Errorf("unexpected Content-Language %q", lang)
+			}
+
+			if tt.name == "query" && !strings.Contains(w.Header().Get("Set-Cookie"), "lang=fr") {
+				t.Errorf("expected the locale to be remembered, got %q", w.Header().Get("Set-Cookie"))
+			}
+		})
+	}
+}
+
+func TestTemplateFunc(t *testing.T) {
+	b := newBundle(t)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "home.html"), []byte(`<h1>{{ T . "home.title" .name }}</h1>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	templ, err := gor.ParseTemplatesRecursive(dir, b.FuncMap())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gor.NewRouter(gor.WithTemplates(templ))
+	r.Use(b.Middleware())
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		r.Render(w, req, "home", gor.Map{"name": "Ann"})
+	})
+
+	req := httptest.NewRequest("GET", "/?lang=fr", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Body.String() != "<h1>Bienvenue, Ann</h1>" {
+		t.Errorf("unexpected page %q", w.Body.String())
+	}
+}
+
+func TestValidationMessages(t *testing.T) {
+	b := newBundle(t)
+
+	type Signup struct {
+		Name  string `json:"name" validate:"required"`
+		Login string `json:"login" validate:"min=3"`
+		Age   int    `json:"age" validate:"min=18"`
+	}
+
+	r := gor.NewRouter()
+	r.Use(b.Middleware())
+	r.Post("/signup", func(w http.ResponseWriter, req *http.Request) {
+		var signup Signup
+		err := gor.BodyParser(req, &signup)
+
+		var errs gor.ValidationErrors
+		if !errors.As(err, &errs) {
+			t.Errorf("expected ValidationErrors, got %v", err)
+			return
+		}
+		gor.SendJSON(w, errs.Map())
+	})
+
+	req := httptest.NewRequest("POST", "/signup?lang=fr", strings.NewReader(`{"login": "ab", "age": 12}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var got map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"Name":  "est obligatoire",
+		"Login": "doit contenir au moins 3 caractères",
+		"Age":   "doit être au moins 18",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
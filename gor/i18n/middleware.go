@@ -0,0 +1,131 @@
+package i18n
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/abiiranathan/gor/gor"
+)
+
+// Config configures the locale detection of the middleware.
+type Config struct {
+	// QueryParam is the query parameter choosing the locale, e.g "?lang=fr".
+	// Defaults to "lang". Set it to "-" to ignore the query.
+	QueryParam string
+
+	// CookieName is the cookie remembering the locale chosen with the query
+	// parameter. Defaults to "lang". Set it to "-" to ignore cookies.
+	CookieName string
+
+	// CookieMaxAge is the lifetime of the cookie in seconds. Defaults to one year.
+	CookieMaxAge int
+}
+
+// Middleware detects the locale of each request and sets it with
+// gor.SetLocale. The locale is the first supported locale of the query
+// parameter, the cookie, and the Accept-Language header, or the default locale.
+//
+// A locale chosen with the query parameter is remembered in the cookie.
+// The Content-Language header of the response is set to the locale.
+func (b *Bundle) Middleware(config ...Config) gor.Middleware {
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	if cfg.QueryParam == "" {
+		cfg.QueryParam = "lang"
+	}
+
+	if cfg.CookieName == "" {
+		cfg.CookieName = "lang"
+	}
+
+	if cfg.CookieMaxAge == 0 {
+		cfg.CookieMaxAge = 365 * 24 * 3600
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var locale string
+			if cfg.QueryParam != "-" {
+				if lang := req.URL.Query().Get(cfg.QueryParam); lang != "" {
+					locale = b.Match(lang)
+					if locale != "" && cfg.CookieName != "-" {
+						http.SetCookie(w, &http.Cookie{
+							Name:     cfg.CookieName,
+							Value:    locale,
+							Path:     "/",
+							MaxAge:   cfg.CookieMaxAge,
+							HttpOnly: true,
+							SameSite: http.SameSiteLaxMode,
+						})
+					}
+				}
+			}
+
+			if locale == "" && cfg.CookieName != "-" {
+				if cookie, err := req.Cookie(cfg.CookieName); err == nil {
+					locale = b.Match(cookie.Value)
+				}
+			}
+
+			if locale == "" {
+				locale = b.Match(ParseAcceptLanguage(req.Header.Get("Accept-Language"))...)
+			}
+
+			if locale == "" {
+				locale = b.defaultLocale
+			}
+
+			w.Header().Set("Content-Language", locale)
+			w.Header().Add("Vary", "Accept-Language")
+			gor.SetLocale(req, locale, b)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// ParseAcceptLanguage returns the language tags of an Accept-Language header
+// in order of preference, e.g "fr-CH, fr;q=0.9, en;q=0.8" returns
+// [fr-CH fr en]. The wildcard and tags with a zero quality are omitted.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = n
+		}
+
+		if q > 0 {
+			tags = append(tags, weighted{tag, q})
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].q > tags[j].q
+	})
+
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
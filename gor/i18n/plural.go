@@ -0,0 +1,169 @@
+package i18n
+
+import (
+	"math"
+	"sync"
+)
+
+// PluralRule returns the plural category of the count n:
+// "zero", "one", "two", "few", "many" or "other".
+type PluralRule func(n float64) string
+
+var (
+	pluralRulesMu sync.RWMutex
+	pluralRules   = map[string]PluralRule{}
+)
+
+func init() {
+	for _, lang := range []string{"ja", "ko", "zh", "vi", "th", "id", "ms"} {
+		pluralRules[lang] = pluralNone
+	}
+
+	for _, lang := range []string{"fr", "pt"} {
+		pluralRules[lang] = pluralZeroOne
+	}
+
+	for _, lang := range []string{"ru", "uk", "be"} {
+		pluralRules[lang] = pluralEastSlavic
+	}
+
+	pluralRules["pl"] = pluralPolish
+	pluralRules["cs"] = pluralCzech
+	pluralRules["sk"] = pluralCzech
+	pluralRules["ar"] = pluralArabic
+}
+
+// RegisterPluralRule sets the plural rule of the language lang, e.g "ga",
+// replacing the built-in rule if any. Languages without a rule use the
+// English rule, "one" for 1 and "other" otherwise.
+func RegisterPluralRule(lang string, rule PluralRule) {
+	pluralRulesMu.Lock()
+	defer pluralRulesMu.Unlock()
+	pluralRules[Canonical(lang)] = rule
+}
+
+// PluralCategory returns the plural category of the count n in locale.
+func PluralCategory(locale string, n float64) string {
+	pluralRulesMu.RLock()
+	rule, ok := pluralRules[Canonical(locale)]
+	if !ok {
+		rule, ok = pluralRules[language(Canonical(locale))]
+	}
+	pluralRulesMu.RUnlock()
+
+	if !ok {
+		rule = pluralEnglish
+	}
+	return rule(math.Abs(n))
+}
+
+// pluralCount returns the first numeric argument.
+func pluralCount(args []any) (float64, bool) {
+	for _, arg := range args {
+		switch n := arg.(type) {
+		case int:
+			return float64(n), true
+		case int8:
+			return float64(n), true
+		case int16:
+			return float64(n), true
+		case int32:
+			return float64(n), true
+		case int64:
+			return float64(n), true
+		case uint:
+			return float64(n), true
+		case uint8:
+			return float64(n), true
+		case uint16:
+			return float64(n), true
+		case uint32:
+			return float64(n), true
+		case uint64:
+			return float64(n), true
+		case float32:
+			return float64(n), true
+		case float64:
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+func pluralNone(n float64) string {
+	return "other"
+}
+
+func pluralEnglish(n float64) string {
+	if n == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+// pluralZeroOne is the rule of French, where 0 and 1 are singular.
+func pluralZeroOne(n float64) string {
+	if n < 2 {
+		return "one"
+	}
+	return "other"
+}
+
+func pluralEastSlavic(n float64) string {
+	if n != math.Trunc(n) {
+		return "other"
+	}
+
+	mod10, mod100 := math.Mod(n, 10), math.Mod(n, 100)
+	switch {
+	case mod10 == 1 && mod100 != 11:
+		return "one"
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return "few"
+	}
+	return "many"
+}
+
+func pluralPolish(n float64) string {
+	if n != math.Trunc(n) {
+		return "other"
+	}
+
+	mod10, mod100 := math.Mod(n, 10), math.Mod(n, 100)
+	switch {
+	case n == 1:
+		return "one"
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return "few"
+	}
+	return "many"
+}
+
+func pluralCzech(n float64) string {
+	switch {
+	case n != math.Trunc(n):
+		return "many"
+	case n == 1:
+		return "one"
+	case n >= 2 && n <= 4:
+		return "few"
+	}
+	return "other"
+}
+
+func pluralArabic(n float64) string {
+	mod100 := math.Mod(n, 100)
+	switch {
+	case n == 0:
+		return "zero"
+	case n == 1:
+		return "one"
+	case n == 2:
+		return "two"
+	case mod100 >= 3 && mod100 <= 10:
+		return "few"
+	case mod100 >= 11 && mod100 <= 99:
+		return "many"
+	}
+	return "other"
+}
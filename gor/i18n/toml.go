@@ -0,0 +1,319 @@
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseTOML parses the subset of TOML used by message files: tables, dotted
+// and quoted keys, basic, literal and multi-line strings, and inline tables.
+func parseTOML(doc string) (map[string]any, error) {
+	root := map[string]any{}
+	table := root
+	lines := strings.Split(strings.ReplaceAll(doc, "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.LastIndexByte(stripComment(line), ']')
+			if end < 0 || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: invalid table %s", lineNo, line)
+			}
+
+			keys, err := parseKey(line[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+
+			if table, err = subTable(root, keys); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			continue
+		}
+
+		rawKey, rest, ok := cutUnquoted(line, '=')
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+
+		keys, err := parseKey(rawKey)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		rest = strings.TrimSpace(rest)
+
+		// Multi-line strings span the following lines.
+		for _, delim := range []string{`"""`, `'''`} {
+			if strings.HasPrefix(rest, delim) && !strings.Contains(rest[3:], delim) {
+				for i+1 < len(lines) && !strings.Contains(rest[3:], delim) {
+					i++
+					rest += "\n" + lines[i]
+				}
+			}
+		}
+
+		value, rest, err := parseValue(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		if rest = strings.TrimSpace(rest); rest != "" && rest[0] != '#' {
+			return nil, fmt.Errorf("line %d: unexpected %q after value", lineNo, rest)
+		}
+
+		if err := setKey(table, keys, value); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	return root, nil
+}
+
+// parseKey parses a dotted key, e.g `cart."total price"`.
+func parseKey(s string) ([]string, error) {
+	var keys []string
+	for {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return nil, fmt.Errorf("invalid key")
+		}
+
+		var key string
+		switch s[0] {
+		case '"', '\'':
+			value, rest, err := parseString(s)
+			if err != nil {
+				return nil, err
+			}
+			key, s = value, rest
+		default:
+			end := strings.IndexFunc(s, func(r rune) bool {
+				return !(r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+			})
+			if end < 0 {
+				end = len(s)
+			}
+
+			if end == 0 {
+				return nil, fmt.Errorf("invalid key %q", s)
+			}
+			key, s = s[:end], s[end:]
+		}
+
+		keys = append(keys, key)
+		if s = strings.TrimSpace(s); s == "" {
+			return keys, nil
+		}
+
+		if s[0] != '.' {
+			return nil, fmt.Errorf("invalid key %q", s)
+		}
+		s = s[1:]
+	}
+}
+
+// parseValue parses the string or inline table at the start of s and
+// returns the rest of s.
+func parseValue(s string) (any, string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, "", fmt.Errorf("missing value")
+	}
+
+	if s[0] != '{' {
+		return parseString(s)
+	}
+
+	table := map[string]any{}
+	s = strings.TrimSpace(s[1:])
+	for !strings.HasPrefix(s, "}") {
+		rawKey, rest, ok := cutUnquoted(s, '=')
+		if !ok {
+			return nil, "", fmt.Errorf("expected key = value in inline table")
+		}
+
+		keys, err := parseKey(rawKey)
+		if err != nil {
+			return nil, "", err
+		}
+
+		value, rest, err := parseValue(rest)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if err := setKey(table, keys, value); err != nil {
+			return nil, "", err
+		}
+
+		s = strings.TrimSpace(rest)
+		if strings.HasPrefix(s, ",") {
+			s = strings.TrimSpace(s[1:])
+		} else if !strings.HasPrefix(s, "}") {
+			return nil, "", fmt.Errorf("unterminated inline table")
+		}
+	}
+	return table, s[1:], nil
+}
+
+// parseString parses the string at the start of s and returns the rest of s.
+func parseString(s string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(s, `"""`), strings.HasPrefix(s, `'''`):
+		delim := s[:3]
+		end := strings.Index(s[3:], delim)
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated string")
+		}
+
+		value := strings.TrimPrefix(s[3:3+end], "\n")
+		rest := s[6+end:]
+		if delim == `'''` {
+			return value, rest, nil
+		}
+
+		value, err := unescape(value)
+		return value, rest, err
+	case strings.HasPrefix(s, "'"):
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated string")
+		}
+		return s[1 : 1+end], s[2+end:], nil
+	case strings.HasPrefix(s, `"`):
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				value, err := unescape(s[1:i])
+				return value, s[i+1:], err
+			}
+		}
+		return "", "", fmt.Errorf("unterminated string")
+	}
+	return "", "", fmt.Errorf("unsupported value %q, messages must be strings", s)
+}
+
+// unescape replaces the escape sequences of a basic string.
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		if i++; i == len(s) {
+			return "", fmt.Errorf("invalid escape at end of string")
+		}
+
+		switch c := s[i]; c {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case '"', '\\':
+			b.WriteByte(c)
+		case 'u', 'U':
+			size := 4
+			if c == 'U' {
+				size = 8
+			}
+
+			if i+size >= len(s) {
+				return "", fmt.Errorf("invalid escape \\%c", c)
+			}
+
+			code, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				return "", fmt.Errorf("invalid escape \\%c%s", c, s[i+1:i+1+size])
+			}
+			b.WriteRune(rune(code))
+			i += size
+		case '\n', ' ', '\t':
+			// A backslash at the end of a line trims the following whitespace.
+			for i+1 < len(s) && strings.IndexByte(" \t\n", s[i+1]) >= 0 {
+				i++
+			}
+		default:
+			return "", fmt.Errorf("invalid escape \\%c", c)
+		}
+	}
+	return b.String(), nil
+}
+
+// cutUnquoted cuts s around the first sep outside of quotes.
+func cutUnquoted(s string, sep byte) (string, string, bool) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			return s[:i], s[i+1:], true
+		}
+	}
+	return s, "", false
+}
+
+// stripComment removes the comment at the end of line.
+func stripComment(line string) string {
+	before, _, _ := cutUnquoted(line, '#')
+	return before
+}
+
+// subTable returns the table of the dotted keys, creating it if needed.
+func subTable(table map[string]any, keys []string) (map[string]any, error) {
+	for _, key := range keys {
+		switch v := table[key].(type) {
+		case nil:
+			sub := map[string]any{}
+			table[key] = sub
+			table = sub
+		case map[string]any:
+			table = v
+		default:
+			return nil, fmt.Errorf("key %q is already defined", key)
+		}
+	}
+	return table, nil
+}
+
+// setKey sets the value of the dotted keys in table.
+func setKey(table map[string]any, keys []string, value any) error {
+	table, err := subTable(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+
+	key := keys[len(keys)-1]
+	if _, ok := table[key]; ok {
+		return fmt.Errorf("key %q is already defined", key)
+	}
+	table[key] = value
+	return nil
+}
@@ -4,32 +4,105 @@ import (
 	"crypto/subtle"
 	"fmt"
 	"net/http"
+	"sync"
 
 	"github.com/abiiranathan/gor/gor"
+	"golang.org/x/crypto/bcrypt"
 )
 
+const basicAuthUserKey claimsType = "basic_auth_user"
+
+// BasicAuthConfig is the configuration for the basic auth middleware.
+type BasicAuthConfig struct {
+	// Realm is the realm to display in the login box. Default is "Restricted".
+	Realm string
+
+	// Validator reports whether the credentials are valid, e.g by looking
+	// the user up in a database. It takes precedence over Users.
+	Validator func(user, pass string) bool
+
+	// Users maps usernames to bcrypt password hashes. See BcryptValidator.
+	Users map[string]string
+}
+
 // Basic Auth middleware.
 // If the username and password are not correct, a 401 status code is sent.
 // The realm is the realm to display in the login box. Default is "Restricted".
 func BasicAuth(username, password string, realm ...string) gor.Middleware {
-	defaultRealm := "Restricted"
+	config := BasicAuthConfig{
+		Validator: func(user, pass string) bool {
+			// Evaluate both comparisons to avoid leaking which one failed.
+			userOK := subtle.ConstantTimeCompare([]byte(user), []byte(username))
+			passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(password))
+			return userOK&passOK == 1
+		},
+	}
+
 	if len(realm) > 0 {
-		defaultRealm = realm[0]
+		config.Realm = realm[0]
+	}
+	return BasicAuthWithConfig(config)
+}
+
+// BasicAuthWithConfig creates a basic auth middleware with the given configuration.
+// The middleware can be shared by several groups or routes of the same realm.
+// The authenticated username is available with BasicAuthUser.
+//
+//	admin := auth.BasicAuthWithConfig(auth.BasicAuthConfig{
+//		Realm: "Admin",
+//		Users: map[string]string{"alice": "$2a$10$..."},
+//	})
+//	r.Group("/admin", admin)
+func BasicAuthWithConfig(config BasicAuthConfig) gor.Middleware {
+	realm := config.Realm
+	if realm == "" {
+		realm = "Restricted"
+	}
+
+	validate := config.Validator
+	if validate == nil {
+		validate = BcryptValidator(config.Users)
 	}
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 			user, pass, ok := req.BasicAuth()
 
-			if !ok || subtle.ConstantTimeCompare([]byte(user), []byte(username)) != 1 ||
-				subtle.ConstantTimeCompare([]byte(pass), []byte(password)) != 1 {
-
-				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s"`, defaultRealm))
+			if !ok || !validate(user, pass) {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s"`, realm))
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
+			gor.SetContextValue(req, basicAuthUserKey, user)
 			next.ServeHTTP(w, req)
 		})
 	}
 }
+
+// dummyHash is compared for unknown users so that they take as long as known users.
+// It is computed on first use, not at startup, since bcrypt is slow by design.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	return hash
+})
+
+// BcryptValidator returns a validator checking passwords against the bcrypt hashes
+// of users, keyed by username. Hashes are created with bcrypt.GenerateFromPassword.
+func BcryptValidator(users map[string]string) func(user, pass string) bool {
+	return func(user, pass string) bool {
+		hash, ok := users[user]
+		if !ok {
+			bcrypt.CompareHashAndPassword(dummyHash(), []byte(pass))
+			return false
+		}
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(pass)) == nil
+	}
+}
+
+// BasicAuthUser returns the username authenticated by the basic auth middleware
+// or "" if not found.
+func BasicAuthUser(req *http.Request) string {
+	user, _ := gor.GetContextValue(req, basicAuthUserKey).(string)
+	return user
+}
@@ -0,0 +1,67 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/abiiranathan/gor/gor/middleware/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBasicAuth(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gor.NewRouter()
+	whoami := func(w http.ResponseWriter, req *http.Request) {
+		gor.SendString(w, auth.BasicAuthUser(req))
+	}
+
+	r.Get("/plain", whoami, auth.BasicAuth("admin", "pass"))
+	r.Get("/hashed", whoami, auth.BasicAuthWithConfig(auth.BasicAuthConfig{
+		Realm: "Admin",
+		Users: map[string]string{"alice": string(hash)},
+	}))
+	r.Get("/validator", whoami, auth.BasicAuthWithConfig(auth.BasicAuthConfig{
+		Validator: func(user, pass string) bool { return user == "bob" && pass == "builder" },
+	}))
+
+	tests := []struct {
+		path   string
+		user   string
+		pass   string
+		status int
+		realm  string
+	}{
+		{"/plain", "admin", "pass", http.StatusOK, ""},
+		{"/plain", "admin", "wrong", http.StatusUnauthorized, `Basic realm="Restricted"`},
+		{"/hashed", "alice", "secret", http.StatusOK, ""},
+		{"/hashed", "alice", "wrong", http.StatusUnauthorized, `Basic realm="Admin"`},
+		{"/hashed", "mallory", "secret", http.StatusUnauthorized, `Basic realm="Admin"`},
+		{"/validator", "bob", "builder", http.StatusOK, ""},
+		{"/validator", "bob", "wrong", http.StatusUnauthorized, `Basic realm="Restricted"`},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.SetBasicAuth(tt.user, tt.pass)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.path, tt.user, tt.status, w.Code)
+		}
+
+		if tt.status == http.StatusOK && w.Body.String() != tt.user {
+			t.Errorf("%s: expected user %q, got %q", tt.path, tt.user, w.Body.String())
+		}
+
+		if realm := w.Header().Get("WWW-Authenticate"); realm != tt.realm {
+			t.Errorf("%s: expected WWW-Authenticate %q, got %q", tt.path, tt.realm, realm)
+		}
+	}
+}
@@ -15,19 +15,67 @@ type claimsType string
 
 const jwtClaimsKey claimsType = "claims"
 
-// JWT creates a JWT middleware with the given secret and options.
-func JWT(secret string) gor.Middleware {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			// Extract the JWT token from the request
-			tokenString := req.Header.Get("Authorization")
+// TokenExtractor extracts a token from the request. It returns "" if the token is missing.
+type TokenExtractor func(req *http.Request) string
+
+// FromAuthHeader extracts a bearer token from the Authorization header.
+func FromAuthHeader() TokenExtractor {
+	return func(req *http.Request) string {
+		// Remove the "Bearer " prefix and whitespace
+		return strings.TrimSpace(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+	}
+}
+
+// FromCookie extracts the token from the cookie with the given name.
+func FromCookie(name string) TokenExtractor {
+	return func(req *http.Request) string {
+		cookie, err := req.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+	}
+}
+
+// FromQuery extracts the token from the query parameter with the given name.
+func FromQuery(name string) TokenExtractor {
+	return func(req *http.Request) string {
+		return req.URL.Query().Get(name)
+	}
+}
 
-			// Remove the "Bearer " prefix
-			tokenString = strings.TrimPrefix(tokenString, "Bearer ")
+// FromForm extracts the token from the form value with the given name.
+func FromForm(name string) TokenExtractor {
+	return func(req *http.Request) string {
+		return req.FormValue(name)
+	}
+}
 
-			// remove whitespace
-			tokenString = strings.TrimSpace(tokenString)
+// extractToken returns the first token found by the extractors.
+func extractToken(req *http.Request, extractors []TokenExtractor) string {
+	for _, extract := range extractors {
+		if token := extract(req); token != "" {
+			return token
+		}
+	}
+	return ""
+}
 
+// JWT creates a JWT middleware with the given secret.
+// The token is read with the extractors in order, defaulting to FromAuthHeader.
+//
+//	r.Use(auth.JWT(secret, auth.FromAuthHeader(), auth.FromCookie("access_token")))
+//
+// Refresh tokens issued by a Refresher are rejected.
+func JWT(secret string, extractors ...TokenExtractor) gor.Middleware {
+	if len(extractors) == 0 {
+		extractors = []TokenExtractor{FromAuthHeader()}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// Extract the JWT token from the request
+			tokenString := extractToken(req, extractors)
 			if tokenString == "" {
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
@@ -35,7 +83,7 @@ func JWT(secret string) gor.Middleware {
 
 			// Verify the token
 			claims, err := VerifyJWToken(secret, tokenString)
-			if err != nil {
+			if err != nil || claims["typ"] == refreshTokenType {
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
@@ -0,0 +1,119 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/abiiranathan/gor/gor/middleware/auth"
+)
+
+const secret = "super secret"
+
+func TestJWTExtractors(t *testing.T) {
+	r := gor.NewRouter()
+	r.Use(auth.JWT(secret, auth.FromAuthHeader(), auth.FromCookie("access_token"), auth.FromQuery("token")))
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		gor.SendString(w, auth.GetClaims(req)["payload"].(string))
+	})
+
+	token, err := auth.CreateJWTToken(secret, "alice", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refresher := auth.NewRefresher(secret, time.Minute, time.Hour)
+	pair, err := refresher.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		setup  func(req *http.Request)
+		status int
+	}{
+		{"header", func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }, http.StatusOK},
+		{"cookie", func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "access_token", Value: token}) }, http.StatusOK},
+		{"query", func(req *http.Request) { req.URL.RawQuery = "token=" + token }, http.StatusOK},
+		{"missing", func(req *http.Request) {}, http.StatusUnauthorized},
+		{"invalid", func(req *http.Request) { req.Header.Set("Authorization", "Bearer invalid") }, http.StatusUnauthorized},
+		{"refresh token", func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+pair.RefreshToken) }, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		tt.setup(req)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, w.Code)
+		}
+
+		if tt.status == http.StatusOK && w.Body.String() != "alice" {
+			t.Errorf("%s: expected payload alice, got %q", tt.name, w.Body.String())
+		}
+	}
+}
+
+func TestRefresher(t *testing.T) {
+	refresher := auth.NewRefresher(secret, time.Minute, time.Hour)
+
+	r := gor.NewRouter()
+	r.Post("/refresh", refresher.Handler())
+	r.Get("/me", func(w http.ResponseWriter, req *http.Request) {
+		gor.SendString(w, auth.GetClaims(req)["payload"].(string))
+	}, auth.JWT(secret))
+
+	pair, err := refresher.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refresh := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/refresh", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := refresh(pair.RefreshToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected refresh to succeed, got %d", w.Code)
+	}
+
+	var next auth.TokenPair
+	if err := json.Unmarshal(w.Body.Bytes(), &next); err != nil {
+		t.Fatal(err)
+	}
+
+	if next.RefreshToken == pair.RefreshToken || next.TokenType != "Bearer" || next.ExpiresIn != 60 {
+		t.Errorf("expected a rotated token pair, got %+v", next)
+	}
+
+	req := httptest.NewRequest("GET", "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+next.AccessToken)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Body.String() != "alice" {
+		t.Errorf("expected the new access token to carry the payload, got %q", w.Body.String())
+	}
+
+	// Refresh tokens can be used only once, and access tokens can not refresh.
+	if w = refresh(pair.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected reused refresh token to be rejected, got %d", w.Code)
+	}
+
+	if w = refresh(next.AccessToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected access token to be rejected, got %d", w.Code)
+	}
+
+	if _, err := refresher.Rotate(pair.RefreshToken); err != auth.ErrRefreshTokenReused {
+		t.Errorf("expected ErrRefreshTokenReused, got %v", err)
+	}
+}
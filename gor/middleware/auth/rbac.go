@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/abiiranathan/gor/gor"
+	gorauth "github.com/abiiranathan/gor/gor/auth"
+)
+
+// RoleHolder is implemented by session users that have roles.
+type RoleHolder interface {
+	Roles() []string
+}
+
+// PermissionHolder is implemented by session users that have permissions.
+type PermissionHolder interface {
+	Permissions() []string
+}
+
+// Policy decides whether a request is authorized.
+type Policy interface {
+	Allow(req *http.Request) bool
+}
+
+// PolicyFunc is a function implementing Policy.
+type PolicyFunc func(req *http.Request) bool
+
+// Allow calls f(req).
+func (f PolicyFunc) Allow(req *http.Request) bool {
+	return f(req)
+}
+
+// RequireRoles allows requests whose principal has at least one of the roles.
+// Roles are read from the "roles" claim of the JWT, or its payload,
+// or from the session user of gor/auth if it implements RoleHolder.
+// Like other middleware, it can be applied to a group:
+//
+//	admin := r.Group("/admin", auth.JWT(secret), auth.RequireRoles("admin", "editor"))
+func RequireRoles(roles ...string) gor.Middleware {
+	return RequirePolicy(PolicyFunc(func(req *http.Request) bool {
+		for _, role := range Roles(req) {
+			if slices.Contains(roles, role) {
+				return true
+			}
+		}
+		return false
+	}))
+}
+
+// RequirePermission allows requests whose principal has all the permissions.
+// Permissions are read from the "permissions" claim of the JWT, or its payload,
+// or from the session user of gor/auth if it implements PermissionHolder.
+// A granted permission ending with "*" matches all permissions with its prefix,
+// e.g "orders:*" grants "orders:write".
+func RequirePermission(permissions ...string) gor.Middleware {
+	return RequirePolicy(PolicyFunc(func(req *http.Request) bool {
+		granted := Permissions(req)
+		for _, permission := range permissions {
+			if !hasPermission(granted, permission) {
+				return false
+			}
+		}
+		return true
+	}))
+}
+
+// RequirePolicy allows requests authorized by policy.
+// Requests without a principal get 401 Unauthorized
+// and requests denied by the policy get 403 Forbidden.
+func RequirePolicy(policy Policy) gor.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if GetClaims(req) == nil && gorauth.CurrentUser(req) == nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			if !policy.Allow(req) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// Roles returns the roles of the principal of the request.
+func Roles(req *http.Request) []string {
+	if claims := GetClaims(req); claims != nil {
+		return claimStrings(claims, "roles")
+	}
+
+	if user, ok := gorauth.CurrentUser(req).(RoleHolder); ok {
+		return user.Roles()
+	}
+	return nil
+}
+
+// Permissions returns the permissions of the principal of the request.
+func Permissions(req *http.Request) []string {
+	if claims := GetClaims(req); claims != nil {
+		return claimStrings(claims, "permissions")
+	}
+
+	if user, ok := gorauth.CurrentUser(req).(PermissionHolder); ok {
+		return user.Permissions()
+	}
+	return nil
+}
+
+// claimStrings returns the strings of the claim name, or of the same key
+// of the payload claim. A string claim is split on spaces, like OAuth scopes.
+func claimStrings(claims map[string]any, name string) []string {
+	value, ok := claims[name]
+	if !ok {
+		if payload, isMap := claims["payload"].(map[string]any); isMap {
+			value = payload[name]
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []string:
+		return v
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// hasPermission reports whether permission is granted.
+func hasPermission(granted []string, permission string) bool {
+	for _, g := range granted {
+		if g == permission || g == "*" {
+			return true
+		}
+
+		if prefix, ok := strings.CutSuffix(g, "*"); ok && strings.HasPrefix(permission, prefix) {
+			return true
+		}
+	}
+	return false
+}
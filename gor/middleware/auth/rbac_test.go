@@ -0,0 +1,58 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/abiiranathan/gor/gor/middleware/auth"
+)
+
+func TestRBAC(t *testing.T) {
+	r := gor.NewRouter()
+	ok := func(w http.ResponseWriter, req *http.Request) { gor.SendString(w, "ok") }
+
+	admin := r.Group("/admin", auth.JWT(secret), auth.RequireRoles("admin", "editor"))
+	admin.Get("/posts", ok)
+	admin.Post("/orders", ok, auth.RequirePermission("orders:write"))
+	r.Get("/mine", ok, auth.JWT(secret), auth.RequirePolicy(auth.PolicyFunc(func(req *http.Request) bool {
+		return req.URL.Query().Get("owner") == auth.GetClaims(req)["payload"].(map[string]any)["name"]
+	})))
+
+	token := func(payload map[string]any) string {
+		token, err := auth.CreateJWTToken(secret, payload, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	editor := token(map[string]any{"name": "bob", "roles": []string{"editor"}, "permissions": "orders:*"})
+	viewer := token(map[string]any{"name": "eve", "roles": []string{"viewer"}})
+	reader := token(map[string]any{"name": "ann", "roles": []string{"admin"}, "permissions": []string{"orders:read"}})
+
+	tests := []struct {
+		method, path, token string
+		status              int
+	}{
+		{"GET", "/admin/posts", editor, http.StatusOK},
+		{"GET", "/admin/posts", viewer, http.StatusForbidden},
+		{"POST", "/admin/orders", editor, http.StatusOK},
+		{"POST", "/admin/orders", reader, http.StatusForbidden},
+		{"GET", "/mine?owner=bob", editor, http.StatusOK},
+		{"GET", "/mine?owner=eve", editor, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.status, w.Code)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// refreshTokenType is the "typ" claim of refresh tokens.
+const refreshTokenType = "refresh"
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token already used")
+)
+
+// TokenPair is an access token with the refresh token used to renew it.
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // Lifetime of the access token in seconds
+}
+
+// RefreshStore records used refresh tokens so that each can be exchanged only once.
+// Implementations must be safe for concurrent use.
+type RefreshStore interface {
+	// Revoke marks the refresh token with id as used until it expires at exp.
+	// It returns ErrRefreshTokenReused if the token was already revoked.
+	Revoke(id string, exp time.Time) error
+}
+
+// MemoryRefreshStore is an in-memory RefreshStore.
+// Revoked ids are removed once their token expires.
+type MemoryRefreshStore struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+// NewMemoryRefreshStore creates an empty MemoryRefreshStore.
+func NewMemoryRefreshStore() *MemoryRefreshStore {
+	return &MemoryRefreshStore{revoked: make(map[string]time.Time)}
+}
+
+// Revoke marks the refresh token with id as used.
+func (s *MemoryRefreshStore) Revoke(id string, exp time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, e := range s.revoked {
+		if now.After(e) {
+			delete(s.revoked, k)
+		}
+	}
+
+	if _, ok := s.revoked[id]; ok {
+		return ErrRefreshTokenReused
+	}
+	s.revoked[id] = exp
+	return nil
+}
+
+// Refresher issues access and refresh token pairs and rotates refresh tokens.
+// Access tokens are verified by the JWT middleware with the same secret.
+//
+//	refresher := auth.NewRefresher(secret, 15*time.Minute, 7*24*time.Hour)
+//	r.Post("/login", func(w http.ResponseWriter, req *http.Request) {
+//		pair, err := refresher.Issue(user.ID)
+//		...
+//	})
+//	r.Post("/refresh", refresher.Handler())
+type Refresher struct {
+	Secret     string        // Secret signing both tokens
+	AccessTTL  time.Duration // Lifetime of access tokens
+	RefreshTTL time.Duration // Lifetime of refresh tokens
+	Store      RefreshStore  // Used refresh tokens
+}
+
+// NewRefresher creates a Refresher with a MemoryRefreshStore.
+func NewRefresher(secret string, accessTTL, refreshTTL time.Duration) *Refresher {
+	return &Refresher{
+		Secret:     secret,
+		AccessTTL:  accessTTL,
+		RefreshTTL: refreshTTL,
+		Store:      NewMemoryRefreshStore(),
+	}
+}
+
+// Issue creates a new token pair carrying payload.
+func (r *Refresher) Issue(payload any) (TokenPair, error) {
+	access, err := CreateJWTToken(r.Secret, payload, r.AccessTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return TokenPair{}, err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"payload": payload,
+		"typ":     refreshTokenType,
+		"jti":     hex.EncodeToString(id),
+		"exp":     time.Now().Add(r.RefreshTTL).Unix(),
+	})
+
+	refresh, err := token.SignedString([]byte(r.Secret))
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(r.AccessTTL / time.Second),
+	}, nil
+}
+
+// Rotate exchanges a refresh token for a new token pair with the same payload.
+// The refresh token is revoked and cannot be exchanged again.
+func (r *Refresher) Rotate(refreshToken string) (TokenPair, error) {
+	claims, err := VerifyJWToken(r.Secret, refreshToken)
+	if err != nil || claims["typ"] != refreshTokenType {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	id, _ := claims["jti"].(string)
+	exp, err := claims.GetExpirationTime()
+	if id == "" || err != nil || exp == nil {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	if err := r.Store.Revoke(id, exp.Time); err != nil {
+		return TokenPair{}, err
+	}
+	return r.Issue(claims["payload"])
+}
+
+// Handler returns a handler that rotates the refresh token of the request
+// and sends the new TokenPair as JSON. The refresh token is read with the
+// extractors in order, defaulting to FromAuthHeader and the "refresh_token" form value.
+// Invalid or reused refresh tokens are rejected with 401 Unauthorized.
+func (r *Refresher) Handler(extractors ...TokenExtractor) http.HandlerFunc {
+	if len(extractors) == 0 {
+		extractors = []TokenExtractor{FromAuthHeader(), FromForm("refresh_token")}
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		refreshToken := extractToken(req, extractors)
+		if refreshToken == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		pair, err := r.Rotate(refreshToken)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		gor.SendJSON(w, pair)
+	}
+}
@@ -0,0 +1,357 @@
+// Package bruteforce protects login routes from password guessing.
+//
+// The middleware counts the failed attempts of each username and client IP.
+// After MaxAttempts failures, further attempts are rejected with 429 Too Many
+// Requests for a delay doubling with each failure, or for a fixed Lockout.
+// A successful attempt resets the count.
+//
+//	r.Post("/login", login, bruteforce.NewWithConfig(bruteforce.Config{
+//		MaxAttempts: 5,
+//		OnEvent: func(e bruteforce.Event) {
+//			if e.Type == bruteforce.Locked {
+//				slog.Warn("login locked", "key", e.Key, "failures", e.Failures)
+//			}
+//		},
+//	}))
+package bruteforce
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abiiranathan/gor/gor"
+)
+
+// EventType is the type of an Event.
+type EventType int
+
+const (
+	Failure EventType = iota // A failed attempt below MaxAttempts
+	Locked                   // A failed attempt locking the key
+	Blocked                  // An attempt rejected because the key is locked
+	Reset                    // A successful attempt clearing previous failures
+)
+
+func (t EventType) String() string {
+	switch t {
+	case Failure:
+		return "failure"
+	case Locked:
+		return "locked"
+	case Blocked:
+		return "blocked"
+	case Reset:
+		return "reset"
+	}
+	return "unknown"
+}
+
+// Event describes an attempt, for logging and alerting.
+type Event struct {
+	Type        EventType
+	Key         string    // Key of the attempt, see Config.Key
+	Failures    int       // Failed attempts of the key
+	LockedUntil time.Time // End of the lock, for Locked and Blocked events
+	Request     *http.Request
+}
+
+// Config is the configuration of the brute-force protection middleware.
+type Config struct {
+	// Store keeps the failed attempts. Defaults to a MemoryStore.
+	// Share a Store between instances to protect a cluster.
+	Store Store
+
+	// Key returns the key attempts are counted by.
+	// Defaults to the UsernameField form value and the client IP.
+	Key func(req *http.Request) string
+
+	// TrustedProxies are the IP addresses or CIDR ranges of the reverse proxies
+	// in front of the server, e.g "10.0.0.0/8". The client IP of the default Key
+	// is the remote address of the connection, or, for requests from a trusted
+	// proxy, the last address of X-Forwarded-For that is not a trusted proxy.
+	// X-Forwarded-For is ignored by default since clients can set it to avoid
+	// the lock.
+	TrustedProxies []string
+
+	// UsernameField is the form field of the username used by the default Key.
+	// Defaults to "username".
+	UsernameField string
+
+	// MaxAttempts is the number of failures allowed before the key is locked.
+	// Attempts in progress count as failures, so concurrent attempts beyond
+	// MaxAttempts are rejected while the first ones run. Defaults to 5.
+	MaxAttempts int
+
+	// BaseDelay is the lock after MaxAttempts failures, doubled with each
+	// further failure up to MaxDelay. Defaults to 1 second.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the exponential delay. Defaults to 15 minutes.
+	MaxDelay time.Duration
+
+	// Lockout, if set, locks the key for this fixed duration after
+	// MaxAttempts failures instead of the exponential delay.
+	Lockout time.Duration
+
+	// ResetAfter is how long failures are remembered after the last one.
+	// Defaults to 1 hour.
+	ResetAfter time.Duration
+
+	// Failed reports whether the response status is a failed attempt.
+	// Defaults to 401 Unauthorized and 403 Forbidden.
+	Failed func(status int) bool
+
+	// OnEvent is called for every failed, locked, blocked and reset attempt.
+	OnEvent func(e Event)
+}
+
+// New returns the brute-force protection middleware with the default configuration.
+func New() gor.Middleware {
+	return NewWithConfig(Config{})
+}
+
+// NewWithConfig returns the brute-force protection middleware with config.
+// It is designed to wrap login routes.
+// It panics if a trusted proxy is not an IP address or a CIDR range.
+func NewWithConfig(config Config) gor.Middleware {
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+
+	if config.UsernameField == "" {
+		config.UsernameField = "username"
+	}
+
+	proxies := parseProxies(config.TrustedProxies)
+	if config.Key == nil {
+		config.Key = func(req *http.Request) string {
+			return strings.ToLower(req.FormValue(config.UsernameField)) + "|" + clientIP(req, proxies)
+		}
+	}
+
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+
+	if config.BaseDelay <= 0 {
+		config.BaseDelay = time.Second
+	}
+
+	if config.MaxDelay <= 0 {
+		config.MaxDelay = 15 * time.Minute
+	}
+
+	if config.ResetAfter <= 0 {
+		config.ResetAfter = time.Hour
+	}
+
+	if config.Failed == nil {
+		config.Failed = func(status int) bool {
+			return status == http.StatusUnauthorized || status == http.StatusForbidden
+		}
+	}
+
+	// Serialize the updates of records within this process.
+	var mu sync.Mutex
+
+	// Attempts in progress by key, reserved before calling the handler.
+	inflight := make(map[string]int)
+
+	emit := func(e Event) {
+		if config.OnEvent != nil {
+			config.OnEvent(e)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			key := config.Key(req)
+
+			mu.Lock()
+			record, found := config.Store.Get(key)
+			if !found {
+				record = &Record{}
+			}
+
+			locked := time.Now().Before(record.LockedUntil)
+
+			// Concurrent attempts may all fail, so the attempts in progress count
+			// as failures. Once locked, one attempt at a time is allowed after the lock.
+			if !locked && inflight[key] >= max(config.MaxAttempts-record.Failures, 1) {
+				locked = true
+			}
+
+			if !locked {
+				inflight[key]++
+			}
+			mu.Unlock()
+
+			if locked {
+				emit(Event{Type: Blocked, Key: key, Failures: record.Failures, LockedUntil: record.LockedUntil, Request: req})
+
+				retry := max(math.Ceil(time.Until(record.LockedUntil).Seconds()), 1)
+				w.Header().Set("Retry-After", strconv.Itoa(int(retry)))
+				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+				return
+			}
+
+			sw, ok := w.(statusWriter)
+			if !ok {
+				sw = &responseWriter{ResponseWriter: w, status: http.StatusOK}
+				w = sw.(http.ResponseWriter)
+			}
+
+			// Release the attempt, even if the handler panics.
+			defer func() {
+				mu.Lock()
+				if inflight[key]--; inflight[key] <= 0 {
+					delete(inflight, key)
+				}
+				mu.Unlock()
+			}()
+
+			next.ServeHTTP(w, req)
+			status := sw.Status()
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if !config.Failed(status) {
+				if status < http.StatusBadRequest {
+					if _, found := config.Store.Get(key); found {
+						config.Store.Delete(key)
+						emit(Event{Type: Reset, Key: key, Request: req})
+					}
+				}
+				return
+			}
+
+			// Reload the record, other attempts may have updated it.
+			record, found = config.Store.Get(key)
+			if !found {
+				record = &Record{}
+			}
+
+			now := time.Now()
+			record.Failures++
+			record.LastFailure = now
+			record.Expires = now.Add(config.ResetAfter)
+
+			event := Event{Type: Failure, Key: key, Failures: record.Failures, Request: req}
+			if record.Failures >= config.MaxAttempts {
+				record.LockedUntil = now.Add(config.delay(record.Failures))
+				if record.LockedUntil.After(record.Expires) {
+					record.Expires = record.LockedUntil
+				}
+
+				event.Type = Locked
+				event.LockedUntil = record.LockedUntil
+			}
+
+			config.Store.Set(key, record)
+			emit(event)
+		})
+	}
+}
+
+// delay returns the lock after the given number of failures.
+func (config *Config) delay(failures int) time.Duration {
+	if config.Lockout > 0 {
+		return config.Lockout
+	}
+
+	delay := config.BaseDelay
+	for i := config.MaxAttempts; i < failures && delay < config.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, config.MaxDelay)
+}
+
+// parseProxies parses the IP addresses and CIDR ranges of trusted proxies.
+func parseProxies(proxies []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if addr, err := netip.ParseAddr(proxy); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			panic(fmt.Sprintf("bruteforce: invalid trusted proxy %q", proxy))
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
+}
+
+// trusted reports whether addr is one of the trusted proxies.
+func trusted(addr netip.Addr, proxies []netip.Prefix) bool {
+	for _, prefix := range proxies {
+		if prefix.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the remote address of the request. For requests from a
+// trusted proxy, it returns the last address of X-Forwarded-For that is not
+// a trusted proxy, since proxies append the address they received from.
+func clientIP(req *http.Request, proxies []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+
+	remote, err := netip.ParseAddr(host)
+	if err != nil || !trusted(remote, proxies) {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			return host
+		}
+
+		if !trusted(addr, proxies) {
+			return addr.Unmap().String()
+		}
+	}
+	return host
+}
+
+// statusWriter is implemented by writers that track the response status, like gor.ResponseWriter.
+type statusWriter interface {
+	Status() int
+}
+
+// responseWriter records the status code when gor.ResponseWriter is not the writer
+// passed to the middleware.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+// Unwrap returns the underlying http.ResponseWriter for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
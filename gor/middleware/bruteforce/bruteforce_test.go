@@ -0,0 +1,198 @@
+package bruteforce_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/abiiranathan/gor/gor/middleware/bruteforce"
+)
+
+func TestBruteForce(t *testing.T) {
+	var events []bruteforce.EventType
+
+	r := gor.NewRouter()
+	r.Post("/login", func(w http.ResponseWriter, req *http.Request) {
+		if req.FormValue("password") != "secret" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		gor.SendString(w, "welcome")
+	}, bruteforce.NewWithConfig(bruteforce.Config{
+		MaxAttempts: 3,
+		BaseDelay:   time.Minute,
+		OnEvent:     func(e bruteforce.Event) { events = append(events, e.Type) },
+	}))
+
+	login := func(username, password, ip string) *httptest.ResponseRecorder {
+		form := url.Values{"username": {username}, "password": {password}}
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// A success resets the failures.
+	login("alice", "wrong", "10.0.0.1")
+	login("alice", "secret", "10.0.0.1")
+
+	for i := 0; i < 3; i++ {
+		if w := login("alice", "wrong", "10.0.0.1"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected 401, got %d", i, w.Code)
+		}
+	}
+
+	w := login("Alice", "secret", "10.0.0.1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" {
+		t.Errorf("expected the locked key to be blocked, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// Other users and clients are not affected.
+	if w := login("alice", "secret", "10.0.0.2"); w.Code != http.StatusOK {
+		t.Errorf("expected another client to log in, got %d", w.Code)
+	}
+
+	want := []bruteforce.EventType{
+		bruteforce.Failure, bruteforce.Reset,
+		bruteforce.Failure, bruteforce.Failure, bruteforce.Locked,
+		bruteforce.Blocked,
+	}
+
+	if len(events) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, events)
+	}
+
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d: expected %s, got %s", i, want[i], events[i])
+		}
+	}
+}
+
+func TestBruteForceBackoff(t *testing.T) {
+	store := bruteforce.NewMemoryStore()
+
+	r := gor.NewRouter()
+	r.Post("/login", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}, bruteforce.NewWithConfig(bruteforce.Config{
+		Store:       store,
+		Key:         func(req *http.Request) string { return "key" },
+		MaxAttempts: 1,
+		BaseDelay:   time.Second,
+		MaxDelay:    3 * time.Second,
+	}))
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	for i, delay := range expected {
+		// Unlock the key to record another failure.
+		if record, ok := store.Get("key"); ok {
+			record.LockedUntil = time.Time{}
+			store.Set("key", record)
+		}
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/login", nil))
+
+		record, _ := store.Get("key")
+		got := record.LockedUntil.Sub(record.LastFailure)
+		if got != delay {
+			t.Errorf("failure %d: expected a lock of %s, got %s", i+1, delay, got)
+		}
+	}
+}
+
+func TestBruteForceForwardedFor(t *testing.T) {
+	for _, proxies := range [][]string{nil, {"10.0.0.0/8"}} {
+		r := gor.NewRouter()
+		r.Post("/login", func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}, bruteforce.NewWithConfig(bruteforce.Config{
+			MaxAttempts:    2,
+			BaseDelay:      time.Minute,
+			TrustedProxies: proxies,
+		}))
+
+		login := func(remoteAddr, forwardedFor string) int {
+			req := httptest.NewRequest("POST", "/login?username=alice", nil)
+			req.RemoteAddr = remoteAddr
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			return w.Code
+		}
+
+		// The client changes X-Forwarded-For on every attempt.
+		login("10.0.0.1:1234", "192.0.2.1")
+		login("10.0.0.1:1234", "192.0.2.2")
+		code := login("10.0.0.1:1234", "192.0.2.3")
+
+		if proxies == nil && code != http.StatusTooManyRequests {
+			t.Errorf("expected a spoofed X-Forwarded-For to be ignored, got %d", code)
+		}
+
+		if proxies != nil && code != http.StatusUnauthorized {
+			t.Errorf("expected the clients behind a trusted proxy to be counted apart, got %d", code)
+		}
+
+		// The address appended by the trusted proxy is used, not the one set by the client.
+		if proxies != nil {
+			login("10.0.0.1:1234", "192.0.2.1, 198.51.100.7")
+			login("10.0.0.1:1234", "192.0.2.2, 198.51.100.7")
+			if code := login("10.0.0.1:1234", "192.0.2.3, 198.51.100.7"); code != http.StatusTooManyRequests {
+				t.Errorf("expected the client address appended by the proxy to be locked, got %d", code)
+			}
+		}
+	}
+}
+
+func TestBruteForceConcurrentAttempts(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	r := gor.NewRouter()
+	r.Post("/login", func(w http.ResponseWriter, req *http.Request) {
+		calls.Add(1)
+		<-release // A slow password check
+		w.WriteHeader(http.StatusUnauthorized)
+	}, bruteforce.NewWithConfig(bruteforce.Config{MaxAttempts: 3, BaseDelay: time.Minute}))
+
+	var wg sync.WaitGroup
+	codes := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("POST", "/login?username=alice", nil))
+			codes <- w.Code
+		}()
+	}
+
+	// Wait for the rejected attempts, then let the others fail.
+	blocked := 0
+	for blocked < 7 {
+		if code := <-codes; code == http.StatusTooManyRequests {
+			blocked++
+		}
+	}
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 3 {
+		t.Errorf("expected 3 attempts to reach the handler, got %d", n)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/login?username=alice", nil))
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" {
+		t.Errorf("expected the key to be locked, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+}
@@ -0,0 +1,88 @@
+package bruteforce
+
+import (
+	"sync"
+	"time"
+)
+
+// Record is the failed login history of a key.
+type Record struct {
+	Failures    int       // Failed attempts since the last success
+	LastFailure time.Time // Time of the last failed attempt
+	LockedUntil time.Time // Attempts are rejected until this time
+	Expires     time.Time // Time after which the record is forgotten
+}
+
+// Store stores the records of keys. Implementations must be safe for concurrent use.
+// Records must not be returned by Get after their Expires time.
+type Store interface {
+	// Get returns the record stored at key.
+	Get(key string) (*Record, bool)
+
+	// Set stores record at key, replacing any existing record.
+	Set(key string, record *Record)
+
+	// Delete removes the record stored at key.
+	Delete(key string)
+}
+
+// sweepInterval is how often MemoryStore removes all expired records.
+const sweepInterval = time.Minute
+
+// MemoryStore is an in-memory Store. Expired records are removed when they are read,
+// and all of them at most once a minute when records are added.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]*Record
+	swept   time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]*Record)}
+}
+
+// Get returns a copy of the record stored at key if it has not expired.
+func (s *MemoryStore) Get(key string) (*Record, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.records[key]
+	if !ok {
+		return nil, false
+	}
+
+	if time.Now().After(r.Expires) {
+		delete(s.records, key)
+		return nil, false
+	}
+
+	record := *r
+	return &record, true
+}
+
+// Set stores a copy of record at key.
+func (s *MemoryStore) Set(key string, record *Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Forget expired records so that the store does not grow with every key seen.
+	if now := time.Now(); now.Sub(s.swept) > sweepInterval {
+		for k, r := range s.records {
+			if now.After(r.Expires) {
+				delete(s.records, k)
+			}
+		}
+		s.swept = now
+	}
+
+	r := *record
+	s.records[key] = &r
+}
+
+// Delete removes the record stored at key.
+func (s *MemoryStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+}
@@ -0,0 +1,382 @@
+// Package cache implements a middleware that stores full responses
+// and serves them to subsequent requests without calling the handler.
+//
+//	c := cache.New(cache.Config{TTL: time.Minute})
+//	r.Get("/products", listProducts, c.Middleware())
+//
+//	// after a product changes
+//	c.InvalidatePrefix("GET /products")
+//
+// Responses are keyed by method, URL and the values of the request headers
+// listed in the Vary header of the response. Handlers control caching with
+// the Cache-Control header: no-store and private responses are not cached,
+// and max-age or s-maxage override the configured TTL.
+//
+// Since the cache is shared by all users, responses to requests with an
+// Authorization header are only cached if they are public or set s-maxage
+// (RFC 9111, section 3.5), and requests with cookies bypass the cache unless
+// Config.AllowCookies is set.
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/abiiranathan/gor/gor"
+)
+
+// DefaultTTL is the time a response is cached when the handler does not set max-age.
+const DefaultTTL = 5 * time.Minute
+
+// DefaultMaxBodySize is the default maximum size of a cached response body (1 MiB).
+const DefaultMaxBodySize = 1 << 20
+
+// Config is the configuration for the cache middleware.
+type Config struct {
+	// Store holds the cached responses. Default is a new MemoryStore.
+	Store Store
+
+	// TTL is the time a response is cached when the handler does not set
+	// max-age or s-maxage in its Cache-Control header. Default is DefaultTTL.
+	TTL time.Duration
+
+	// MaxBodySize is the maximum size of a cached response body.
+	// Larger responses are sent but not cached. Default is DefaultMaxBodySize.
+	// A negative value disables the limit.
+	MaxBodySize int
+
+	// Statuses lists the status codes of cacheable responses. Default is 200 OK.
+	Statuses []int
+
+	// Skip is a list of functions that return true if the request should bypass the cache.
+	Skip []func(r *http.Request) bool
+
+	// AllowCookies caches the responses to requests with a Cookie header.
+	// By default, they bypass the cache, since their responses may depend on
+	// the session and would be served to other users. With AllowCookies, the
+	// handlers of pages depending on the session must send Cache-Control: private.
+	AllowCookies bool
+}
+
+// Stats reports the cache effectiveness.
+type Stats struct {
+	Hits   uint64 // Requests served from the cache
+	Misses uint64 // Requests passed to the handler
+}
+
+// Cache is a response cache. Use Middleware to cache the responses of routes.
+type Cache struct {
+	config Config
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// New creates a Cache with the given configuration.
+func New(config ...Config) *Cache {
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+
+	if cfg.TTL == 0 {
+		cfg.TTL = DefaultTTL
+	}
+
+	if cfg.MaxBodySize == 0 {
+		cfg.MaxBodySize = DefaultMaxBodySize
+	}
+
+	if len(cfg.Statuses) == 0 {
+		cfg.Statuses = []int{http.StatusOK}
+	}
+	return &Cache{config: cfg}
+}
+
+// Key returns the cache key of a request for method and the request URI, e.g "GET /users?page=2".
+// Use it with Invalidate and InvalidatePrefix.
+func Key(method, requestURI string) string {
+	return method + " " + requestURI
+}
+
+// Invalidate removes the cached response for key and all its variants. See Key.
+func (c *Cache) Invalidate(key string) {
+	c.config.Store.Delete(key)
+	c.config.Store.DeletePrefix(key + "\n")
+}
+
+// InvalidatePrefix removes all cached responses whose key starts with prefix.
+func (c *Cache) InvalidatePrefix(prefix string) {
+	c.config.Store.DeletePrefix(prefix)
+}
+
+// Stats returns the number of cache hits and misses.
+func (c *Cache) Stats() Stats {
+	return Stats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+// Middleware returns the cache middleware. Only GET requests are cached.
+// Cached responses carry an Age header and an X-Cache header set to HIT or MISS.
+func (c *Cache) Middleware() gor.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodGet || c.skip(req) {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			key := Key(req.Method, req.URL.RequestURI())
+			if entry, ok := c.lookup(key, req); ok {
+				c.hits.Add(1)
+				c.serve(w, entry)
+				return
+			}
+			c.misses.Add(1)
+
+			w.Header().Set("X-Cache", "MISS")
+			rw := &recordWriter{
+				ResponseWriter: w,
+				status:         http.StatusOK,
+				maxSize:        c.config.MaxBodySize,
+			}
+			next.ServeHTTP(rw, req)
+			c.store(key, req, rw)
+		})
+	}
+}
+
+// skip reports whether the request bypasses the cache.
+func (c *Cache) skip(req *http.Request) bool {
+	for _, s := range c.config.Skip {
+		if s(req) {
+			return true
+		}
+	}
+
+	if !c.config.AllowCookies && req.Header.Get("Cookie") != "" {
+		return true
+	}
+
+	cc := req.Header.Get("Cache-Control")
+	return hasDirective(cc, "no-store") || hasDirective(cc, "no-cache")
+}
+
+// lookup returns the entry for key, resolving the variant of the request if
+// the response varies on request headers.
+func (c *Cache) lookup(key string, req *http.Request) (*Entry, bool) {
+	entry, ok := c.config.Store.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	if len(entry.Vary) > 0 {
+		return c.config.Store.Get(variantKey(key, entry.Vary, req))
+	}
+	return entry, true
+}
+
+// variantKey returns the key of the response variant for the values of
+// the vary headers in req.
+func variantKey(key string, vary []string, req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(key)
+	for _, name := range vary {
+		b.WriteString("\n")
+		b.WriteString(name)
+		b.WriteString(":")
+		b.WriteString(strings.Join(req.Header.Values(name), ","))
+	}
+	return b.String()
+}
+
+// serve writes a cached response.
+func (c *Cache) serve(w http.ResponseWriter, entry *Entry) {
+	h := w.Header()
+	for k, v := range entry.Header {
+		h[k] = append([]string(nil), v...)
+	}
+
+	h.Set("X-Cache", "HIT")
+	h.Set("Age", strconv.Itoa(int(time.Since(entry.Created).Seconds())))
+	w.WriteHeader(entry.Status)
+	w.Write(entry.Body)
+}
+
+// store caches the recorded response if it is cacheable.
+func (c *Cache) store(key string, req *http.Request, rw *recordWriter) {
+	if rw.skipped || rw.header == nil || !c.cacheableStatus(rw.status) {
+		return
+	}
+
+	cc := rw.header.Get("Cache-Control")
+	if hasDirective(cc, "no-store") || hasDirective(cc, "private") ||
+		hasDirective(cc, "no-cache") || rw.header.Get("Set-Cookie") != "" {
+		return
+	}
+
+	// Responses to authorized requests are only shared if explicitly allowed.
+	if req.Header.Get("Authorization") != "" && !hasDirective(cc, "public") && !hasDirective(cc, "s-maxage") {
+		return
+	}
+
+	ttl := c.config.TTL
+	if age, ok := maxAge(cc); ok {
+		ttl = age
+	}
+
+	if ttl <= 0 {
+		return
+	}
+
+	header := rw.header.Clone()
+	header.Del("X-Cache")
+
+	now := time.Now()
+	entry := &Entry{
+		Status:  rw.status,
+		Header:  header,
+		Body:    rw.buf.Bytes(),
+		Created: now,
+		Expires: now.Add(ttl),
+	}
+
+	var vary []string
+	for _, v := range header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				vary = append(vary, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+
+	if len(vary) == 0 {
+		c.config.Store.Set(key, entry)
+		return
+	}
+
+	for _, name := range vary {
+		if name == "*" {
+			return
+		}
+	}
+
+	c.config.Store.Set(key, &Entry{Vary: vary, Created: now, Expires: entry.Expires})
+	c.config.Store.Set(variantKey(key, vary, req), entry)
+}
+
+func (c *Cache) cacheableStatus(status int) bool {
+	for _, s := range c.config.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDirective reports whether the Cache-Control value cc contains directive.
+func hasDirective(cc, directive string) bool {
+	for _, d := range strings.Split(cc, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(d), "=")
+		if strings.EqualFold(name, directive) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxAge returns the s-maxage or max-age of the Cache-Control value cc.
+// s-maxage takes precedence since the cache is shared.
+func maxAge(cc string) (time.Duration, bool) {
+	var age time.Duration
+	var found bool
+	for _, d := range strings.Split(cc, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(d), "=")
+		if !ok {
+			continue
+		}
+
+		seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+		if err != nil {
+			continue
+		}
+
+		switch strings.ToLower(name) {
+		case "s-maxage":
+			return time.Duration(seconds) * time.Second, true
+		case "max-age":
+			age, found = time.Duration(seconds)*time.Second, true
+		}
+	}
+	return age, found
+}
+
+// recordWriter sends the response to the client while recording the
+// status, headers and body for the cache.
+type recordWriter struct {
+	http.ResponseWriter
+	header  http.Header  // snapshot of the headers when the status was written
+	buf     bytes.Buffer // recorded body
+	status  int          // status code of the response
+	maxSize int          // maximum size of the recorded body
+	skipped bool         // the response is too large or the connection was hijacked
+}
+
+func (rw *recordWriter) WriteHeader(code int) {
+	if rw.header != nil {
+		return
+	}
+
+	rw.status = code
+	rw.header = rw.ResponseWriter.Header().Clone()
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordWriter) Write(p []byte) (int, error) {
+	if rw.header == nil {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	if !rw.skipped {
+		if rw.maxSize >= 0 && rw.buf.Len()+len(p) > rw.maxSize {
+			rw.skipped = true
+			rw.buf = bytes.Buffer{}
+		} else {
+			rw.buf.Write(p)
+		}
+	}
+	return rw.ResponseWriter.Write(p)
+}
+
+// Flush sends any buffered data to the client.
+func (rw *recordWriter) Flush() {
+	if rw.header == nil {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack takes over the connection. Hijacked responses are not cached.
+func (rw *recordWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	rw.skipped = true
+	if h, ok := rw.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Unwrap returns the original ResponseWriter for http.ResponseController.
+func (rw *recordWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,228 @@
+package cache_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/abiiranathan/gor/gor/middleware/cache"
+)
+
+func get(r http.Handler, path string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCache(t *testing.T) {
+	c := cache.New()
+	r := gor.NewRouter()
+
+	calls := 0
+	r.Get("/products", func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		gor.SendString(w, fmt.Sprintf("products %d", calls))
+	}, c.Middleware())
+
+	w := get(r, "/products")
+	if w.Header().Get("X-Cache") != "MISS" || w.Body.String() != "products 1" {
+		t.Fatalf("expected a miss, got %s %q", w.Header().Get("X-Cache"), w.Body.String())
+	}
+
+	w = get(r, "/products")
+	if w.Header().Get("X-Cache") != "HIT" || w.Body.String() != "products 1" {
+		t.Fatalf("expected a hit, got %s %q", w.Header().Get("X-Cache"), w.Body.String())
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != gor.ContentTypeText {
+		t.Errorf("expected cached Content-Type %s, got %s", gor.ContentTypeText, ct)
+	}
+
+	// Different query strings are cached separately
+	if w = get(r, "/products?page=2"); w.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("expected a miss for another URL")
+	}
+
+	c.Invalidate(cache.Key("GET", "/products"))
+	if w = get(r, "/products"); w.Body.String() != "products 3" {
+		t.Errorf("expected a fresh response after Invalidate, got %q", w.Body.String())
+	}
+
+	c.InvalidatePrefix("GET /products")
+	get(r, "/products")
+	get(r, "/products?page=2")
+	if calls != 5 {
+		t.Errorf("expected 5 handler calls after InvalidatePrefix, got %d", calls)
+	}
+
+	stats := c.Stats()
+	if stats.Hits != 1 || stats.Misses != 5 {
+		t.Errorf("expected 1 hit and 5 misses, got %+v", stats)
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	c := cache.New()
+	r := gor.NewRouter()
+	r.Use(c.Middleware())
+
+	calls := 0
+	handler := func(cacheControl string) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			calls++
+			w.Header().Set("Cache-Control", cacheControl)
+			gor.SendString(w, "ok")
+		}
+	}
+
+	r.Get("/nostore", handler("no-store"))
+	r.Get("/private", handler("private, max-age=60"))
+	r.Get("/expired", handler("max-age=0"))
+	r.Get("/public", handler("public, max-age=60"))
+
+	tests := []struct {
+		path   string
+		cached bool
+	}{
+		{"/nostore", false},
+		{"/private", false},
+		{"/expired", false},
+		{"/public", true},
+	}
+
+	for _, tt := range tests {
+		calls = 0
+		get(r, tt.path)
+		get(r, tt.path)
+
+		if cached := calls == 1; cached != tt.cached {
+			t.Errorf("%s: expected cached=%v, handler called %d times", tt.path, tt.cached, calls)
+		}
+	}
+
+	// Requests with no-cache bypass the cache
+	calls = 0
+	if w := get(r, "/public", "Cache-Control", "no-cache"); w.Header().Get("X-Cache") == "HIT" || calls != 1 {
+		t.Errorf("expected no-cache request to bypass the cache")
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	c := cache.New(cache.Config{TTL: 10 * time.Millisecond})
+	r := gor.NewRouter()
+	r.Use(c.Middleware())
+
+	calls := 0
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		gor.SendString(w, "ok")
+	})
+
+	get(r, "/")
+	get(r, "/")
+	time.Sleep(20 * time.Millisecond)
+	get(r, "/")
+
+	if calls != 2 {
+		t.Errorf("expected entry to expire after TTL, handler called %d times", calls)
+	}
+}
+
+func TestCacheVary(t *testing.T) {
+	c := cache.New()
+	r := gor.NewRouter()
+	r.Use(c.Middleware())
+
+	calls := 0
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.Header().Set("Vary", "Accept-Language")
+		gor.SendString(w, req.Header.Get("Accept-Language"))
+	})
+
+	get(r, "/", "Accept-Language", "en")
+	get(r, "/", "Accept-Language", "fr")
+
+	if w := get(r, "/", "Accept-Language", "en"); w.Body.String() != "en" || w.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("expected cached en variant, got %s %q", w.Header().Get("X-Cache"), w.Body.String())
+	}
+
+	if w := get(r, "/", "Accept-Language", "fr"); w.Body.String() != "fr" || w.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("expected cached fr variant, got %s %q", w.Header().Get("X-Cache"), w.Body.String())
+	}
+
+	if calls != 2 {
+		t.Errorf("expected 2 handler calls, got %d", calls)
+	}
+}
+
+func TestCacheMaxBodySize(t *testing.T) {
+	c := cache.New(cache.Config{MaxBodySize: 4})
+	r := gor.NewRouter()
+	r.Use(c.Middleware())
+
+	calls := 0
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		gor.SendString(w, "too large")
+	})
+
+	get(r, "/")
+	if w := get(r, "/"); w.Body.String() != "too large" {
+		t.Errorf("expected full body, got %q", w.Body.String())
+	}
+
+	if calls != 2 {
+		t.Errorf("expected large responses not to be cached, handler called %d times", calls)
+	}
+}
+
+func TestCacheCredentials(t *testing.T) {
+	for _, allowCookies := range []bool{false, true} {
+		c := cache.New(cache.Config{AllowCookies: allowCookies})
+		r := gor.NewRouter()
+
+		r.Get("/account", func(w http.ResponseWriter, req *http.Request) {
+			user := req.Header.Get("Authorization")
+			if cookie, err := req.Cookie("session"); err == nil {
+				user = cookie.Value
+			}
+			gor.SendString(w, "account of "+user)
+		}, c.Middleware())
+
+		r.Get("/public", func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Cache-Control", "public, max-age=60")
+			gor.SendString(w, "public page")
+		}, c.Middleware())
+
+		// A response to an authorized request is not served to other users.
+		get(r, "/account", "Authorization", "Bearer alice")
+		if w := get(r, "/account", "Authorization", "Bearer bob"); w.Body.String() != "account of Bearer bob" {
+			t.Errorf("expected the page of bob, got %q", w.Body.String())
+		}
+
+		// Unless it is public.
+		get(r, "/public", "Authorization", "Bearer alice")
+		if w := get(r, "/public"); w.Header().Get("X-Cache") != "HIT" {
+			t.Errorf("expected a public response to be cached, got %s", w.Header().Get("X-Cache"))
+		}
+
+		// Requests with cookies bypass the cache by default.
+		w := get(r, "/account", "Cookie", "session=alice")
+		if cached := w.Header().Get("X-Cache") != ""; cached != allowCookies {
+			t.Errorf("AllowCookies %v: unexpected X-Cache %q", allowCookies, w.Header().Get("X-Cache"))
+		}
+
+		w = get(r, "/account", "Cookie", "session=bob")
+		if want := "account of bob"; !allowCookies && w.Body.String() != want {
+			t.Errorf("expected %q, got %q", want, w.Body.String())
+		}
+	}
+}
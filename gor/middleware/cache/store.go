@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a cached response.
+type Entry struct {
+	Status  int         // Status code of the response
+	Header  http.Header // Response headers
+	Body    []byte      // Response body
+	Created time.Time   // Time the response was cached
+	Expires time.Time   // Time after which the entry is stale
+
+	// Vary lists the request headers the response varies on.
+	// Entries with Vary only index the variants stored under their own keys.
+	Vary []string
+}
+
+// expired reports whether the entry is stale at now.
+func (e *Entry) expired(now time.Time) bool {
+	return !e.Expires.IsZero() && now.After(e.Expires)
+}
+
+// Store stores cached responses. Implementations must be safe for concurrent use.
+// Entries must not be returned by Get after their Expires time.
+type Store interface {
+	// Get returns the entry stored at key.
+	Get(key string) (*Entry, bool)
+
+	// Set stores entry at key, replacing any existing entry.
+	Set(key string, entry *Entry)
+
+	// Delete removes the entry stored at key.
+	Delete(key string)
+
+	// DeletePrefix removes all entries whose key starts with prefix.
+	DeletePrefix(prefix string)
+}
+
+// MemoryStore is an in-memory Store. Expired entries are removed when they are read.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries map[string]*Entry
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*Entry)}
+}
+
+// Get returns the entry stored at key if it has not expired.
+func (s *MemoryStore) Get(key string) (*Entry, bool) {
+	s.mu.RLock()
+	e, ok := s.entries[key]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	if e.expired(time.Now()) {
+		s.mu.Lock()
+		if s.entries[key] == e {
+			delete(s.entries, key)
+		}
+		s.mu.Unlock()
+		return nil, false
+	}
+	return e, true
+}
+
+// Set stores entry at key.
+func (s *MemoryStore) Set(key string, entry *Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = entry
+}
+
+// Delete removes the entry stored at key.
+func (s *MemoryStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// DeletePrefix removes all entries whose key starts with prefix.
+func (s *MemoryStore) DeletePrefix(prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// Len returns the number of stored entries, including expired ones not yet removed.
+func (s *MemoryStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries)
+}
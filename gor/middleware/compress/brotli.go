@@ -0,0 +1,23 @@
+//go:build brotli
+
+package compress
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+)
+
+func init() {
+	Register(Encoding{
+		Name: "br",
+		Levels: map[Preset]int{
+			PresetDefault: 5,
+			PresetFastest: brotli.BestSpeed,
+			PresetBest:    brotli.BestCompression,
+		},
+		NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			return brotli.NewWriterLevel(w, level), nil
+		},
+	})
+}
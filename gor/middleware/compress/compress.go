@@ -0,0 +1,296 @@
+// Package compress implements a middleware that compresses responses with
+// the best encoding accepted by the client.
+//
+// gzip and deflate are always available. Brotli (br) and zstd are available
+// when building with the "brotli" and "zstd" build tags:
+//
+//	go build -tags brotli,zstd
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/abiiranathan/gor/gor"
+)
+
+// DefaultMinSize is the minimum response size in bytes that is compressed.
+const DefaultMinSize = 1024
+
+// Config is the configuration for the compress middleware.
+type Config struct {
+	// Encodings lists the encodings to use, from most to least preferred.
+	// When the client accepts several encodings with the same quality,
+	// the first one in this list is chosen. Unregistered encodings are ignored.
+	// Default is all registered encodings. See Registered.
+	Encodings []string
+
+	// Preset selects the compression level of encodings without a level in Levels.
+	Preset Preset
+
+	// Levels sets the compression level per encoding, e.g {"gzip": 6}.
+	Levels map[string]int
+
+	// MinSize is the minimum response size in bytes that is compressed.
+	// Smaller responses are sent as is. Default is DefaultMinSize.
+	// A negative value compresses all responses.
+	MinSize int
+
+	// Skip is a list of functions that return true if the response should not be compressed.
+	Skip []func(r *http.Request) bool
+}
+
+// New creates a compress middleware with the default configuration.
+// skip functions return true if the response should not be compressed.
+func New(skip ...func(r *http.Request) bool) gor.Middleware {
+	return NewWithConfig(Config{Skip: skip})
+}
+
+// NewWithConfig creates a compress middleware with the given configuration.
+func NewWithConfig(config Config) gor.Middleware {
+	if len(config.Encodings) == 0 {
+		config.Encodings = Registered()
+	}
+
+	if config.MinSize == 0 {
+		config.MinSize = DefaultMinSize
+	}
+
+	pools := make(map[string]*writerPool)
+	var names []string
+	for _, name := range config.Encodings {
+		e, ok := lookup(name)
+		if !ok {
+			continue
+		}
+
+		level, ok := config.Levels[name]
+		if !ok {
+			level = e.Levels[config.Preset]
+		}
+		pools[name] = &writerPool{encoding: e, level: level}
+		names = append(names, name)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, s := range config.Skip {
+				if s(r) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := Negotiate(r.Header.Get("Accept-Encoding"), names)
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				pool:           pools[encoding],
+				status:         http.StatusOK,
+				minSize:        config.MinSize,
+			}
+			defer cw.close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// Negotiate returns the encoding in offered with the highest quality in the
+// Accept-Encoding header value. Ties are broken by the order of offered.
+// It returns "" if the client accepts none of the offered encodings.
+func Negotiate(acceptEncoding string, offered []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+
+		if name == "*" {
+			wildcard = q
+		} else if name != "" {
+			qualities[name] = q
+		}
+	}
+
+	var best string
+	bestQ := 0.0
+	for _, name := range offered {
+		q, ok := qualities[name]
+		if !ok {
+			q = wildcard
+		}
+
+		if q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the response until minSize bytes are written,
+// then compresses the rest of it. Smaller responses are sent uncompressed.
+type compressWriter struct {
+	http.ResponseWriter
+	pool        *writerPool
+	zw          io.WriteCloser // compressing writer, nil until compression starts
+	buf         bytes.Buffer   // response body buffered before deciding to compress
+	status      int            // status code of the response
+	minSize     int            // minimum size of a compressed response
+	wroteHeader bool           // WriteHeader was called by the handler
+	decided     bool           // the header was sent to the original ResponseWriter
+}
+
+func (c *compressWriter) WriteHeader(code int) {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+	c.status = code
+
+	// Responses without a body, or already encoded by the handler, are sent as is.
+	if !bodyAllowed(code) || c.Header().Get("Content-Encoding") != "" {
+		c.start(false)
+	}
+}
+
+func (c *compressWriter) Write(p []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+
+	if !c.decided {
+		if c.buf.Len()+len(p) < c.minSize {
+			return c.buf.Write(p)
+		}
+
+		c.buf.Write(p)
+		c.start(true)
+		if err := c.flushBuffer(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if c.zw != nil {
+		return c.zw.Write(p)
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+// bodyAllowed reports whether a response with status may have a body.
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// start sends the header to the original ResponseWriter, compressing
+// the body if compress is true. If the compressing writer cannot be created,
+// the body is sent uncompressed.
+func (c *compressWriter) start(compress bool) {
+	if c.decided {
+		return
+	}
+	c.decided = true
+
+	if compress {
+		if zw, err := c.pool.get(c.ResponseWriter); err == nil {
+			c.zw = zw
+			h := c.Header()
+			h.Set("Content-Encoding", c.pool.encoding.Name)
+			h.Del("Content-Length")
+		}
+	}
+	c.ResponseWriter.WriteHeader(c.status)
+}
+
+// flushBuffer writes the buffered body to the compressing or original writer.
+func (c *compressWriter) flushBuffer() error {
+	if c.buf.Len() == 0 {
+		return nil
+	}
+
+	var err error
+	if c.zw != nil {
+		_, err = c.zw.Write(c.buf.Bytes())
+	} else {
+		_, err = c.ResponseWriter.Write(c.buf.Bytes())
+	}
+	c.buf.Reset()
+	return err
+}
+
+// close sends small buffered responses uncompressed and finishes the
+// compressed stream of larger ones.
+func (c *compressWriter) close() {
+	if !c.wroteHeader {
+		// Nothing was written by the handler.
+		return
+	}
+
+	if !c.decided {
+		c.start(false)
+		c.flushBuffer()
+		return
+	}
+
+	if c.zw != nil {
+		c.zw.Close()
+		c.pool.put(c.zw)
+		c.zw = nil
+	}
+}
+
+// Flush compresses and sends any buffered data to the client.
+// Flushed responses are compressed regardless of their size.
+func (c *compressWriter) Flush() {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+
+	if !c.decided {
+		c.start(true)
+		c.flushBuffer()
+	}
+
+	if f, ok := c.zw.(flusher); ok {
+		f.Flush()
+	}
+
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (c *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := c.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Unwrap returns the original ResponseWriter for http.ResponseController.
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
@@ -0,0 +1,155 @@
+package compress_test
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/abiiranathan/gor/gor/middleware/compress"
+)
+
+func TestNegotiate(t *testing.T) {
+	offered := []string{"br", "gzip", "deflate"}
+
+	tests := []struct {
+		accept   string
+		expected string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br", "br"},
+		{"gzip;q=1.0, br;q=0.5", "gzip"},
+		{"br;q=0, *", "gzip"},
+		{"identity", ""},
+		{"*;q=0", ""},
+		{"GZIP", "gzip"},
+	}
+
+	for _, tt := range tests {
+		if got := compress.Negotiate(tt.accept, offered); got != tt.expected {
+			t.Errorf("Negotiate(%q): expected %q, got %q", tt.accept, tt.expected, got)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat("hello world ", 200)
+
+	r := gor.NewRouter()
+	r.Use(compress.New())
+	r.Get("/large", func(w http.ResponseWriter, req *http.Request) {
+		gor.SendString(w, large)
+	})
+	r.Get("/small", func(w http.ResponseWriter, req *http.Request) {
+		gor.SendString(w, "hello")
+	})
+	r.Get("/nocontent", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		path     string
+		accept   string
+		encoding string
+		status   int
+		body     string
+	}{
+		{"/large", "gzip", "gzip", http.StatusOK, large},
+		{"/large", "", "", http.StatusOK, large},
+		{"/small", "gzip", "", http.StatusOK, "hello"},
+		{"/nocontent", "gzip", "", http.StatusNoContent, ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept-Encoding", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, w.Code)
+		}
+
+		if enc := w.Header().Get("Content-Encoding"); enc != tt.encoding {
+			t.Errorf("%s: expected Content-Encoding %q, got %q", tt.path, tt.encoding, enc)
+		}
+
+		body := w.Body.String()
+		if tt.encoding == "gzip" {
+			zr, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, _ := io.ReadAll(zr)
+			body = string(b)
+		}
+
+		if body != tt.body {
+			t.Errorf("%s: unexpected body of length %d", tt.path, len(body))
+		}
+	}
+}
+
+func TestCompressLevelsAndEncodings(t *testing.T) {
+	large := strings.Repeat("hello world ", 200)
+
+	r := gor.NewRouter()
+	r.Use(compress.NewWithConfig(compress.Config{
+		Encodings: []string{"deflate", "gzip"},
+		Levels:    map[string]int{"gzip": gzip.BestSpeed},
+		Preset:    compress.PresetBest,
+	}))
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		gor.SendString(w, large)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if enc := w.Header().Get("Content-Encoding"); enc != "deflate" {
+		t.Errorf("expected the preferred deflate encoding, got %q", enc)
+	}
+
+	if vary := w.Header().Get("Vary"); vary != "Accept-Encoding" {
+		t.Errorf("expected Vary: Accept-Encoding, got %q", vary)
+	}
+}
+
+func TestCompressFlush(t *testing.T) {
+	r := gor.NewRouter()
+	r.Use(compress.New())
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "chunk")
+		http.NewResponseController(w).Flush()
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if !w.Flushed {
+		t.Error("expected the response to be flushed")
+	}
+
+	if enc := w.Header().Get("Content-Encoding"); enc != "gzip" {
+		t.Fatalf("expected flushed response to be compressed, got %q", enc)
+	}
+
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b, _ := io.ReadAll(zr); string(b) != "chunk" {
+		t.Errorf("expected chunk, got %q", b)
+	}
+}
@@ -0,0 +1,154 @@
+package compress
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Preset selects the compression level of every encoding without
+// configuring each level separately.
+type Preset int
+
+const (
+	PresetDefault Preset = iota // Balance compression ratio and latency
+	PresetFastest               // Lowest latency, larger responses
+	PresetBest                  // Smallest responses, highest latency
+)
+
+// Encoding describes a content encoding supported by the middleware.
+// Additional encodings are enabled with Register. Brotli (br) and zstd are
+// registered when building with the "brotli" and "zstd" build tags.
+type Encoding struct {
+	// Name is the content coding token, e.g "gzip".
+	Name string
+
+	// Levels maps each preset to a compression level for NewWriter.
+	Levels map[Preset]int
+
+	// NewWriter returns a writer that compresses into w at level.
+	// Writers that implement Reset(io.Writer) are pooled and reused.
+	// Writers that implement Flush() error are flushed when the response is flushed.
+	NewWriter func(w io.Writer, level int) (io.WriteCloser, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Encoding{}
+
+	// defaultOrder lists encodings from most to least preferred when the client
+	// accepts several with the same quality.
+	defaultOrder = []string{"zstd", "br", "gzip", "deflate"}
+)
+
+// Register makes an encoding available to the middleware.
+// Registering an encoding with the same name replaces it.
+func Register(e Encoding) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[e.Name] = e
+}
+
+// lookup returns the registered encoding named name.
+func lookup(name string) (Encoding, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	e, ok := registry[name]
+	return e, ok
+}
+
+// Registered returns the names of the registered encodings in the default
+// order of preference.
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	var names []string
+	for _, name := range defaultOrder {
+		if _, ok := registry[name]; ok {
+			names = append(names, name)
+		}
+	}
+
+	for name := range registry {
+		known := false
+		for _, n := range defaultOrder {
+			if n == name {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func init() {
+	Register(Encoding{
+		Name: "gzip",
+		Levels: map[Preset]int{
+			PresetDefault: gzip.DefaultCompression,
+			PresetFastest: gzip.BestSpeed,
+			PresetBest:    gzip.BestCompression,
+		},
+		NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			return gzip.NewWriterLevel(w, level)
+		},
+	})
+
+	Register(Encoding{
+		Name: "deflate",
+		Levels: map[Preset]int{
+			PresetDefault: flate.DefaultCompression,
+			PresetFastest: flate.BestSpeed,
+			PresetBest:    flate.BestCompression,
+		},
+		NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			return flate.NewWriter(w, level)
+		},
+	})
+}
+
+// resetter is implemented by writers that can be reused for another response.
+type resetter interface {
+	Reset(w io.Writer)
+}
+
+// flusher is implemented by writers that can flush pending compressed data.
+type flusher interface {
+	Flush() error
+}
+
+// writerPool pools the writers of one encoding at one level.
+type writerPool struct {
+	encoding Encoding
+	level    int
+	pool     sync.Pool
+}
+
+// get returns a writer compressing into w.
+func (p *writerPool) get(w io.Writer) (io.WriteCloser, error) {
+	if zw, ok := p.pool.Get().(io.WriteCloser); ok {
+		zw.(resetter).Reset(w)
+		return zw, nil
+	}
+
+	zw, err := p.encoding.NewWriter(w, p.level)
+	if err != nil {
+		return nil, fmt.Errorf("compress: %s writer: %w", p.encoding.Name, err)
+	}
+	return zw, nil
+}
+
+// put returns zw to the pool if it can be reset.
+func (p *writerPool) put(zw io.WriteCloser) {
+	if r, ok := zw.(resetter); ok {
+		r.Reset(io.Discard)
+		p.pool.Put(zw)
+	}
+}
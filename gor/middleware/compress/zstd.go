@@ -0,0 +1,24 @@
+//go:build zstd
+
+package compress
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func init() {
+	Register(Encoding{
+		Name: "zstd",
+		Levels: map[Preset]int{
+			PresetDefault: int(zstd.SpeedDefault),
+			PresetFastest: int(zstd.SpeedFastest),
+			PresetBest:    int(zstd.SpeedBestCompression),
+		},
+		NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			// Responses are compressed in a single goroutine to keep latency predictable.
+			return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevel(level)), zstd.WithEncoderConcurrency(1))
+		},
+	})
+}
@@ -1,12 +1,16 @@
 package csrf
 
 import (
-	"context"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
 	"errors"
 	"html/template"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/abiiranathan/gor/gor"
@@ -24,10 +28,32 @@ const (
 	headerKeyName = "X-CSRF-Token"
 	formKeyName   = "csrf_token"
 	sessionName   = "csrf_session"
+	cookieName    = "csrf_token"
+)
+
+// Mode selects where the expected CSRF token is kept.
+type Mode int
+
+const (
+	// SessionMode keeps the token in a gorilla/sessions store. This is the default.
+	SessionMode Mode = iota
+
+	// DoubleSubmit keeps the token in a cookie signed with an HMAC key.
+	// Requests must submit the same token in the header or form.
+	// It is stateless and does not require a sessions.Store. See WithKey.
+	//
+	// Bind the token to the session with WithSessionID. Otherwise any token
+	// issued by the server is valid for any client, and an attacker able to
+	// set cookies for the domain, e.g from a sibling subdomain, can plant a
+	// cookie and token pair of their own for the victim.
+	DoubleSubmit
 )
 
 type TokenContextType string
 
+// configKey is the context key of the middleware configuration, used by Rotate.
+const configKey = TokenContextType("csrf_config")
+
 var (
 	ErrMissingHeader  = errors.New("missing CSRF token in request header")
 	ErrMissingFormKey = errors.New("missing CSRF token in request body")
@@ -84,6 +110,34 @@ type csrf struct {
 	// The middleware will look for the CSRF token in the session first, before looking in the request.
 	Store sessions.Store
 
+	// Mode selects where the expected token is kept. Defaults to SessionMode.
+	Mode Mode
+
+	// Key signs the double submit cookie. Required with DoubleSubmit.
+	Key []byte
+
+	// Name of the double submit cookie. Defaults to "csrf_token".
+	CookieName string
+
+	// SessionID returns an identifier of the session or user of the request,
+	// bound to the double submit token by its signature. See WithSessionID.
+	SessionID func(req *http.Request) string
+
+	// PerRequestTokens rotates the token after every verified unsafe request,
+	// so that each token can be used only once.
+	PerRequestTokens bool
+
+	// TrustedOrigins are the hosts, besides the request host, allowed in the Origin
+	// or Referer header of unsafe requests, e.g "app.example.com" or "*.example.com".
+	TrustedOrigins []string
+
+	// Skip is a list of functions that return true if the request bypasses the middleware.
+	Skip []func(req *http.Request) bool
+
+	// SameSite is the SameSite attribute of the double submit cookie, and of the
+	// session cookie if the store does not set one. Defaults to http.SameSiteLaxMode.
+	SameSite http.SameSite
+
 	// Must satisfy the CSRFTokenGetter interface.
 	// The function to call to get the CSRF token from the request.
 	tokenGetter func(req *http.Request) (string, error)
@@ -124,7 +178,9 @@ func New(store sessions.Store, options ...CSRFOption) gor.Middleware {
 			http.Error(w, "Forbidden", http.StatusForbidden)
 			return false
 		},
-		Store: store,
+		Store:      store,
+		CookieName: cookieName,
+		SameSite:   http.SameSiteLaxMode,
 	}
 
 	for _, opt := range options {
@@ -154,24 +210,232 @@ func WithSessionName(name string) CSRFOption {
 	}
 }
 
-// Verify the CSRF token in the request against the token in the session.
-func (c *csrf) verifyToken(req *http.Request) bool {
-	session, err := c.Store.Get(req, sessionName)
-	if err != nil {
+// WithMode selects where the expected token is kept.
+// The store passed to New may be nil with DoubleSubmit.
+//
+//	mux.Use(csrf.New(nil, csrf.WithMode(csrf.DoubleSubmit), csrf.WithKey(key)))
+func WithMode(mode Mode) CSRFOption {
+	return func(c *csrf) {
+		c.Mode = mode
+	}
+}
+
+// WithPerRequestTokens rotates the token after every verified unsafe request.
+// Handlers must render the new token, e.g with TokenFromRequest or {{ csrf_field . }}.
+func WithPerRequestTokens(perRequest bool) CSRFOption {
+	return func(c *csrf) {
+		c.PerRequestTokens = perRequest
+	}
+}
+
+// WithTrustedOrigins allows unsafe requests from hosts other than the request host.
+// A leading "*." matches any subdomain, e.g "*.example.com".
+func WithTrustedOrigins(hosts ...string) CSRFOption {
+	return func(c *csrf) {
+		c.TrustedOrigins = append(c.TrustedOrigins, hosts...)
+	}
+}
+
+// WithSkip adds functions that return true if the request bypasses the middleware.
+// See SkipPaths and SkipMethods.
+func WithSkip(skip ...func(req *http.Request) bool) CSRFOption {
+	return func(c *csrf) {
+		c.Skip = append(c.Skip, skip...)
+	}
+}
+
+// WithSameSite sets the SameSite attribute of the token cookies.
+func WithSameSite(sameSite http.SameSite) CSRFOption {
+	return func(c *csrf) {
+		c.SameSite = sameSite
+	}
+}
+
+// SkipPaths returns a skip function matching requests for any of paths.
+// Paths ending with "/" match all paths under them.
+func SkipPaths(paths ...string) func(req *http.Request) bool {
+	return func(req *http.Request) bool {
+		for _, p := range paths {
+			if req.URL.Path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(req.URL.Path, p)) {
+				return true
+			}
+		}
 		return false
 	}
+}
 
-	expectedToken, ok := session.Values["token"].(string)
-	if !ok {
+// SkipMethods returns a skip function matching requests with any of methods.
+func SkipMethods(methods ...string) func(req *http.Request) bool {
+	return func(req *http.Request) bool {
+		return slices.Contains(methods, req.Method)
+	}
+}
+
+// verifyOrigin reports whether the Origin, or the Referer if there is no Origin,
+// of an unsafe request is the request host or a trusted origin.
+// Requests without both headers, e.g from non-browser clients, are allowed
+// and rely on the token check alone.
+func (c *csrf) verifyOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		origin = req.Header.Get("Referer")
+		if origin == "" {
+			return true
+		}
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		// Includes the opaque "null" origin of sandboxed documents.
 		return false
 	}
 
+	if strings.EqualFold(u.Host, req.Host) {
+		return true
+	}
+
+	for _, trusted := range c.TrustedOrigins {
+		if matchHost(u.Host, trusted) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchHost reports whether host matches the trusted pattern.
+// A scheme in the pattern is ignored and "*." matches any subdomain.
+func matchHost(host, pattern string) bool {
+	if _, rest, ok := strings.Cut(pattern, "://"); ok {
+		pattern = rest
+	}
+
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		return len(host) > len(suffix) && strings.HasSuffix(strings.ToLower(host), strings.ToLower(suffix))
+	}
+	return strings.EqualFold(host, pattern)
+}
+
+// WithKey sets the HMAC key that signs the double submit cookie.
+func WithKey(key []byte) CSRFOption {
+	return func(c *csrf) {
+		c.Key = key
+	}
+}
+
+// WithCookieName sets the name of the double submit cookie.
+func WithCookieName(name string) CSRFOption {
+	return func(c *csrf) {
+		c.CookieName = name
+	}
+}
+
+// WithSessionID binds the double submit token to the session of the request,
+// as the signed double submit cookie pattern of OWASP. The token is signed
+// with the identifier returned by fn, e.g the session ID or the user ID, so
+// that a token issued to another session is rejected. The token is replaced
+// when the identifier changes, e.g on login.
+func WithSessionID(fn func(req *http.Request) string) CSRFOption {
+	return func(c *csrf) {
+		c.SessionID = fn
+	}
+}
+
+// Verify the CSRF token in the request against the expected token.
+func (c *csrf) verifyToken(req *http.Request, expectedToken string) bool {
 	token, err := c.tokenGetter(req)
 	if err != nil {
 		return false
 	}
 
-	return token == expectedToken
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expectedToken)) == 1
+}
+
+// sessionToken returns the token stored in the session, creating and saving one if missing.
+func (c *csrf) sessionToken(w http.ResponseWriter, req *http.Request) (string, error) {
+	session, err := c.Store.Get(req, sessionName)
+	if err != nil {
+		return "", err
+	}
+
+	token, ok := session.Values["token"].(string)
+	if ok && token != "" {
+		return token, nil
+	}
+	return c.rotateSession(w, req)
+}
+
+// rotateSession stores a new token in the session.
+func (c *csrf) rotateSession(w http.ResponseWriter, req *http.Request) (string, error) {
+	session, err := c.Store.Get(req, sessionName)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := createToken()
+	if err != nil {
+		return "", err
+	}
+
+	session.Values["token"] = token
+	if session.Options != nil && session.Options.SameSite == 0 {
+		session.Options.SameSite = c.SameSite
+	}
+
+	if err := session.Save(req, w); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// cookieToken returns the token in the double submit cookie if its signature is valid.
+// Otherwise a new signed token is created and set in the cookie.
+func (c *csrf) cookieToken(w http.ResponseWriter, req *http.Request) (string, error) {
+	if cookie, err := req.Cookie(c.CookieName); err == nil && c.validSignature(req, cookie.Value) {
+		return cookie.Value, nil
+	}
+	return c.rotateCookie(w, req)
+}
+
+// rotateCookie sets a new signed token in the double submit cookie.
+func (c *csrf) rotateCookie(w http.ResponseWriter, req *http.Request) (string, error) {
+	token, err := createToken()
+	if err != nil {
+		return "", err
+	}
+	token = token + "." + c.sign(req, token)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     c.CookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   req.TLS != nil || c.SameSite == http.SameSiteNoneMode,
+		SameSite: c.SameSite,
+	})
+	return token, nil
+}
+
+// sign returns the base64 encoded HMAC-SHA256 of token and the session ID
+// of the request, if SessionID is set.
+func (c *csrf) sign(req *http.Request, token string) string {
+	mac := hmac.New(sha256.New, c.Key)
+	if c.SessionID != nil {
+		// The token never contains "!", so the session ID cannot be confused with it.
+		mac.Write([]byte(c.SessionID(req)))
+		mac.Write([]byte("!"))
+	}
+	mac.Write([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// validSignature reports whether the signed token was signed with the key
+// for the session of the request.
+func (c *csrf) validSignature(req *http.Request, signed string) bool {
+	i := strings.LastIndexByte(signed, '.')
+	if i < 0 {
+		return false
+	}
+	return hmac.Equal([]byte(signed[i+1:]), []byte(c.sign(req, signed[:i])))
 }
 
 // createToken generates a random CSRF token.
@@ -188,33 +452,34 @@ func createToken() (string, error) {
 
 // Middleware implements the CSRF protection middleware.
 func (c *csrf) Middleware(next http.Handler) http.Handler {
-	if c.Store == nil {
-		panic("Store cannot be nil")
+	getToken := c.sessionToken
+	switch c.Mode {
+	case DoubleSubmit:
+		if len(c.Key) == 0 {
+			panic("Key cannot be empty in DoubleSubmit mode")
+		}
+		getToken = c.cookieToken
+	default:
+		if c.Store == nil {
+			panic("Store cannot be nil")
+		}
 	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for _, skip := range c.Skip {
+			if skip(req) {
+				next.ServeHTTP(w, req)
+				return
+			}
+		}
+
 		// Get or create CSRF token.
-		session, err := c.Store.Get(req, sessionName)
+		token, err := getToken(w, req)
 		if err != nil {
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
-
-		token, ok := session.Values["token"].(string)
-		if !ok || token == "" {
-			token, err = createToken()
-			if err != nil {
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-				return
-			}
-
-			session.Values["token"] = token
-			err = session.Save(req, w)
-			if err != nil {
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-				return
-			}
-		}
+		gor.SetContextValue(req, configKey, c)
 
 		// Skip CSRF check for safe methods (GET, HEAD, OPTIONS, TRACE).
 		if req.Method == http.MethodGet || req.Method == http.MethodHead ||
@@ -222,15 +487,15 @@ func (c *csrf) Middleware(next http.Handler) http.Handler {
 			// We still need to set the token in the response header for GET requests.
 			// if the key is not valid, the next request will fail.
 			w.Header().Set(c.HeaderKeyName, token)
-			gor.SetContextValue(req, TokenContextType(formKeyName), token)
+			setToken(req, token)
 
 			// fmt.Println("Token:", token)
 			next.ServeHTTP(w, req)
 			return
 		}
 
-		// Verify CSRF token.
-		if !c.verifyToken(req) {
+		// Verify the origin and the CSRF token.
+		if !c.verifyOrigin(req) || !c.verifyToken(req, token) {
 			if c.ErrorHandler != nil && c.ErrorHandler(w, req) {
 				return
 			}
@@ -238,14 +503,55 @@ func (c *csrf) Middleware(next http.Handler) http.Handler {
 			return
 		}
 
-		ctx := context.WithValue(req.Context(), TokenContextType(formKeyName), token)
-		*req = *req.WithContext(ctx)
+		if c.PerRequestTokens {
+			if _, err := c.rotate(w, req); err != nil {
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			setToken(req, token)
+		}
 
 		// Continue with the next handler if all checks pass.
 		next.ServeHTTP(w, req)
 	})
 }
 
+// Rotate replaces the token of the session or double submit cookie with a new one.
+// Call it after login and privilege changes to prevent session fixation.
+// The new token is returned and placed in the request context for templates.
+// Rotate must be called before the response header is written
+// by a handler behind the csrf middleware.
+func Rotate(w http.ResponseWriter, req *http.Request) (string, error) {
+	c, ok := gor.GetContextValue(req, configKey).(*csrf)
+	if !ok {
+		return "", errors.New("csrf: Rotate called without the csrf middleware")
+	}
+	return c.rotate(w, req)
+}
+
+// rotate replaces the token and exposes the new one in the response header and request context.
+func (c *csrf) rotate(w http.ResponseWriter, req *http.Request) (string, error) {
+	var token string
+	var err error
+	if c.Mode == DoubleSubmit {
+		token, err = c.rotateCookie(w, req)
+	} else {
+		token, err = c.rotateSession(w, req)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	w.Header().Set(c.HeaderKeyName, token)
+	setToken(req, token)
+	return token, nil
+}
+
+// TokenFromRequest returns the token placed in the request context by the middleware.
+// It is available to templates as "csrf_token", returned by {{ csrf_token . }}
+// and rendered in a hidden input by {{ csrf_field . }}.
 func TokenFromRequest(req *http.Request) string {
 	token, ok := gor.GetContextValue(req, TokenContextType(formKeyName)).(string)
 	if !ok {
@@ -253,3 +559,10 @@ func TokenFromRequest(req *http.Request) string {
 	}
 	return token
 }
+
+// setToken places the token in the request context, for TokenFromRequest
+// and the csrf_token and csrf_field template functions.
+func setToken(req *http.Request, token string) {
+	gor.SetContextValue(req, TokenContextType(formKeyName), token)
+	gor.SetCSRFToken(req, token)
+}
@@ -3,8 +3,13 @@ package csrf_test
 import (
 	"bytes"
 	"encoding/json"
+	"html"
+	"html/template"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/abiiranathan/gor/gor"
@@ -81,3 +86,300 @@ func TestCSRF(t *testing.T) {
 	// 	t.Errorf("POST /csrf failed: %d", w.Code)
 	// }
 }
+
+func TestCSRFDoubleSubmit(t *testing.T) {
+	router := gor.NewRouter()
+	router.Use(csrf.New(nil, csrf.WithMode(csrf.DoubleSubmit), csrf.WithKey([]byte("super secret key"))))
+
+	router.Get("/csrf", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(csrf.TokenFromRequest(r)))
+	})
+
+	router.Post("/csrf", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	req := httptest.NewRequest("GET", "/csrf", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	token := w.Header().Get("X-CSRF-Token")
+	cookies := w.Result().Cookies()
+	if token == "" || len(cookies) != 1 || cookies[0].Value != token || w.Body.String() != token {
+		t.Fatalf("expected the token in the header, body and cookie, got %q and %v", token, cookies)
+	}
+
+	tests := []struct {
+		name   string
+		cookie string
+		header string
+		status int
+	}{
+		{"valid", token, token, http.StatusOK},
+		{"missing header", token, "", http.StatusForbidden},
+		{"missing cookie", "", token, http.StatusForbidden},
+		{"mismatch", token, token + "x", http.StatusForbidden},
+		{"forged signature", "forged.signature", "forged.signature", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/csrf", nil)
+		if tt.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "csrf_token", Value: tt.cookie})
+		}
+
+		if tt.header != "" {
+			req.Header.Set("X-CSRF-Token", tt.header)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, w.Code)
+		}
+	}
+}
+
+func TestCSRFDoubleSubmitSessionID(t *testing.T) {
+	router := gor.NewRouter()
+	router.Use(csrf.New(nil,
+		csrf.WithMode(csrf.DoubleSubmit),
+		csrf.WithKey([]byte("super secret key")),
+		csrf.WithSessionID(func(req *http.Request) string {
+			if c, err := req.Cookie("session"); err == nil {
+				return c.Value
+			}
+			return ""
+		}),
+	))
+
+	router.Get("/csrf", func(w http.ResponseWriter, r *http.Request) {})
+	router.Post("/csrf", func(w http.ResponseWriter, r *http.Request) {})
+
+	request := func(method, session, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/csrf", nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		if token != "" {
+			req.AddCookie(&http.Cookie{Name: "csrf_token", Value: token})
+			req.Header.Set("X-CSRF-Token", token)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// A token issued to the attacker's session.
+	token := request("GET", "attacker", "").Header().Get("X-CSRF-Token")
+
+	if w := request("POST", "attacker", token); w.Code != http.StatusOK {
+		t.Errorf("expected the token to be valid for its session, got %d", w.Code)
+	}
+
+	// Planted in the victim's browser.
+	if w := request("POST", "victim", token); w.Code != http.StatusForbidden {
+		t.Errorf("expected the token to be rejected for another session, got %d", w.Code)
+	}
+
+	if w := request("GET", "victim", token); w.Header().Get("X-CSRF-Token") == token {
+		t.Error("expected a new token for another session")
+	}
+}
+
+func TestCSRFRotateAndTemplateField(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "form.html"), []byte(`<form>{{ csrf_field . }}</form>`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templ, err := gor.ParseTemplatesRecursive(dir, template.FuncMap{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gor.NewRouter(gor.PassContextToViews(true), gor.WithTemplates(templ))
+	router.Use(csrf.New(nil, csrf.WithMode(csrf.DoubleSubmit), csrf.WithKey([]byte("super secret key"))))
+
+	router.Get("/form", func(w http.ResponseWriter, r *http.Request) {
+		router.Render(w, r, "form.html", gor.Map{})
+	})
+
+	router.Post("/login", func(w http.ResponseWriter, r *http.Request) {
+		token, err := csrf.Rotate(w, r)
+		if err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(token))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/form", nil))
+
+	token := w.Header().Get("X-CSRF-Token")
+	expected := `<input type="hidden" name="csrf_token" value="` + template.HTMLEscapeString(token) + `">`
+	if !strings.Contains(w.Body.String(), expected) {
+		t.Fatalf("expected %q in the form, got %q", expected, w.Body.String())
+	}
+
+	req := httptest.NewRequest("POST", "/login", nil)
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: token})
+	req.Header.Set("X-CSRF-Token", token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	rotated := w.Body.String()
+	if w.Code != http.StatusOK || rotated == "" || rotated == token {
+		t.Fatalf("expected a new token after Rotate, got %d %q", w.Code, rotated)
+	}
+
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].Value != rotated {
+		t.Errorf("expected the rotated token in the cookie, got %v", cookies)
+	}
+
+	if h := w.Header().Get("X-CSRF-Token"); h != rotated {
+		t.Errorf("expected the rotated token in the header, got %q", h)
+	}
+}
+
+func TestCSRFTemplateFuncs(t *testing.T) {
+	dir := t.TempDir()
+	page := `<meta name="csrf-token" content="{{ csrf_token . }}"><form>{{ csrf_field . }}</form>`
+	if err := os.WriteFile(filepath.Join(dir, "form.html"), []byte(page), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	templ, err := gor.ParseTemplatesRecursive(dir, template.FuncMap{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The token is passed to templates without PassContextToViews.
+	router := gor.NewRouter(gor.WithTemplates(templ))
+	router.Use(csrf.New(nil, csrf.WithMode(csrf.DoubleSubmit), csrf.WithKey([]byte("super secret key"))))
+
+	router.Get("/form", func(w http.ResponseWriter, r *http.Request) {
+		if gor.CSRFToken(r) != csrf.TokenFromRequest(r) {
+			t.Errorf("expected %q, got %q", csrf.TokenFromRequest(r), gor.CSRFToken(r))
+		}
+		router.Render(w, r, "form", nil)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/form", nil))
+
+	// html/template escapes the token differently in attributes, e.g "+" as "&#43;".
+	token := w.Header().Get("X-CSRF-Token")
+	expected := `<meta name="csrf-token" content="` + token + `"><form><input type="hidden" name="csrf_token" value="` + token + `"></form>`
+	if body := html.UnescapeString(w.Body.String()); token == "" || body != expected {
+		t.Errorf("expected %q, got %q", expected, body)
+	}
+}
+
+func TestCSRFPerRequestTokens(t *testing.T) {
+	router := gor.NewRouter()
+	store := sessions.NewCookieStore([]byte("super secret token"))
+	router.Use(csrf.New(store, csrf.WithPerRequestTokens(true)))
+
+	router.Get("/csrf", func(w http.ResponseWriter, r *http.Request) {})
+	router.Post("/csrf", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(csrf.TokenFromRequest(r)))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/csrf", nil))
+	token := w.Header().Get("X-CSRF-Token")
+	cookies := w.Result().Cookies()
+
+	post := func(token string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/csrf", nil)
+		req.Header.Set("X-CSRF-Token", token)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w = post(token, cookies)
+	next := w.Header().Get("X-CSRF-Token")
+	if w.Code != http.StatusOK || next == token || w.Body.String() != next {
+		t.Fatalf("expected a new token after a verified request, got %d %q", w.Code, next)
+	}
+
+	// The used token is rejected with the rotated session.
+	if w = post(token, w.Result().Cookies()); w.Code != http.StatusForbidden {
+		t.Errorf("expected the used token to be rejected, got %d", w.Code)
+	}
+}
+
+func TestCSRFOriginsAndSkip(t *testing.T) {
+	router := gor.NewRouter()
+	router.Use(csrf.New(nil,
+		csrf.WithMode(csrf.DoubleSubmit),
+		csrf.WithKey([]byte("super secret key")),
+		csrf.WithTrustedOrigins("https://app.example.com", "*.trusted.com"),
+		csrf.WithSkip(csrf.SkipPaths("/webhooks/"), csrf.SkipMethods(http.MethodPut)),
+		csrf.WithSameSite(http.SameSiteStrictMode),
+	))
+
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	router.Get("/csrf", ok)
+	router.Post("/csrf", ok)
+	router.Put("/csrf", ok)
+	router.Post("/webhooks/github", ok)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/csrf", nil))
+	token := w.Header().Get("X-CSRF-Token")
+
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].SameSite != http.SameSiteStrictMode {
+		t.Fatalf("expected a SameSite=Strict cookie, got %v", cookies)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		origin  string
+		referer string
+		token   string
+		status  int
+	}{
+		{"same origin", "POST", "/csrf", "http://example.com", "", token, http.StatusOK},
+		{"no origin", "POST", "/csrf", "", "", token, http.StatusOK},
+		{"trusted origin", "POST", "/csrf", "https://app.example.com", "", token, http.StatusOK},
+		{"trusted subdomain", "POST", "/csrf", "https://a.trusted.com", "", token, http.StatusOK},
+		{"untrusted origin", "POST", "/csrf", "https://evil.com", "", token, http.StatusForbidden},
+		{"null origin", "POST", "/csrf", "null", "", token, http.StatusForbidden},
+		{"untrusted referer", "POST", "/csrf", "", "https://evil.com/page", token, http.StatusForbidden},
+		{"trusted referer", "POST", "/csrf", "", "http://example.com/form", token, http.StatusOK},
+		{"skipped path", "POST", "/webhooks/github", "https://github.com", "", "", http.StatusOK},
+		{"skipped method", "PUT", "/csrf", "", "", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.token != "" {
+			req.AddCookie(&http.Cookie{Name: "csrf_token", Value: tt.token})
+			req.Header.Set("X-CSRF-Token", tt.token)
+		}
+
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+
+		if tt.referer != "" {
+			req.Header.Set("Referer", tt.referer)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, w.Code)
+		}
+	}
+}
@@ -6,43 +6,122 @@ import (
 	"crypto/sha1"
 	"fmt"
 	"hash"
-	"io"
 	"net"
 	"net/http"
+	"strconv"
 
 	"github.com/abiiranathan/gor/gor"
 )
 
+// DefaultMaxBufferSize is the default maximum size of a response body
+// that is buffered to compute the ETag (1 MiB).
+const DefaultMaxBufferSize = 1 << 20
+
+// Config is the configuration for the etag middleware.
+type Config struct {
+	// MaxBufferSize is the maximum number of bytes buffered to compute the ETag.
+	// Responses that grow beyond this size, or declare a larger Content-Length,
+	// are streamed through unmodified without an ETag.
+	// Default is DefaultMaxBufferSize. A negative value disables the limit.
+	MaxBufferSize int
+
+	// Skip is a list of functions that return true if the ETag should not be computed.
+	Skip []func(r *http.Request) bool
+}
+
 type etagResponseWriter struct {
 	http.ResponseWriter              // the original ResponseWriter
 	buf                 bytes.Buffer // buffer to store the response body
-	hash                hash.Hash    // hash to calculate the ETag
-	w                   io.Writer    // multiwriter to write to both the buffer and the hash
+	hash                hash.Hash    // hash to calculate the ETag incrementally
 	status              int          // status code of the response
 	written             bool         // whether the header has been written
+	maxSize             int          // maximum size of the buffer
+	passthrough         bool         // the response is streamed to the original ResponseWriter
 }
 
 func (e *etagResponseWriter) WriteHeader(code int) {
+	if e.written {
+		return
+	}
+
 	e.status = code
 	e.written = true
+
+	// Only 200 OK responses carry an ETag. Stream everything else.
+	if code != http.StatusOK || e.exceedsLimit(e.declaredLength()) {
+		e.startPassthrough()
+	}
 	// Don't actually write the header yet, we'll do that later
 }
 
 func (e *etagResponseWriter) Write(p []byte) (int, error) {
 	if !e.written {
 		// If WriteHeader was not explicitly called, we need to set the status
-		e.status = http.StatusOK
-		e.written = true
+		e.WriteHeader(http.StatusOK)
+	}
+
+	if e.passthrough {
+		return e.ResponseWriter.Write(p)
+	}
+
+	if e.exceedsLimit(int64(e.buf.Len() + len(p))) {
+		e.startPassthrough()
+		return e.ResponseWriter.Write(p)
+	}
+
+	e.hash.Write(p)
+	return e.buf.Write(p)
+}
+
+// declaredLength returns the Content-Length set by the handler or -1.
+func (e *etagResponseWriter) declaredLength() int64 {
+	cl := e.Header().Get("Content-Length")
+	if cl == "" {
+		return -1
+	}
+
+	n, err := strconv.ParseInt(cl, 10, 64)
+	if err != nil {
+		return -1
 	}
-	return e.w.Write(p)
+	return n
+}
+
+func (e *etagResponseWriter) exceedsLimit(n int64) bool {
+	return e.maxSize >= 0 && n > int64(e.maxSize)
 }
 
+// startPassthrough writes the status and any buffered data to the original
+// ResponseWriter. All subsequent writes go directly to it.
+func (e *etagResponseWriter) startPassthrough() {
+	if e.passthrough {
+		return
+	}
+
+	e.passthrough = true
+	e.ResponseWriter.WriteHeader(e.status)
+	if e.buf.Len() > 0 {
+		e.buf.WriteTo(e.ResponseWriter)
+	}
+}
+
+// Flush streams the response. Flushed responses do not carry an ETag.
 func (e *etagResponseWriter) Flush() {
+	if !e.written {
+		e.WriteHeader(http.StatusOK)
+	}
+	e.startPassthrough()
+
 	if f, ok := e.ResponseWriter.(http.Flusher); ok {
 		f.Flush()
 	}
 }
 
+// Unwrap returns the original ResponseWriter for http.ResponseController.
+func (e *etagResponseWriter) Unwrap() http.ResponseWriter {
+	return e.ResponseWriter
+}
+
 func (e *etagResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	if h, ok := e.ResponseWriter.(http.Hijacker); ok {
 		return h.Hijack()
@@ -50,11 +129,24 @@ func (e *etagResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	return nil, nil, http.ErrNotSupported
 }
 
+// New creates an etag middleware with the default configuration.
+// skip functions return true if the ETag should not be computed for the request.
 func New(skip ...func(r *http.Request) bool) gor.Middleware {
+	return NewWithConfig(Config{MaxBufferSize: DefaultMaxBufferSize, Skip: skip})
+}
+
+// NewWithConfig creates an etag middleware with the given configuration.
+// The ETag is computed incrementally while the response is buffered, up to
+// config.MaxBufferSize bytes.
+func NewWithConfig(config Config) gor.Middleware {
+	if config.MaxBufferSize == 0 {
+		config.MaxBufferSize = DefaultMaxBufferSize
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			var skipEtag bool
-			for _, s := range skip {
+			for _, s := range config.Skip {
 				if s(r) {
 					skipEtag = true
 					break
@@ -75,15 +167,13 @@ func New(skip ...func(r *http.Request) bool) gor.Middleware {
 				buf:            bytes.Buffer{},
 				hash:           sha1.New(),
 				status:         http.StatusOK,
+				maxSize:        config.MaxBufferSize,
 			}
-			ew.w = io.MultiWriter(&ew.buf, ew.hash)
 
 			next.ServeHTTP(ew, r)
 
-			if ew.status != http.StatusOK {
-				// For non-200 responses, write the status and body without ETag
-				w.WriteHeader(ew.status)
-				ew.buf.WriteTo(w)
+			// The response has already been streamed.
+			if ew.passthrough {
 				return
 			}
 
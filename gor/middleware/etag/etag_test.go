@@ -0,0 +1,119 @@
+package etag_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/abiiranathan/gor/gor/middleware/etag"
+)
+
+func newRouter() *gor.Router {
+	r := gor.NewRouter()
+	r.Use(etag.NewWithConfig(etag.Config{MaxBufferSize: 16}))
+
+	r.Get("/small", func(w http.ResponseWriter, req *http.Request) {
+		gor.SendString(w, "hello")
+	})
+
+	r.Get("/large", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(strings.Repeat("a", 10)))
+		w.Write([]byte(strings.Repeat("b", 10)))
+	})
+
+	r.Get("/declared", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte(strings.Repeat("c", 100)))
+	})
+
+	r.Get("/flush", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("event"))
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			w.Write([]byte(err.Error()))
+		}
+	})
+	return r
+}
+
+func get(r http.Handler, path string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestETag(t *testing.T) {
+	r := newRouter()
+
+	w := get(r, "/small")
+	tag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.String() != "hello" || tag == "" {
+		t.Fatalf("expected a response with an ETag, got %d %q %q", w.Code, w.Body.String(), tag)
+	}
+
+	if w := get(r, "/small"); w.Header().Get("ETag") != tag {
+		t.Errorf("expected a stable ETag, got %q and %q", tag, w.Header().Get("ETag"))
+	}
+
+	// Not modified
+	w = get(r, "/small", "If-None-Match", tag)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("expected 304 without a body, got %d %q", w.Code, w.Body.String())
+	}
+
+	if w := get(r, "/small", "If-None-Match", `"other"`); w.Code != http.StatusOK || w.Body.String() != "hello" {
+		t.Errorf("expected 200 for another ETag, got %d %q", w.Code, w.Body.String())
+	}
+
+	// Precondition failed
+	w = get(r, "/small", "If-Match", `"other"`)
+	if w.Code != http.StatusPreconditionFailed || w.Body.Len() != 0 {
+		t.Errorf("expected 412 without a body, got %d %q", w.Code, w.Body.String())
+	}
+
+	if w := get(r, "/small", "If-Match", tag); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a matching If-Match, got %d", w.Code)
+	}
+}
+
+func TestETagPassthrough(t *testing.T) {
+	r := newRouter()
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/large", strings.Repeat("a", 10) + strings.Repeat("b", 10)}, // grows beyond MaxBufferSize
+		{"/declared", strings.Repeat("c", 100)},                       // declares a larger Content-Length
+		{"/flush", "event"},                                           // flushed
+	}
+
+	for _, tt := range tests {
+		w := get(r, tt.path)
+		if w.Code != http.StatusOK || w.Body.String() != tt.body {
+			t.Errorf("%s: expected the full body, got %d %q", tt.path, w.Code, w.Body.String())
+		}
+
+		if tag := w.Header().Get("ETag"); tag != "" {
+			t.Errorf("%s: expected no ETag, got %q", tt.path, tag)
+		}
+
+		// Preconditions are not evaluated without an ETag.
+		if w := get(r, tt.path, "If-Match", `"other"`); w.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", tt.path, w.Code)
+		}
+	}
+
+	if w := get(r, "/declared"); w.Header().Get("Content-Length") != "100" {
+		t.Errorf("expected the declared Content-Length, got %q", w.Header().Get("Content-Length"))
+	}
+
+	if w := get(r, "/flush"); !w.Flushed {
+		t.Error("expected the response to be flushed")
+	}
+}
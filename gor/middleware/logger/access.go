@@ -0,0 +1,188 @@
+package logger
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abiiranathan/gor/gor"
+)
+
+// Apache style access log patterns used by CommonLog and CombinedLog.
+const (
+	CommonLogPattern   = `%h %l %u %t "%r" %s %b`
+	CombinedLogPattern = CommonLogPattern + ` "%{Referer}i" "%{User-Agent}i"`
+)
+
+// accessEntry holds the request information formatted by the access log verbs.
+type accessEntry struct {
+	req     *http.Request
+	status  int
+	size    int64
+	start   time.Time
+	latency time.Duration
+}
+
+// accessVerb appends a formatted field of the entry to b.
+type accessVerb func(b []byte, e *accessEntry) []byte
+
+// compilePattern parses an access log pattern into verbs. Supported verbs:
+//
+//	%h  client ip address
+//	%l  remote logname, always "-"
+//	%u  basic auth user or "-"
+//	%t  time the request was received, e.g [10/Oct/2000:13:55:36 -0700]
+//	%r  request line, e.g GET /path?q=1 HTTP/1.1
+//	%m  request method
+//	%U  request path
+//	%q  query string with a leading "?", or ""
+//	%H  request protocol
+//	%s  response status
+//	%b  response size in bytes, "-" if 0
+//	%B  response size in bytes
+//	%D  duration in microseconds
+//	%T  duration in seconds
+//	%{Name}i  request header Name, "-" if empty
+//	%%  a literal "%"
+//
+// Request data is escaped, see appendEscaped. Unknown verbs are written as is.
+func compilePattern(pattern string) []accessVerb {
+	var verbs []accessVerb
+	literal := func(s string) {
+		if s != "" {
+			verbs = append(verbs, func(b []byte, _ *accessEntry) []byte { return append(b, s...) })
+		}
+	}
+
+	for {
+		i := strings.IndexByte(pattern, '%')
+		if i < 0 || i == len(pattern)-1 {
+			literal(pattern)
+			return verbs
+		}
+		literal(pattern[:i])
+		pattern = pattern[i+1:]
+
+		if pattern[0] == '{' {
+			end := strings.Index(pattern, "}i")
+			if end < 0 {
+				literal("%" + pattern)
+				return verbs
+			}
+
+			name := pattern[1:end]
+			verbs = append(verbs, func(b []byte, e *accessEntry) []byte {
+				return appendOrDash(b, e.req.Header.Get(name))
+			})
+			pattern = pattern[end+2:]
+			continue
+		}
+
+		if verb, ok := accessVerbs[pattern[0]]; ok {
+			verbs = append(verbs, verb)
+		} else {
+			literal("%" + pattern[:1])
+		}
+		pattern = pattern[1:]
+	}
+}
+
+// accessVerbs maps the single letter verbs to their formatters.
+var accessVerbs = map[byte]accessVerb{
+	'h': func(b []byte, e *accessEntry) []byte {
+		ip, _ := gor.ClientIPAddress(e.req)
+		return appendOrDash(b, ip)
+	},
+	'l': func(b []byte, _ *accessEntry) []byte { return append(b, '-') },
+	'u': func(b []byte, e *accessEntry) []byte {
+		user, _, _ := e.req.BasicAuth()
+		return appendOrDash(b, user)
+	},
+	't': func(b []byte, e *accessEntry) []byte {
+		b = append(b, '[')
+		b = e.start.AppendFormat(b, "02/Jan/2006:15:04:05 -0700")
+		return append(b, ']')
+	},
+	'r': func(b []byte, e *accessEntry) []byte {
+		b = appendEscaped(b, e.req.Method)
+		b = append(b, ' ')
+		b = appendEscaped(b, e.req.URL.RequestURI())
+		b = append(b, ' ')
+		return appendEscaped(b, e.req.Proto)
+	},
+	'm': func(b []byte, e *accessEntry) []byte { return appendEscaped(b, e.req.Method) },
+	'U': func(b []byte, e *accessEntry) []byte { return appendEscaped(b, e.req.URL.Path) },
+	'q': func(b []byte, e *accessEntry) []byte {
+		if e.req.URL.RawQuery == "" {
+			return b
+		}
+		b = append(b, '?')
+		return appendEscaped(b, e.req.URL.RawQuery)
+	},
+	'H': func(b []byte, e *accessEntry) []byte { return appendEscaped(b, e.req.Proto) },
+	's': func(b []byte, e *accessEntry) []byte { return strconv.AppendInt(b, int64(e.status), 10) },
+	'b': func(b []byte, e *accessEntry) []byte {
+		if e.size == 0 {
+			return append(b, '-')
+		}
+		return strconv.AppendInt(b, e.size, 10)
+	},
+	'B': func(b []byte, e *accessEntry) []byte { return strconv.AppendInt(b, e.size, 10) },
+	'D': func(b []byte, e *accessEntry) []byte { return strconv.AppendInt(b, e.latency.Microseconds(), 10) },
+	'T': func(b []byte, e *accessEntry) []byte { return strconv.AppendInt(b, int64(e.latency/time.Second), 10) },
+	'%': func(b []byte, _ *accessEntry) []byte { return append(b, '%') },
+}
+
+// appendOrDash appends the escaped s to b, or "-" if s is empty.
+func appendOrDash(b []byte, s string) []byte {
+	if s == "" {
+		return append(b, '-')
+	}
+	return appendEscaped(b, s)
+}
+
+// appendEscaped appends s to b, escaping `"`, `\` and control characters
+// as \xHH like Apache, so that request data cannot forge log lines or
+// break out of quoted fields.
+func appendEscaped(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c < 0x20 || c == 0x7f:
+			b = append(b, '\\', 'x', hex[c>>4], hex[c&0xf])
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+// linePool reuses access log line buffers between requests.
+var linePool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 256)
+		return &b
+	},
+}
+
+// writeAccessLine formats the entry with the compiled verbs and writes it to the output.
+func (l *Config) writeAccessLine(e *accessEntry) {
+	bp := linePool.Get().(*[]byte)
+	b := (*bp)[:0]
+	for _, verb := range l.verbs {
+		b = verb(b, e)
+	}
+	b = append(b, '\n')
+
+	l.mu.Lock()
+	l.output.Write(b)
+	l.mu.Unlock()
+
+	*bp = b
+	linePool.Put(bp)
+}
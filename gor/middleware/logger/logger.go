@@ -1,11 +1,13 @@
 package logger
 
 import (
+	"cmp"
 	"io"
 	"log/slog"
 	"net/http"
 	"os"
 	"slices"
+	"sync"
 	"time"
 
 	"github.com/abiiranathan/gor/gor"
@@ -16,14 +18,18 @@ type LogFormat int
 type LogFlags int8
 
 const (
-	TextFormat LogFormat = iota + 1 // This is the default format
-	JSONFormat                      // Log in JSON format
+	TextFormat  LogFormat = iota + 1 // This is the default format
+	JSONFormat                       // Log in JSON format
+	CommonLog                        // Apache Common Log Format. See CommonLogPattern.
+	CombinedLog                      // Apache Combined Log Format. See CombinedLogPattern.
+	CustomLog                        // Access log formatted with Config.Pattern
 )
 
 const (
 	LOG_IP LogFlags = 1 << iota
 	LOG_LATENCY
 	LOG_USERAGENT
+	LOG_SIZE // Response body size in bytes
 )
 
 const StdLogFlags LogFlags = LOG_LATENCY | LOG_IP
@@ -34,8 +40,15 @@ type Config struct {
 	Output io.Writer
 
 	// Format is the format of the log output. Default is TextFormat.
+	// CommonLog, CombinedLog and CustomLog write plain access log lines
+	// instead of slog records. Flags, Options and Callback are ignored for them.
 	Format LogFormat
 
+	// Pattern is the access log pattern used with CustomLog, e.g `%h "%r" %s %b %D`.
+	// Default is CommonLogPattern.
+	// See compilePattern for the supported verbs.
+	Pattern string
+
 	// Flags is the flags to be used for logging. Default is StdLogFlags.
 	Flags LogFlags
 
@@ -46,12 +59,113 @@ type Config struct {
 	// If it returns true, the request will not be logged.
 	SkipIf func(r *http.Request) bool
 
+	// SlowThreshold logs requests that take longer than the threshold at Warn level
+	// with the handler name and route pattern. Zero disables slow request logging.
+	// It is ignored by the access log formats.
+	SlowThreshold time.Duration
+
+	// SampleRate is the fraction of requests with status below 400 that are logged,
+	// e.g 0.01 logs 1% of successful requests and all errors.
+	// Slow requests are always logged. Zero logs all requests.
+	SampleRate float64
+
+	// Routes overrides the level, sample rate or disables logging for routes
+	// matched by their registered pattern or a path prefix. See routeConfig.
+	//
+	//	Routes: map[string]logger.RouteConfig{
+	//		"GET /health": {Disabled: true},
+	//		"/api/":       {SampleRate: 0.1},
+	//	}
+	Routes map[string]RouteConfig
+
 	// Options is the options to be passed to the slog.Handler.
 	Options *slog.HandlerOptions
 
 	// Callback is a function that can be used to modify the arguments passed to the logger.
 	// Forexample the request_id, user_id etc.
 	Callback func(r *http.Request, args ...any) []any
+
+	once   sync.Once    // guards construction of logger
+	logger *slog.Logger // logger built once from Output, Format and Options
+
+	resolved sync.Map // RouteConfig resolved for each route pattern
+
+	verbs  []accessVerb // compiled access log pattern, nil for slog formats
+	output io.Writer    // destination of access log lines
+	mu     sync.Mutex   // serializes access log writes
+}
+
+// statusWriter is implemented by writers that track the response status, like gor.ResponseWriter.
+type statusWriter interface {
+	http.ResponseWriter
+	Status() int
+}
+
+// sizeWriter is implemented by writers that track the response size, like gor.ResponseWriter.
+type sizeWriter interface {
+	BytesWritten() int64
+}
+
+// responseWriter records the status code and size when gor.ResponseWriter is not
+// the writer passed to the middleware. e.g when the logger is used with another router
+// or another middleware replaced the writer.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+func (w *responseWriter) BytesWritten() int64 {
+	return w.size
+}
+
+// Unwrap returns the underlying http.ResponseWriter for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// attrPool reuses attribute buffers between requests.
+var attrPool = sync.Pool{
+	New: func() any {
+		attrs := make([]slog.Attr, 0, 8)
+		return &attrs
+	},
+}
+
+// build constructs the slog.Logger from the config. It is called once.
+func (l *Config) build() {
+	output := l.Output
+	if output == nil {
+		output = os.Stderr
+	}
+
+	switch l.Format {
+	case CommonLog:
+		l.verbs, l.output = compilePattern(CommonLogPattern), output
+	case CombinedLog:
+		l.verbs, l.output = compilePattern(CombinedLogPattern), output
+	case CustomLog:
+		l.verbs, l.output = compilePattern(cmp.Or(l.Pattern, CommonLogPattern)), output
+	case JSONFormat:
+		l.logger = slog.New(slog.NewJSONHandler(output, l.Options))
+	default:
+		l.logger = slog.New(slog.NewTextHandler(output, l.Options))
+	}
 }
 
 // DefaultLogger is the default logger used by the Logger middleware.
@@ -87,6 +201,7 @@ func New(config *Config) gor.Middleware {
 		}
 	}
 
+	config.once.Do(config.build)
 	return config.Logger
 }
 
@@ -103,43 +218,108 @@ func (l *Config) Logger(handler http.Handler) http.Handler {
 			return
 		}
 
+		// Logger may be used without calling New.
+		l.once.Do(l.build)
+
+		rc := l.routeConfig(req)
+		if rc.Disabled {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		sw, ok := w.(statusWriter)
+		if !ok {
+			sw = &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		}
+
 		start := time.Now()
-		handler.ServeHTTP(w, req)
-		latency := time.Since(start).String()
+		handler.ServeHTTP(sw, req)
+		latency := time.Since(start)
+
+		// Requests canceled by the client are logged with status 499.
+		status := sw.Status()
+		if gor.ClientGone(req) {
+			status = gor.StatusClientClosedRequest
+		}
 
-		var logger *slog.Logger
-		switch l.Format {
-		case TextFormat:
-			logger = slog.New(slog.NewTextHandler(l.Output, l.Options))
-		case JSONFormat:
-			logger = slog.New(slog.NewJSONHandler(l.Output, l.Options))
-		default:
-			logger = slog.New(slog.NewTextHandler(l.Output, l.Options))
+		slow := l.SlowThreshold > 0 && latency > l.SlowThreshold
+		sampleRate := l.SampleRate
+		if rc.SampleRate != 0 {
+			sampleRate = rc.SampleRate
+		}
+
+		if !slow && !sampled(status, sampleRate) {
+			return
+		}
+
+		var size int64
+		if s, ok := sw.(sizeWriter); ok {
+			size = s.BytesWritten()
+		}
+
+		if l.verbs != nil {
+			l.writeAccessLine(&accessEntry{req: req, status: status, size: size, start: start, latency: latency})
+			return
 		}
 
-		args := []any{"status", w.(*gor.ResponseWriter).Status()}
+		attrs := attrPool.Get().(*[]slog.Attr)
+		defer func() {
+			clear(*attrs)
+			*attrs = (*attrs)[:0]
+			attrPool.Put(attrs)
+		}()
+
+		a := append((*attrs)[:0], slog.Int("status", status))
 		if l.Flags&LOG_LATENCY != 0 {
-			args = append(args, "latency", latency)
+			a = append(a, slog.Duration("latency", latency))
 		}
-		args = append(args, "method", req.Method, "path", req.URL.Path)
+		a = append(a, slog.String("method", req.Method), slog.String("path", req.URL.Path))
 
 		if l.Flags&LOG_IP != 0 {
 			ipAddr, _ := gor.ClientIPAddress(req)
-			args = append(args, "ip", ipAddr)
+			a = append(a, slog.String("ip", ipAddr))
 		}
 
 		if l.Flags&LOG_USERAGENT != 0 {
-			args = append(args, "user_agent", req.UserAgent())
+			a = append(a, slog.String("user_agent", req.UserAgent()))
+		}
+
+		if l.Flags&LOG_SIZE != 0 {
+			a = append(a, slog.Int64("size", size))
+		}
+
+		level, msg := slog.LevelInfo, ""
+		if rc.Level != nil && status < http.StatusBadRequest {
+			level = rc.Level.Level()
 		}
 
+		if slow {
+			level, msg = slog.LevelWarn, "slow request"
+			if l.Flags&LOG_LATENCY == 0 {
+				a = append(a, slog.Duration("latency", latency))
+			}
+
+			if route := gor.MatchedRoute(req); route != nil {
+				a = append(a, slog.String("route", route.Pattern()), slog.String("handler", route.HandlerName()))
+			}
+		}
+		*attrs = a
+
 		if l.Callback != nil {
-			args = l.Callback(req, args...)
+			args := make([]any, 0, len(a)*2)
+			for _, attr := range a {
+				args = append(args, attr.Key, attr.Value.Any())
+			}
 
+			args = l.Callback(req, args...)
 			if len(args)%2 != 0 {
 				panic("Callback must return an even number of arguments")
 			}
+
+			l.logger.Log(req.Context(), level, msg, args...)
+			return
 		}
 
-		logger.Info("", args...)
+		l.logger.LogAttrs(req.Context(), level, msg, a...)
 	})
 }
@@ -0,0 +1,303 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/abiiranathan/gor/gor/middleware/logger"
+)
+
+func TestLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	r := gor.NewRouter()
+	r.Use(logger.New(&logger.Config{Output: buf, Flags: logger.StdLogFlags}))
+	r.Get("/logger", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/logger", nil)
+	r.ServeHTTP(w, req)
+
+	for _, s := range []string{"status=418", "method=GET", "path=/logger", "latency=", "ip="} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("expected %q in log output, got %q", s, buf.String())
+		}
+	}
+}
+
+func TestLoggerSize(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("hello"))
+	}
+
+	r := gor.NewRouter()
+	r.Use(logger.New(&logger.Config{Output: buf, Flags: logger.LOG_SIZE}))
+	r.Get("/size", handler)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/size", nil))
+
+	// Without gor.ResponseWriter
+	h := logger.New(&logger.Config{Output: buf, Flags: logger.LOG_SIZE})(http.HandlerFunc(handler))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/size", nil))
+
+	if n := strings.Count(buf.String(), "size=5"); n != 2 {
+		t.Errorf("expected size=5 in both log lines, got %q", buf.String())
+	}
+}
+
+// The logger should work when gor.ResponseWriter is not the writer.
+func TestLoggerWithoutGorRouter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	mw := logger.New(&logger.Config{Output: buf})
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if !strings.Contains(buf.String(), "status=404") {
+		t.Errorf("expected status=404 in log output, got %q", buf.String())
+	}
+}
+
+// wrappedWriter is a writer of another middleware hiding gor.ResponseWriter.
+type wrappedWriter struct {
+	http.ResponseWriter
+}
+
+func (w *wrappedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// The logger should log the response when another middleware replaced
+// gor.ResponseWriter, and let handlers flush it.
+func TestLoggerWrappedWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	r := gor.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(&wrappedWriter{w}, req)
+		})
+	})
+	r.Use(logger.New(&logger.Config{Output: buf, Flags: logger.LOG_SIZE}))
+	r.Get("/stream", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("chunk"))
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("expected the writer to be flushed, got %v", err)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/stream", nil))
+
+	if !w.Flushed || w.Code != http.StatusAccepted {
+		t.Errorf("expected a flushed 202 response, got %d (flushed %v)", w.Code, w.Flushed)
+	}
+
+	for _, s := range []string{"status=202", "size=5"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("expected %q in log output, got %q", s, buf.String())
+		}
+	}
+}
+
+func TestLoggerClientGone(t *testing.T) {
+	buf := new(bytes.Buffer)
+	r := gor.NewRouter()
+	r.Use(logger.New(&logger.Config{Output: buf}))
+	r.Get("/logger", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req := httptest.NewRequest("GET", "/logger", nil).WithContext(ctx)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !strings.Contains(buf.String(), "status=499") {
+		t.Errorf("expected status=499 in log output, got %q", buf.String())
+	}
+}
+
+func BenchmarkLogger(b *testing.B) {
+	r := gor.NewRouter()
+	r.Use(logger.New(&logger.Config{Output: io.Discard, Flags: logger.StdLogFlags}))
+	r.Get("/logger", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/logger", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkLoggerJSON(b *testing.B) {
+	r := gor.NewRouter()
+	r.Use(logger.New(&logger.Config{Output: io.Discard, Format: logger.JSONFormat, Flags: logger.StdLogFlags}))
+	r.Get("/logger", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/logger", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.ServeHTTP(w, req)
+	}
+}
+
+func TestLoggerAccessFormats(t *testing.T) {
+	tests := []struct {
+		config   *logger.Config
+		expected string
+	}{
+		{&logger.Config{Format: logger.CommonLog}, `192.0.2.1 - alice [`},
+		{&logger.Config{Format: logger.CommonLog}, `] "GET /logger?q=1 HTTP/1.1" 418 5` + "\n"},
+		{&logger.Config{Format: logger.CombinedLog}, `418 5 "https://example.com" "test-agent"` + "\n"},
+		{&logger.Config{Format: logger.CustomLog, Pattern: "%m %U%q %s %B %{X-Missing}i 100%%"}, "GET /logger?q=1 418 5 - 100%\n"},
+	}
+
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		tt.config.Output = buf
+
+		r := gor.NewRouter()
+		r.Use(logger.New(tt.config))
+		r.Get("/logger", func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+			io.WriteString(w, "hello")
+		})
+
+		req := httptest.NewRequest("GET", "/logger?q=1", nil)
+		req.SetBasicAuth("alice", "secret")
+		req.Header.Set("Referer", "https://example.com")
+		req.Header.Set("User-Agent", "test-agent")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		if !strings.Contains(buf.String(), tt.expected) {
+			t.Errorf("expected %q in log output, got %q", tt.expected, buf.String())
+		}
+	}
+}
+
+// Request data must not forge log lines or break out of quoted fields.
+func TestLoggerAccessEscaping(t *testing.T) {
+	buf := new(bytes.Buffer)
+	pattern := logger.CombinedLogPattern + " %U"
+	mw := logger.New(&logger.Config{Format: logger.CustomLog, Pattern: pattern, Output: buf})
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "/a%0Ab", nil)
+	req.SetBasicAuth("eve\n192.0.2.9 - admin", "secret")
+	req.Header.Set("User-Agent", `agent" "injected\`)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := buf.String()
+	if strings.Count(line, "\n") != 1 {
+		t.Fatalf("expected a single log line, got %q", line)
+	}
+
+	for _, expected := range []string{
+		` eve\x0a192.0.2.9 - admin [`,
+		`"agent\" \"injected\\"`,
+		` /a\x0ab` + "\n",
+	} {
+		if !strings.Contains(line, expected) {
+			t.Errorf("expected %q in log output, got %q", expected, line)
+		}
+	}
+}
+
+func slowHandler(w http.ResponseWriter, req *http.Request) {
+	time.Sleep(5 * time.Millisecond)
+}
+
+func TestLoggerSlowThreshold(t *testing.T) {
+	buf := new(bytes.Buffer)
+	r := gor.NewRouter()
+	r.Use(logger.New(&logger.Config{Output: buf, SlowThreshold: time.Millisecond}))
+	r.Get("/slow/{id}", slowHandler)
+	r.Get("/fast", func(w http.ResponseWriter, req *http.Request) {})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow/1", nil))
+	for _, s := range []string{"level=WARN", `msg="slow request"`, `route="GET /slow/{id}"`, "handler=", "slowHandler", "latency="} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("expected %q in log output, got %q", s, buf.String())
+		}
+	}
+
+	buf.Reset()
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fast", nil))
+	if !strings.Contains(buf.String(), "level=INFO") || strings.Contains(buf.String(), "route=") {
+		t.Errorf("expected fast request at info level without route, got %q", buf.String())
+	}
+}
+
+func TestLoggerRoutes(t *testing.T) {
+	buf := new(bytes.Buffer)
+	r := gor.NewRouter()
+	r.Use(logger.New(&logger.Config{
+		Output:  buf,
+		Options: &slog.HandlerOptions{Level: slog.LevelDebug},
+		Routes: map[string]logger.RouteConfig{
+			"GET /health":     {Disabled: true},
+			"/api/":           {Level: slog.LevelDebug},
+			"/api/users/{id}": {SampleRate: 1e-9},
+		},
+	}))
+
+	ok := func(w http.ResponseWriter, req *http.Request) {}
+	r.Get("/health", ok)
+	r.Get("/api/items", ok)
+	r.Get("/api/fail", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	r.Get("/api/users/{id}", func(w http.ResponseWriter, req *http.Request) {
+		if req.PathValue("id") == "0" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/health", ""},
+		{"/api/items", "level=DEBUG"},
+		{"/api/fail", "level=INFO msg=\"\" status=500"},
+		{"/api/users/1", ""},
+		{"/api/users/0", "status=404"},
+	}
+
+	for _, tt := range tests {
+		buf.Reset()
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.path, nil))
+
+		if tt.expected == "" && buf.Len() > 0 {
+			t.Errorf("%s: expected no log output, got %q", tt.path, buf.String())
+		}
+
+		if !strings.Contains(buf.String(), tt.expected) {
+			t.Errorf("%s: expected %q in log output, got %q", tt.path, tt.expected, buf.String())
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+
+	"github.com/abiiranathan/gor/gor"
+)
+
+// RouteConfig overrides the logging of the routes matching a key of Config.Routes.
+type RouteConfig struct {
+	// Disabled disables logging for the matching routes.
+	Disabled bool
+
+	// Level is the level successful requests are logged at instead of Info.
+	// Requests with status 400 and above are still logged at Info, and slow
+	// requests at Warn.
+	Level slog.Leveler
+
+	// SampleRate overrides Config.SampleRate for the matching routes.
+	SampleRate float64
+}
+
+// defaultRouteConfig is used for requests that match no key of Config.Routes.
+var defaultRouteConfig = &RouteConfig{}
+
+// routeConfig returns the RouteConfig for the route matching req.
+//
+// Keys of Config.Routes are matched against the registered pattern of the route:
+//   - "GET /users/{id}" matches the route registered with that method and path.
+//   - "/users/{id}" matches the routes registered with that path for any method.
+//   - "/api/" matches all routes whose path starts with "/api/", e.g a group.
+//
+// Exact matches take precedence, then the longest matching prefix.
+// Resolved configs are cached per route.
+func (l *Config) routeConfig(req *http.Request) *RouteConfig {
+	if len(l.Routes) == 0 {
+		return defaultRouteConfig
+	}
+
+	route := gor.MatchedRoute(req)
+	if route == nil {
+		return defaultRouteConfig
+	}
+
+	pattern := route.Pattern()
+	if rc, ok := l.resolved.Load(pattern); ok {
+		return rc.(*RouteConfig)
+	}
+
+	rc := l.resolveRoute(pattern)
+	l.resolved.Store(pattern, rc)
+	return rc
+}
+
+// resolveRoute finds the RouteConfig for a registered pattern.
+func (l *Config) resolveRoute(pattern string) *RouteConfig {
+	if rc, ok := l.Routes[pattern]; ok {
+		return &rc
+	}
+
+	path := pattern
+	if _, p, found := strings.Cut(pattern, " "); found {
+		path = p
+	}
+
+	if rc, ok := l.Routes[path]; ok {
+		return &rc
+	}
+
+	var best string
+	for key := range l.Routes {
+		if strings.HasSuffix(key, "/") && strings.HasPrefix(path, key) && len(key) > len(best) {
+			best = key
+		}
+	}
+
+	if best != "" {
+		rc := l.Routes[best]
+		return &rc
+	}
+	return defaultRouteConfig
+}
+
+// sampled reports whether a request with status should be logged given the sample rate.
+// Requests with status 400 and above are always logged.
+func sampled(status int, rate float64) bool {
+	if rate <= 0 || rate >= 1 || status >= http.StatusBadRequest {
+		return true
+	}
+	return rand.Float64() < rate
+}
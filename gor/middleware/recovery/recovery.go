@@ -2,6 +2,7 @@ package recovery
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"runtime/debug"
@@ -9,40 +10,89 @@ import (
 	"github.com/abiiranathan/gor/gor"
 )
 
+// Reporter is called with the recovered error, the stack trace of the panic
+// and the request, e.g to send the error to Sentry or Rollbar.
+type Reporter func(err error, stack []byte, req *http.Request)
+
+// Config is the configuration for the recovery middleware.
+type Config struct {
+	// StackTrace logs the stack trace of the panic if true.
+	StackTrace bool
+
+	// Reporters are called in order after the error is logged.
+	Reporters []Reporter
+}
+
 // Panic recovery middleware.
 // If stack trace is true, a stack trace will be logged.
 // If errorHandler is passed, it will be called with the error. No response will be sent to the client.
-// Otherwise the error will be logged and sent with a 500 status code.
+// Otherwise the error will be logged and sent with a 500 status code
+// through the router's error pipeline. See gor.HandleError.
 func New(stackTrace bool, errorHandler ...func(err error)) gor.Middleware {
+	if len(errorHandler) == 0 {
+		return NewWithConfig(Config{StackTrace: stackTrace})
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 			defer func() {
 				if r := recover(); r != nil {
-					err, ok := r.(error)
-					if !ok {
-						// must be a string
-						err = errors.New(r.(string))
-					}
-
-					if len(errorHandler) > 0 {
-						errorHandler[0](err)
-					} else {
-						log.Println(err)
-						if stackTrace {
-							log.Println(string(debug.Stack()))
-						}
-
-						w.WriteHeader(http.StatusInternalServerError)
-						_, err = w.Write([]byte(err.Error()))
-						if err != nil {
-							log.Printf("could not write response: %v\n", err)
-						}
-					}
+					errorHandler[0](toError(r))
+				}
+			}()
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// NewWithConfig creates a recovery middleware with the given configuration.
+// The error is logged, passed to the reporters and sent with a 500 status code
+// through the router's error pipeline, rendering the error template if configured.
+// Panics with an error with a Status() int method, like gor.HTTPError, are sent with its status.
+// Panics with http.ErrAbortHandler are propagated to abort the response.
+func NewWithConfig(config Config) gor.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+
+				err := toError(r)
+				stack := debug.Stack()
 
+				log.Println(err)
+				if config.StackTrace {
+					log.Println(string(stack))
 				}
+
+				for _, report := range config.Reporters {
+					report(err, stack, req)
+				}
+
+				status := http.StatusInternalServerError
+				var statusErr interface{ Status() int }
+				if errors.As(err, &statusErr) && statusErr.Status() >= 400 {
+					status = statusErr.Status()
+				}
+				gor.HandleError(w, req, err, status)
 			}()
 
 			next.ServeHTTP(w, req)
 		})
 	}
 }
+
+// toError converts a recovered value to an error.
+func toError(r any) error {
+	if err, ok := r.(error); ok {
+		return err
+	}
+	return errors.New(fmt.Sprint(r))
+}
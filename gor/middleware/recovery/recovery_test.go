@@ -0,0 +1,73 @@
+package recovery_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abiiranathan/gor/gor"
+	"github.com/abiiranathan/gor/gor/middleware/recovery"
+)
+
+func TestRecoveryReporters(t *testing.T) {
+	var reported []string
+	reporter := func(err error, stack []byte, req *http.Request) {
+		if len(stack) == 0 {
+			t.Error("expected a stack trace")
+		}
+		reported = append(reported, req.URL.Path+": "+err.Error())
+	}
+
+	r := gor.NewRouter()
+	r.Use(recovery.NewWithConfig(recovery.Config{Reporters: []recovery.Reporter{reporter, reporter}}))
+	r.Get("/panic", func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", w.Code)
+	}
+
+	if !strings.Contains(w.Body.String(), "boom") {
+		t.Errorf("expected the error to be sent through the error pipeline, got %q", w.Body.String())
+	}
+
+	if len(reported) != 2 || reported[0] != "/panic: boom" {
+		t.Errorf("expected both reporters to be called, got %v", reported)
+	}
+}
+
+func TestRecoveryErrorHandler(t *testing.T) {
+	var handled error
+	r := gor.NewRouter()
+	r.Use(recovery.New(false, func(err error) { handled = err }))
+	r.Get("/panic", func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+
+	if handled == nil || handled.Error() != "boom" {
+		t.Errorf("expected error handler to be called with boom, got %v", handled)
+	}
+}
+
+func TestRecoveryHTTPError(t *testing.T) {
+	r := gor.NewRouter()
+	r.Use(recovery.NewWithConfig(recovery.Config{}))
+	r.Get("/panic", func(w http.ResponseWriter, req *http.Request) {
+		panic(gor.NewError(http.StatusServiceUnavailable, "maintenance"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "maintenance") {
+		t.Errorf("expected 503 maintenance, got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,134 @@
+package gor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// OldInputCookieName is the name of the cookie carrying the submitted values
+// and validation errors of a form to the next request.
+var OldInputCookieName = "gor_old_input"
+
+// Template data keys of the old input injected by Render.
+const (
+	oldValuesKey = "old"
+	oldErrorsKey = formErrorsKey
+)
+
+// oldInputKey is the context key of the old input of the request.
+const oldInputKey = contextType("old_input")
+
+// oldInput are the submitted values and validation errors of a form.
+type oldInput struct {
+	Values map[string]string `json:"v,omitempty"`
+	Errors map[string]string `json:"e,omitempty"`
+}
+
+// WithOldInput keeps the submitted form values of the request and the
+// validation errors of err in a cookie until the next page is rendered,
+// so a form can be re-displayed after a redirect (post/redirect/get):
+//
+//	var signup Signup
+//	if err := gor.BodyParser(req, &signup); err != nil {
+//		gor.WithOldInput(w, req, err)
+//		gor.Redirect(w, req, "/signup", http.StatusSeeOther)
+//		return
+//	}
+//
+// Render passes the values as "old" and the messages of the errors by field
+// as "errors", unless data already has these keys, and the form template
+// function fills its inputs with them:
+//
+//	<input name="email" value="{{ .old.email }}"> {{ .errors.Email }}
+//
+// The values are the first value of each field of the urlencoded or multipart
+// form parsed by BodyParser. Files, the CSRF token and the fields whose name
+// contains "password" are not kept. err may be ValidationErrors, FormErrors,
+// an error wrapping them, or nil to keep the values only.
+//
+// The cookie is encrypted with the keys of WithCookieKeys, if any.
+// It returns ErrCookieTooLong if the values do not fit in a cookie.
+func WithOldInput(w http.ResponseWriter, req *http.Request, err error) error {
+	input := oldInput{
+		Values: make(map[string]string, len(req.Form)),
+		Errors: formErrorMessages(err),
+	}
+
+	for name, values := range req.Form {
+		if len(values) == 0 || name == csrfTokenKey || strings.Contains(strings.ToLower(name), "password") {
+			continue
+		}
+		input.Values[name] = values[0]
+	}
+
+	if ctx, ok := req.Context().Value(contextKey).(*CTX); ok {
+		ctx.Set(oldInputKey, input)
+	}
+
+	b, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	cookie := NewCookie(req, OldInputCookieName, string(b))
+	if keys := routerCookieKeys(req); len(keys) > 0 {
+		return SetEncryptedCookie(w, cookie, keys)
+	}
+	return setEncodedCookie(w, cookie, base64.RawURLEncoding.EncodeToString(b))
+}
+
+// OldInput returns the form values and the validation error messages kept by
+// WithOldInput, and clears them, so they are returned only once.
+// Both maps are nil if there is no old input.
+func OldInput(w http.ResponseWriter, req *http.Request) (values, errs map[string]string) {
+	input, ok := pendingOldInput(req)
+	if !ok {
+		return nil, nil
+	}
+
+	if ctx, ok := req.Context().Value(contextKey).(*CTX); ok {
+		ctx.Set(oldInputKey, oldInput{})
+	}
+
+	cookie := NewCookie(req, OldInputCookieName, "")
+	cookie.MaxAge = -1
+	http.SetCookie(w, cookie)
+	return input.Values, input.Errors
+}
+
+// pendingOldInput returns the old input kept during the request, or that of
+// the old input cookie, and false if there is none.
+func pendingOldInput(req *http.Request) (oldInput, bool) {
+	if ctx, ok := req.Context().Value(contextKey).(*CTX); ok {
+		if input, ok := ctx.Get(oldInputKey).(oldInput); ok {
+			return input, input.Values != nil || input.Errors != nil
+		}
+	}
+
+	var value []byte
+	if keys := routerCookieKeys(req); len(keys) > 0 {
+		v, err := GetEncryptedCookie(req, OldInputCookieName, keys)
+		if err != nil {
+			return oldInput{}, false
+		}
+		value = []byte(v)
+	} else {
+		cookie, err := req.Cookie(OldInputCookieName)
+		if err != nil {
+			return oldInput{}, false
+		}
+
+		value, err = base64.RawURLEncoding.DecodeString(cookie.Value)
+		if err != nil {
+			return oldInput{}, false
+		}
+	}
+
+	var input oldInput
+	if err := json.Unmarshal(value, &input); err != nil {
+		return oldInput{}, false
+	}
+	return input, true
+}
@@ -0,0 +1,190 @@
+package gor
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Query parameters of the page and the number of items per page.
+const (
+	pageParam    = "page"
+	perPageParam = "per_page"
+)
+
+// ParsePagination returns the page, starting at 1, and the number of items
+// per page of the "page" and "per_page" query parameters. Missing or invalid
+// values default to the first page and defaultPerPage items, and perPage is
+// at most maxPerPage, if positive.
+//
+//	page, perPage := gor.ParsePagination(req, 20, 100)
+//	users, total := listUsers(page, perPage)
+func ParsePagination(req *http.Request, defaultPerPage, maxPerPage int) (page, perPage int) {
+	page = QueryInt(req, pageParam, 1)
+	if page < 1 {
+		page = 1
+	}
+
+	perPage = QueryInt(req, perPageParam, defaultPerPage)
+	if perPage < 1 {
+		perPage = defaultPerPage
+	}
+
+	if maxPerPage > 0 && perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+	return page, perPage
+}
+
+// Paginator computes the pages of a list of items and their URLs, built from
+// the request URL, for the pagination component and SendPage:
+//
+//	page, perPage := gor.ParsePagination(req, 20, 100)
+//	users, total := listUsers(page, perPage)
+//	r.Render(w, req, "users/list", gor.Map{
+//		"users":      users,
+//		"pagination": gor.NewPaginator(req, total, page, perPage),
+//	})
+//
+//	{{ template "pagination" . }}
+type Paginator struct {
+	Page       int   // Number of the current page, starting at 1
+	PerPage    int   // Maximum number of items of a page
+	Total      int64 // Number of items in the list
+	TotalPages int   // Number of pages in the list, at least 1
+
+	// Window is the number of pages linked on each side of the current page
+	// by Pages. Defaults to 2.
+	Window int
+
+	url url.URL
+}
+
+// PageItem is a page of the window returned by Paginator.Pages.
+type PageItem struct {
+	Number  int    // Number of the page, 0 for a gap
+	URL     string // URL of the page
+	Current bool   // Whether it is the current page
+	Gap     bool   // Whether it stands for the pages omitted from the window
+}
+
+// NewPaginator returns the Paginator of page, the page of a list of total
+// items with perPage items per page, whose URLs are the request URL with the
+// "page" and "per_page" query parameters replaced.
+func NewPaginator(req *http.Request, total int64, page, perPage int) *Paginator {
+	if page < 1 {
+		page = 1
+	}
+
+	totalPages := 1
+	if perPage > 0 && total > 0 {
+		totalPages = int((total + int64(perPage) - 1) / int64(perPage))
+	}
+
+	p := &Paginator{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: totalPages,
+		Window:     2,
+		url:        *req.URL,
+	}
+	p.url.Scheme, p.url.Host = "", ""
+	return p
+}
+
+// Offset returns the number of items before the current page,
+// e.g for the OFFSET clause of a query.
+func (p *Paginator) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+// HasPrev reports whether there is a page before the current page.
+func (p *Paginator) HasPrev() bool {
+	return p.Page > 1
+}
+
+// HasNext reports whether there is a page after the current page.
+func (p *Paginator) HasNext() bool {
+	return p.Page < p.TotalPages
+}
+
+// URL returns the URL of the page n.
+func (p *Paginator) URL(n int) string {
+	u := p.url
+	query := u.Query()
+	query.Set(pageParam, strconv.Itoa(n))
+	query.Set(perPageParam, strconv.Itoa(p.PerPage))
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// PrevURL returns the URL of the previous page, or "" on the first page.
+func (p *Paginator) PrevURL() string {
+	if !p.HasPrev() {
+		return ""
+	}
+	return p.URL(p.Page - 1)
+}
+
+// NextURL returns the URL of the next page, or "" on the last page.
+func (p *Paginator) NextURL() string {
+	if !p.HasNext() {
+		return ""
+	}
+	return p.URL(p.Page + 1)
+}
+
+// Links returns the URLs of the pages around the current page.
+func (p *Paginator) Links() PageLinks {
+	return PageLinks{
+		Self:  p.URL(p.Page),
+		First: p.URL(1),
+		Last:  p.URL(p.TotalPages),
+		Next:  p.NextURL(),
+		Prev:  p.PrevURL(),
+	}
+}
+
+// Pages returns the first and the last pages, and the Window pages on each
+// side of the current page, with gaps for the pages in between, e.g
+// 1 … 4 5 [6] 7 8 … 20. A gap never stands for a single page.
+func (p *Paginator) Pages() []PageItem {
+	window := p.Window
+	if window < 1 {
+		window = 2
+	}
+
+	// A page past the last page shows the window of the last page.
+	current := min(p.Page, p.TotalPages)
+	start, end := max(1, current-window), min(p.TotalPages, current+window)
+	pages := make([]PageItem, 0, end-start+5)
+
+	if start > 1 {
+		pages = append(pages, p.item(1))
+		if start > 3 {
+			pages = append(pages, PageItem{Gap: true})
+		} else if start == 3 {
+			pages = append(pages, p.item(2))
+		}
+	}
+
+	for n := start; n <= end; n++ {
+		pages = append(pages, p.item(n))
+	}
+
+	if end < p.TotalPages {
+		if end < p.TotalPages-2 {
+			pages = append(pages, PageItem{Gap: true})
+		} else if end == p.TotalPages-2 {
+			pages = append(pages, p.item(p.TotalPages-1))
+		}
+		pages = append(pages, p.item(p.TotalPages))
+	}
+	return pages
+}
+
+// item returns the page n of the window.
+func (p *Paginator) item(n int) PageItem {
+	return PageItem{Number: n, URL: p.URL(n), Current: n == p.Page}
+}
@@ -0,0 +1,75 @@
+package gor
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		url     string
+		page    int
+		perPage int
+	}{
+		{"/users", 1, 20},
+		{"/users?page=3&per_page=50", 3, 50},
+		{"/users?page=-1&per_page=0", 1, 20},
+		{"/users?page=abc&per_page=500", 1, 100},
+	}
+
+	for _, tt := range tests {
+		page, perPage := ParsePagination(httptest.NewRequest("GET", tt.url, nil), 20, 100)
+		if page != tt.page || perPage != tt.perPage {
+			t.Errorf("%s: expected %d, %d, got %d, %d", tt.url, tt.page, tt.perPage, page, perPage)
+		}
+	}
+}
+
+func TestPaginator(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://example.com/users?q=jo&page=6", nil)
+	p := NewPaginator(req, 200, 6, 10)
+
+	if p.TotalPages != 20 || p.Offset() != 50 || !p.HasPrev() || !p.HasNext() {
+		t.Errorf("unexpected paginator %+v", p)
+	}
+
+	if p.PrevURL() != "/users?page=5&per_page=10&q=jo" || p.NextURL() != "/users?page=7&per_page=10&q=jo" {
+		t.Errorf("unexpected URLs %q, %q", p.PrevURL(), p.NextURL())
+	}
+
+	numbers := func(pages []PageItem) []int {
+		n := make([]int, len(pages))
+		for i, page := range pages {
+			n[i] = page.Number
+			if page.Current {
+				n[i] = -page.Number
+			}
+		}
+		return n
+	}
+
+	tests := []struct {
+		page int
+		want []int // Current page negated, gaps are 0
+	}{
+		{6, []int{1, 0, 4, 5, -6, 7, 8, 0, 20}},
+		{1, []int{-1, 2, 3, 0, 20}},
+		{4, []int{1, 2, 3, -4, 5, 6, 0, 20}},
+		{17, []int{1, 0, 15, 16, -17, 18, 19, 20}},
+		{20, []int{1, 0, 18, 19, -20}},
+		{25, []int{1, 0, 18, 19, 20}},
+	}
+
+	for _, tt := range tests {
+		got := numbers(NewPaginator(req, 200, tt.page, 10).Pages())
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("page %d: expected %v, got %v", tt.page, tt.want, got)
+		}
+	}
+
+	single := NewPaginator(req, 0, 1, 10)
+	if single.TotalPages != 1 || single.HasNext() || single.NextURL() != "" || len(single.Pages()) != 1 {
+		t.Errorf("unexpected empty paginator %+v", single)
+	}
+}
@@ -0,0 +1,280 @@
+package gor
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// matcher matches requests to handlers registered with ServeMux patterns.
+// It is implemented by *http.ServeMux and *radixTree.
+type matcher interface {
+	Handle(pattern string, handler http.Handler)
+	Handler(r *http.Request) (h http.Handler, pattern string)
+	ServeHTTP(w http.ResponseWriter, r *http.Request)
+}
+
+// WithRadixTree replaces the http.ServeMux used to match routes with a radix tree.
+// Lookups are proportional to the number of path segments rather than the number
+// of registered routes. See BenchmarkRadixTreeManyRoutes.
+//
+// Patterns have the same syntax as http.ServeMux patterns("[METHOD ]/path") and support
+// wildcards "{name}", "{name...}", "{$}" and trailing slash subtree matches.
+// Route matching prefers static segments to wildcards, and single segment wildcards
+// to multi segment wildcards.
+//
+// Unlike http.ServeMux, host patterns are not supported and request paths
+// are not cleaned or redirected.
+func WithRadixTree() RouterOption {
+	return func(r *Router) {
+		r.mux = newRadixTree()
+	}
+}
+
+// radixEntry is a handler registered in the tree.
+type radixEntry struct {
+	pattern  string       // the registered pattern
+	handler  http.Handler // the handler
+	names    []string     // names of the {name} wildcards in order
+	wildcard string       // name of the {name...} wildcard for subtree entries
+}
+
+// radixNode is a node in the tree, one per path segment.
+type radixNode struct {
+	static  map[string]*radixNode  // static child segments
+	param   *radixNode             // child for a {name} segment
+	exact   map[string]*radixEntry // handlers matching at this node, keyed by method
+	subtree map[string]*radixEntry // handlers matching this node and all its descendants
+}
+
+// radixTree is a path segment tree implementing the matcher interface.
+type radixTree struct {
+	root *radixNode
+}
+
+func newRadixTree() *radixTree {
+	return &radixTree{root: &radixNode{}}
+}
+
+// Handle registers the handler for the given pattern.
+// It panics if the pattern is invalid or conflicts with an existing pattern.
+func (t *radixTree) Handle(pattern string, handler http.Handler) {
+	method, path, found := strings.Cut(pattern, " ")
+	if !found {
+		method, path = "", pattern
+	}
+	path = strings.TrimLeft(path, " \t")
+
+	if !strings.HasPrefix(path, "/") {
+		panic(fmt.Sprintf("gor: invalid pattern %q: host patterns are not supported by the radix tree", pattern))
+	}
+
+	segments := strings.Split(path[1:], "/")
+	node := t.root
+	entry := &radixEntry{pattern: pattern, handler: handler}
+
+	for i, seg := range segments {
+		last := i == len(segments)-1
+
+		switch {
+		case seg == "" && last:
+			// Trailing slash. Matches this node's subtree.
+			node.register(&node.subtree, method, entry)
+			return
+		case seg == "{$}":
+			if !last {
+				panic(fmt.Sprintf("gor: invalid pattern %q: {$} must be at the end", pattern))
+			}
+			node = node.child("")
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "...}"):
+			if !last {
+				panic(fmt.Sprintf("gor: invalid pattern %q: {...} wildcard must be at the end", pattern))
+			}
+			entry.wildcard = seg[1 : len(seg)-4]
+			node.register(&node.subtree, method, entry)
+			return
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+			entry.names = append(entry.names, seg[1:len(seg)-1])
+			if node.param == nil {
+				node.param = &radixNode{}
+			}
+			node = node.param
+		case strings.ContainsAny(seg, "{}"):
+			panic(fmt.Sprintf("gor: invalid pattern %q: wildcards must be full path segments", pattern))
+		default:
+			node = node.child(seg)
+		}
+	}
+	node.register(&node.exact, method, entry)
+}
+
+// child returns the static child for seg, creating it if it does not exist.
+func (n *radixNode) child(seg string) *radixNode {
+	if n.static == nil {
+		n.static = make(map[string]*radixNode)
+	}
+
+	c, ok := n.static[seg]
+	if !ok {
+		c = &radixNode{}
+		n.static[seg] = c
+	}
+	return c
+}
+
+// register adds the entry to the method map, panicking on conflicts.
+func (n *radixNode) register(m *map[string]*radixEntry, method string, entry *radixEntry) {
+	if *m == nil {
+		*m = make(map[string]*radixEntry)
+	}
+
+	if existing, ok := (*m)[method]; ok {
+		panic(fmt.Sprintf("gor: pattern %q conflicts with pattern %q", entry.pattern, existing.pattern))
+	}
+	(*m)[method] = entry
+}
+
+// match finds the entry for the request method and path. It returns the entry,
+// the wildcard values in order and the allowed methods if the path matched with another method.
+func (t *radixTree) match(r *http.Request, values []string) (*radixEntry, []string, []string) {
+	var segBuf [16]string
+	var allowed []string
+
+	segments := splitPath(segBuf[:0], r)
+	entry, values := t.root.match(r.Method, segments, 0, values, &allowed)
+	return entry, values, allowed
+}
+
+func (n *radixNode) match(method string, segments []string, i int, values []string, allowed *[]string) (*radixEntry, []string) {
+	if i == len(segments) {
+		if e := selectMethod(method, n.exact, allowed); e != nil {
+			return e, values
+		}
+		return nil, nil
+	}
+
+	seg := segments[i]
+	if child, ok := n.static[seg]; ok {
+		if e, v := child.match(method, segments, i+1, values, allowed); e != nil {
+			return e, v
+		}
+	}
+
+	if n.param != nil && seg != "" {
+		if e, v := n.param.match(method, segments, i+1, append(values, seg), allowed); e != nil {
+			return e, v
+		}
+	}
+
+	if e := selectMethod(method, n.subtree, allowed); e != nil {
+		if e.wildcard != "" {
+			values = append(values, strings.Join(segments[i:], "/"))
+		}
+		return e, values
+	}
+	return nil, nil
+}
+
+// selectMethod returns the entry for method. GET handlers also match HEAD requests
+// and entries registered without a method match all methods.
+// If entries exist but none matches, their methods are recorded as allowed.
+func selectMethod(method string, entries map[string]*radixEntry, allowed *[]string) *radixEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if e, ok := entries[method]; ok {
+		return e
+	}
+
+	if method == http.MethodHead {
+		if e, ok := entries[http.MethodGet]; ok {
+			return e
+		}
+	}
+
+	if e, ok := entries[""]; ok {
+		return e
+	}
+
+	for k := range entries {
+		*allowed = append(*allowed, k)
+		if k == http.MethodGet {
+			*allowed = append(*allowed, http.MethodHead)
+		}
+	}
+	return nil
+}
+
+// splitPath appends the unescaped segments of the request path to segments.
+func splitPath(segments []string, r *http.Request) []string {
+	path := r.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	path = path[1:]
+	for {
+		seg, rest, found := strings.Cut(path, "/")
+		if strings.IndexByte(seg, '%') >= 0 {
+			if s, err := url.PathUnescape(seg); err == nil {
+				seg = s
+			}
+		}
+
+		segments = append(segments, seg)
+		if !found {
+			return segments
+		}
+		path = rest
+	}
+}
+
+// Handler returns the handler to use for the given request and its pattern.
+// If no route matches, a not found or method not allowed handler is returned
+// with an empty pattern. Like http.ServeMux.Handler, path values are not set.
+func (t *radixTree) Handler(r *http.Request) (http.Handler, string) {
+	var buf [8]string
+	entry, _, allowed := t.match(r, buf[:0])
+	if entry != nil {
+		return entry.handler, entry.pattern
+	}
+	return notFound(allowed), ""
+}
+
+// notFound returns a 405 handler if the path matched with a different method.
+// Otherwise it returns http.NotFoundHandler.
+func notFound(allowed []string) http.Handler {
+	if len(allowed) == 0 {
+		return http.NotFoundHandler()
+	}
+
+	sort.Strings(allowed)
+	allow := strings.Join(slices.Compact(allowed), ", ")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allow)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	})
+}
+
+// ServeHTTP dispatches the request to the matching handler after setting path values.
+func (t *radixTree) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buf [8]string
+	entry, values, allowed := t.match(r, buf[:0])
+	if entry == nil {
+		notFound(allowed).ServeHTTP(w, r)
+		return
+	}
+
+	for i, name := range entry.names {
+		r.SetPathValue(name, values[i])
+	}
+
+	if entry.wildcard != "" {
+		r.SetPathValue(entry.wildcard, values[len(values)-1])
+	}
+	entry.handler.ServeHTTP(w, r)
+}
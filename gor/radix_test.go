@@ -0,0 +1,97 @@
+package gor_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abiiranathan/gor/gor"
+)
+
+// The radix tree must match the same requests as the http.ServeMux backend.
+func TestRadixTreeMatchesServeMux(t *testing.T) {
+	register := func(r *gor.Router) {
+		handler := func(name string) http.HandlerFunc {
+			return func(w http.ResponseWriter, req *http.Request) {
+				fmt.Fprintf(w, "%s id=%s rest=%s", name, req.PathValue("id"), req.PathValue("rest"))
+			}
+		}
+
+		r.Get("/", handler("home"))
+		r.Get("/users", handler("users"))
+		r.Get("/users/new", handler("new"))
+		r.Get("/users/{id}", handler("user"))
+		r.Post("/users/{id}", handler("update"))
+		r.Get("/users/{id}/posts", handler("posts"))
+		r.Get("/files/{rest...}", handler("files"))
+	}
+
+	mux := gor.NewRouter()
+	tree := gor.NewRouter(gor.WithRadixTree())
+	register(mux)
+	register(tree)
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{"GET", "/"},
+		{"GET", "/users"},
+		{"GET", "/users/"},
+		{"GET", "/users/new"},
+		{"GET", "/users/10"},
+		{"HEAD", "/users/10"},
+		{"POST", "/users/10"},
+		{"DELETE", "/users/10"},
+		{"GET", "/users/10/posts"},
+		{"GET", "/users/hello%20world"},
+		{"GET", "/files/a/b/c.txt"},
+		{"GET", "/notfound"},
+		{"GET", "/users/10/notfound"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w1 := httptest.NewRecorder()
+			mux.ServeHTTP(w1, httptest.NewRequest(tt.method, tt.path, nil))
+
+			w2 := httptest.NewRecorder()
+			tree.ServeHTTP(w2, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w1.Code != w2.Code {
+				t.Errorf("expected status %d, got %d", w1.Code, w2.Code)
+			}
+
+			if w1.Body.String() != w2.Body.String() {
+				t.Errorf("expected body %q, got %q", w1.Body.String(), w2.Body.String())
+			}
+		})
+	}
+}
+
+func benchmarkManyRoutes(b *testing.B, options ...gor.RouterOption) {
+	r := gor.NewRouter(options...)
+	for i := 0; i < 1000; i++ {
+		r.Get(fmt.Sprintf("/resource%d/{id}/items", i), func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/resource999/10/items", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkServeMuxManyRoutes(b *testing.B) {
+	benchmarkManyRoutes(b)
+}
+
+func BenchmarkRadixTreeManyRoutes(b *testing.B) {
+	benchmarkManyRoutes(b, gor.WithRadixTree())
+}
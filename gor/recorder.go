@@ -0,0 +1,154 @@
+package gor
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+)
+
+// ResponseRecorder is a ResponseWriter that buffers the status and body of
+// a response, so that middlewares can inspect or rewrite it before sending
+// it with Replay, e.g to compute an ETag or to inject HTML.
+//
+// Headers are set on the wrapped ResponseWriter, and are only sent by Replay,
+// so middlewares can change them after the handler returned.
+// Responses larger than the maximum size, or flushed by the handler, are
+// streamed to the wrapped ResponseWriter instead. Passthrough reports it.
+//
+//	rec := gor.NewResponseRecorder(w, 1<<20)
+//	next.ServeHTTP(rec, req)
+//	if !rec.Passthrough() {
+//		body := bytes.ReplaceAll(rec.Body().Bytes(), []byte("</body>"), script)
+//		rec.Body().Reset()
+//		rec.Body().Write(body)
+//	}
+//	rec.Replay()
+type ResponseRecorder struct {
+	w           http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	maxSize     int
+	passthrough bool
+	replayed    bool
+}
+
+// NewResponseRecorder returns a ResponseRecorder buffering the response
+// written to w, up to maxSize bytes. A zero or negative maxSize buffers the
+// whole response.
+func NewResponseRecorder(w http.ResponseWriter, maxSize int) *ResponseRecorder {
+	return &ResponseRecorder{w: w, status: http.StatusOK, maxSize: maxSize}
+}
+
+// Header returns the header map of the wrapped ResponseWriter.
+func (rec *ResponseRecorder) Header() http.Header {
+	return rec.w.Header()
+}
+
+// WriteHeader records the status code. Only the first call has an effect.
+func (rec *ResponseRecorder) WriteHeader(status int) {
+	if rec.wroteHeader {
+		return
+	}
+
+	rec.status = status
+	rec.wroteHeader = true
+
+	if length, err := strconv.Atoi(rec.Header().Get("Content-Length")); err == nil && rec.exceedsLimit(length) {
+		rec.startPassthrough()
+	}
+}
+
+// Write buffers b, or writes it to the wrapped ResponseWriter in passthrough.
+func (rec *ResponseRecorder) Write(b []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+
+	if !rec.passthrough && rec.exceedsLimit(rec.body.Len()+len(b)) {
+		rec.startPassthrough()
+	}
+
+	if rec.passthrough {
+		return rec.w.Write(b)
+	}
+	return rec.body.Write(b)
+}
+
+// Flush streams the response to the wrapped ResponseWriter and flushes it.
+func (rec *ResponseRecorder) Flush() {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+
+	rec.startPassthrough()
+	http.NewResponseController(rec.w).Flush()
+}
+
+// Unwrap returns the wrapped ResponseWriter for http.ResponseController.
+func (rec *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return rec.w
+}
+
+// Status returns the recorded status code, http.StatusOK by default.
+func (rec *ResponseRecorder) Status() int {
+	return rec.status
+}
+
+// SetStatus replaces the status code sent by Replay.
+func (rec *ResponseRecorder) SetStatus(status int) {
+	rec.status = status
+}
+
+// Body returns the buffered body, which may be modified before Replay.
+// It is empty in passthrough.
+func (rec *ResponseRecorder) Body() *bytes.Buffer {
+	return &rec.body
+}
+
+// Written reports whether the handler wrote the header or the body.
+func (rec *ResponseRecorder) Written() bool {
+	return rec.wroteHeader
+}
+
+// Passthrough reports whether the response was streamed to the wrapped
+// ResponseWriter because it exceeded the maximum size or was flushed.
+func (rec *ResponseRecorder) Passthrough() bool {
+	return rec.passthrough
+}
+
+// Replay sends the recorded status and body to the wrapped ResponseWriter.
+// The Content-Length header, if set, is updated to the length of the body.
+// Replay does nothing in passthrough or if it was already called.
+func (rec *ResponseRecorder) Replay() error {
+	if rec.passthrough || rec.replayed {
+		return nil
+	}
+	rec.replayed = true
+
+	if rec.Header().Get("Content-Length") != "" {
+		rec.Header().Set("Content-Length", strconv.Itoa(rec.body.Len()))
+	}
+
+	rec.w.WriteHeader(rec.status)
+	_, err := rec.body.WriteTo(rec.w)
+	return err
+}
+
+func (rec *ResponseRecorder) exceedsLimit(n int) bool {
+	return rec.maxSize > 0 && n > rec.maxSize
+}
+
+// startPassthrough writes the status and the buffered body to the wrapped
+// ResponseWriter. Subsequent writes go directly to it.
+func (rec *ResponseRecorder) startPassthrough() {
+	if rec.passthrough {
+		return
+	}
+
+	rec.passthrough = true
+	rec.w.WriteHeader(rec.status)
+	if rec.body.Len() > 0 {
+		rec.body.WriteTo(rec.w)
+	}
+}
@@ -0,0 +1,78 @@
+package gor
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseRecorder(t *testing.T) {
+	w := httptest.NewRecorder()
+	rec := NewResponseRecorder(w, 0)
+
+	rec.Header().Set("Content-Length", "12")
+	rec.WriteHeader(http.StatusCreated)
+	rec.Write([]byte("<body></body>"))
+
+	if w.Body.Len() != 0 || w.Code != http.StatusOK {
+		t.Fatalf("expected nothing to be written before Replay, got %d %q", w.Code, w.Body.String())
+	}
+
+	if rec.Status() != http.StatusCreated || !rec.Written() || rec.Passthrough() {
+		t.Errorf("unexpected recorder state status=%d written=%v passthrough=%v", rec.Status(), rec.Written(), rec.Passthrough())
+	}
+
+	body := bytes.Replace(rec.Body().Bytes(), []byte("</body>"), []byte("<script></script></body>"), 1)
+	rec.Body().Reset()
+	rec.Body().Write(body)
+	rec.SetStatus(http.StatusAccepted)
+
+	if err := rec.Replay(); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Code != http.StatusAccepted || w.Body.String() != "<body><script></script></body>" {
+		t.Errorf("unexpected replayed response %d %q", w.Code, w.Body.String())
+	}
+
+	if cl := w.Header().Get("Content-Length"); cl != "30" {
+		t.Errorf("expected the Content-Length to be updated to 30, got %s", cl)
+	}
+
+	// Replay is idempotent.
+	rec.Replay()
+	if w.Body.Len() != 30 {
+		t.Errorf("expected a single replay, got %q", w.Body.String())
+	}
+}
+
+func TestResponseRecorderPassthrough(t *testing.T) {
+	w := httptest.NewRecorder()
+	rec := NewResponseRecorder(w, 4)
+	rec.Write([]byte("abc"))
+	rec.Write([]byte("def"))
+
+	if !rec.Passthrough() || w.Body.String() != "abcdef" || rec.Body().Len() != 0 {
+		t.Errorf("expected large responses to be streamed, got %q", w.Body.String())
+	}
+
+	// Flushed responses are streamed.
+	w = httptest.NewRecorder()
+	rec = NewResponseRecorder(w, 0)
+	rec.Write([]byte("event"))
+	http.NewResponseController(rec).Flush()
+
+	if !rec.Passthrough() || !w.Flushed || w.Body.String() != "event" {
+		t.Errorf("expected flushed responses to be streamed, got %q", w.Body.String())
+	}
+
+	// Declared lengths beyond the limit are streamed.
+	w = httptest.NewRecorder()
+	rec = NewResponseRecorder(w, 4)
+	rec.Header().Set("Content-Length", "100")
+	rec.WriteHeader(http.StatusOK)
+	if !rec.Passthrough() {
+		t.Error("expected a declared length beyond the limit to be streamed")
+	}
+}
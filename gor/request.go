@@ -2,37 +2,62 @@ package gor
 
 import (
 	"context"
-	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"os"
+	"reflect"
+	"regexp"
 	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
 	ContentTypeJSON          string = "application/json"
 	ContentTypeXML           string = "application/xml"
+	ContentTypeMsgPack       string = "application/msgpack"
 	ContentTypeUrlEncoded    string = "application/x-www-form-urlencoded"
 	ContentTypeMultipartForm string = "multipart/form-data"
 	ContentTypeHTML          string = "text/html"
 	ContentTypeCSV           string = "text/csv"
 	ContentTypeText          string = "text/plain"
 	ContentTypeEventStream   string = "text/event-stream"
+	ContentTypeJavaScript    string = "application/javascript"
 )
 
-// Set a value in the request context. Also saves a copy in locals map.
-// This allows for passing of locals to templates.
-func SetContextValue(req *http.Request, key any, value interface{}) {
-	ctx := context.WithValue(req.Context(), key, value)
-	*req = *req.WithContext(ctx)
+// StatusClientClosedRequest is the non-standard status code reported for
+// requests whose client disconnected before the response was complete.
+// It is never sent to the client.
+const StatusClientClosedRequest = 499
 
-	// Set the value in the locals map. This allows us to access the value in the templates
+// ClientGone reports whether the client disconnected or canceled the request.
+// Long running handlers should check it, or select on req.Context().Done(),
+// to stop work that nobody will receive.
+func ClientGone(req *http.Request) bool {
+	return errors.Is(req.Context().Err(), context.Canceled)
+}
+
+// Set a value in the request context.
+// When using gor.Router, the value is stored in the locals of the request CTX.
+// It is then visible to req.Context().Value(key) for this request (and any request
+// derived from it) and is passed to templates. The request itself is not modified.
+//
+// If the request was not dispatched by gor.Router, the request context is
+// replaced in place as a fallback.
+func SetContextValue(req *http.Request, key any, value interface{}) {
 	if localCtx, ok := req.Context().Value(contextKey).(*CTX); ok {
 		localCtx.Set(key, value)
+		return
 	}
+
+	ctx := context.WithValue(req.Context(), key, value)
+	*req = *req.WithContext(ctx)
 }
 
 // return a value from context.
@@ -41,11 +66,218 @@ func GetContextValue(req *http.Request, key any) interface{} {
 	return req.Context().Value(key)
 }
 
-// Send v as JSON. Uses json.NewEncoder and sets content-type
+// Send v as JSON. Uses gor.JSONCodec and sets content-type
 // application/json for the response.
+// v is encoded into a pooled buffer first so that nothing is written if encoding fails.
+// The JSON is formatted as configured with JSONIndent and JSONEscapeHTML.
 func SendJSON(w http.ResponseWriter, v interface{}) error {
+	return sendJSON(w, v, responseJSONFormat(w))
+}
+
+// SendJSONIndent is like SendJSON, but always indents the JSON with the
+// prefix and indentation of JSONIndent, or two spaces.
+func SendJSONIndent(w http.ResponseWriter, v interface{}) error {
+	format := responseJSONFormat(w)
+	if !format.indent && !format.pretty {
+		format.indentStr = "  "
+	}
+	format.indent = true
+	return sendJSON(w, v, format)
+}
+
+// sendJSON sends v encoded with format.
+func sendJSON(w http.ResponseWriter, v interface{}, format jsonFormat) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := encodeJSON(buf, v, format); err != nil {
+		return err
+	}
+
 	w.Header().Set("Content-Type", ContentTypeJSON)
-	return json.NewEncoder(w).Encode(v)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// ErrInvalidJSONPCallback is returned by SendJSONP for callback names that
+// are not JavaScript identifiers or dotted paths of identifiers.
+var ErrInvalidJSONPCallback = errors.New("gor: invalid JSONP callback")
+
+var jsonpCallbackRegex = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*(\.[A-Za-z_$][A-Za-z0-9_$]*)*$`)
+
+// SendJSONP sends v as JSON wrapped in a call to the function named by the
+// callbackParam query parameter, "callback" by default, with content-type
+// application/javascript, e.g "/**/ render({...});" for "?callback=render".
+// Requests without the parameter receive plain JSON like SendJSON.
+// Callback names other than dotted JavaScript identifiers of up to 128
+// characters are rejected with ErrInvalidJSONPCallback.
+func SendJSONP(w http.ResponseWriter, req *http.Request, v interface{}, callbackParam string) error {
+	if callbackParam == "" {
+		callbackParam = "callback"
+	}
+
+	callback := req.URL.Query().Get(callbackParam)
+	if callback == "" {
+		return SendJSON(w, v)
+	}
+
+	if len(callback) > 128 || !jsonpCallbackRegex.MatchString(callback) {
+		return ErrInvalidJSONPCallback
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	// The comment prevents the response from starting with attacker-controlled bytes.
+	buf.WriteString("/**/ ")
+	buf.WriteString(callback)
+	buf.WriteByte('(')
+	if err := encodeJSON(buf, v, responseJSONFormat(w)); err != nil {
+		return err
+	}
+	if b := buf.Bytes(); b[len(b)-1] == '\n' {
+		buf.Truncate(len(b) - 1) // Trailing newline of the encoder
+	}
+	buf.WriteString(");")
+
+	w.Header().Set("Content-Type", ContentTypeJavaScript)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Page is the JSON envelope of a page of items sent by SendPage.
+type Page struct {
+	Data  any       `json:"data"`
+	Meta  PageMeta  `json:"meta"`
+	Links PageLinks `json:"links"`
+}
+
+// PageMeta describes the position of a Page in the list.
+type PageMeta struct {
+	Total      int64 `json:"total"`       // Number of items in the list
+	Page       int   `json:"page"`        // Number of the page, starting at 1
+	PerPage    int   `json:"per_page"`    // Maximum number of items of a page
+	TotalPages int   `json:"total_pages"` // Number of pages in the list
+}
+
+// PageLinks are the URLs of the pages around a Page.
+// Next and Prev are empty on the last and first pages.
+type PageLinks struct {
+	Self  string `json:"self"`
+	First string `json:"first"`
+	Last  string `json:"last"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+}
+
+// SendPage sends items, the page of a list of total items, in a Page envelope
+// with SendJSON, so that list endpoints share one format:
+//
+//	{"data": [...], "meta": {"total": 42, "page": 2, "per_page": 10, "total_pages": 5},
+//	 "links": {"self": "/users?page=2&per_page=10", "next": "/users?page=3&per_page=10", ...}}
+//
+// The links are the request URL with the "page" and "per_page" query parameters
+// replaced, and are also sent in a Link header (RFC 8288).
+//
+//	page, perPage := gor.ParsePagination(req, 20, 100)
+//	users, total := listUsers(page, perPage)
+//	gor.SendPage(w, req, users, total, page, perPage)
+//
+// See Paginator to render the pages of a list in HTML.
+func SendPage(w http.ResponseWriter, req *http.Request, items any, total int64, page, perPage int) error {
+	p := NewPaginator(req, total, page, perPage)
+
+	// Send an empty array rather than null for nil slices.
+	if rv := reflect.ValueOf(items); rv.Kind() == reflect.Slice && rv.IsNil() {
+		items = reflect.MakeSlice(rv.Type(), 0, 0).Interface()
+	}
+
+	links := p.Links()
+	header := []string{
+		fmt.Sprintf("<%s>; rel=\"first\"", links.First),
+		fmt.Sprintf("<%s>; rel=\"last\"", links.Last),
+	}
+	if links.Next != "" {
+		header = append(header, fmt.Sprintf("<%s>; rel=\"next\"", links.Next))
+	}
+	if links.Prev != "" {
+		header = append(header, fmt.Sprintf("<%s>; rel=\"prev\"", links.Prev))
+	}
+	w.Header().Set("Link", strings.Join(header, ", "))
+
+	return SendJSON(w, Page{
+		Data: items,
+		Meta: PageMeta{
+			Total:      p.Total,
+			Page:       p.Page,
+			PerPage:    p.PerPage,
+			TotalPages: p.TotalPages,
+		},
+		Links: links,
+	})
+}
+
+// SendMsgPack sends v encoded with gor.MsgPackCodec and sets content-type
+// application/msgpack for the response. It returns ErrNoMsgPackCodec if
+// no codec is configured.
+func SendMsgPack(w http.ResponseWriter, v interface{}) error {
+	if MsgPackCodec == nil {
+		return ErrNoMsgPackCodec
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := MsgPackCodec.Encode(buf, v); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", ContentTypeMsgPack)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// XMLOptions configures SendXMLWithOptions.
+type XMLOptions struct {
+	// Indent, if set, indents nested elements with it on new lines.
+	Indent string
+
+	// Header writes xml.Header, the <?xml ...?> declaration, before the document.
+	Header bool
+}
+
+// SendXML sends v encoded with encoding/xml and sets content-type
+// application/xml for the response.
+// v is encoded into a pooled buffer first so that nothing is written if encoding fails.
+func SendXML(w http.ResponseWriter, v interface{}) error {
+	return SendXMLWithOptions(w, v, XMLOptions{})
+}
+
+// SendXMLWithOptions is like SendXML, with indentation and the XML declaration
+// configured by opts.
+//
+//	gor.SendXMLWithOptions(w, feed, gor.XMLOptions{Indent: "  ", Header: true})
+func SendXMLWithOptions(w http.ResponseWriter, v interface{}, opts XMLOptions) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if opts.Header {
+		buf.WriteString(xml.Header)
+	}
+
+	enc := xml.NewEncoder(buf)
+	if opts.Indent != "" {
+		enc.Indent("", opts.Indent)
+	}
+
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", ContentTypeXML)
+	_, err := w.Write(buf.Bytes())
+	return err
 }
 
 // Send HTML string.
@@ -67,42 +299,211 @@ func SendString(w http.ResponseWriter, s string) error {
 	return err
 }
 
-// Sends the error message to the client as html.
-// If the Router has errorTemplate configured, the error template will be rendered instead.
-// You can also pass a status code to be used.
-// Yo do not need to call SendError after template rendering since the template will be rendered
-// automatically if an error occurs during template rendering.
-func SendError(w http.ResponseWriter, req *http.Request, err error, status ...int) {
-	var statusCode = http.StatusInternalServerError
-	if len(status) > 0 {
-		statusCode = status[0]
+// copyBufferPool pools the buffers of SendReader.
+var copyBufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 32<<10)
+		return &buf
+	},
+}
+
+// SendReader streams the content of r without loading it into memory,
+// e.g an object downloaded from storage or the output of a pipe.
+// If size is not negative, it is sent as the Content-Length and at most size
+// bytes are read, returning io.ErrUnexpectedEOF if r has fewer.
+// If contentType is empty, net/http sniffs it from the first bytes.
+// Readers implementing io.Closer are not closed.
+//
+//	obj, err := bucket.Get(ctx, key)
+//	...
+//	defer obj.Close()
+//	gor.SendReader(w, obj, obj.Size, obj.ContentType)
+func SendReader(w http.ResponseWriter, r io.Reader, size int64, contentType string) error {
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
 	}
 
-	// Print the error stack trace
-	debug.PrintStack()
+	if size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		r = io.LimitReader(r, size)
+	}
 
-	// In case its htmx, return the error as is
-	isHtmx := req.Header.Get("HX-Request") == "true"
-	if isHtmx {
-		w.Header().Set("Content-Type", ContentTypeHTML)
-		w.WriteHeader(statusCode)
-		w.Write([]byte(err.Error()))
-		return
+	buf := copyBufferPool.Get().(*[]byte)
+	defer copyBufferPool.Put(buf)
+
+	n, err := io.CopyBuffer(w, r, *buf)
+	if err != nil {
+		return err
 	}
 
-	// We are using go router.
-	if ctx, ok := req.Context().Value(contextKey).(*CTX); ok {
-		// get the CTX from the request
-		if ctx.Router.errorTemplate != "" {
-			ctx.Router.renderErrorTemplate(w, err, statusCode)
-			return
+	if size >= 0 && n < size {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// SendStream streams the response by calling fn repeatedly and flushing what it
+// wrote after each call, e.g for log tailing and progress endpoints.
+// Streaming stops when fn returns false, when a write fails or when the client
+// disconnects. Set the Content-Type header before calling SendStream.
+// It returns the write error or the request context error, if any.
+//
+//	gor.SendStream(w, req, func(w io.Writer) bool {
+//		fmt.Fprintf(w, "progress: %d%%\n", <-progress)
+//		return !done
+//	})
+func SendStream(w http.ResponseWriter, req *http.Request, fn func(w io.Writer) bool) error {
+	rc := http.NewResponseController(w)
+	sw := &streamWriter{w: w}
+
+	for {
+		if err := req.Context().Err(); err != nil {
+			return err
+		}
+
+		more := fn(sw)
+		if sw.err != nil {
+			return sw.err
+		}
+
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+
+		if !more {
+			return nil
 		}
 	}
+}
 
-	w.Header().Set("Content-Type", ContentTypeHTML)
-	w.WriteHeader(statusCode)
-	w.Write([]byte(err.Error()))
+// JSONStream writes a JSON array to a response one item at a time, so that
+// large result sets are exported without building them in memory.
+// Create it with NewJSONStream.
+type JSONStream struct {
+	// FlushEvery is the number of items written between flushes of the
+	// response. Defaults to 100.
+	FlushEvery int
+
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	format jsonFormat
+	count  int
+	err    error
+	closed bool
+}
 
+// NewJSONStream returns a JSONStream writing to w. The Content-Type header
+// is set to application/json when the first item is written.
+//
+//	stream := gor.NewJSONStream(w)
+//	for rows.Next() {
+//		...
+//		if err := stream.WriteItem(user); err != nil {
+//			return err
+//		}
+//	}
+//	return stream.Close()
+func NewJSONStream(w http.ResponseWriter) *JSONStream {
+	return &JSONStream{
+		FlushEvery: 100,
+		w:          w,
+		rc:         http.NewResponseController(w),
+		format:     responseJSONFormat(w),
+	}
+}
+
+// WriteItem writes v as the next item of the array.
+// After an error, WriteItem and Close return the same error.
+func (s *JSONStream) WriteItem(v any) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	if s.closed {
+		return errors.New("gor: write to closed JSONStream")
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if s.count == 0 {
+		s.w.Header().Set("Content-Type", ContentTypeJSON)
+		buf.WriteByte('[')
+	} else {
+		buf.WriteByte(',')
+	}
+
+	if err := encodeJSON(buf, v, s.format); err != nil {
+		return err // Nothing was written, the stream can continue
+	}
+
+	if b := buf.Bytes(); b[len(b)-1] == '\n' {
+		buf.Truncate(len(b) - 1) // Trailing newline of the encoder
+	}
+
+	if _, err := s.w.Write(buf.Bytes()); err != nil {
+		s.err = err
+		return err
+	}
+
+	s.count++
+	if s.FlushEvery > 0 && s.count%s.FlushEvery == 0 {
+		if err := s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			s.err = err
+			return err
+		}
+	}
+	return nil
+}
+
+// Close ends the array, writing "[]" if no item was written.
+func (s *JSONStream) Close() error {
+	if s.err != nil || s.closed {
+		return s.err
+	}
+	s.closed = true
+
+	end := "]\n"
+	if s.count == 0 {
+		s.w.Header().Set("Content-Type", ContentTypeJSON)
+		end = "[]\n"
+	}
+
+	if _, err := io.WriteString(s.w, end); err != nil {
+		s.err = err
+	}
+	return s.err
+}
+
+// streamWriter records the first error returned by the underlying writer
+// and discards subsequent writes.
+type streamWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (sw *streamWriter) Write(b []byte) (int, error) {
+	if sw.err != nil {
+		return 0, sw.err
+	}
+
+	n, err := sw.w.Write(b)
+	sw.err = err
+	return n, err
+}
+
+// Sends the error message to the client through the Router's error pipeline.
+// If the Router has errorTemplate configured, the error template will be rendered instead.
+// Clients that accept JSON receive a JSON error. See DefaultErrorHandler.
+// You can also pass a status code to be used.
+// Yo do not need to call SendError after template rendering since the template will be rendered
+// automatically if an error occurs during template rendering.
+// If the response header has already been written, the error is only logged.
+func SendError(w http.ResponseWriter, req *http.Request, err error, status ...int) {
+	// Print the error stack trace
+	debug.PrintStack()
+
+	HandleError(w, req, err, status...)
 }
 
 // sends the error message as a JSON string with the status code
@@ -112,9 +513,13 @@ func SendJSONError(w http.ResponseWriter, resp map[string]any, status ...int) {
 		statusCode = status[0]
 	}
 
+	buf := getBuffer()
+	defer putBuffer(buf)
+	encodeJSON(buf, resp, responseJSONFormat(w))
+
 	w.Header().Set("Content-Type", ContentTypeJSON)
 	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(resp)
+	w.Write(buf.Bytes())
 }
 
 // Returns the header content type stripping everything after ; like
@@ -158,6 +563,46 @@ func ParamInt(req *http.Request, key string, defaults ...int) int {
 	return vInt
 }
 
+// ParamInt64 returns the value of the path parameter as an int64.
+// The value is parsed directly from req.PathValue without intermediate allocations.
+// If the parameter is missing or invalid, the default or 0 is returned.
+func ParamInt64(req *http.Request, key string, defaults ...int64) int64 {
+	v, err := strconv.ParseInt(req.PathValue(key), 10, 64)
+	if err != nil {
+		if len(defaults) > 0 {
+			return defaults[0]
+		}
+		return 0
+	}
+	return v
+}
+
+// ParamUint64 returns the value of the path parameter as a uint64.
+// If the parameter is missing or invalid, the default or 0 is returned.
+func ParamUint64(req *http.Request, key string, defaults ...uint64) uint64 {
+	v, err := strconv.ParseUint(req.PathValue(key), 10, 64)
+	if err != nil {
+		if len(defaults) > 0 {
+			return defaults[0]
+		}
+		return 0
+	}
+	return v
+}
+
+// ParamFloat64 returns the value of the path parameter as a float64.
+// If the parameter is missing or invalid, the default or 0 is returned.
+func ParamFloat64(req *http.Request, key string, defaults ...float64) float64 {
+	v, err := strconv.ParseFloat(req.PathValue(key), 64)
+	if err != nil {
+		if len(defaults) > 0 {
+			return defaults[0]
+		}
+		return 0
+	}
+	return v
+}
+
 // queryInt returns the value of the query as an integer
 func QueryInt(req *http.Request, key string, defaults ...int) int {
 	v := Query(req, key)
@@ -175,6 +620,98 @@ func QueryInt(req *http.Request, key string, defaults ...int) int {
 	return vInt
 }
 
+// QueryBool returns the value of the query parameter as a bool, accepting
+// the values of strconv.ParseBool, e.g "1", "true" or "false".
+// If the parameter is missing or invalid, the default or false is returned.
+func QueryBool(req *http.Request, key string, defaults ...bool) bool {
+	v, err := strconv.ParseBool(Query(req, key))
+	if err != nil {
+		if len(defaults) > 0 {
+			return defaults[0]
+		}
+		return false
+	}
+	return v
+}
+
+// QueryFloat returns the value of the query parameter as a float64.
+// If the parameter is missing or invalid, the default or 0 is returned.
+func QueryFloat(req *http.Request, key string, defaults ...float64) float64 {
+	v, err := strconv.ParseFloat(Query(req, key), 64)
+	if err != nil {
+		if len(defaults) > 0 {
+			return defaults[0]
+		}
+		return 0
+	}
+	return v
+}
+
+// QueryTime returns the value of the query parameter parsed with ParseTime
+// in gor.DefaultTimezone, e.g "2024-08-20" or "2024-08-20T10:11:09Z".
+// If the parameter is missing or invalid, the default or the zero time is returned.
+func QueryTime(req *http.Request, key string, defaults ...time.Time) time.Time {
+	v, err := ParseTime(Query(req, key), DefaultTimezone)
+	if err != nil {
+		if len(defaults) > 0 {
+			return defaults[0]
+		}
+		return time.Time{}
+	}
+	return v
+}
+
+// ParamUUID returns the value of the path parameter if it is a UUID like
+// "123e4567-e89b-12d3-a456-426614174000", in lower case.
+// If the parameter is missing or invalid, the default or "" is returned.
+func ParamUUID(req *http.Request, key string, defaults ...string) string {
+	v := req.PathValue(key)
+	if !uuidRegex.MatchString(v) {
+		if len(defaults) > 0 {
+			return defaults[0]
+		}
+		return ""
+	}
+	return strings.ToLower(v)
+}
+
+// Param returns the value of the path parameter converted to T, like the
+// fields of BodyParser: numbers, bool, time.Time parsed with ParseTime,
+// FormScanner implementations and types with a RegisterFormConverter converter,
+// e.g uuid.UUID. If the parameter is missing or invalid, the default or the
+// zero value is returned.
+//
+//	id := gor.Param[int64](req, "id")
+//	day := gor.Param(req, "day", time.Now())
+func Param[T any](req *http.Request, key string, defaults ...T) T {
+	return convertValue(req.PathValue(key), defaults)
+}
+
+// QueryVal returns the value of the query parameter converted to T, like Param.
+// If the parameter is missing or invalid, the default or the zero value is returned.
+//
+//	page := gor.QueryVal(req, "page", 1)
+//	active := gor.QueryVal[bool](req, "active")
+func QueryVal[T any](req *http.Request, key string, defaults ...T) T {
+	return convertValue(req.URL.Query().Get(key), defaults)
+}
+
+// convertValue converts s to T, returning the first default or the zero value
+// if s is empty or invalid.
+func convertValue[T any](s string, defaults []T) T {
+	var v T
+	if s != "" && setField("", reflect.ValueOf(&v).Elem(), s) == nil {
+		return v
+	}
+
+	if len(defaults) > 0 {
+		return defaults[0]
+	}
+
+	var zero T
+	return zero
+}
+
 // save file
 func SaveFile(fh *multipart.FileHeader, dst string) error {
 	src, err := fh.Open()
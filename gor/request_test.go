@@ -2,6 +2,7 @@ package gor
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
@@ -9,7 +10,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSetAndGetContextValue(t *testing.T) {
@@ -29,6 +33,150 @@ func TestSetAndGetContextValue(t *testing.T) {
 
 }
 
+func TestSetContextValueDoesNotMutateRequest(t *testing.T) {
+	r := NewRouter()
+
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// middleware that clones the request
+			next.ServeHTTP(w, req.Clone(req.Context()))
+		})
+	})
+
+	r.Get("/context", func(w http.ResponseWriter, req *http.Request) {
+		SetContextValue(req, "key", "value")
+
+		if req.Context().Value("key") != "value" {
+			t.Error("value not visible in request context")
+		}
+	})
+
+	req := httptest.NewRequest("GET", "/context", nil)
+	origCtx := req.Context()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if req.Context() != origCtx {
+		t.Error("ServeHTTP mutated the caller's request")
+	}
+
+	if req.Context().Value(contextKey) != nil {
+		t.Error("caller's request context should not carry the CTX")
+	}
+}
+
+func TestDetach(t *testing.T) {
+	r := NewRouter()
+
+	var detached context.Context
+	r.Get("/detach", func(w http.ResponseWriter, req *http.Request) {
+		SetContextValue(req, "user", "john")
+		detached = Detach(req)
+		SetContextValue(req, "user", "jane")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/detach", nil).WithContext(ctx)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	cancel()
+
+	if err := detached.Err(); err != nil {
+		t.Errorf("expected detached context not to be canceled, got %v", err)
+	}
+
+	if v := detached.Value("user"); v != "john" {
+		t.Errorf("expected user=john, got %v", v)
+	}
+
+	if c, ok := detached.Value(contextKey).(*CTX); !ok || c.Router != r {
+		t.Error("expected the detached context to carry the router")
+	}
+}
+
+func TestContextOutlivesRequest(t *testing.T) {
+	r := NewRouter()
+
+	contexts := make(chan context.Context, 2)
+	r.Get("/context/{id}", func(w http.ResponseWriter, req *http.Request) {
+		SetContextValue(req, "id", req.PathValue("id"))
+
+		// A goroutine keeps a derived context after the handler returns.
+		ctx, cancel := context.WithCancel(req.Context())
+		go func() {
+			defer cancel()
+			contexts <- ctx
+		}()
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/context/1", nil))
+	first := <-contexts
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/context/2", nil))
+	second := <-contexts
+
+	if first.Value("id") != "1" || second.Value("id") != "2" {
+		t.Errorf("expected the locals of each request, got %v and %v", first.Value("id"), second.Value("id"))
+	}
+
+	if first.Value(contextKey) == second.Value(contextKey) {
+		t.Error("expected a CTX per request")
+	}
+}
+
+func TestResponseWriterReleased(t *testing.T) {
+	r := NewRouter()
+	r.Get("/teapot", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("hello"))
+	})
+
+	r.Get("/fresh", func(w http.ResponseWriter, req *http.Request) {
+		rw := w.(*ResponseWriter)
+		if rw.Status() != http.StatusOK || rw.Size() != 0 || rw.Written() {
+			t.Errorf("expected a reset writer, got status=%d size=%d written=%v", rw.Status(), rw.Size(), rw.Written())
+		}
+	})
+
+	for i := 0; i < 10; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/teapot", nil))
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fresh", nil))
+	}
+}
+
+func TestResponseWriterHijacked(t *testing.T) {
+	r := NewRouter()
+
+	writers := make(chan *ResponseWriter, 1)
+	r.Get("/hijack", func(w http.ResponseWriter, req *http.Request) {
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		io.WriteString(conn, "HTTP/1.1 204 No Content\r\nConnection: close\r\n\r\n")
+		writers <- w.(*ResponseWriter)
+	})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/hijack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	// The connection may still be served with the writer of a hijacked request.
+	hijacked := <-writers
+	for i := 0; i < 100; i++ {
+		if writerPool.Get().(*ResponseWriter) == hijacked {
+			t.Fatal("expected the writer of a hijacked request not to be reused")
+		}
+	}
+}
+
 func TestSendJSON(t *testing.T) {
 	r := NewRouter()
 
@@ -55,6 +203,245 @@ func TestSendJSON(t *testing.T) {
 	}
 }
 
+func TestSendXML(t *testing.T) {
+	type Item struct {
+		XMLName struct{} `xml:"item"`
+		Name    string   `xml:"name"`
+	}
+
+	w := httptest.NewRecorder()
+	if err := SendXML(w, Item{Name: "pen"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Header().Get("Content-Type") != ContentTypeXML {
+		t.Errorf("Content-Type is not application/xml")
+	}
+
+	if expected := "<item><name>pen</name></item>"; w.Body.String() != expected {
+		t.Errorf("SendXML() failed, expected %s, got %q", expected, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	if err := SendXMLWithOptions(w, Item{Name: "pen"}, XMLOptions{Indent: "  ", Header: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<item>\n  <name>pen</name>\n</item>"
+	if w.Body.String() != expected {
+		t.Errorf("SendXMLWithOptions() failed, expected %s, got %q", expected, w.Body.String())
+	}
+
+	// Nothing is written if encoding fails.
+	w = httptest.NewRecorder()
+	if err := SendXML(w, make(chan int)); err == nil {
+		t.Error("expected an error for an unsupported type")
+	}
+
+	if w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+		t.Errorf("expected an empty response, got %q", w.Body.String())
+	}
+}
+
+func TestSendJSONIndent(t *testing.T) {
+	data := map[string]string{"key": "<b>"}
+
+	w := httptest.NewRecorder()
+	if err := SendJSONIndent(w, data); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "{\n  \"key\": \"\\u003cb\\u003e\"\n}\n"; w.Body.String() != expected {
+		t.Errorf("SendJSONIndent() failed, expected %q, got %q", expected, w.Body.String())
+	}
+
+	r := NewRouter(JSONIndent(false, "", "\t"), JSONEscapeHTML(false))
+	r.Get("/data", func(w http.ResponseWriter, req *http.Request) {
+		SendJSON(w, data)
+	})
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"/data", "{\"key\":\"<b>\"}\n"},
+		{"/data?pretty=1", "{\n\t\"key\": \"<b>\"\n}\n"},
+		{"/data?pretty=false", "{\"key\":\"<b>\"}\n"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+		if w.Body.String() != tt.expected {
+			t.Errorf("GET %s: expected %q, got %q", tt.url, tt.expected, w.Body.String())
+		}
+	}
+
+	r = NewRouter(JSONIndent(true, "", " "))
+	r.Get("/data", func(w http.ResponseWriter, req *http.Request) {
+		SendJSON(w, data)
+	})
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/data", nil))
+	if expected := "{\n \"key\": \"\\u003cb\\u003e\"\n}\n"; w.Body.String() != expected {
+		t.Errorf("expected %q, got %q", expected, w.Body.String())
+	}
+}
+
+func TestSendJSONP(t *testing.T) {
+	data := map[string]int{"count": 1}
+
+	tests := []struct {
+		url         string
+		param       string
+		expected    string
+		contentType string
+		err         error
+	}{
+		{"/widget?callback=render", "", "/**/ render({\"count\":1});", ContentTypeJavaScript, nil},
+		{"/widget?cb=app.widgets.render", "cb", "/**/ app.widgets.render({\"count\":1});", ContentTypeJavaScript, nil},
+		{"/widget", "", "{\"count\":1}\n", ContentTypeJSON, nil},
+		{"/widget?callback=alert(1)", "", "", "", ErrInvalidJSONPCallback},
+		{"/widget?callback=a..b", "", "", "", ErrInvalidJSONPCallback},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		err := SendJSONP(w, httptest.NewRequest("GET", tt.url, nil), data, tt.param)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("GET %s: expected error %v, got %v", tt.url, tt.err, err)
+		}
+
+		if w.Body.String() != tt.expected || w.Header().Get("Content-Type") != tt.contentType {
+			t.Errorf("GET %s: expected %q (%s), got %q (%s)", tt.url, tt.expected, tt.contentType,
+				w.Body.String(), w.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestSendPage(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "http://example.com/users?q=jo&page=2", nil)
+	if err := SendPage(w, req, []string{"jane", "john"}, 5, 2, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	var page struct {
+		Data  []string  `json:"data"`
+		Meta  PageMeta  `json:"meta"`
+		Links PageLinks `json:"links"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(page.Data) != 2 || page.Meta != (PageMeta{Total: 5, Page: 2, PerPage: 2, TotalPages: 3}) {
+		t.Errorf("unexpected page %+v", page)
+	}
+
+	wantLinks := PageLinks{
+		Self:  "/users?page=2&per_page=2&q=jo",
+		First: "/users?page=1&per_page=2&q=jo",
+		Last:  "/users?page=3&per_page=2&q=jo",
+		Next:  "/users?page=3&per_page=2&q=jo",
+		Prev:  "/users?page=1&per_page=2&q=jo",
+	}
+	if page.Links != wantLinks {
+		t.Errorf("expected links %+v, got %+v", wantLinks, page.Links)
+	}
+
+	wantHeader := `</users?page=1&per_page=2&q=jo>; rel="first", </users?page=3&per_page=2&q=jo>; rel="last", ` +
+		`</users?page=3&per_page=2&q=jo>; rel="next", </users?page=1&per_page=2&q=jo>; rel="prev"`
+	if h := w.Header().Get("Link"); h != wantHeader {
+		t.Errorf("expected Link header %q, got %q", wantHeader, h)
+	}
+
+	// An empty list
+	w = httptest.NewRecorder()
+	var none []string
+	if err := SendPage(w, httptest.NewRequest("GET", "/users", nil), none, 0, 1, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(w.Body.String(), `"data":[]`) || strings.Contains(w.Body.String(), `"next"`) {
+		t.Errorf("unexpected empty page %s", w.Body.String())
+	}
+}
+
+type upperCodec struct{}
+
+func (upperCodec) Encode(w io.Writer, v any) error {
+	_, err := w.Write([]byte(`"CUSTOM"`))
+	return err
+}
+
+func (upperCodec) Decode(r io.Reader, v any) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+func TestSendJSONCustomCodec(t *testing.T) {
+	defer func(c Codec) { JSONCodec = c }(JSONCodec)
+	JSONCodec = upperCodec{}
+
+	w := httptest.NewRecorder()
+	if err := SendJSON(w, map[string]string{"key": "value"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Body.String() != `"CUSTOM"` {
+		t.Errorf("expected custom codec output, got %q", w.Body.String())
+	}
+}
+
+func TestMsgPackCodec(t *testing.T) {
+	w := httptest.NewRecorder()
+	if err := SendMsgPack(w, "value"); !errors.Is(err, ErrNoMsgPackCodec) {
+		t.Errorf("expected ErrNoMsgPackCodec, got %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/", bytes.NewBufferString(`{"name": "gor"}`))
+	req.Header.Set("Content-Type", ContentTypeMsgPack)
+
+	var body struct {
+		Name string `json:"name"`
+	}
+
+	var formErr FormError
+	if err := BodyParser(req, &body); !errors.As(err, &formErr) || formErr.Kind != InvalidContentType {
+		t.Errorf("expected InvalidContentType without a codec, got %v", err)
+	}
+
+	defer func(c Codec) { MsgPackCodec = c }(MsgPackCodec)
+	MsgPackCodec = upperCodec{}
+
+	if err := SendMsgPack(w, "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Header().Get("Content-Type") != ContentTypeMsgPack || w.Body.String() != `"CUSTOM"` {
+		t.Errorf("expected the output of the codec, got %q", w.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "/", bytes.NewBufferString(`{"name": "gor"}`))
+	req.Header.Set("Content-Type", ContentTypeMsgPack)
+	if err := BodyParser(req, &body); err != nil || body.Name != "gor" {
+		t.Errorf("expected the codec to decode the body, got %q, %v", body.Name, err)
+	}
+}
+
+func BenchmarkSendJSON(b *testing.B) {
+	data := map[string]any{"name": "gor", "stars": 100, "tags": []string{"router", "go"}}
+	w := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.Body.Reset()
+		SendJSON(w, data)
+	}
+}
+
 func TestSendString(t *testing.T) {
 	r := NewRouter()
 
@@ -131,6 +518,129 @@ func TestSendFile(t *testing.T) {
 	}
 }
 
+func TestSendReader(t *testing.T) {
+	w := httptest.NewRecorder()
+	if err := SendReader(w, strings.NewReader("hello world"), 5, ContentTypeText); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Body.String() != "hello" {
+		t.Errorf("SendReader() failed, expected hello, got %q", w.Body.String())
+	}
+
+	if w.Header().Get("Content-Length") != "5" || w.Header().Get("Content-Type") != ContentTypeText {
+		t.Errorf("unexpected headers %v", w.Header())
+	}
+
+	// Unknown size
+	w = httptest.NewRecorder()
+	if err := SendReader(w, io.MultiReader(strings.NewReader("a"), strings.NewReader("b")), -1, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Body.String() != "ab" || w.Header().Get("Content-Length") != "" {
+		t.Errorf("SendReader() failed, got %q with headers %v", w.Body.String(), w.Header())
+	}
+
+	// Short reader
+	err := SendReader(httptest.NewRecorder(), strings.NewReader("abc"), 10, ContentTypeText)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestJSONStream(t *testing.T) {
+	w := httptest.NewRecorder()
+	stream := NewJSONStream(w)
+	stream.FlushEvery = 2
+
+	for i := 1; i <= 3; i++ {
+		if err := stream.WriteItem(map[string]int{"id": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Items that fail to encode are not written.
+	if err := stream.WriteItem(make(chan int)); err == nil {
+		t.Error("expected an error for an unsupported type")
+	}
+
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "[{\"id\":1},{\"id\":2},{\"id\":3}]\n"; w.Body.String() != expected {
+		t.Errorf("expected %q, got %q", expected, w.Body.String())
+	}
+
+	if !w.Flushed || w.Header().Get("Content-Type") != ContentTypeJSON {
+		t.Errorf("expected a flushed JSON response, got flushed=%v headers=%v", w.Flushed, w.Header())
+	}
+
+	if err := stream.WriteItem(1); err == nil {
+		t.Error("expected an error after Close")
+	}
+
+	// No items
+	w = httptest.NewRecorder()
+	if err := NewJSONStream(w).Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var items []any
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil || items == nil || len(items) != 0 {
+		t.Errorf("expected an empty array, got %q", w.Body.String())
+	}
+}
+
+func TestSendStream(t *testing.T) {
+	r := NewRouter()
+
+	var streamErr error
+	r.Get("/stream", func(w http.ResponseWriter, req *http.Request) {
+		i := 0
+		streamErr = SendStream(w, req, func(w io.Writer) bool {
+			i++
+			io.WriteString(w, strconv.Itoa(i)+"\n")
+			return i < 3
+		})
+	})
+
+	req := httptest.NewRequest("GET", "/stream", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if streamErr != nil {
+		t.Errorf("SendStream() returned error: %v", streamErr)
+	}
+
+	if w.Body.String() != "1\n2\n3\n" {
+		t.Errorf("SendStream() failed, expected 3 lines, got %q", w.Body.String())
+	}
+
+	if !w.Flushed {
+		t.Error("SendStream() failed, expected response to be flushed")
+	}
+
+	// Stops when the client disconnects
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	r.Get("/canceled", func(w http.ResponseWriter, req *http.Request) {
+		streamErr = SendStream(w, req, func(w io.Writer) bool {
+			calls++
+			cancel()
+			return true
+		})
+	})
+
+	req = httptest.NewRequest("GET", "/canceled", nil).WithContext(ctx)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !errors.Is(streamErr, context.Canceled) || calls != 1 {
+		t.Errorf("SendStream() failed, expected to stop after cancel, got %v after %d calls", streamErr, calls)
+	}
+}
+
 func TestSendError(t *testing.T) {
 	r := NewRouter()
 
@@ -147,6 +657,29 @@ func TestSendError(t *testing.T) {
 	}
 }
 
+func TestSendErrorEscapesMessage(t *testing.T) {
+	r := NewRouter()
+
+	r.Get("/search", func(w http.ResponseWriter, req *http.Request) {
+		_, err := strconv.Atoi(req.URL.Query().Get("page"))
+		SendError(w, req, err, http.StatusBadRequest)
+	})
+
+	for _, htmx := range []bool{false, true} {
+		req := httptest.NewRequest("GET", "/search?page=%3Cscript%3Ealert(1)%3C/script%3E", nil)
+		if htmx {
+			req.Header.Set("HX-Request", "true")
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if strings.Contains(w.Body.String(), "<script>") || !strings.Contains(w.Body.String(), "&lt;script&gt;") {
+			t.Errorf("expected the message to be escaped, got %q", w.Body.String())
+		}
+	}
+}
+
 func TestSendJSONError(t *testing.T) {
 	r := NewRouter()
 
@@ -281,6 +814,117 @@ func TestParamInt(t *testing.T) {
 	}
 }
 
+func TestParamTyped(t *testing.T) {
+	r := NewRouter()
+	r.Get("/typed/{int}/{uint}/{float}", func(w http.ResponseWriter, req *http.Request) {
+		if v := ParamInt64(req, "int"); v != -10 {
+			t.Errorf("ParamInt64() failed, expected -10, got %d", v)
+		}
+
+		if v := ParamUint64(req, "uint"); v != 20 {
+			t.Errorf("ParamUint64() failed, expected 20, got %d", v)
+		}
+
+		if v := ParamFloat64(req, "float"); v != 1.5 {
+			t.Errorf("ParamFloat64() failed, expected 1.5, got %f", v)
+		}
+
+		if v := ParamInt64(req, "missing", 7); v != 7 {
+			t.Errorf("ParamInt64() failed, expected default 7, got %d", v)
+		}
+	})
+
+	req := httptest.NewRequest("GET", "/typed/-10/20/1.5", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+}
+
+func TestQueryScalars(t *testing.T) {
+	req := httptest.NewRequest("GET", "/events/123E4567-E89B-12D3-A456-426614174000?active=1&ratio=2.5&since=2024-08-20&bad=x", nil)
+	req.SetPathValue("id", "123E4567-E89B-12D3-A456-426614174000")
+	req.SetPathValue("slug", "not-a-uuid")
+
+	if v := QueryBool(req, "active"); !v {
+		t.Error("QueryBool() failed, expected true")
+	}
+
+	if v := QueryBool(req, "bad", true); !v {
+		t.Error("QueryBool() failed, expected default true")
+	}
+
+	if v := QueryFloat(req, "ratio"); v != 2.5 {
+		t.Errorf("QueryFloat() failed, expected 2.5, got %f", v)
+	}
+
+	if v := QueryFloat(req, "missing", 1.5); v != 1.5 {
+		t.Errorf("QueryFloat() failed, expected default 1.5, got %f", v)
+	}
+
+	want := time.Date(2024, 8, 20, 0, 0, 0, 0, DefaultTimezone)
+	if v := QueryTime(req, "since"); !v.Equal(want) {
+		t.Errorf("QueryTime() failed, expected %v, got %v", want, v)
+	}
+
+	if v := QueryTime(req, "bad"); !v.IsZero() {
+		t.Errorf("QueryTime() failed, expected the zero time, got %v", v)
+	}
+
+	if v := ParamUUID(req, "id"); v != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Errorf("ParamUUID() failed, got %q", v)
+	}
+
+	if v := ParamUUID(req, "slug", "none"); v != "none" {
+		t.Errorf("ParamUUID() failed, expected default none, got %q", v)
+	}
+}
+
+func TestParamGeneric(t *testing.T) {
+	req := httptest.NewRequest("GET", "/events/42?since=2024-08-20&active=true&ratio=0.5&page=x", nil)
+	req.SetPathValue("id", "42")
+
+	if v := Param[int64](req, "id"); v != 42 {
+		t.Errorf("Param[int64]() failed, expected 42, got %d", v)
+	}
+
+	if v := Param(req, "missing", uint8(3)); v != 3 {
+		t.Errorf("Param[uint8]() failed, expected default 3, got %d", v)
+	}
+
+	if v := QueryVal[bool](req, "active"); !v {
+		t.Error("QueryVal[bool]() failed, expected true")
+	}
+
+	if v := QueryVal[float64](req, "ratio"); v != 0.5 {
+		t.Errorf("QueryVal[float64]() failed, expected 0.5, got %f", v)
+	}
+
+	want := time.Date(2024, 8, 20, 0, 0, 0, 0, DefaultTimezone)
+	if v := QueryVal[time.Time](req, "since"); !v.Equal(want) {
+		t.Errorf("QueryVal[time.Time]() failed, expected %v, got %v", want, v)
+	}
+
+	if v := QueryVal[*int](req, "id"); v != nil {
+		t.Errorf("QueryVal[*int]() failed, expected nil, got %v", *v)
+	}
+
+	if v := QueryVal(req, "page", 1); v != 1 {
+		t.Errorf("QueryVal[int]() failed, expected default 1 for an invalid value, got %d", v)
+	}
+}
+
+func BenchmarkParamInt64(b *testing.B) {
+	req := httptest.NewRequest("GET", "/users/12345", nil)
+	req.SetPathValue("id", "12345")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ParamInt64(req, "id") != 12345 {
+			b.Fatal("unexpected value")
+		}
+	}
+}
+
 func TestSaveFile(t *testing.T) {
 	// create temp file
 	f, err := os.CreateTemp("", "testfile")
@@ -0,0 +1,130 @@
+package gor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	expiresParam   = "expires"
+	signatureParam = "signature"
+)
+
+var (
+	// ErrInvalidSignature is returned for URLs without a valid signature.
+	ErrInvalidSignature = errors.New("gor: invalid URL signature")
+
+	// ErrURLExpired is returned for signed URLs past their expiry.
+	ErrURLExpired = errors.New("gor: signed URL expired")
+)
+
+// SignURL returns the URL of the route named name, like URL, signed with the
+// keys of the router so that it can be verified by VerifySignedURL.
+// The URL expires after expiry, or never if expiry is 0.
+//
+//	r := gor.NewRouter(gor.WithCookieKeys(key))
+//	r.Get("/unsubscribe/{id}", unsubscribe, gor.VerifySignedURL).Name("unsubscribe")
+//	link, err := r.SignURL("unsubscribe", gor.Map{"id": user.ID}, 7*24*time.Hour)
+func (r *Router) SignURL(name string, params Map, expiry time.Duration) (string, error) {
+	path, err := r.URL(name, params)
+	if err != nil {
+		return "", err
+	}
+	return SignPath(path, r.cookieKeys, expiry)
+}
+
+// SignPath signs a path and its query string with the first key, adding the
+// "expires" and "signature" query parameters. The path can be made absolute
+// by prepending the scheme and host, which are not signed.
+func SignPath(path string, keys CookieKeys, expiry time.Duration) (string, error) {
+	if len(keys) == 0 {
+		return "", ErrNoCookieKeys
+	}
+
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	query.Del(signatureParam)
+	query.Del(expiresParam)
+	if expiry > 0 {
+		query.Set(expiresParam, strconv.FormatInt(time.Now().Add(expiry).Unix(), 10))
+	}
+
+	// Encode sorts the parameters, making the signature independent of their order.
+	u.RawQuery = query.Encode()
+	signature := signURL(keys[0], u.EscapedPath(), u.RawQuery)
+
+	if u.RawQuery != "" {
+		u.RawQuery += "&"
+	}
+	u.RawQuery += signatureParam + "=" + base64.RawURLEncoding.EncodeToString(signature)
+	return u.String(), nil
+}
+
+// VerifySignedPath verifies the signature and expiry of a URL signed by SignPath
+// with any of the keys. It returns ErrInvalidSignature or ErrURLExpired.
+func VerifySignedPath(u *url.URL, keys CookieKeys) error {
+	query := u.Query()
+	signature, err := base64.RawURLEncoding.DecodeString(query.Get(signatureParam))
+	if err != nil || len(signature) == 0 {
+		return ErrInvalidSignature
+	}
+	query.Del(signatureParam)
+	rawQuery := query.Encode()
+
+	valid := false
+	for _, key := range keys {
+		if hmac.Equal(signature, signURL(key, u.EscapedPath(), rawQuery)) {
+			valid = true
+			break
+		}
+	}
+
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	if expires := query.Get(expiresParam); expires != "" {
+		unix, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil {
+			return ErrInvalidSignature
+		}
+
+		if time.Now().Unix() >= unix {
+			return ErrURLExpired
+		}
+	}
+	return nil
+}
+
+// signURL returns the HMAC of the path and sorted query string.
+func signURL(key []byte, path, rawQuery string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("url:"))
+	mac.Write([]byte(path))
+	mac.Write([]byte{'?'})
+	mac.Write([]byte(rawQuery))
+	return mac.Sum(nil)
+}
+
+// VerifySignedURL is a middleware rejecting requests whose URL was not signed
+// with the keys of the router, see Router.SignURL, or has expired.
+// Rejected requests are handled with HandleError and 403 Forbidden.
+func VerifySignedURL(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := VerifySignedPath(req.URL, routerCookieKeys(req)); err != nil {
+			HandleError(w, req, err, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
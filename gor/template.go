@@ -1,12 +1,17 @@
 package gor
 
 import (
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"io/fs"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"text/template/parse"
 )
 
 var componentName string = "gor_components"
@@ -36,6 +41,27 @@ func ErrorTemplate(errorTemplate string) RouterOption {
 	}
 }
 
+// ErrorTemplates sets the error templates of specific status codes, e.g a
+// "page not found" page for 404. Errors with other status codes are rendered
+// with the ErrorTemplate, if set. The templates are passed the same context as
+// the ErrorTemplate, and are used by SendError, HandleError, the not found and
+// method not allowed handlers and the recovery middleware.
+//
+// Example:
+//
+//	r := gor.NewRouter(
+//		gor.ErrorTemplate("errors/error.html"),
+//		gor.ErrorTemplates(map[int]string{
+//			404: "errors/404.html",
+//			500: "errors/500.html",
+//		}),
+//	)
+func ErrorTemplates(templates map[int]string) RouterOption {
+	return func(r *Router) {
+		r.errorTemplates = templates
+	}
+}
+
 // ContentBlock sets the name of the content block in the base layout template.
 // This block will be replaced with the rendered content of the view.
 // The default content block name is "content".
@@ -50,6 +76,27 @@ func ContentBlock(contentBlock string) RouterOption {
 	}
 }
 
+// LayoutBlocks sets the names of the blocks rendered from the page template
+// and passed to the layouts, in addition to the content block, e.g the
+// scripts of the page at the end of the body of the base layout.
+//
+// A page defines a block as a template named "<page>:<block>", and the layouts
+// insert it like the content block. Blocks that the page does not define are empty.
+//
+//	r := gor.NewRouter(gor.BaseLayout("base.html"), gor.LayoutBlocks("Head", "Scripts"))
+//
+//	{{/* products/edit.html */}}
+//	<form>...</form>
+//	{{ define "products/edit.html:Scripts" }}<script src="/static/editor.js"></script>{{ end }}
+//
+//	{{/* base.html */}}
+//	<head>{{ .Head }}</head><body>{{ .Content }}{{ .Scripts }}</body>
+func LayoutBlocks(blocks ...string) RouterOption {
+	return func(r *Router) {
+		r.layoutBlocks = blocks
+	}
+}
+
 // PassContextToViews enables or disables passing the router context to views.
 // If enabled, the router context will be available as a variable named "ctx" in the views.
 // This allows views to access information about the request and the router.
@@ -71,10 +118,228 @@ func PassContextToViews(passContextToViews bool) RouterOption {
 //
 //	t := template.Must(template.ParseFiles("views/index.html"))
 //	r := NewRouter(gor.WithTemplates(t))
+//
+// The "url" and "slot" template functions are bound to the router.
 func WithTemplates(t *template.Template) RouterOption {
 	return func(r *Router) {
 		r.template = t
+		if t != nil {
+			t.Funcs(template.FuncMap{"url": r.URL, "slot": r.slot})
+		}
+	}
+}
+
+// VerifyTemplates checks the templates of the router, so that a missing
+// template is reported at startup rather than by the first request rendering it.
+// It checks the base layout, which must use the content block, the Layout
+// of the groups, the error templates and the page templates named by names:
+//
+//	if err := r.VerifyTemplates("home", "users/list", "users/edit"); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Each template is executed with empty data into io.Discard, which reports
+// the templates it calls that are not defined. Errors caused by the empty
+// data, like missing fields, are ignored.
+//
+// NewRouter verifies the base layout and the error templates, and panics if
+// one of them is invalid.
+func (r *Router) VerifyTemplates(names ...string) error {
+	if r.template == nil {
+		return fmt.Errorf("gor: no template is configured")
+	}
+
+	var errs []error
+	if err := r.verifyConfiguredTemplates(); err != nil {
+		errs = append(errs, err)
+	}
+
+	prefixes := make([]string, 0, len(r.groups))
+	for prefix := range r.groups {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	for _, prefix := range prefixes {
+		if layout := r.groups[prefix].Layout; layout != "" {
+			errs = append(errs, r.verifyLayout(layout))
+		}
+	}
+
+	for _, name := range names {
+		errs = append(errs, r.verifyTemplate(pageName(name)))
+	}
+	return errors.Join(errs...)
+}
+
+// verifyConfiguredTemplates checks the base layout and the error templates.
+func (r *Router) verifyConfiguredTemplates() error {
+	var errs []error
+	if r.baseLayout != "" {
+		errs = append(errs, r.verifyLayout(r.baseLayout))
+	}
+
+	if r.errorTemplate != "" {
+		errs = append(errs, r.verifyTemplate(pageName(r.errorTemplate)))
+	}
+
+	statuses := make([]int, 0, len(r.errorTemplates))
+	for status := range r.errorTemplates {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+
+	for _, status := range statuses {
+		errs = append(errs, r.verifyTemplate(pageName(r.errorTemplates[status])))
+	}
+	return errors.Join(errs...)
+}
+
+// verifyLayout checks that the layout exists and uses the content block.
+func (r *Router) verifyLayout(name string) error {
+	if err := r.verifyTemplate(name); err != nil {
+		return err
+	}
+
+	if r.contentBlock != "" && !r.usesField(r.template.Lookup(name).Tree.Root, r.contentBlock, map[string]bool{}) {
+		return fmt.Errorf("gor: layout %q does not use the content block .%s", name, r.contentBlock)
+	}
+	return nil
+}
+
+// verifyTemplate checks that the template exists and that the templates it calls are defined.
+func (r *Router) verifyTemplate(name string) error {
+	t := r.template.Lookup(name)
+	if t == nil || t.Tree == nil {
+		return fmt.Errorf("gor: template %q is not defined", name)
 	}
+
+	// Escaping errors, including undefined templates, are *template.Error.
+	// Other errors come from the empty data.
+	err := r.template.ExecuteTemplate(io.Discard, name, Map{})
+	var tmplErr *template.Error
+	if errors.As(err, &tmplErr) {
+		return fmt.Errorf("gor: template %q: %w", name, err)
+	}
+	return nil
+}
+
+// usesField reports whether node uses the field .name of the data, directly
+// or in the templates it calls. visited are the templates already walked.
+func (r *Router) usesField(node parse.Node, name string, visited map[string]bool) bool {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+
+		for _, child := range n.Nodes {
+			if r.usesField(child, name, visited) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return r.usesField(n.Pipe, name, visited)
+	case *parse.IfNode:
+		return r.usesField(n.Pipe, name, visited) || r.usesField(n.List, name, visited) || r.usesField(n.ElseList, name, visited)
+	case *parse.RangeNode:
+		return r.usesField(n.Pipe, name, visited) || r.usesField(n.List, name, visited) || r.usesField(n.ElseList, name, visited)
+	case *parse.WithNode:
+		return r.usesField(n.Pipe, name, visited) || r.usesField(n.List, name, visited) || r.usesField(n.ElseList, name, visited)
+	case *parse.TemplateNode:
+		if n.Pipe != nil && r.usesField(n.Pipe, name, visited) {
+			return true
+		}
+
+		if t := r.template.Lookup(n.Name); t != nil && t.Tree != nil && !visited[n.Name] {
+			visited[n.Name] = true
+			return r.usesField(t.Tree.Root, name, visited)
+		}
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+
+		for _, cmd := range n.Cmds {
+			if r.usesField(cmd, name, visited) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if r.usesField(arg, name, visited) {
+				return true
+			}
+		}
+	case *parse.ChainNode:
+		return r.usesField(n.Node, name, visited)
+	case *parse.FieldNode:
+		return len(n.Ident) > 0 && n.Ident[0] == name
+	}
+	return false
+}
+
+// pageName returns the name of a page template, with the ".html" extension
+// appended if it is missing, like Render.
+func pageName(name string) string {
+	if filepath.Ext(name) == "" {
+		return name + ".html"
+	}
+	return name
+}
+
+// urlPlaceholder is the "url" template function until the templates are
+// passed to a router with WithTemplates.
+func urlPlaceholder(name string, params ...any) (string, error) {
+	return "", fmt.Errorf("url %q: templates are not attached to a router", name)
+}
+
+// csrfTokenKey is the template data key of the token placed in the
+// request context by the csrf middleware.
+const csrfTokenKey = "csrf_token"
+
+// csrfTokenContextKey is the request context key of the CSRF token.
+const csrfTokenContextKey = contextType(csrfTokenKey)
+
+// SetCSRFToken sets the CSRF token of the request, passed to templates as
+// "csrf_token" by Render and rendered by the csrf_token and csrf_field
+// template functions. The gor/middleware/csrf middleware calls it.
+func SetCSRFToken(req *http.Request, token string) {
+	SetContextValue(req, csrfTokenContextKey, token)
+}
+
+// CSRFToken returns the CSRF token of the request set by SetCSRFToken, or "".
+func CSRFToken(req *http.Request) string {
+	token, _ := req.Context().Value(csrfTokenContextKey).(string)
+	return token
+}
+
+// csrfToken is the "csrf_token" template function. It returns the CSRF token
+// of the template data, e.g to send it in a header with fetch or HTMX:
+//
+//	<body hx-headers='{"X-CSRF-Token": "{{ csrf_token . }}"}'>
+func csrfToken(data any) string {
+	var token string
+	switch d := data.(type) {
+	case Map:
+		token, _ = d[csrfTokenKey].(string)
+	case map[string]any:
+		token, _ = d[csrfTokenKey].(string)
+	}
+	return token
+}
+
+// csrfField is the "csrf_field" template function. It renders a hidden input
+// with the CSRF token of the template data, set by Render from the request.
+//
+//	<form method="post">{{ csrf_field . }}</form>
+func csrfField(data any) template.HTML {
+	token := csrfToken(data)
+	if token == "" {
+		return ""
+	}
+	return template.HTML(`<input type="hidden" name="` + csrfTokenKey + `" value="` +
+		template.HTMLEscapeString(token) + `">`)
 }
 
 func isTrue(value any) bool {
@@ -94,7 +359,14 @@ Parse pre-build html form components generated with {{ block "tag" .}} attribute
 Used as "{{ template "input" Props "id" "username" "label" "Enter username" "placeholder" "Username..." "required" "true"}}".
 Available components are:
 
-input: props(id, name, value, label, required, disabled, readonly, placeholder).
+input: props(id, name, value, label, required, disabled, readonly, placeholder, error).
+The error prop is a validation message shown below the input, also accepted by
+select, textarea and checkbox.
+
+Every component accepts a "class" prop overriding its classes, and the
+inputs accept "label_class" and "wrapper_class". The "children" prop is HTML
+rendered below the inputs, e.g help text created with the "slot" function.
+The default classes are set with RegisterComponentTheme.
 
 select: Like input, also has "options" []string prop.
 
@@ -104,7 +376,14 @@ checkbox: Like input, also has "checked" prop(A bool or string("true"/"on" are t
 
 radio: Same as checkbox. also has "options" []string prop
 
-button: Props(ID, Type, Disabled)
+button: Props(ID, Type, Disabled, variant, text), or "children" instead of text.
+
+flashes: Renders the flash messages of the page data, used as {{ template "flashes" . }}. See Flash.
+
+pagination: Renders the links to the pages of the "pagination" Paginator of the page data,
+used as {{ template "pagination" . }}. Nothing is rendered for a single page. See NewPaginator.
+
+The "form" template function renders these components for the fields of a struct. See formHTML.
 */
 func parseComponents(funcMap template.FuncMap) *template.Template {
 	return template.Must(template.New(componentName).Funcs(funcMap).Parse(components))
@@ -134,6 +413,22 @@ func ParseTemplatesRecursive(rootDir string, funcMap template.FuncMap, suffix ..
 
 	funcMap["Props"] = Props
 	funcMap["IsTrue"] = isTrue
+	funcMap["theme"] = themeClass
+	if _, ok := funcMap["slot"]; !ok {
+		funcMap["slot"] = slotPlaceholder
+	}
+	if _, ok := funcMap["url"]; !ok {
+		funcMap["url"] = urlPlaceholder
+	}
+	if _, ok := funcMap["csrf_field"]; !ok {
+		funcMap["csrf_field"] = csrfField
+	}
+	if _, ok := funcMap["csrf_token"]; !ok {
+		funcMap["csrf_token"] = csrfToken
+	}
+	if _, ok := funcMap["form"]; !ok {
+		funcMap["form"] = formHTML
+	}
 	components := parseComponents(funcMap)
 
 	cleanRoot := filepath.Clean(rootDir)
@@ -194,6 +489,22 @@ func ParseTemplatesRecursiveFS(root fs.FS, rootDir string, funcMap template.Func
 
 	funcMap["Props"] = Props
 	funcMap["IsTrue"] = isTrue
+	funcMap["theme"] = themeClass
+	if _, ok := funcMap["slot"]; !ok {
+		funcMap["slot"] = slotPlaceholder
+	}
+	if _, ok := funcMap["url"]; !ok {
+		funcMap["url"] = urlPlaceholder
+	}
+	if _, ok := funcMap["csrf_field"]; !ok {
+		funcMap["csrf_field"] = csrfField
+	}
+	if _, ok := funcMap["csrf_token"]; !ok {
+		funcMap["csrf_token"] = csrfToken
+	}
+	if _, ok := funcMap["form"]; !ok {
+		funcMap["form"] = formHTML
+	}
 	components := parseComponents(funcMap)
 
 	pfx := len(rootDir) + 1  // +1 for the trailing slash
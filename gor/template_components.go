@@ -1,5 +1,114 @@
 package gor
 
+import (
+	"fmt"
+	"html/template"
+	"sync"
+)
+
+var (
+	componentThemeMu sync.RWMutex
+
+	// componentTheme are the classes of the components by key.
+	componentTheme = map[string]string{
+		"field":               "mb-4",
+		"label":               "block text-base font-medium text-gray-800 mb-1",
+		"error":               "mt-1 text-sm text-red-600",
+		"input":               "py-2 px-3 mt-1 block w-full rounded-md border border-gray-300 shadow-sm focus:border-indigo-300 focus:ring focus:ring-indigo-200 focus:ring-opacity-50",
+		"textarea":            "py-2 px-3 mt-1 block w-full rounded-md border border-gray-300 shadow-sm focus:border-indigo-300 focus:ring focus:ring-indigo-200 focus:ring-opacity-50",
+		"select":              "py-2 px-3 mt-1 block w-full rounded-md border bg-white border-gray-300 shadow-sm focus:border-indigo-300 focus:ring focus:ring-indigo-200 focus:ring-opacity-50",
+		"checkbox":            "py-2 px-3 rounded border border-gray-300 text-indigo-600 shadow-sm focus:border-indigo-300 focus:ring focus:ring-indigo-200 focus:ring-opacity-50",
+		"checkbox.label":      "inline-flex items-center gap-2",
+		"checkbox.text":       "ml-2 text-base text-gray-800",
+		"radio":               "border border-gray-300 text-indigo-600 shadow-sm focus:border-indigo-300 focus:ring focus:ring-indigo-200 focus:ring-opacity-50",
+		"radio.group":         "mt-1 space-y-2",
+		"radio.label":         "inline-flex items-center",
+		"radio.text":          "ml-2 text-base text-gray-800",
+		"button.primary":      "whitespace-nowrap inline-flex items-center px-4 py-2 border border-transparent text-base font-medium rounded-md shadow-sm text-white bg-indigo-600 hover:bg-indigo-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500",
+		"button.secondary":    "whitespace-nowrap inline-flex items-center px-4 py-2 border border-gray-300 text-base font-medium rounded-md shadow-sm text-gray-700 bg-white hover:bg-gray-100 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-gray-500",
+		"button.success":      "whitespace-nowrap inline-flex items-center px-4 py-2 border border-transparent text-base font-medium rounded-md shadow-sm text-white bg-green-600 hover:bg-green-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-green-500",
+		"button.danger":       "whitespace-nowrap inline-flex items-center px-4 py-2 border border-transparent text-base font-medium rounded-md shadow-sm text-white bg-red-600 hover:bg-red-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-red-500",
+		"button.warning":      "whitespace-nowrap inline-flex items-center px-4 py-2 border border-transparent text-base font-medium rounded-md shadow-sm text-white bg-yellow-600 hover:bg-yellow-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-yellow-500",
+		"button.info":         "whitespace-nowrap inline-flex items-center px-4 py-2 border border-transparent text-base font-medium rounded-md shadow-sm text-white bg-sky-600 hover:bg-sky-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-sky-500",
+		"button.disabled":     "opacity-50 cursor-not-allowed",
+		"flash":               "mb-4 px-4 py-3 rounded-md border",
+		"flash.info":          "bg-sky-50 text-sky-800 border-sky-200",
+		"flash.success":       "bg-green-50 text-green-800 border-green-200",
+		"flash.error":         "bg-red-50 text-red-800 border-red-200",
+		"flash.warning":       "bg-yellow-50 text-yellow-800 border-yellow-200",
+		"pagination":          "flex items-center justify-center gap-1 my-4",
+		"pagination.link":     "px-3 py-1 rounded-md border border-gray-300 text-sm text-gray-700 bg-white hover:bg-gray-100",
+		"pagination.current":  "px-3 py-1 rounded-md border border-indigo-600 text-sm text-white bg-indigo-600",
+		"pagination.disabled": "px-3 py-1 rounded-md border border-gray-200 text-sm text-gray-400 cursor-not-allowed",
+		"pagination.gap":      "px-2 py-1 text-sm text-gray-500",
+	}
+)
+
+// RegisterComponentTheme sets the classes of the built-in components, replacing
+// the default Tailwind classes, e.g for Bootstrap:
+//
+//	gor.RegisterComponentTheme(map[string]string{
+//		"field":          "mb-3",
+//		"label":          "form-label",
+//		"input":          "form-control",
+//		"select":         "form-select",
+//		"error":          "invalid-feedback d-block",
+//		"button.primary": "btn btn-primary",
+//	})
+//
+// The keys are the names of the components, "field" for the element wrapping
+// each input, "label", "error" for validation messages, "checkbox.label",
+// "checkbox.text", "radio.group", "radio.label", "radio.text", "button.<variant>",
+// "button.disabled", "flash", "flash.<category>", "pagination" for the nav of
+// the pager, "pagination.link", "pagination.current", "pagination.disabled"
+// and "pagination.gap". Keys that are not given
+// keep their classes, and empty classes remove them.
+//
+// Components also accept the "class", "label_class" and "wrapper_class" props,
+// which override the theme for one component.
+func RegisterComponentTheme(theme map[string]string) {
+	componentThemeMu.Lock()
+	defer componentThemeMu.Unlock()
+
+	for key, class := range theme {
+		componentTheme[key] = class
+	}
+}
+
+// themeClass is the "theme" template function used by the components.
+// It returns the classes of the key in the component theme.
+func themeClass(key string) string {
+	componentThemeMu.RLock()
+	defer componentThemeMu.RUnlock()
+	return componentTheme[key]
+}
+
+// slotPlaceholder is the "slot" template function until the templates are
+// passed to a router with WithTemplates.
+func slotPlaceholder(name string, data ...any) (template.HTML, error) {
+	return "", fmt.Errorf("slot %q: templates are not attached to a router", name)
+}
+
+// slot is the "slot" template function. It renders the template name with
+// data, to pass it as the "children" prop of a component:
+//
+//	{{ define "icons/save" }}<svg>...</svg> Save{{ end }}
+//	{{ template "button" Props "variant" "primary" "children" (slot "icons/save" .) }}
+func (r *Router) slot(name string, data ...any) (template.HTML, error) {
+	var value any
+	if len(data) > 0 {
+		value = data[0]
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := r.template.ExecuteTemplate(buf, name, value); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
 var components = `
 {{- block "input" . }}
   {{- $ID := .id }}
@@ -22,12 +131,20 @@ var components = `
   {{- $autocomplete := .autocomplete }}
   {{- $autofocus := IsTrue .autofocus }}
   {{- $class := .class }}
-  {{ if not $class }}
-  {{ $class = "py-2 px-3 mt-1 block w-full rounded-md border border-gray-300 shadow-sm focus:border-indigo-300 focus:ring focus:ring-indigo-200 focus:ring-opacity-50" }}
-  {{ end}}
+  {{- if not $class }}
+  {{- $class = theme "input" }}
+  {{- end }}
+  {{- $wrapperClass := .wrapper_class }}
+  {{- if not $wrapperClass }}
+  {{- $wrapperClass = theme "field" }}
+  {{- end }}
+  {{- $labelClass := .label_class }}
+  {{- if not $labelClass }}
+  {{- $labelClass = theme "label" }}
+  {{- end }}
 
-  <div class="mb-4">
-    <label for="{{ $ID }}">{{ .label }}</label>
+  <div class="{{ $wrapperClass }}">
+    <label for="{{ $ID }}"{{ if $labelClass }} class="{{ $labelClass }}"{{ end }}>{{ .label }}</label>
     <input
       type="{{ $type }}"
       id="{{ $ID }}"
@@ -45,6 +162,10 @@ var components = `
       {{- if $autofocus }} autofocus{{ end }}
       {{- if $class }} class="{{ $class }}"{{ end }}
     >
+    {{- .children }}
+    {{- if .error }}
+    <p class="{{ theme "error" }}">{{ .error }}</p>
+    {{- end }}
   </div>
 {{ end }}
 
@@ -57,14 +178,30 @@ var components = `
 {{- $disabled := IsTrue .disabled }}
 {{- $readonly := IsTrue .readonly }}
 {{- $required := IsTrue .required }}
+{{- $class := .class }}
+{{- if not $class }}
+{{- $class = theme "textarea" }}
+{{- end }}
+{{- $wrapperClass := .wrapper_class }}
+{{- if not $wrapperClass }}
+{{- $wrapperClass = theme "field" }}
+{{- end }}
+{{- $labelClass := .label_class }}
+{{- if not $labelClass }}
+{{- $labelClass = theme "label" }}
+{{- end }}
 
-<div class="mb-4">
-    <label for="{{ $ID }}" class="block text-base font-medium text-gray-800 mb-1">{{.label}}</label>
+<div class="{{ $wrapperClass }}">
+    <label for="{{ $ID }}"{{ if $labelClass }} class="{{ $labelClass }}"{{ end }}>{{.label}}</label>
     <textarea id="{{ $ID }}" 
               name="{{ .name }}"
               placeholder="{{ .placeholder }}"
-              class="py-2 px-3 mt-1 block w-full rounded-md border border-gray-300 shadow-sm focus:border-indigo-300 focus:ring focus:ring-indigo-200 focus:ring-opacity-50"
+              class="{{ $class }}"
               {{ if $required}}required{{ end }} {{ if $readonly}}readonly{{ end }} {{ if $disabled}}disabled{{ end }}>{{- .value -}}</textarea>
+    {{- .children }}
+    {{- if .error }}
+    <p class="{{ theme "error" }}">{{ .error }}</p>
+    {{- end }}
 </div>
 {{ end }}
 
@@ -77,21 +214,36 @@ var components = `
 {{- $disabled := IsTrue .disabled }}
 {{- $readonly := IsTrue .readonly }}
 {{- $required := IsTrue .required }}
+{{- $class := .class }}
+{{- if not $class }}
+{{- $class = theme "select" }}
+{{- end }}
+{{- $wrapperClass := .wrapper_class }}
+{{- if not $wrapperClass }}
+{{- $wrapperClass = theme "field" }}
+{{- end }}
+{{- $labelClass := .label_class }}
+{{- if not $labelClass }}
+{{- $labelClass = theme "label" }}
+{{- end }}
 
-<div class="mb-4">
-    <label for="{{ $ID }}" class="block text-base font-medium text-gray-800 mb-1">{{.label}}</label>
+<div class="{{ $wrapperClass }}">
+    <label for="{{ $ID }}"{{ if $labelClass }} class="{{ $labelClass }}"{{ end }}>{{.label}}</label>
     <select id="{{ $ID }}" 
             name="{{ .name }}"
-			value={{ $.Value }}
-            class="py-2 px-3 mt-1 block w-full rounded-md border bg-white border-gray-300 shadow-sm focus:border-indigo-300 focus:ring focus:ring-indigo-200 focus:ring-opacity-50"
+            class="{{ $class }}"
             {{ if $required}}required{{ end }} {{ if $readonly}}readonly{{ end }} {{ if $disabled}}disabled{{ end }}>
-		{{ if .Placeholder }}
-        	<option value="">{{.Placeholder}}</option>
+		{{ if .placeholder }}
+        	<option value="">{{.placeholder}}</option>
 		{{ end }}
         {{ range .options }}
         <option value="{{ . }}" {{ if eq . $.value }}selected{{ end }}>{{.}}</option>
         {{ end }}
     </select>
+    {{- .children }}
+    {{- if .error }}
+    <p class="{{ theme "error" }}">{{ .error }}</p>
+    {{- end }}
 </div>
 {{ end }}
 
@@ -105,18 +257,34 @@ var components = `
 {{- $readonly := IsTrue .readonly }}
 {{- $required := IsTrue .required }}
 {{- $checked := IsTrue .checked }}
+{{- $class := .class }}
+{{- if not $class }}
+{{- $class = theme "checkbox" }}
+{{- end }}
+{{- $wrapperClass := .wrapper_class }}
+{{- if not $wrapperClass }}
+{{- $wrapperClass = theme "field" }}
+{{- end }}
+{{- $labelClass := .label_class }}
+{{- if not $labelClass }}
+{{- $labelClass = theme "checkbox.label" }}
+{{- end }}
 
-<div class="mb-4">
-    <label for="{{ $ID }}" class="inline-flex items-center gap-2">
-        <input type="checkbox" class="py-2 px-3 rounded border border-gray-300 text-indigo-600 shadow-sm focus:border-indigo-300 focus:ring focus:ring-indigo-200 focus:ring-opacity-50"
+<div class="{{ $wrapperClass }}">
+    <label for="{{ $ID }}" class="{{ $labelClass }}">
+        <input type="checkbox" class="{{ $class }}"
                id="{{ $ID }}" name="{{ .name }}" value="{{ .value }}"
                {{ if $checked}}checked{{ end }}
 			   {{ if $required}}required{{ end }} 
 			   {{ if $readonly}}readonly{{ end }} 
 			   {{ if $disabled}}disabled{{ end }}  
 			   >
-        <span class="ml-2 text-base text-gray-800">{{ .label }}</span>
+        <span class="{{ theme "checkbox.text" }}">{{ .label }}</span>
     </label>
+    {{- .children }}
+    {{- if .error }}
+    <p class="{{ theme "error" }}">{{ .error }}</p>
+    {{- end }}
 </div>
 {{ end }}
 
@@ -130,24 +298,37 @@ var components = `
 {{- $readonly := IsTrue .readonly }}
 {{- $required := IsTrue .required }}
 {{- $checked := IsTrue .checked }}
+{{- $class := .class }}
+{{- if not $class }}
+{{- $class = theme "radio" }}
+{{- end }}
+{{- $wrapperClass := .wrapper_class }}
+{{- if not $wrapperClass }}
+{{- $wrapperClass = theme "field" }}
+{{- end }}
+{{- $labelClass := .label_class }}
+{{- if not $labelClass }}
+{{- $labelClass = theme "label" }}
+{{- end }}
 
-<div class="mb-4">
-    <span class="block text-base font-medium text-gray-800 mb-1">{{ .label }}</span>
-    <div class="mt-1 space-y-2">
+<div class="{{ $wrapperClass }}">
+    <span class="{{ $labelClass }}">{{ .label }}</span>
+    <div class="{{ theme "radio.group" }}">
         {{ range .options }}
-        <label class="inline-flex items-center">
+        <label class="{{ theme "radio.label" }}">
             <input type="radio" 
-			class="border border-gray-300 text-indigo-600 shadow-sm focus:border-indigo-300 focus:ring focus:ring-indigo-200 focus:ring-opacity-50"
+			class="{{ $class }}"
 					id="{{ $ID }}_{{.name}}" name="{{ .name }}" value="{{ .value }}"
 	               {{ if $checked}}checked{{ end }}
 				   {{ if $required}}required{{ end }} 
 				   {{ if $readonly}}readonly{{ end }} 
 				   {{ if $disabled}}disabled{{ end }}   
 				   >
-            <span class="ml-2 text-base text-gray-800">{{ . }}</span>
+            <span class="{{ theme "radio.text" }}">{{ . }}</span>
         </label>
         {{ end }}
     </div>
+    {{- $.children }}
 </div>
 {{ end }}
 
@@ -160,31 +341,67 @@ var components = `
 {{ end }}
 
 {{- $variant := .variant }}
-{{- $class := "secondary" }}
-
-{{- if eq $variant "primary" }}
-    {{ $class = "whitespace-nowrap inline-flex items-center px-4 py-2 border border-transparent text-base font-medium rounded-md shadow-sm text-white bg-indigo-600 hover:bg-indigo-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500" }}
-{{- else if eq $variant "secondary" }}
-    {{ $class = "whitespace-nowrap inline-flex items-center px-4 py-2 border border-gray-300 text-base font-medium rounded-md shadow-sm text-gray-700 bg-white hover:bg-gray-100 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-gray-500" }}
-{{- else if eq $variant "success" }}
-    {{ $class = "whitespace-nowrap inline-flex items-center px-4 py-2 border border-transparent text-base font-medium rounded-md shadow-sm text-white bg-green-600 hover:bg-green-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-green-500" }}
-{{- else if eq $variant "danger" }}
-    {{ $class = "whitespace-nowrap inline-flex items-center px-4 py-2 border border-transparent text-base font-medium rounded-md shadow-sm text-white bg-red-600 hover:bg-red-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-red-500" }}
-{{- else if eq $variant "warning" }}
-    {{ $class = "whitespace-nowrap inline-flex items-center px-4 py-2 border border-transparent text-base font-medium rounded-md shadow-sm text-white bg-yellow-600 hover:bg-yellow-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-yellow-500" }}
-{{- else if eq $variant "info" }}
-    {{ $class = "whitespace-nowrap inline-flex items-center px-4 py-2 border border-transparent text-base font-medium rounded-md shadow-sm text-white bg-sky-600 hover:bg-sky-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-sky-500" }}
-{{- else }}
-    {{ $class = "whitespace-nowrap inline-flex items-center px-4 py-2 border border-transparent text-base font-medium rounded-md shadow-sm text-white bg-indigo-600 hover:bg-indigo-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500" }}
-{{ end }}
+{{- if not $variant }}
+    {{- $variant = "primary" }}
+{{- end }}
+
+{{- $class := .class }}
+{{- if not $class }}
+    {{- $class = theme (printf "button.%s" $variant) }}
+{{- end }}
+{{- if not $class }}
+    {{- $class = theme "button.primary" }}
+{{- end }}
 
 <button type="{{ $type }}" {{ if .id }} id="{{ .id }}"{{ end }}
-        class="{{ $class }} {{ if $disabled }}opacity-50 cursor-not-allowed{{ end }}"
+        class="{{ $class }} {{ if $disabled }}{{ theme "button.disabled" }}{{ end }}"
         {{ if $disabled }}disabled{{ end }}>
-    {{ .text }}
+    {{ if .children }}{{ .children }}{{ else }}{{ .text }}{{ end }}
 </button>
 {{ end }}
 
+{{- block "flashes" . }}
+{{- range .flashes }}
+{{- $class := theme "flash.info" }}
+{{- if eq .Category "success" }}
+    {{- $class = theme "flash.success" }}
+{{- else if or (eq .Category "error") (eq .Category "danger") }}
+    {{- $class = theme "flash.error" }}
+{{- else if eq .Category "warning" }}
+    {{- $class = theme "flash.warning" }}
+{{- end }}
+<div class="{{ theme "flash" }} {{ $class }}" role="alert" data-category="{{ .Category }}">
+    {{ .Message }}
+</div>
+{{- end }}
+{{ end }}
 
+{{- block "pagination" . }}
+{{- with .pagination }}
+{{- if gt .TotalPages 1 }}
+<nav class="{{ theme "pagination" }}" aria-label="Pagination">
+    {{- if .HasPrev }}
+    <a href="{{ .PrevURL }}" rel="prev" class="{{ theme "pagination.link" }}">&laquo; Previous</a>
+    {{- else }}
+    <span class="{{ theme "pagination.disabled" }}" aria-disabled="true">&laquo; Previous</span>
+    {{- end }}
+    {{- range .Pages }}
+    {{- if .Gap }}
+    <span class="{{ theme "pagination.gap" }}">&hellip;</span>
+    {{- else if .Current }}
+    <span class="{{ theme "pagination.current" }}" aria-current="page">{{ .Number }}</span>
+    {{- else }}
+    <a href="{{ .URL }}" class="{{ theme "pagination.link" }}">{{ .Number }}</a>
+    {{- end }}
+    {{- end }}
+    {{- if .HasNext }}
+    <a href="{{ .NextURL }}" rel="next" class="{{ theme "pagination.link" }}">Next &raquo;</a>
+    {{- else }}
+    <span class="{{ theme "pagination.disabled" }}" aria-disabled="true">Next &raquo;</span>
+    {{- end }}
+</nav>
+{{- end }}
+{{- end }}
+{{ end }}
 
 `
@@ -0,0 +1,299 @@
+package gor
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Template data keys read by the "form" template function.
+const (
+	formValueKey  = "form"   // Struct rendered by {{ form . }}
+	formErrorsKey = "errors" // Validation errors of the struct
+)
+
+// formField is a field rendered by the "form" template function.
+type formField struct {
+	component string // Component rendering the field, e.g "input" or "select"
+	props     map[string]any
+}
+
+var (
+	formComponentsOnce sync.Once
+	formComponents     *template.Template
+)
+
+// formComponentsTemplate returns the components used by the "form" template
+// function, parsed once.
+func formComponentsTemplate() *template.Template {
+	formComponentsOnce.Do(func() {
+		formComponents = parseComponents(template.FuncMap{"Props": Props, "IsTrue": isTrue, "theme": themeClass})
+	})
+	return formComponents
+}
+
+// formHTML is the "form" template function. It renders the inputs of the
+// fields of a struct with the input, textarea, select and checkbox components,
+// filled with the values of the struct and the messages of its validation errors:
+//
+//	r.Render(w, req, "signup", gor.Map{"form": signup, "errors": err})
+//
+//	<form method="post">
+//		{{ csrf_field . }}
+//		{{ form . }}
+//		{{ template "button" Props "text" "Sign up" }}
+//	</form>
+//
+// data is the page data, whose "form" key is the struct, or a pointer to it,
+// and whose "errors" key are the ValidationErrors returned by BodyParser, or a
+// map of messages by field. data may also be the struct itself. The values of
+// the "old" key, the input kept by WithOldInput, replace those of the struct.
+//
+// Inputs are named like BodyParser reads them, by the "form" tag of the fields,
+// the "json" tag, or the snake case of their name, and the fields of nested
+// structs are prefixed with the name of the struct, e.g "address.city".
+// Fields tagged `form:"-"`, maps and slices of structs are skipped.
+//
+// The input type is derived from the field type and its validation rules:
+// checkboxes for booleans, number inputs for numbers, date inputs for times,
+// email and url inputs for the email and url rules, and selects for the oneof
+// rule. These tags customize the inputs:
+//
+//	type Signup struct {
+//		Email string `form:"email" validate:"required,email" label:"Email address"`
+//		Bio   string `form:"bio" input:"textarea" placeholder:"Tell us about you"`
+//		Token string `form:"token" input:"hidden"`
+//	}
+func formHTML(data any) (template.HTML, error) {
+	value, errs, old := data, any(nil), map[string]string(nil)
+	switch d := data.(type) {
+	case Map:
+		value, errs = d[formValueKey], d[formErrorsKey]
+		old, _ = d[oldValuesKey].(map[string]string)
+	case map[string]any:
+		value, errs = d[formValueKey], d[formErrorsKey]
+		old, _ = d[oldValuesKey].(map[string]string)
+	}
+
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return "", fmt.Errorf("form: %T is nil", value)
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return "", fmt.Errorf("form: expected a struct, got %T", value)
+	}
+
+	var fields []formField
+	formFields(rv, "", "", formErrorMessages(errs), &fields)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	components := formComponentsTemplate()
+	for _, field := range fields {
+		if value, ok := old[field.props["name"].(string)]; ok {
+			field.setValue(value)
+		}
+
+		if field.component == "hidden" {
+			fmt.Fprintf(buf, `<input type="hidden" name="%s" value="%s">`,
+				template.HTMLEscapeString(field.props["name"].(string)),
+				template.HTMLEscapeString(field.props["value"].(string)))
+			continue
+		}
+
+		if err := components.ExecuteTemplate(buf, field.component, field.props); err != nil {
+			return "", err
+		}
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// setValue sets the value of the field to the submitted value, e.g the old
+// input kept by WithOldInput.
+func (f formField) setValue(value string) {
+	switch {
+	case f.component == "checkbox":
+		checked, err := strconv.ParseBool(value)
+		f.props["checked"] = checked || err != nil && value == "on"
+	case f.props["type"] != "file":
+		f.props["value"] = value
+	}
+}
+
+// formErrorMessages returns the validation messages by field path of errs,
+// ValidationErrors, FormErrors, an error wrapping them, or a map of messages.
+func formErrorMessages(errs any) map[string]string {
+	switch e := errs.(type) {
+	case map[string]string:
+		return e
+	case ValidationErrors:
+		return e.Map()
+	case FormErrors:
+		return e.Map()
+	case error:
+		var validationErrs ValidationErrors
+		if errors.As(e, &validationErrs) {
+			return validationErrs.Map()
+		}
+
+		var formErrs FormErrors
+		if errors.As(e, &formErrs) {
+			return formErrs.Map()
+		}
+	}
+	return nil
+}
+
+// formFields appends the fields of the struct rv to fields. prefix is the
+// prefix of the input names, and path the prefix of the validation error paths.
+func formFields(rv reflect.Value, prefix, path string, messages map[string]string, fields *[]formField) {
+	for _, plan := range structPlan(rv.Type(), "form") {
+		field, fieldVal := plan.field, rv.Field(plan.index)
+		if plan.tag == "-" || !field.IsExported() && plan.kind != embeddedField {
+			continue
+		}
+
+		for fieldVal.Kind() == reflect.Ptr && plan.kind != scalarField {
+			if fieldVal.IsNil() {
+				fieldVal = reflect.New(fieldVal.Type().Elem())
+			}
+			fieldVal = fieldVal.Elem()
+		}
+
+		switch plan.kind {
+		case embeddedField:
+			formFields(fieldVal, prefix, path, messages, fields)
+			continue
+		case nestedField:
+			formFields(fieldVal, prefix+plan.tag+".", path+field.Name+".", messages, fields)
+			continue
+		case mapField, structSliceField:
+			continue
+		}
+
+		*fields = append(*fields, newFormField(field, fieldVal, prefix+plan.tag, messages[path+field.Name], plan.required))
+	}
+}
+
+// newFormField returns the input of a scalar field.
+func newFormField(field reflect.StructField, fieldVal reflect.Value, name, message string, required bool) formField {
+	props := map[string]any{
+		"id":          strings.ReplaceAll(name, ".", "_"),
+		"name":        name,
+		"label":       formLabel(field),
+		"placeholder": field.Tag.Get("placeholder"),
+		"error":       message,
+	}
+
+	inputType := field.Tag.Get("input")
+	for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+		rule, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch rule {
+		case "required":
+			required = true
+		case "email", "url":
+			if inputType == "" {
+				inputType = rule
+			}
+		case "oneof":
+			props["options"] = strings.Fields(param)
+			if inputType == "" {
+				inputType = "select"
+			}
+		case "min", "max":
+			props[rule] = param
+		}
+	}
+	props["required"] = required
+
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	if inputType == "" {
+		switch {
+		case fieldType.Kind() == reflect.Bool:
+			inputType = "checkbox"
+		case isTimeType(fieldType):
+			inputType = "date"
+		case isFileType(field.Type):
+			inputType = "file"
+		case fieldType.Kind() >= reflect.Int && fieldType.Kind() <= reflect.Uint64:
+			inputType = "number"
+		case fieldType.Kind() == reflect.Float32 || fieldType.Kind() == reflect.Float64:
+			inputType = "number"
+			props["step"] = "any"
+		default:
+			inputType = "text"
+		}
+	}
+
+	if fieldType.Kind() == reflect.String && inputType != "number" {
+		// min and max are lengths for strings, not input bounds.
+		delete(props, "min")
+		delete(props, "max")
+	}
+
+	value := formValue(fieldVal, inputType, timeLayout(field))
+	props["value"] = value
+
+	switch inputType {
+	case "checkbox":
+		props["value"] = "true"
+		props["checked"] = value == "true"
+		return formField{component: "checkbox", props: props}
+	case "select", "textarea", "hidden":
+		return formField{component: inputType, props: props}
+	case "file":
+		delete(props, "value")
+	}
+
+	props["type"] = inputType
+	return formField{component: "input", props: props}
+}
+
+// formValue formats the value of an input. Zero values are empty.
+func formValue(v reflect.Value, inputType, layout string) string {
+	if !v.IsValid() || v.IsZero() || isFileType(v.Type()) {
+		return ""
+	}
+
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if t, ok := v.Interface().(time.Time); ok && layout == "" {
+		switch inputType {
+		case "datetime-local":
+			return t.Format("2006-01-02T15:04")
+		case "time":
+			return t.Format("15:04")
+		case "month":
+			return t.Format("2006-01")
+		}
+		return t.Format(time.DateOnly)
+	}
+	return formatCSVValue(v, layout)
+}
+
+// formLabel returns the "label" tag of the field, or its name in words,
+// e.g "First name" for FirstName.
+func formLabel(field reflect.StructField) string {
+	if label := field.Tag.Get("label"); label != "" {
+		return label
+	}
+
+	words := strings.ReplaceAll(SnakeCase(field.Name), "_", " ")
+	return strings.ToUpper(words[:1]) + words[1:]
+}
@@ -0,0 +1,120 @@
+package gor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrTimeout is the error sent when a route does not complete within its timeout.
+var ErrTimeout = errors.New("request timed out")
+
+// WithRouteTimeout returns a middleware that limits the time a handler may take.
+// Pass it as a route or group middleware:
+//
+//	r.Get("/report", report, gor.WithRouteTimeout(5*time.Second))
+//
+// The request context is canceled after d. The response is buffered and
+// only sent if the handler returns in time. Otherwise ErrTimeout is sent with
+// status 503 through the router's error pipeline, which renders the error
+// template or JSON. See HandleError.
+//
+// Like http.TimeoutHandler, the handler keeps running after a timeout, but its
+// writes fail with http.ErrHandlerTimeout. It should stop once the request
+// context is done. Its request, context and locals stay valid: the router
+// allocates them per request and never reuses them for another request.
+func WithRouteTimeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx, cancel := context.WithTimeout(req.Context(), d)
+			defer cancel()
+
+			req = req.WithContext(ctx)
+			tw := &timeoutWriter{header: make(http.Header), status: http.StatusOK}
+
+			done := make(chan struct{})
+			panicChan := make(chan any, 1)
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicChan <- p
+					}
+				}()
+				next.ServeHTTP(tw, req)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicChan:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				dst := w.Header()
+				for k, v := range tw.header {
+					dst[k] = v
+				}
+				w.WriteHeader(tw.status)
+				w.Write(tw.buf.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				tw.timedOut = true
+				tw.mu.Unlock()
+
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					HandleError(w, req, ErrTimeout, http.StatusServiceUnavailable)
+				}
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers the response of a handler wrapped by WithRouteTimeout.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	written  bool
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	tw.written = true
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.written {
+		return
+	}
+
+	tw.written = true
+	tw.status = status
+}
+
+// Written reports whether the handler has written the header or body.
+func (tw *timeoutWriter) Written() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.written
+}
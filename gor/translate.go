@@ -0,0 +1,92 @@
+package gor
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Translator translates the messages of a locale, e.g the Bundle of the
+// gor/i18n package.
+type Translator interface {
+	// Translate returns the message of key in locale, formatted with args,
+	// and false if there is no message for key.
+	Translate(locale, key string, args ...any) (string, bool)
+}
+
+const (
+	localeKey     = contextType("locale")
+	translatorKey = contextType("translator")
+)
+
+// SetLocale sets the locale of the request and the Translator of its messages,
+// used by Translate, Render and the validation errors of BodyParser,
+// QueryParser and BindCSV. The gor/i18n middleware calls it with the locale
+// detected from the request.
+//
+// The locale is passed to templates as "locale".
+func SetLocale(req *http.Request, locale string, t Translator) {
+	SetContextValue(req, localeKey, locale)
+	SetContextValue(req, translatorKey, t)
+}
+
+// Locale returns the locale of the request set by SetLocale, or "".
+func Locale(req *http.Request) string {
+	locale, _ := req.Context().Value(localeKey).(string)
+	return locale
+}
+
+// Translate returns the message of key in the locale of the request,
+// formatted with args, or key if there is no message or no locale. See SetLocale.
+//
+//	gor.SendString(w, gor.Translate(req, "cart.items", count))
+func Translate(req *http.Request, key string, args ...any) string {
+	if message, ok := translate(req, key, args...); ok {
+		return message
+	}
+	return key
+}
+
+// translate returns the message of key in the locale of the request.
+func translate(req *http.Request, key string, args ...any) (string, bool) {
+	t, ok := req.Context().Value(translatorKey).(Translator)
+	if !ok || t == nil {
+		return "", false
+	}
+	return t.Translate(Locale(req), key, args...)
+}
+
+// translateValidationErrors replaces the messages of the validation errors in
+// err with their translation in the locale of the request, if any.
+//
+// The messages are looked up as "validation.<rule>", e.g "validation.email",
+// and "validation.<rule>_characters" or "validation.<rule>_items" for the
+// min, max and len rules applied to lengths. The parameter of the rule, if
+// any, is passed as the argument, as an int if it is an integer.
+func translateValidationErrors(req *http.Request, err error) {
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		return
+	}
+
+	for i, e := range errs {
+		key := "validation." + e.Rule
+		if e.unit != "" {
+			key += "_" + e.unit
+		}
+
+		var args []any
+		if n, err := strconv.Atoi(e.Param); err == nil {
+			args = append(args, n)
+		} else if e.Rule == "oneof" {
+			args = append(args, strings.Join(strings.Fields(e.Param), ", "))
+		} else if e.Param != "" {
+			args = append(args, e.Param)
+		}
+
+		if message, ok := translate(req, key, args...); ok {
+			errs[i].Message = message
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package gor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrFileTooLarge is returned for files larger than FileRules.MaxSize.
+	ErrFileTooLarge = errors.New("gor: file too large")
+
+	// ErrFileExtension is returned for files whose extension is not in FileRules.AllowedExts.
+	ErrFileExtension = errors.New("gor: file extension not allowed")
+
+	// ErrFileType is returned for files whose content type is not in FileRules.AllowedMIME.
+	ErrFileType = errors.New("gor: file type not allowed")
+)
+
+// FileRules are the constraints of an uploaded file checked by ValidateFile.
+type FileRules struct {
+	// MaxSize is the maximum size of the file in bytes. Zero means no limit.
+	MaxSize int64
+
+	// AllowedExts are the allowed extensions of the file name, e.g ".png".
+	// They are matched case-insensitively. Empty allows any extension.
+	AllowedExts []string
+
+	// AllowedMIME are the allowed content types sniffed from the first bytes
+	// of the file, e.g "image/png", or "image/*" for any image.
+	// The Content-Type header sent by the client is ignored. Empty allows any type.
+	AllowedMIME []string
+}
+
+// FileError is the error of a file that breaks the FileRules.
+type FileError struct {
+	Filename    string // Name of the file sent by the client
+	ContentType string // Sniffed content type, if the file was read
+	Err         error  // ErrFileTooLarge, ErrFileExtension or ErrFileType
+}
+
+// Error implements the error interface.
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s: %q", e.Err, e.Filename)
+}
+
+// Unwrap returns the rule error.
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateFile checks the uploaded file against rules. The content type is
+// sniffed from the content of the file with http.DetectContentType.
+// It returns a *FileError wrapping ErrFileTooLarge, ErrFileExtension or ErrFileType.
+//
+//	file, fh, err := req.FormFile("avatar")
+//	...
+//	err = gor.ValidateFile(fh, gor.FileRules{
+//		MaxSize:     2 << 20,
+//		AllowedExts: []string{".png", ".jpg", ".jpeg"},
+//		AllowedMIME: []string{"image/png", "image/jpeg"},
+//	})
+func ValidateFile(fh *multipart.FileHeader, rules FileRules) error {
+	if rules.MaxSize > 0 && fh.Size > rules.MaxSize {
+		return &FileError{Filename: fh.Filename, Err: ErrFileTooLarge}
+	}
+
+	if len(rules.AllowedExts) > 0 {
+		ext := filepath.Ext(fh.Filename)
+		allowed := false
+		for _, allowedExt := range rules.AllowedExts {
+			if strings.EqualFold(ext, allowedExt) {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return &FileError{Filename: fh.Filename, Err: ErrFileExtension}
+		}
+	}
+
+	if len(rules.AllowedMIME) == 0 {
+		return nil
+	}
+
+	contentType, err := sniffContentType(fh)
+	if err != nil {
+		return err
+	}
+
+	for _, allowedType := range rules.AllowedMIME {
+		if matchMIME(contentType, allowedType) {
+			return nil
+		}
+	}
+	return &FileError{Filename: fh.Filename, ContentType: contentType, Err: ErrFileType}
+}
+
+// sniffContentType returns the media type of the first 512 bytes of the file.
+func sniffContentType(fh *multipart.FileHeader) (string, error) {
+	file, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	if err != nil {
+		return "", err
+	}
+	return mediaType, nil
+}
+
+// matchMIME reports whether contentType matches pattern, which may end with "/*".
+func matchMIME(contentType, pattern string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(contentType, prefix+"/")
+	}
+	return strings.EqualFold(contentType, pattern)
+}
+
+// parseFileRules parses the "file" tag of a field, e.g
+// `file:"maxsize=2MB,ext=.png .jpg,mime=image/png image/jpeg"`.
+func parseFileRules(tag string) (FileRules, error) {
+	var rules FileRules
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "":
+		case "maxsize":
+			size, err := parseSize(param)
+			if err != nil {
+				return rules, err
+			}
+			rules.MaxSize = size
+		case "ext":
+			rules.AllowedExts = strings.Fields(param)
+		case "mime":
+			rules.AllowedMIME = strings.Fields(param)
+		default:
+			return rules, fmt.Errorf("unknown file rule %q", name)
+		}
+	}
+	return rules, nil
+}
+
+// parseSize parses a size in bytes with an optional KB, MB or GB suffix.
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"B", 1}} {
+		if number, ok := strings.CutSuffix(s, unit.suffix); ok {
+			s, multiplier = strings.TrimSpace(number), unit.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file size %q", s)
+	}
+	return n * multiplier, nil
+}
+
+var fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+
+// isFileType reports whether t is *multipart.FileHeader or []*multipart.FileHeader.
+func isFileType(t reflect.Type) bool {
+	return t == fileHeaderType || (t.Kind() == reflect.Slice && t.Elem() == fileHeaderType)
+}
+
+// setFileField stores the uploaded files in the file field, after checking
+// the rules of its "file" tag.
+func setFileField(field reflect.StructField, fieldVal reflect.Value, files []*multipart.FileHeader) error {
+	if !isFileType(field.Type) {
+		return FormError{
+			Err:   fmt.Errorf("unsupported type: %s, file fields must be *multipart.FileHeader or []*multipart.FileHeader", field.Type),
+			Kind:  UnsupportedType,
+			Field: field.Name,
+		}
+	}
+
+	if tag := field.Tag.Get("file"); tag != "" {
+		rules, err := parseFileRules(tag)
+		if err != nil {
+			return FormError{Err: err, Kind: UnsupportedType, Field: field.Name}
+		}
+
+		for _, fh := range files {
+			if err := ValidateFile(fh, rules); err != nil {
+				return FormError{Err: err, Kind: InvalidFile, Field: field.Name}
+			}
+		}
+	}
+
+	if field.Type == fileHeaderType {
+		fieldVal.Set(reflect.ValueOf(files[0]))
+	} else {
+		fieldVal.Set(reflect.ValueOf(files))
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package gor
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// pngHeader is the signature of PNG files.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+// multipartRequest returns a request uploading the files, keyed by field and file name.
+func multipartRequest(t *testing.T, files map[string]map[string][]byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for field, named := range files {
+		for name, content := range named {
+			w, err := mw.CreateFormFile(field, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write(content)
+		}
+	}
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestValidateFile(t *testing.T) {
+	req := multipartRequest(t, map[string]map[string][]byte{
+		"image": {"photo.PNG": append(pngHeader, make([]byte, 100)...)},
+		"fake":  {"fake.png": []byte("#!/bin/sh\necho hello\n")},
+	})
+	if err := req.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+
+	image, fake := req.MultipartForm.File["image"][0], req.MultipartForm.File["fake"][0]
+	rules := FileRules{AllowedExts: []string{".png"}, AllowedMIME: []string{"image/*"}}
+
+	if err := ValidateFile(image, rules); err != nil {
+		t.Errorf("expected a valid image, got %v", err)
+	}
+
+	var fileErr *FileError
+	err := ValidateFile(fake, rules)
+	if !errors.As(err, &fileErr) || !errors.Is(err, ErrFileType) || fileErr.ContentType != "text/plain" {
+		t.Errorf("expected ErrFileType for a script renamed to png, got %v", err)
+	}
+
+	if err := ValidateFile(image, FileRules{MaxSize: 64}); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("expected ErrFileTooLarge, got %v", err)
+	}
+
+	if err := ValidateFile(image, FileRules{AllowedExts: []string{".jpg"}}); !errors.Is(err, ErrFileExtension) {
+		t.Errorf("expected ErrFileExtension, got %v", err)
+	}
+}
+
+func TestBodyParserFiles(t *testing.T) {
+	type Upload struct {
+		Avatar      *multipart.FileHeader   `form:"avatar,required" file:"maxsize=1KB,ext=.png,mime=image/png"`
+		Attachments []*multipart.FileHeader `form:"attachments"`
+	}
+
+	req := multipartRequest(t, map[string]map[string][]byte{
+		"avatar":      {"me.png": pngHeader},
+		"attachments": {"a.txt": []byte("a"), "b.txt": []byte("b")},
+	})
+
+	var upload Upload
+	if err := BodyParser(req, &upload); err != nil {
+		t.Fatal(err)
+	}
+
+	if upload.Avatar == nil || upload.Avatar.Filename != "me.png" || len(upload.Attachments) != 2 {
+		t.Errorf("unexpected upload %+v", upload)
+	}
+
+	req = multipartRequest(t, map[string]map[string][]byte{
+		"avatar": {"me.png": make([]byte, 2048)},
+	})
+
+	var formErr FormError
+	err := BodyParser(req, &Upload{})
+	if !errors.As(err, &formErr) || formErr.Kind != InvalidFile || formErr.Field != "Avatar" || !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("expected the avatar to be too large, got %v", err)
+	}
+}
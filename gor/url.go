@@ -0,0 +1,141 @@
+package gor
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Name names the route for reverse URL generation with Router.URL
+// and the "url" template function. It panics if the name is already in use.
+func (rt *Route) Name(name string) *Route {
+	if existing, ok := rt.router.names[name]; ok && existing != rt {
+		panic(fmt.Sprintf("gor: route name %q is already used by %q", name, existing.prefix))
+	}
+
+	if rt.name != "" {
+		delete(rt.router.names, rt.name)
+	}
+
+	rt.name = name
+	rt.router.names[name] = rt
+	return rt
+}
+
+// URL builds the path of the route named name.
+// params are either a single Map or alternating key/value pairs,
+// where keys name the path wildcards. Values are formatted with fmt.Sprint
+// and escaped. Parameters that do not match a wildcard are added to the query string.
+//
+// Example:
+//
+//	r.Get("/users/{id}", showUser).Name("user.show")
+//	r.URL("user.show", "id", 10)          // "/users/10"
+//	r.URL("user.show", gor.Map{"id": 10}) // "/users/10"
+//
+// In templates, use the "url" function:
+//
+//	<a href="{{ url "user.show" "id" .ID }}">Profile</a>
+func (r *Router) URL(name string, params ...any) (string, error) {
+	rt, ok := r.names[name]
+	if !ok {
+		return "", fmt.Errorf("gor: no route named %q", name)
+	}
+
+	values, err := urlParams(params)
+	if err != nil {
+		return "", fmt.Errorf("gor: url for %q: %w", name, err)
+	}
+
+	path, err := buildPath(rt.path, values)
+	if err != nil {
+		return "", fmt.Errorf("gor: url for %q: %w", name, err)
+	}
+	return path, nil
+}
+
+// urlParams converts a Map or key/value pairs to a map of strings.
+func urlParams(params []any) (map[string]string, error) {
+	values := make(map[string]string, len(params)/2)
+
+	if len(params) == 1 {
+		switch m := params[0].(type) {
+		case Map:
+			for k, v := range m {
+				values[k] = fmt.Sprint(v)
+			}
+			return values, nil
+		case map[string]string:
+			for k, v := range m {
+				values[k] = v
+			}
+			return values, nil
+		}
+	}
+
+	if len(params)%2 != 0 {
+		return nil, fmt.Errorf("odd number of parameters")
+	}
+
+	for i := 0; i < len(params); i += 2 {
+		key, ok := params[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("parameter name %v is not a string", params[i])
+		}
+		values[key] = fmt.Sprint(params[i+1])
+	}
+	return values, nil
+}
+
+// buildPath substitutes the wildcards in pattern with values.
+// Values that are not used are encoded as the query string.
+func buildPath(pattern string, values map[string]string) (string, error) {
+	var b strings.Builder
+	used := 0
+
+	segments := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	for _, seg := range segments {
+		b.WriteByte('/')
+
+		switch {
+		case seg == "{$}":
+			// Matches the end of the path, e.g "/{$}".
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "...}"):
+			name := seg[1 : len(seg)-4]
+			v, ok := values[name]
+			if !ok {
+				return "", fmt.Errorf("missing parameter %q", name)
+			}
+			used++
+
+			parts := strings.Split(strings.TrimPrefix(v, "/"), "/")
+			for i, part := range parts {
+				parts[i] = url.PathEscape(part)
+			}
+			b.WriteString(strings.Join(parts, "/"))
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+			name := seg[1 : len(seg)-1]
+			v, ok := values[name]
+			if !ok {
+				return "", fmt.Errorf("missing parameter %q", name)
+			}
+			used++
+			b.WriteString(url.PathEscape(v))
+		default:
+			b.WriteString(seg)
+		}
+	}
+
+	if used == len(values) {
+		return b.String(), nil
+	}
+
+	// Add the remaining values as query parameters.
+	query := make(url.Values, len(values)-used)
+	for k, v := range values {
+		if !strings.Contains(pattern, "{"+k+"}") && !strings.Contains(pattern, "{"+k+"...}") {
+			query.Set(k, v)
+		}
+	}
+	return b.String() + "?" + query.Encode(), nil
+}
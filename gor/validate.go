@@ -0,0 +1,369 @@
+package gor
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ValidationError describes a struct field that failed a validation rule.
+type ValidationError struct {
+	Field   string `json:"field"`           // Path of the field, e.g "Address.Street" or "Items[0].Name"
+	Rule    string `json:"rule"`            // Rule that failed, e.g "min"
+	Param   string `json:"param,omitempty"` // Parameter of the rule, e.g "1"
+	Message string `json:"message"`         // Message for users, e.g "must be at least 1"
+
+	unit string // Unit of the min, max and len rules applied to lengths, to translate the message
+}
+
+// Error implements the error interface.
+func (e ValidationError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// ValidationErrors are the errors of every invalid field of a struct.
+// HandleError sends them with 422 Unprocessable Entity.
+type ValidationErrors []ValidationError
+
+// Error implements the error interface.
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Map returns the message of each invalid field keyed by its path,
+// e.g to show the errors next to the inputs of a form.
+func (e ValidationErrors) Map() map[string]string {
+	m := make(map[string]string, len(e))
+	for _, err := range e {
+		if _, ok := m[err.Field]; !ok {
+			m[err.Field] = err.Message
+		}
+	}
+	return m
+}
+
+// Validator validates the structs parsed by BodyParser and QueryParser.
+type Validator interface {
+	// Validate returns an error if v, a pointer to a struct, is invalid.
+	Validate(v any) error
+}
+
+// ValidatorFunc adapts a function to the Validator interface.
+type ValidatorFunc func(v any) error
+
+// Validate calls f(v).
+func (f ValidatorFunc) Validate(v any) error {
+	return f(v)
+}
+
+// WithValidator replaces the built-in validation rules of BodyParser and
+// QueryParser with v, e.g github.com/go-playground/validator:
+//
+//	validate := validator.New()
+//	r := gor.NewRouter(gor.WithValidator(gor.ValidatorFunc(validate.Struct)))
+//
+// Errors other than ValidationErrors are wrapped in a FormError of kind ValidationFailed.
+func WithValidator(v Validator) RouterOption {
+	return func(r *Router) {
+		r.validator = v
+	}
+}
+
+// validate validates v with the Validator of the router serving req, or Validate.
+func validate(req *http.Request, v any) error {
+	var validator Validator = ValidatorFunc(Validate)
+	if ctx, ok := req.Context().Value(contextKey).(*CTX); ok && ctx.Router != nil && ctx.Router.validator != nil {
+		validator = ctx.Router.validator
+	}
+
+	err := validator.Validate(v)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs ValidationErrors
+	if errors.As(err, &validationErrs) {
+		translateValidationErrors(req, err)
+		return err
+	}
+	return FormError{Err: err, Kind: ValidationFailed}
+}
+
+// Validate validates v, a struct or a pointer to a struct, with the rules of
+// the "validate" tags of its fields, and returns ValidationErrors listing
+// the first failed rule of every invalid field. Nested structs and the structs
+// of slices are validated too. BodyParser and QueryParser call it after parsing.
+//
+// Rules are separated by commas and parameters follow an equal sign:
+//
+//	type Signup struct {
+//		Email string   `form:"email" validate:"required,email"`
+//		Age   int      `form:"age" validate:"min=18,max=130"`
+//		Plan  string   `form:"plan" validate:"oneof=free pro"`
+//		Tags  []string `form:"tags" validate:"omitempty,max=5"`
+//	}
+//
+// The rules are:
+//   - required: the value is not zero, or the pointer not nil.
+//   - omitempty: skip the other rules if the value is zero.
+//   - min=n, max=n, len=n: bounds of numbers, or of the length of strings, slices and maps.
+//   - oneof=a b c: the value is one of the space separated values.
+//   - email, url: the string is an email address or an absolute URL.
+//   - alpha, alphanum, numeric: the string only contains letters, letters and digits, or is a number.
+//
+// Validate panics on unknown rules, which are programming errors.
+func Validate(v any) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return FormError{
+			Err:  fmt.Errorf("v must be a struct or a pointer to a struct"),
+			Kind: InvalidStructPointer,
+		}
+	}
+
+	var errs ValidationErrors
+	validateStruct(rv, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateStruct appends the errors of the fields of the struct rv to errs.
+// prefix is the path of rv, e.g "Address.".
+func validateStruct(rv reflect.Value, prefix string, errs *ValidationErrors) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		// The fields of embedded structs are promoted.
+		path := prefix + field.Name
+		if field.Anonymous {
+			path = strings.TrimSuffix(prefix, ".")
+		}
+
+		fieldVal := rv.Field(i)
+		if tag != "" && !validateField(fieldVal, path, tag, errs) {
+			continue
+		}
+		validateNested(fieldVal, path, field.Anonymous, errs)
+	}
+}
+
+// validateNested validates the nested struct or the structs of the slice fieldVal.
+func validateNested(fieldVal reflect.Value, path string, embedded bool, errs *ValidationErrors) {
+	for fieldVal.Kind() == reflect.Ptr || fieldVal.Kind() == reflect.Interface {
+		if fieldVal.IsNil() {
+			return
+		}
+		fieldVal = fieldVal.Elem()
+	}
+
+	switch fieldVal.Kind() {
+	case reflect.Struct:
+		if fieldVal.Type() == reflect.TypeOf(time.Time{}) {
+			return
+		}
+
+		prefix := path + "."
+		if embedded && path == "" {
+			prefix = ""
+		}
+		validateStruct(fieldVal, prefix, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < fieldVal.Len(); i++ {
+			elem := reflect.Indirect(fieldVal.Index(i))
+			if elem.Kind() == reflect.Struct && elem.Type() != reflect.TypeOf(time.Time{}) {
+				validateStruct(elem, fmt.Sprintf("%s[%d].", path, i), errs)
+			}
+		}
+	}
+}
+
+// validateField checks the rules of tag on fieldVal and appends the first failure to errs.
+// It returns false if the value is nil or omitted and nested values must not be validated.
+func validateField(fieldVal reflect.Value, path, tag string, errs *ValidationErrors) bool {
+	rules := strings.Split(tag, ",")
+
+	pointer := fieldVal.Kind() == reflect.Ptr || fieldVal.Kind() == reflect.Interface
+	if pointer {
+		if fieldVal.IsNil() {
+			for _, rule := range rules {
+				if strings.TrimSpace(rule) == "required" {
+					*errs = append(*errs, ValidationError{Field: path, Rule: "required", Message: "is required"})
+				}
+			}
+			return false
+		}
+		fieldVal = fieldVal.Elem()
+	}
+
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "":
+			continue
+		case "omitempty":
+			if fieldVal.IsZero() {
+				return false
+			}
+		case "required":
+			if !pointer && fieldVal.IsZero() {
+				*errs = append(*errs, ValidationError{Field: path, Rule: name, Message: "is required"})
+				return false
+			}
+		default:
+			check, ok := validationRules[name]
+			if !ok {
+				panic(fmt.Sprintf("gor: unknown validation rule %q of field %s", name, path))
+			}
+
+			if message := check(fieldVal, param); message != "" {
+				err := ValidationError{Field: path, Rule: name, Param: param, Message: message}
+				if name == "min" || name == "max" || name == "len" {
+					_, err.unit = ruleSize(fieldVal, name, param)
+				}
+				*errs = append(*errs, err)
+				return true
+			}
+		}
+	}
+	return true
+}
+
+// validationRule returns the message of the failed rule, or "" if v is valid.
+type validationRule func(v reflect.Value, param string) string
+
+var validationRules = map[string]validationRule{
+	"min": func(v reflect.Value, param string) string {
+		n, unit := ruleSize(v, "min", param)
+		if n < ruleNumber("min", param) {
+			return boundMessage("at least", param, unit)
+		}
+		return ""
+	},
+	"max": func(v reflect.Value, param string) string {
+		n, unit := ruleSize(v, "max", param)
+		if n > ruleNumber("max", param) {
+			return boundMessage("at most", param, unit)
+		}
+		return ""
+	},
+	"len": func(v reflect.Value, param string) string {
+		n, unit := ruleSize(v, "len", param)
+		if n != ruleNumber("len", param) {
+			if unit == "" {
+				return "must be equal to " + param
+			}
+			return boundMessage("exactly", param, unit)
+		}
+		return ""
+	},
+	"oneof": func(v reflect.Value, param string) string {
+		options := strings.Fields(param)
+		value := fmt.Sprint(v)
+		for _, option := range options {
+			if value == option {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(options, ", ")
+	},
+	"email": func(v reflect.Value, param string) string {
+		addr, err := mail.ParseAddress(ruleString(v, "email"))
+		if err != nil || addr.Address != v.String() {
+			return "must be a valid email address"
+		}
+		return ""
+	},
+	"url": func(v reflect.Value, param string) string {
+		u, err := url.ParseRequestURI(ruleString(v, "url"))
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be a valid URL"
+		}
+		return ""
+	},
+	"alpha": func(v reflect.Value, param string) string {
+		for _, r := range ruleString(v, "alpha") {
+			if !unicode.IsLetter(r) {
+				return "must contain only letters"
+			}
+		}
+		return ""
+	},
+	"alphanum": func(v reflect.Value, param string) string {
+		for _, r := range ruleString(v, "alphanum") {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				return "must contain only letters and numbers"
+			}
+		}
+		return ""
+	},
+	"numeric": func(v reflect.Value, param string) string {
+		if _, err := strconv.ParseFloat(ruleString(v, "numeric"), 64); err != nil {
+			return "must be a number"
+		}
+		return ""
+	},
+}
+
+// ruleSize returns the number v, or the length of v and its unit.
+func ruleSize(v reflect.Value, rule, param string) (float64, string) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), "characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), "items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), ""
+	}
+	panic(fmt.Sprintf("gor: validation rule %s=%s does not apply to %s", rule, param, v.Type()))
+}
+
+// ruleNumber parses the parameter of a rule.
+func ruleNumber(rule, param string) float64 {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("gor: invalid parameter of validation rule %s=%s", rule, param))
+	}
+	return n
+}
+
+// ruleString returns the string v, a string value.
+func ruleString(v reflect.Value, rule string) string {
+	if v.Kind() != reflect.String {
+		panic(fmt.Sprintf("gor: validation rule %s does not apply to %s", rule, v.Type()))
+	}
+	return v.String()
+}
+
+// boundMessage returns the message of a failed bound, e.g "must be at least 3 characters long".
+func boundMessage(bound, param, unit string) string {
+	switch unit {
+	case "characters":
+		return fmt.Sprintf("must be %s %s characters long", bound, param)
+	case "items":
+		return fmt.Sprintf("must contain %s %s items", bound, param)
+	}
+	return fmt.Sprintf("must be %s %s", bound, param)
+}
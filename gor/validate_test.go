@@ -0,0 +1,152 @@
+package gor
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type Address struct {
+	Street string `validate:"required"`
+	City   string `validate:"alpha"`
+}
+
+type Signup struct {
+	Email    string    `form:"email" validate:"required,email"`
+	Name     string    `form:"name" validate:"min=2,max=10"`
+	Age      int       `form:"age" validate:"min=18"`
+	Plan     string    `form:"plan" validate:"oneof=free pro"`
+	Website  string    `form:"website" validate:"omitempty,url"`
+	Tags     []string  `form:"tags" validate:"max=2"`
+	Code     *string   `form:"code" validate:"required,len=4"`
+	Address  Address   `form:"address"`
+	Contacts []Address `form:"-"`
+}
+
+func TestValidate(t *testing.T) {
+	code := "12"
+	signup := Signup{
+		Email:    "not an email",
+		Name:     "J",
+		Age:      12,
+		Plan:     "gold",
+		Tags:     []string{"a", "b", "c"},
+		Code:     &code,
+		Address:  Address{City: "K1"},
+		Contacts: []Address{{Street: "Main"}, {}},
+	}
+
+	var errs ValidationErrors
+	if err := Validate(&signup); !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+
+	want := map[string]string{
+		"Email":              "must be a valid email address",
+		"Name":               "must be at least 2 characters long",
+		"Age":                "must be at least 18",
+		"Plan":               "must be one of: free, pro",
+		"Tags":               "must contain at most 2 items",
+		"Code":               "must be exactly 4 characters long",
+		"Address.Street":     "is required",
+		"Address.City":       "must contain only letters",
+		"Contacts[1].Street": "is required",
+	}
+	if !reflect.DeepEqual(errs.Map(), want) {
+		t.Errorf("expected %v, got %v", want, errs.Map())
+	}
+
+	code = "1234"
+	valid := Signup{
+		Email:   "jane@example.com",
+		Name:    "Jane",
+		Age:     30,
+		Plan:    "pro",
+		Code:    &code,
+		Address: Address{Street: "Main", City: "Kampala"},
+	}
+	if err := Validate(valid); err != nil {
+		t.Errorf("expected a valid struct, got %v", err)
+	}
+
+	valid.Code = nil
+	if err := Validate(valid); err == nil || err.Error() != "Code is required" {
+		t.Errorf("expected Code to be required, got %v", err)
+	}
+}
+
+func TestValidateUnknownRule(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an unknown rule")
+		}
+	}()
+
+	Validate(struct {
+		Phone string `validate:"e164"`
+	}{})
+}
+
+func TestBodyParserValidation(t *testing.T) {
+	type Login struct {
+		Username string `form:"username" validate:"required,alphanum"`
+		Password string `form:"password" validate:"min=8"`
+	}
+
+	newRequest := func(form url.Values) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", ContentTypeUrlEncoded)
+		req.Header.Set("Accept", ContentTypeJSON)
+		return req
+	}
+
+	r := NewRouter()
+	r.Post("/login", func(w http.ResponseWriter, req *http.Request) {
+		var login Login
+		if err := BodyParser(req, &login); err != nil {
+			HandleError(w, req, err)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest(url.Values{"username": {"jane doe"}, "password": {"short"}}))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d", w.Code)
+	}
+
+	var body struct {
+		Errors map[string]string `json:"errors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"Username": "must contain only letters and numbers",
+		"Password": "must be at least 8 characters long",
+	}
+	if !reflect.DeepEqual(body.Errors, want) {
+		t.Errorf("expected errors %v, got %v", want, body.Errors)
+	}
+
+	// A custom validator replaces the built-in rules.
+	errBanned := errors.New("banned")
+	r = NewRouter(WithValidator(ValidatorFunc(func(v any) error {
+		if v.(*Login).Username == "root" {
+			return errBanned
+		}
+		return nil
+	})))
+	r.Post("/login", func(w http.ResponseWriter, req *http.Request) {
+		var login Login
+		if err := BodyParser(req, &login); !errors.Is(err, errBanned) {
+			t.Errorf("expected the error of the custom validator, got %v", err)
+		}
+	})
+	r.ServeHTTP(httptest.NewRecorder(), newRequest(url.Values{"username": {"root"}, "password": {"x"}}))
+}